@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Kinds of operational failure a guild can be alerted about.
+const (
+	AlertConversion = "conversion"
+	AlertVoice      = "voice"
+	AlertPlayback   = "playback"
+	AlertSpeech     = "speech"
+)
+
+var alertTitles = map[string]string{
+	AlertConversion: "Uploads are failing to convert",
+	AlertVoice:      "Can't connect to voice",
+	AlertPlayback:   "Voice memos are failing to play",
+	AlertSpeech:     "The speech provider is failing",
+}
+
+// Failures of the same kind in a guild are coalesced into one alert per window.
+const alertWindow = 10 * time.Minute
+
+// Alerts posts operational failures to the errors channel a guild picked with !alerts.
+type Alerts struct {
+	session  *discordgo.Session
+	metadata *MetadataStore
+
+	// Failures seen since the last alert, by guild and kind. A key is present while its window is open.
+	mu      sync.Mutex
+	pending map[string]*alertBurst
+}
+
+type alertBurst struct {
+	count int
+	last  error
+}
+
+func NewAlerts(session *discordgo.Session, metadata *MetadataStore) *Alerts {
+	return &Alerts{
+		session:  session,
+		metadata: metadata,
+		pending:  make(map[string]*alertBurst),
+	}
+}
+
+// Report records a failure affecting a guild. The first one is posted right away, and any that follow
+// within the window are summed up in a single alert when it closes. Safe to call on a nil Alerts.
+func (a *Alerts) Report(guildID, kind string, err error) {
+	if a == nil || guildID == "" || a.metadata.Guild(guildID).ErrorChannel == "" {
+		return
+	}
+
+	key := guildID + "/" + kind
+	a.mu.Lock()
+	if burst, ok := a.pending[key]; ok {
+		burst.count++
+		burst.last = err
+		a.mu.Unlock()
+		return
+	}
+	a.pending[key] = &alertBurst{}
+	a.mu.Unlock()
+
+	time.AfterFunc(alertWindow, func() {
+		a.flush(key, guildID, kind)
+	})
+	a.send(guildID, kind, err, "")
+}
+
+// Closes a window, posting whatever came in during it. Keeps the window going while failures continue.
+func (a *Alerts) flush(key, guildID, kind string) {
+	a.mu.Lock()
+	burst := a.pending[key]
+	if burst.count == 0 {
+		delete(a.pending, key)
+		a.mu.Unlock()
+		return
+	}
+	count, last := burst.count, burst.last
+	burst.count, burst.last = 0, nil
+	a.mu.Unlock()
+
+	time.AfterFunc(alertWindow, func() {
+		a.flush(key, guildID, kind)
+	})
+	a.send(guildID, kind, last, fmt.Sprintf("Happened %d more times in the last %d minutes", count, int(alertWindow.Minutes())))
+}
+
+func (a *Alerts) send(guildID, kind string, err error, footer string) {
+	channelID := a.metadata.Guild(guildID).ErrorChannel
+	if channelID == "" {
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       alertTitles[kind],
+		Description: err.Error(),
+		Color:       16711680,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+	if footer != "" {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: footer}
+	}
+	if _, err := a.session.ChannelMessageSendEmbed(channelID, embed); err != nil {
+		fmt.Println("Error sending alert to ", channelID, ": ", err)
+	}
+}
+
+func (b *Bot) HandleAlerts(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		channelID := b.VoiceMemoManager.Metadata.Guild(g.ID).ErrorChannel
+		if channelID == "" {
+			s.ChannelMessageSend(c.ID, "Alerts are off in "+g.Name+". !alerts #channel to turn them on.")
+			return
+		}
+		s.ChannelMessageSend(c.ID, "Alerts about failures in "+g.Name+" go to <#"+channelID+">")
+		return
+	}
+
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can change where alerts go.")
+		return
+	}
+
+	channelID := ""
+	if args[0] != "off" {
+		var ok bool
+		if channelID, ok = ParseChannel(s, g, args[0]); !ok {
+			s.ChannelMessageSend(c.ID, "Usage: !alerts #channel | !alerts off")
+			return
+		}
+	}
+
+	err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		gs.ErrorChannel = channelID
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+
+	if channelID == "" {
+		s.ChannelMessageSend(c.ID, "Alerts are now off in "+g.Name)
+		return
+	}
+	s.ChannelMessageSend(c.ID, "Repeated failures in "+g.Name+" will be posted to <#"+channelID+">")
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Returns the memo name that name stands for in a guild. A memo called name in the guild's library wins over
+// an alias, and names that are neither come back as they are.
+func (m *VoiceMemoManager) ResolveAlias(guildID, name string) string {
+	if m.Get(name) != nil && m.InLibrary(guildID, name) {
+		return name
+	}
+	if memo, ok := m.Metadata.Guild(guildID).Aliases[name]; ok {
+		return memo
+	}
+	return name
+}
+
+// Lists the guild's aliases for a memo.
+func (gs GuildSettings) AliasesOf(name string) []string {
+	aliases := make([]string, 0)
+	for alias, memo := range gs.Aliases {
+		if memo == name {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+func (b *Bot) HandleAlias(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := "Usage: !alias add <alias> <name> | !alias remove <alias> | !alias list"
+	if len(args) == 0 || args[0] == "list" {
+		b.SendAliases(s, g, c)
+		return
+	}
+
+	if !b.IsDJ(s, g.ID, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins and DJs can change aliases.")
+		return
+	}
+
+	switch {
+	case args[0] == "add" && len(args) >= 3:
+		alias, name := args[1], b.VoiceMemoManager.ResolveAlias(g.ID, args[2])
+		if err := ValidateMemoName(alias); err != nil {
+			s.ChannelMessageSend(c.ID, "Invalid alias: "+strings.Replace(err.Error(), "the name", "an alias", 1))
+			return
+		}
+		if b.VoiceMemoManager.Get(alias) != nil {
+			s.ChannelMessageSend(c.ID, "There's already a voice memo called "+alias)
+			return
+		}
+		if b.VoiceMemoManager.GuildMemo(g.ID, name) == nil {
+			s.ChannelMessageSend(c.ID, "Cannot find "+args[2])
+			return
+		}
+
+		err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+			if gs.Aliases == nil {
+				gs.Aliases = make(map[string]string)
+			}
+			gs.Aliases[alias] = name
+		})
+		if err != nil {
+			fmt.Println("Error saving guild settings: ", err)
+			return
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("!play %s now plays %s", alias, name))
+		b.Audit(s, g.ID, fmt.Sprintf("<@%s> made %s an alias for %s.", m.Author.ID, alias, name))
+	case args[0] == "remove" && len(args) >= 2:
+		alias := args[1]
+		if _, ok := b.VoiceMemoManager.Metadata.Guild(g.ID).Aliases[alias]; !ok {
+			s.ChannelMessageSend(c.ID, "There's no alias called "+alias)
+			return
+		}
+
+		err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+			delete(gs.Aliases, alias)
+		})
+		if err != nil {
+			fmt.Println("Error saving guild settings: ", err)
+			return
+		}
+		s.ChannelMessageSend(c.ID, "Removed the alias "+alias)
+		b.Audit(s, g.ID, fmt.Sprintf("<@%s> removed the alias %s.", m.Author.ID, alias))
+	default:
+		s.ChannelMessageSend(c.ID, usage)
+	}
+}
+
+func (b *Bot) SendAliases(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel) {
+	aliases := b.VoiceMemoManager.Metadata.Guild(g.ID).Aliases
+	if len(aliases) == 0 {
+		s.ChannelMessageSend(c.ID, "There are no aliases in "+g.Name+". A DJ can add one with !alias add <alias> <name>")
+		return
+	}
+
+	keys := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		keys = append(keys, alias)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, alias := range keys {
+		lines = append(lines, alias+" → "+aliases[alias])
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Aliases",
+		Description: strings.Join(lines, "\n"),
+		Color:       65535,
+	}
+	if len(embed.Description) > 4096 {
+		embed.Description = embed.Description[:4000] + "..."
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+// Returns the test library with aliases set up in guild 1.
+func testAliases(t *testing.T) *VoiceMemoManager {
+	t.Helper()
+	m := testLibrary(t)
+	err := m.Metadata.UpdateGuild("1", func(gs *GuildSettings) {
+		gs.Aliases = map[string]string{"b": "bruh", "br": "bruh", "honk": "bruh", "legacy": "quack", "q": "quack"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestResolveAlias(t *testing.T) {
+	tests := []struct {
+		name    string
+		guildID string
+		arg     string
+		want    string
+	}{
+		{"alias", "1", "b", "bruh"},
+		{"memo name", "1", "bruh", "bruh"},
+		{"memo wins over alias", "1", "legacy", "legacy"},
+		{"another guild's memo doesn't", "1", "honk", "bruh"},
+		{"neither", "1", "zzz", "zzz"},
+		{"other guild's alias", "2", "b", "b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testAliases(t)
+			if got := m.ResolveAlias(tt.guildID, tt.arg); got != tt.want {
+				t.Errorf("ResolveAlias(%s, %q) = %q, want %q", tt.guildID, tt.arg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAliasesFollowMemo(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(ms *MetadataStore) error
+		memo   string
+		want   []string
+	}{
+		{"untouched", func(ms *MetadataStore) error { return nil }, "bruh", []string{"b", "br", "honk"}},
+		{"renamed", func(ms *MetadataStore) error { return ms.RenameMemo("bruh", "oof") }, "oof", []string{"b", "br", "honk"}},
+		{"old name after renaming", func(ms *MetadataStore) error { return ms.RenameMemo("bruh", "oof") }, "bruh", []string{}},
+		{"deleted", func(ms *MetadataStore) error { return ms.RemoveMemo("bruh") }, "bruh", []string{}},
+		{"another memo deleted", func(ms *MetadataStore) error { return ms.RemoveMemo("quack") }, "bruh", []string{"b", "br", "honk"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testAliases(t)
+			if err := tt.change(m.Metadata); err != nil {
+				t.Fatal(err)
+			}
+			if got := m.Metadata.Guild("1").AliasesOf(tt.memo); !equalStrings(got, tt.want) {
+				t.Errorf("aliases of %s = %v, want %v", tt.memo, got, tt.want)
+			}
+		})
+	}
+}
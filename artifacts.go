@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default for -artifact-cache-mb.
+const defaultArtifactCacheMB = 512
+
+// Keeps audio derived from memos, like previews and volume adjusted versions, on disk so making them again
+// is instant. Files are named "<hash>.<transform>", and objects never change, so they never go stale. Once
+// the files add up to more than the limit, the ones that were used longest ago are removed.
+//
+// A nil cache caches nothing and builds everything on demand.
+type ArtifactCache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	entries map[string]*artifact
+	size    int64
+
+	// Artifacts being built, by file name. Requests for one of them wait for it instead of building it again.
+	building map[string]chan struct{}
+
+	hits, misses, evictions, failures int64
+}
+
+type artifact struct {
+	hash     string
+	size     int64
+	lastUsed time.Time
+}
+
+// What the cache holds and how well it's doing, for the dashboard.
+type ArtifactStats struct {
+	Entries   int   `json:"entries"`
+	Bytes     int64 `json:"bytes"`
+	MaxBytes  int64 `json:"max_bytes"`
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+	Failures  int64 `json:"failures"`
+}
+
+// Opens the cache in dir, picking up the artifacts already there. maxBytes is how much they may take up.
+func NewArtifactCache(dir string, maxBytes int64) (*ArtifactCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	c := &ArtifactCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		entries:  make(map[string]*artifact),
+		building: make(map[string]chan struct{}),
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		// Leftovers from builds that never finished.
+		if strings.HasSuffix(file.Name(), ".tmp") {
+			os.Remove(filepath.Join(dir, file.Name()))
+			continue
+		}
+		hash, _, _ := strings.Cut(file.Name(), ".")
+		c.entries[file.Name()] = &artifact{hash: hash, size: info.Size(), lastUsed: info.ModTime()}
+		c.size += info.Size()
+	}
+
+	c.mu.Lock()
+	c.evict("")
+	c.mu.Unlock()
+	return c, nil
+}
+
+// Returns the path of the artifact made by transform from the object with the given hash, calling build to
+// write it if it isn't cached. transform has to work as the end of a file name, like "ogg" or "volume1.5.dca".
+func (c *ArtifactCache) Path(hash, transform string, build func(w io.Writer) error) (string, error) {
+	name := hash + "." + transform
+	path := filepath.Join(c.dir, name)
+
+	for {
+		c.mu.Lock()
+		if entry, ok := c.entries[name]; ok {
+			// Someone may have removed it behind our back, in which case it's built again.
+			if _, err := os.Stat(path); err == nil {
+				c.hits++
+				now := time.Now()
+				entry.lastUsed = now
+				c.mu.Unlock()
+				// Keeps the order of use across restarts.
+				os.Chtimes(path, now, now)
+				return path, nil
+			}
+			c.size -= entry.size
+			delete(c.entries, name)
+		}
+		if wait, ok := c.building[name]; ok {
+			c.mu.Unlock()
+			<-wait
+			continue
+		}
+		c.misses++
+		done := make(chan struct{})
+		c.building[name] = done
+		c.mu.Unlock()
+
+		size, err := c.write(path, build)
+
+		c.mu.Lock()
+		delete(c.building, name)
+		close(done)
+		if err != nil {
+			c.failures++
+			c.mu.Unlock()
+			return "", err
+		}
+		c.entries[name] = &artifact{hash: hash, size: size, lastUsed: time.Now()}
+		c.size += size
+		c.evict(name)
+		c.mu.Unlock()
+		return path, nil
+	}
+}
+
+// Returns cached opus frames made by transform, building and caching them if they aren't there yet.
+// Audio that isn't in the object store, like speech, has no hash and is built every time.
+func (c *ArtifactCache) Frames(hash, transform string, build func() ([][]byte, error)) ([][]byte, error) {
+	if c == nil || hash == "" {
+		return build()
+	}
+
+	frames := [][]byte(nil)
+	path, err := c.Path(hash, transform, func(w io.Writer) error {
+		var err error
+		if frames, err = build(); err != nil {
+			return err
+		}
+		return WriteDCA(w, frames)
+	})
+	if err != nil || frames != nil {
+		return frames, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadDCA(bufio.NewReader(f))
+}
+
+// Writes an artifact next to where it goes and moves it into place once it's complete. Returns its size.
+func (c *ArtifactCache) write(path string, build func(w io.Writer) error) (int64, error) {
+	tmp, err := os.CreateTemp(c.dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = build(tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), os.Rename(tmp.Name(), path)
+}
+
+// Removes the least recently used artifacts until the cache fits, sparing keep. Callers must hold c.mu.
+func (c *ArtifactCache) evict(keep string) {
+	if c.size <= c.maxBytes {
+		return
+	}
+
+	names := make([]string, 0, len(c.entries))
+	for name := range c.entries {
+		if name != keep {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return c.entries[names[i]].lastUsed.Before(c.entries[names[j]].lastUsed)
+	})
+
+	for _, name := range names {
+		if c.size <= c.maxBytes {
+			return
+		}
+		c.remove(name)
+		c.evictions++
+	}
+}
+
+// Removes every artifact of an object, once the object itself is gone.
+func (c *ArtifactCache) Forget(hash string) {
+	if c == nil || hash == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name, entry := range c.entries {
+		if entry.hash == hash {
+			c.remove(name)
+		}
+	}
+}
+
+// Callers must hold c.mu.
+func (c *ArtifactCache) remove(name string) {
+	if err := os.Remove(filepath.Join(c.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Println("Error removing cached ", name, ": ", err)
+	}
+	c.size -= c.entries[name].size
+	delete(c.entries, name)
+}
+
+func (c *ArtifactCache) Stats() ArtifactStats {
+	if c == nil {
+		return ArtifactStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return ArtifactStats{
+		Entries:   len(c.entries),
+		Bytes:     c.size,
+		MaxBytes:  c.maxBytes,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Failures:  c.failures,
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Returns the ID of the guild text channel a "#channel" mention or bare channel ID refers to.
+func ParseChannel(s *discordgo.Session, g *discordgo.Guild, arg string) (string, bool) {
+	channelID := strings.TrimSuffix(strings.TrimPrefix(arg, "<#"), ">")
+	channel, err := s.State.Channel(channelID)
+	if err != nil || channel.GuildID != g.ID {
+		return "", false
+	}
+	return channelID, true
+}
+
+// Posts a record of an admin action to the guild's audit channel, if it has one.
+func (b *Bot) Audit(s *discordgo.Session, guildID, message string) {
+	channelID := b.VoiceMemoManager.Metadata.Guild(guildID).AuditChannel
+	if channelID == "" {
+		return
+	}
+	if _, err := s.ChannelMessageSend(channelID, message); err != nil {
+		fmt.Println("Error writing to audit channel ", channelID, ": ", err)
+	}
+}
+
+func (b *Bot) HandleAudit(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		channelID := b.VoiceMemoManager.Metadata.Guild(g.ID).AuditChannel
+		if channelID == "" {
+			s.ChannelMessageSend(c.ID, "There's no audit channel in "+g.Name+". !audit #channel to pick one.")
+			return
+		}
+		s.ChannelMessageSend(c.ID, "Admin actions in "+g.Name+" are logged to <#"+channelID+">")
+		return
+	}
+
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can change the audit channel.")
+		return
+	}
+
+	channelID := ""
+	if args[0] != "off" {
+		var ok bool
+		if channelID, ok = ParseChannel(s, g, args[0]); !ok {
+			s.ChannelMessageSend(c.ID, "Usage: !audit #channel | !audit off")
+			return
+		}
+	}
+
+	err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		gs.AuditChannel = channelID
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+
+	if channelID == "" {
+		s.ChannelMessageSend(c.ID, "Admin actions in "+g.Name+" are no longer logged.")
+		return
+	}
+	s.ChannelMessageSend(c.ID, "Admin actions in "+g.Name+" will be logged to <#"+channelID+">")
+}
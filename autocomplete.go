@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord shows at most this many autocomplete choices.
+const maxChoices = 25
+
+// Suggests voice memos from the guild's library, and its aliases, for the option being typed in a slash
+// command. Every option with Autocomplete set takes a memo name.
+func (b *Bot) HandleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	term := ""
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Focused {
+			term = opt.StringValue()
+			break
+		}
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: b.MemoChoices(i.GuildID, term)},
+	})
+	if err != nil {
+		fmt.Println("Error responding to autocomplete: ", err)
+	}
+}
+
+// Returns the guild's memos and aliases matching term, best matches first, or the first few alphabetically
+// if nothing has been typed yet. Aliases are shown next to the memo they stand for, which is what gets sent.
+func (b *Bot) MemoChoices(guildID, term string) []*discordgo.ApplicationCommandOptionChoice {
+	targets := make(map[string]string)
+	names := make([]string, 0)
+	for _, vm := range b.VoiceMemoManager.GuildLibrary(guildID) {
+		targets[vm.name] = vm.name
+		names = append(names, vm.name)
+	}
+	for alias, name := range b.VoiceMemoManager.Metadata.Guild(guildID).Aliases {
+		if _, ok := targets[alias]; !ok && targets[name] != "" {
+			targets[alias] = name
+			names = append(names, alias)
+		}
+	}
+
+	var matches []string
+	if results := SearchNames(names, term); term != "" {
+		for _, result := range results {
+			matches = append(matches, result.Name)
+		}
+	} else {
+		sort.Strings(names)
+		matches = names
+	}
+	if len(matches) > maxChoices {
+		matches = matches[:maxChoices]
+	}
+
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(matches))
+	for _, match := range matches {
+		label := match
+		if targets[match] != match {
+			label = match + " → " + targets[match]
+		}
+		// Choice names are limited to 100 characters.
+		if len(label) > 100 {
+			label = label[:97] + "..."
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: label, Value: targets[match]})
+	}
+	return choices
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Returns the voice channel with the most people in it and how many there are. Bots don't count.
+func BusiestVoiceChannel(s *discordgo.Session, g *discordgo.Guild, ignore func(userID string) bool) (string, int) {
+	counts := make(map[string]int)
+	for _, vs := range g.VoiceStates {
+		if vs.ChannelID == "" || vs.UserID == s.State.User.ID || ignore(vs.UserID) {
+			continue
+		}
+		if member, err := s.State.Member(g.ID, vs.UserID); err == nil && member.User != nil && member.User.Bot {
+			continue
+		}
+		counts[vs.ChannelID]++
+	}
+
+	busiest, most := "", 0
+	for channelID, count := range counts {
+		if count > most || count == most && channelID < busiest {
+			busiest, most = channelID, count
+		}
+	}
+	return busiest, most
+}
+
+// Returns the IDs of the people in a voice channel. Bots aren't included.
+func VoiceChannelUsers(s *discordgo.Session, g *discordgo.Guild, channelID string) []string {
+	users := make([]string, 0)
+	for _, vs := range g.VoiceStates {
+		if vs.ChannelID != channelID || vs.UserID == s.State.User.ID {
+			continue
+		}
+		if member, err := s.State.Member(g.ID, vs.UserID); err == nil && member.User != nil && member.User.Bot {
+			continue
+		}
+		users = append(users, vs.UserID)
+	}
+	return users
+}
+
+// Joins the busiest voice channel once it's crowded enough for guilds with auto-join on, and leaves
+// again when it empties out. Sessions started with !join are left alone. People exempted with !intro exempt
+// don't count, and neither does them coming or going.
+func (b *Bot) VoiceStateCenter(s *discordgo.Session, v *discordgo.VoiceStateUpdate) {
+	threshold := b.VoiceMemoManager.Metadata.Guild(v.GuildID).AutoJoin
+	if threshold <= 0 {
+		return
+	}
+	g, err := s.State.Guild(v.GuildID)
+	if err != nil {
+		return
+	}
+	exempt := func(userID string) bool { return b.IsIntroExempt(s, g, userID) }
+	if exempt(v.UserID) {
+		return
+	}
+
+	if gs, ok := b.Session(g.ID); ok {
+		members := 0
+		for _, userID := range VoiceChannelUsers(s, g, gs.VoiceConnection.ChannelID) {
+			if !exempt(userID) {
+				members++
+			}
+		}
+		if gs.AutoJoined.Load() && members < threshold {
+			fmt.Println("Auto-leaving voice channel in ", g.Name)
+			b.LeaveGuild(g.ID)
+		}
+		return
+	}
+
+	channelID, members := BusiestVoiceChannel(s, g, exempt)
+	if members < threshold {
+		return
+	}
+	fmt.Println("Auto-joining voice channel in ", g.Name, " with ", members, " members")
+	gs, err := b.JoinChannel(s, g, channelID)
+	if err != nil {
+		// Someone else may have gotten there first.
+		fmt.Println("Error auto-joining voice channel:", err)
+		b.Alerts.Report(g.ID, AlertVoice, err)
+		return
+	}
+	gs.AutoJoined.Store(true)
+	b.Greet(s, g, gs, "")
+}
+
+func (b *Bot) HandleAutoJoin(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		threshold := b.VoiceMemoManager.Metadata.Guild(g.ID).AutoJoin
+		if threshold <= 0 {
+			s.ChannelMessageSend(c.ID, "Auto-join is off in "+g.Name)
+			return
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("I join the busiest voice channel in %s once it has %d people in it.", g.Name, threshold))
+		return
+	}
+
+	if !b.IsDJ(s, g.ID, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins and DJs can change auto-join.")
+		return
+	}
+
+	threshold := 0
+	if args[0] != "off" {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			s.ChannelMessageSend(c.ID, "Usage: !autojoin <members> | !autojoin off")
+			return
+		}
+		threshold = n
+	}
+
+	err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		gs.AutoJoin = threshold
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+
+	if threshold == 0 {
+		s.ChannelMessageSend(c.ID, "Auto-join is now off in "+g.Name)
+		return
+	}
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("I'll join the busiest voice channel in %s once it has %d people in it, and leave when it drops below that.", g.Name, threshold))
+}
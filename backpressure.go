@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// How many of the latest frames each session keeps send timings for, 30 seconds of audio.
+	sendSamples = 1500
+
+	// The voice connection takes a frame every 20ms, so a p95 above this means it keeps falling behind and
+	// listeners hear gaps.
+	starvedSend = 3 * frameDuration
+)
+
+// How long the player waited for the voice connection to take each of the latest frames.
+type SendTimings struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	frames  int64
+}
+
+// Records how long sending one frame blocked.
+func (st *SendTimings) Record(d time.Duration) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.frames++
+	if len(st.samples) < sendSamples {
+		st.samples = append(st.samples, d)
+		return
+	}
+	st.samples[st.next] = d
+	st.next = (st.next + 1) % sendSamples
+}
+
+// Returns a copy of the latest timings and how many frames were sent in all.
+func (st *SendTimings) Samples() ([]time.Duration, int64) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	return append([]time.Duration(nil), st.samples...), st.frames
+}
+
+// Returns the p-th percentile of samples, sorting them on the way. Zero if there are none.
+func percentile(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	i := int(math.Ceil(p*float64(len(samples)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return samples[i]
+}
+
+// How long frame sends blocked in one guild's session, in milliseconds.
+type GuildSendMetrics struct {
+	GuildID string  `json:"guild_id"`
+	Guild   string  `json:"guild"`
+	Frames  int64   `json:"frames"`
+	P95     float64 `json:"p95_ms"`
+	P99     float64 `json:"p99_ms"`
+	Max     float64 `json:"max_ms"`
+	Starved bool    `json:"starved"`
+}
+
+// How long frame sends blocked across every session, and in each, most starved first.
+type PlaybackMetrics struct {
+	Sessions int                `json:"sessions"`
+	P95      float64            `json:"p95_ms"`
+	P99      float64            `json:"p99_ms"`
+	Starved  int                `json:"starved"`
+	Guilds   []GuildSendMetrics `json:"guilds"`
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func (b *Bot) PlaybackMetrics() PlaybackMetrics {
+	b.sessionsMu.RLock()
+	sessions := make([]*GuildSession, 0, len(b.GuildSessions))
+	for _, gs := range b.GuildSessions {
+		sessions = append(sessions, gs)
+	}
+	b.sessionsMu.RUnlock()
+
+	metrics := PlaybackMetrics{Sessions: len(sessions), Guilds: make([]GuildSendMetrics, 0, len(sessions))}
+	all := make([]time.Duration, 0)
+	for _, gs := range sessions {
+		samples, frames := gs.SendTimings.Samples()
+		if frames == 0 {
+			continue
+		}
+		all = append(all, samples...)
+
+		p95 := percentile(samples, 0.95)
+		guild := GuildSendMetrics{
+			GuildID: gs.ID,
+			Guild:   gs.GuildName,
+			Frames:  frames,
+			P95:     millis(p95),
+			P99:     millis(percentile(samples, 0.99)),
+			Max:     millis(samples[len(samples)-1]),
+			Starved: p95 > starvedSend,
+		}
+		if guild.Starved {
+			metrics.Starved++
+		}
+		metrics.Guilds = append(metrics.Guilds, guild)
+	}
+	sort.Slice(metrics.Guilds, func(i, j int) bool {
+		if metrics.Guilds[i].Starved != metrics.Guilds[j].Starved {
+			return metrics.Guilds[i].Starved
+		}
+		return metrics.Guilds[i].P99 > metrics.Guilds[j].P99
+	})
+	metrics.P95 = millis(percentile(all, 0.95))
+	metrics.P99 = millis(percentile(all, 0.99))
+	return metrics
+}
+
+func (b *Bot) HandleSysinfo(s *discordgo.Session, c *discordgo.Channel, m *discordgo.MessageCreate) {
+	if !b.IsOwner(m.Author.ID) {
+		s.ChannelMessageSend(c.ID, "Only the people running the bot can see how it's doing.")
+		return
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	metrics := b.PlaybackMetrics()
+
+	embed := &discordgo.MessageEmbed{
+		Title: "System info",
+		Color: 65535,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Uptime", Value: FormatDuration(time.Since(b.Started)), Inline: true},
+			{Name: "Servers", Value: fmt.Sprint(len(s.State.Guilds)), Inline: true},
+			{Name: "Voice sessions", Value: fmt.Sprint(metrics.Sessions), Inline: true},
+			{Name: "Memory", Value: FormatSize(int64(mem.Alloc)) + " of " + FormatSize(int64(mem.Sys)), Inline: true},
+			{Name: "Goroutines", Value: fmt.Sprint(runtime.NumGoroutine()), Inline: true},
+			{Name: "Go", Value: runtime.Version(), Inline: true},
+			{Name: "Frame sends blocked", Value: fmt.Sprintf("p95 %.1fms, p99 %.1fms", metrics.P95, metrics.P99)},
+		},
+	}
+	for i, guild := range metrics.Guilds {
+		// Embeds are limited to 25 fields.
+		if len(embed.Fields) == 24 {
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("and %d more", len(metrics.Guilds)-i)}
+			break
+		}
+		name := guild.Guild
+		if guild.Starved {
+			name = "⚠️ " + name
+		}
+		if len(name) > 256 {
+			name = name[:250]
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   name,
+			Value:  fmt.Sprintf("p95 %.1fms, p99 %.1fms, max %.1fms", guild.P95, guild.P99, guild.Max),
+			Inline: true,
+		})
+	}
+	if metrics.Starved > 0 {
+		embed.Color = 16711680
+		embed.Description = fmt.Sprintf("Playback is starved in %d voice sessions: the voice connection keeps taking longer than %s to take a frame, so listeners hear gaps.", metrics.Starved, starvedSend)
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
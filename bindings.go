@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How long a binding waits before it can be triggered again when !bind isn't given a cooldown.
+const defaultBindingCooldown = 10 * time.Second
+
+// The most a memo in a binding's pool can weigh, so adding up the weights can't overflow.
+const maxPoolWeight = 100
+
+// Plays a memo when its emoji is posted or reacted with in the channel it was bound in.
+type EmojiBinding struct {
+	Memo      string        `json:"memo"`
+	ChannelID string        `json:"channel_id"`
+	Cooldown  time.Duration `json:"cooldown"`
+
+	// Memos to pick from at random instead of always playing Memo, which is the first of them.
+	Pool []PoolMemo `json:"pool,omitempty"`
+
+	// Percent chance that the emoji plays anything at all. Zero always plays.
+	Chance int `json:"chance,omitempty"`
+}
+
+// A memo in a binding's pool. Memos with twice the weight are picked twice as often.
+type PoolMemo struct {
+	Memo   string `json:"memo"`
+	Weight int    `json:"weight"`
+}
+
+// Returns the memos the binding picks from.
+func (eb EmojiBinding) Memos() []PoolMemo {
+	if len(eb.Pool) == 0 {
+		return []PoolMemo{{Memo: eb.Memo, Weight: 1}}
+	}
+	return eb.Pool
+}
+
+// Takes a deleted memo out of the binding. Returns true if the binding has nothing left to play.
+func (eb *EmojiBinding) dropMemo(name string) bool {
+	if len(eb.Pool) == 0 {
+		return eb.Memo == name
+	}
+	// Copies of the binding handed out earlier share the pool, so leave it as it is.
+	kept := make([]PoolMemo, 0, len(eb.Pool))
+	for _, pm := range eb.Pool {
+		if pm.Memo != name {
+			kept = append(kept, pm)
+		}
+	}
+	eb.Pool = kept
+	if len(kept) == 0 {
+		return true
+	}
+	eb.Memo = kept[0].Memo
+	return false
+}
+
+// Describes what the binding plays, e.g. "bruh" or "30% chance of bruh (x3), oof".
+func (eb EmojiBinding) Describe() string {
+	names := make([]string, 0, len(eb.Memos()))
+	for _, pm := range eb.Memos() {
+		if pm.Weight > 1 {
+			names = append(names, fmt.Sprintf("%s (x%d)", pm.Memo, pm.Weight))
+			continue
+		}
+		names = append(names, pm.Memo)
+	}
+	description := strings.Join(names, ", ")
+	if eb.Chance > 0 {
+		description = fmt.Sprintf("%d%% chance of %s", eb.Chance, description)
+	}
+	return description
+}
+
+// Picks one of pool at random by weight. pool must not be empty.
+func PickWeighted(pool []PoolMemo) string {
+	total := 0
+	for _, pm := range pool {
+		total += pm.Weight
+	}
+	n := rng.Intn(total)
+	for _, pm := range pool {
+		if n < pm.Weight {
+			return pm.Memo
+		}
+		n -= pm.Weight
+	}
+	return pool[len(pool)-1].Memo
+}
+
+// Reads a memo in a guild's library for a binding's pool, a memo name optionally followed by =<weight>, e.g.
+// bruh=3. Weights over maxPoolWeight count as maxPoolWeight.
+func (m *VoiceMemoManager) ParsePoolMemo(guildID, arg string) (PoolMemo, bool) {
+	// Names may contain =, so a memo that's called arg wins.
+	if m.Get(arg) != nil && m.InLibrary(guildID, arg) {
+		return PoolMemo{Memo: arg, Weight: 1}, true
+	}
+	i := strings.LastIndex(arg, "=")
+	if i < 0 {
+		return PoolMemo{}, false
+	}
+	weight, err := strconv.Atoi(arg[i+1:])
+	if err != nil || weight < 1 || m.Get(arg[:i]) == nil || !m.InLibrary(guildID, arg[:i]) {
+		return PoolMemo{}, false
+	}
+	if weight > maxPoolWeight {
+		weight = maxPoolWeight
+	}
+	return PoolMemo{Memo: arg[:i], Weight: weight}, true
+}
+
+// Turns an emoji as it appears in a message (😀, <:duck:123>, <a:duck:123>) into the form reactions
+// report it in (😀, duck:123), so both can be looked up the same way.
+func EmojiKey(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "<") && strings.HasSuffix(s, ">") {
+		s = strings.TrimSuffix(strings.TrimPrefix(s, "<"), ">")
+		s = strings.TrimPrefix(strings.TrimPrefix(s, "a"), ":")
+	}
+	return s
+}
+
+// Renders a binding key back into something Discord shows as the emoji.
+func EmojiMention(key string) string {
+	if strings.Contains(key, ":") {
+		return "<:" + key + ">"
+	}
+	return key
+}
+
+// Plays the memo bound to emoji in the channel for userID, if there is one and it's off cooldown. Bindings
+// with a pool pick one of the memos userID can play, if their chance comes up.
+func (b *Bot) TriggerBinding(s *discordgo.Session, channelID, userID, emoji string) {
+	c, err := s.State.Channel(channelID)
+	if err != nil {
+		return
+	}
+	binding, ok := b.VoiceMemoManager.Metadata.Guild(c.GuildID).EmojiBindings[EmojiKey(emoji)]
+	if !ok || binding.ChannelID != channelID {
+		return
+	}
+	if _, ok := b.Session(c.GuildID); !ok || !b.FeatureEnabled(c.GuildID, FeatureTriggers) {
+		return
+	}
+	g, err := s.State.Guild(c.GuildID)
+	if err != nil {
+		return
+	}
+
+	// Someone who couldn't play the memo by name shouldn't use up the cooldown either, and neither
+	// should a miss.
+	pool := make([]PoolMemo, 0, len(binding.Memos()))
+	for _, pm := range binding.Memos() {
+		if b.VoiceMemoManager.Get(pm.Memo) != nil && b.VoiceMemoManager.InLibrary(g.ID, pm.Memo) && b.OutsideWindow(g.ID, pm.Memo) == "" && !b.WarnBlocked(g.ID, pm.Memo) &&
+			b.CanPlay(s, g, channelID, userID, pm.Memo) {
+			pool = append(pool, pm)
+		}
+	}
+	if len(pool) == 0 {
+		return
+	}
+	if binding.Chance > 0 && rng.Intn(100) >= binding.Chance {
+		return
+	}
+	if !b.Cooldowns.Try("binding:"+g.ID+":"+EmojiKey(emoji), binding.Cooldown) {
+		return
+	}
+	b.HandlePlay(s, g, c, userID, PickWeighted(pool), 1, false, 0)
+}
+
+func (b *Bot) ReactionCenter(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	if r.UserID == s.State.User.ID {
+		return
+	}
+	if b.NudgeVolume(s, r) {
+		return
+	}
+	b.TriggerBinding(s, r.ChannelID, r.UserID, r.Emoji.APIName())
+}
+
+func (b *Bot) HandleBind(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := "Usage: !bind emoji <emoji> <name[=weight]>... [-chance=<percent>] [cooldown] | !bind remove <emoji> | !bind list"
+	if len(args) == 0 || args[0] == "list" {
+		b.SendBindings(s, g, c)
+		return
+	}
+
+	if !b.IsDJ(s, g.ID, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins and DJs can change emoji bindings.")
+		return
+	}
+
+	switch {
+	case args[0] == "emoji" && len(args) >= 3:
+		key := EmojiKey(args[1])
+		binding := EmojiBinding{ChannelID: c.ID, Cooldown: defaultBindingCooldown}
+		for _, arg := range args[2:] {
+			if value, ok := OptionValue(arg, "chance"); ok {
+				chance, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+				if err != nil || chance < 1 || chance > 100 {
+					s.ChannelMessageSend(c.ID, "The chance has to be 1 to 100 percent, e.g. -chance=30")
+					return
+				}
+				// 100% is the same as always playing.
+				binding.Chance = chance % 100
+				continue
+			}
+			if pm, ok := b.VoiceMemoManager.ParsePoolMemo(g.ID, arg); ok {
+				binding.Pool = append(binding.Pool, pm)
+				continue
+			}
+			d, err := time.ParseDuration(arg)
+			if err != nil || d < 0 {
+				s.ChannelMessageSend(c.ID, "Cannot find "+arg+". Cooldowns have to look like 30s or 2m.")
+				return
+			}
+			binding.Cooldown = d
+		}
+		if len(binding.Pool) == 0 {
+			s.ChannelMessageSend(c.ID, usage)
+			return
+		}
+		binding.Memo = binding.Pool[0].Memo
+		if len(binding.Pool) == 1 && binding.Pool[0].Weight == 1 {
+			binding.Pool = nil
+		}
+
+		err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+			if gs.EmojiBindings == nil {
+				gs.EmojiBindings = make(map[string]EmojiBinding)
+			}
+			gs.EmojiBindings[key] = binding
+		})
+		if err != nil {
+			fmt.Println("Error saving guild settings: ", err)
+			return
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Posting or reacting with %s in this channel now plays %s (cooldown %s).", EmojiMention(key), binding.Describe(), binding.Cooldown))
+
+	case args[0] == "remove" && len(args) >= 2:
+		key := EmojiKey(args[1])
+		if _, ok := b.VoiceMemoManager.Metadata.Guild(g.ID).EmojiBindings[key]; !ok {
+			s.ChannelMessageSend(c.ID, EmojiMention(key)+" isn't bound to anything.")
+			return
+		}
+
+		err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+			delete(gs.EmojiBindings, key)
+		})
+		if err != nil {
+			fmt.Println("Error saving guild settings: ", err)
+			return
+		}
+		s.ChannelMessageSend(c.ID, "Removed the binding for "+EmojiMention(key))
+
+	default:
+		s.ChannelMessageSend(c.ID, usage)
+	}
+}
+
+// Lists a guild's emoji bindings.
+func (b *Bot) SendBindings(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel) {
+	bindings := b.VoiceMemoManager.Metadata.Guild(g.ID).EmojiBindings
+	if len(bindings) == 0 {
+		s.ChannelMessageSend(c.ID, "There are no emoji bindings in "+g.Name+". An admin can add one with !bind emoji <emoji> <name>")
+		return
+	}
+
+	keys := make([]string, 0, len(bindings))
+	for key := range bindings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	embed := &discordgo.MessageEmbed{
+		Title:  "Emoji bindings",
+		Color:  65535,
+		Fields: []*discordgo.MessageEmbedField{},
+	}
+	for i, key := range keys {
+		// Embeds are limited to 25 fields.
+		if i == 25 {
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("and %d more", len(keys)-i)}
+			break
+		}
+		binding := bindings[key]
+		name := EmojiMention(key) + " → " + binding.Describe()
+		if len(name) > 256 {
+			name = name[:250]
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   name,
+			Value:  fmt.Sprintf("In <#%s>, cooldown %s", binding.ChannelID, binding.Cooldown),
+			Inline: true,
+		})
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
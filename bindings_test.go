@@ -0,0 +1,74 @@
+package main
+
+import "testing"
+
+func TestPickWeighted(t *testing.T) {
+	const picks = 4000
+	tests := []struct {
+		name string
+		pool []PoolMemo
+		// Share of picks each memo should get, give or take 5 points.
+		want map[string]float64
+	}{
+		{"one", []PoolMemo{{"bruh", 1}}, map[string]float64{"bruh": 1}},
+		{"even", []PoolMemo{{"bruh", 1}, {"oof", 1}}, map[string]float64{"bruh": 0.5, "oof": 0.5}},
+		{"weighted", []PoolMemo{{"quack", 3}, {"honk", 1}}, map[string]float64{"quack": 0.75, "honk": 0.25}},
+		{"capped weights", []PoolMemo{{"a", maxPoolWeight}, {"b", maxPoolWeight}, {"c", maxPoolWeight}, {"d", maxPoolWeight}},
+			map[string]float64{"a": 0.25, "b": 0.25, "c": 0.25, "d": 0.25}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counts := make(map[string]int)
+			for i := 0; i < picks; i++ {
+				counts[PickWeighted(tt.pool)]++
+			}
+			for name, n := range counts {
+				if _, ok := tt.want[name]; !ok {
+					t.Fatalf("picked %s, which isn't in the pool", name)
+				}
+				if share := float64(n) / picks; share < tt.want[name]-0.05 || share > tt.want[name]+0.05 {
+					t.Errorf("picked %s %.0f%% of the time, want about %.0f%%", name, 100*share, 100*tt.want[name])
+				}
+			}
+		})
+	}
+}
+
+func TestParsePoolMemo(t *testing.T) {
+	m := &VoiceMemoManager{Metadata: testMetadataStore(t), store: map[string]*VoiceMemo{
+		"bruh":   {name: "bruh"},
+		"a=b":    {name: "a=b"},
+		"quack":  {name: "quack"},
+		"e=mc=2": {name: "e=mc=2"},
+		"theirs": {name: "theirs"},
+	}}
+	addTestMemo(t, m.Metadata, "2", "theirs")
+	tests := []struct {
+		arg    string
+		want   PoolMemo
+		wantOK bool
+	}{
+		{"bruh", PoolMemo{"bruh", 1}, true},
+		{"quack=3", PoolMemo{"quack", 3}, true},
+		{"a=b", PoolMemo{"a=b", 1}, true},
+		{"e=mc=2=4", PoolMemo{"e=mc=2", 4}, true},
+		{"quack=100", PoolMemo{"quack", maxPoolWeight}, true},
+		{"quack=1000000", PoolMemo{"quack", maxPoolWeight}, true},
+		{"quack=0", PoolMemo{}, false},
+		{"quack=-1", PoolMemo{}, false},
+		{"quack=lots", PoolMemo{}, false},
+		{"quack=99999999999999999999", PoolMemo{}, false},
+		{"honk=2", PoolMemo{}, false},
+		{"honk", PoolMemo{}, false},
+		{"theirs", PoolMemo{}, false},
+		{"theirs=2", PoolMemo{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, ok := m.ParsePoolMemo("1", tt.arg)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParsePoolMemo(%q) = %+v, %v, want %+v, %v", tt.arg, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Select menus hold at most 25 options, so that's a page.
+	cleanupPageSize = 25
+
+	// How long a !cleanup stays usable after it was started.
+	cleanupTTL = 15 * time.Minute
+)
+
+// Orders !cleanup can list its candidates in.
+var cleanupSorts = map[string]string{
+	"never":   "never played, oldest first",
+	"oldest":  "oldest first",
+	"largest": "largest first",
+}
+
+// A memo !cleanup offers to delete.
+type CleanupCandidate struct {
+	Name       string    `json:"name"`
+	PlayCount  int       `json:"play_count"`
+	UploadedAt time.Time `json:"uploaded_at"`
+	Size       int64     `json:"size"`
+}
+
+// An admin's !cleanup in progress: what's on offer and what they picked so far. It's saved as a
+// ComponentState so the menu keeps working if the bot restarts.
+type Cleanup struct {
+	ID         string             `json:"id"`
+	GuildID    string             `json:"guild_id"`
+	Sort       string             `json:"sort"`
+	Candidates []CleanupCandidate `json:"candidates"`
+	Page       int                `json:"page"`
+	Selected   map[string]bool    `json:"selected"`
+	Confirming bool               `json:"confirming"`
+	Started    time.Time          `json:"started"`
+
+	// Only report what deleting the picks would change.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// Returns the guild's memos ordered for !cleanup. The "never" order leaves out memos that were ever played.
+func (m *VoiceMemoManager) CleanupCandidates(guildID, order string) []CleanupCandidate {
+	candidates := make([]CleanupCandidate, 0)
+	for _, md := range m.Metadata.GuildMemos(guildID) {
+		vm := m.Get(md.Name)
+		if vm == nil || order == "never" && md.PlayCount > 0 {
+			continue
+		}
+		candidate := CleanupCandidate{Name: md.Name, PlayCount: md.PlayCount, UploadedAt: md.UploadedAt}
+		if info, err := os.Stat(ObjectPath(vm.hash)); err == nil {
+			candidate.Size = info.Size()
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if order == "largest" && a.Size != b.Size {
+			return a.Size > b.Size
+		}
+		if !a.UploadedAt.Equal(b.UploadedAt) {
+			return a.UploadedAt.Before(b.UploadedAt)
+		}
+		return a.Name < b.Name
+	})
+	return candidates
+}
+
+// Formats a byte count the way people read file sizes.
+func FormatSize(bytes int64) string {
+	switch {
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%d KB", bytes/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+func (b *Bot) HandleCleanup(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can clean up voice memos.")
+		return
+	}
+
+	dry, args := ParseDryRun(args)
+	order := "never"
+	if len(args) > 0 {
+		order = args[0]
+	}
+	if _, ok := cleanupSorts[order]; !ok {
+		s.ChannelMessageSend(c.ID, "Usage: !cleanup [-dry-run] [never|oldest|largest]")
+		return
+	}
+
+	candidates := b.VoiceMemoManager.CleanupCandidates(g.ID, order)
+	if len(candidates) == 0 {
+		s.ChannelMessageSend(c.ID, "There's nothing to clean up.")
+		return
+	}
+
+	cl := &Cleanup{
+		ID:         m.ID,
+		GuildID:    g.ID,
+		Sort:       order,
+		Candidates: candidates,
+		Page:       1,
+		Selected:   make(map[string]bool),
+		Started:    time.Now(),
+		DryRun:     dry,
+	}
+
+	b.saveCleanup(cl)
+	embed, components := cl.Message()
+
+	msg := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}, Components: components}
+	if _, err := s.ChannelMessageSendComplex(c.ID, msg); err != nil {
+		fmt.Println(err)
+		return
+	}
+}
+
+// Renders the cleanup's current page, or the confirmation once the admin asked to delete.
+func (cl *Cleanup) Message() (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	selected := cl.SelectedNames()
+	if cl.Confirming {
+		list := strings.Join(selected, ", ")
+		if len(list) > 4096 {
+			list = list[:4000] + "..."
+		}
+		embed := &discordgo.MessageEmbed{
+			Title:       fmt.Sprintf("Delete %d voice memos?", len(selected)),
+			Description: list,
+			Color:       16711680,
+			Footer:      &discordgo.MessageEmbedFooter{Text: "This can't be undone."},
+		}
+		if cl.DryRun {
+			embed.Footer.Text = "Dry run: this only shows what deleting them would change."
+		}
+		return embed, []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: fmt.Sprintf("Delete %d voice memos", len(selected)), Style: discordgo.DangerButton, CustomID: cl.customID("delete")},
+			discordgo.Button{Label: "Back", Style: discordgo.SecondaryButton, CustomID: cl.customID("back")},
+		}}}
+	}
+
+	page, pages := Paginate(cl.Candidates, cl.Page, cleanupPageSize)
+	embed := &discordgo.MessageEmbed{
+		Title:       "Clean up voice memos",
+		Description: "Pick the voice memos to delete, " + cleanupSorts[cl.Sort] + ". Picks are kept when you change pages.",
+		Color:       65535,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Page %d of %d · %d selected", cl.Page, pages, len(selected))},
+	}
+	if cl.DryRun {
+		embed.Footer.Text += " · dry run"
+	}
+
+	options := make([]discordgo.SelectMenuOption, 0, len(page))
+	for _, candidate := range page {
+		description := fmt.Sprintf("Played %d times · %s", candidate.PlayCount, FormatSize(candidate.Size))
+		if !candidate.UploadedAt.IsZero() {
+			description += " · uploaded " + candidate.UploadedAt.Format("Jan 2, 2006")
+		}
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       candidate.Name,
+			Value:       candidate.Name,
+			Description: description,
+			Default:     cl.Selected[candidate.Name],
+		})
+	}
+	none := 0
+
+	return embed, []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{
+				CustomID:    cl.customID("select"),
+				Placeholder: "Voice memos to delete",
+				MinValues:   &none,
+				MaxValues:   len(options),
+				Options:     options,
+			},
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Previous", Style: discordgo.SecondaryButton, CustomID: cl.customID("page", strconv.Itoa(cl.Page-1)), Disabled: cl.Page <= 1},
+			discordgo.Button{Label: "Next", Style: discordgo.SecondaryButton, CustomID: cl.customID("page", strconv.Itoa(cl.Page+1)), Disabled: cl.Page >= pages},
+			discordgo.Button{Label: fmt.Sprintf("Delete %d selected", len(selected)), Style: discordgo.DangerButton, CustomID: cl.customID("confirm"), Disabled: len(selected) == 0},
+			discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: cl.customID("cancel")},
+		}},
+	}
+}
+
+// Returns the names the admin picked, in the order they're listed.
+func (cl *Cleanup) SelectedNames() []string {
+	names := make([]string, 0, len(cl.Selected))
+	for _, candidate := range cl.Candidates {
+		if cl.Selected[candidate.Name] {
+			names = append(names, candidate.Name)
+		}
+	}
+	return names
+}
+
+func (cl *Cleanup) customID(parts ...string) string {
+	return "cleanup:" + cl.ID + ":" + strings.Join(parts, ":")
+}
+
+// Handles the components of a !cleanup message. arg is "<cleanup id>:<action>[:<page>]".
+func (b *Bot) HandleCleanupInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	if i.Member == nil || !HasAdminPermissions(i.Member.Permissions) {
+		RespondEphemeral(s, i, "Only admins can clean up voice memos.")
+		return
+	}
+
+	parts := strings.Split(arg, ":")
+	b.cleanupsMu.Lock()
+	cl := &Cleanup{}
+	ok := b.VoiceMemoManager.Metadata.LoadComponent("cleanup", parts[0], cl)
+	if !ok || cl.GuildID != i.GuildID || len(parts) < 2 {
+		b.cleanupsMu.Unlock()
+		RespondEphemeral(s, i, "This cleanup has expired. Run !cleanup again.")
+		return
+	}
+
+	switch parts[1] {
+	case "select":
+		// The menu only reports picks from the page it shows.
+		page, _ := Paginate(cl.Candidates, cl.Page, cleanupPageSize)
+		for _, candidate := range page {
+			delete(cl.Selected, candidate.Name)
+		}
+		for _, name := range i.MessageComponentData().Values {
+			cl.Selected[name] = true
+		}
+	case "page":
+		if len(parts) > 2 {
+			if page, err := strconv.Atoi(parts[2]); err == nil && page >= 1 {
+				cl.Page = page
+			}
+		}
+	case "confirm":
+		cl.Confirming = len(cl.Selected) > 0
+	case "back":
+		cl.Confirming = false
+	case "cancel":
+		b.dropCleanup(cl.ID)
+		b.cleanupsMu.Unlock()
+		b.replaceComponentMessage(s, i, "Cleanup cancelled, nothing was deleted.")
+		return
+	case "delete":
+		names := cl.SelectedNames()
+		b.dropCleanup(cl.ID)
+		b.cleanupsMu.Unlock()
+		// Cleanups started before the bot was restarted with -dry-run don't know about it.
+		if cl.DryRun || dryRun {
+			b.replaceComponentMessageEmbed(s, i, DryRunEmbed(fmt.Sprintf("!cleanup would delete %d voice memos", len(names)), b.VoiceMemoManager.PlanDeletion(names).Fields()))
+			return
+		}
+		b.finishCleanup(s, i, names)
+		return
+	}
+
+	b.saveCleanup(cl)
+	embed, components := cl.Message()
+	b.cleanupsMu.Unlock()
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+	if err != nil {
+		fmt.Println("Error responding to interaction: ", err)
+	}
+}
+
+func (b *Bot) saveCleanup(cl *Cleanup) {
+	if err := b.VoiceMemoManager.Metadata.SaveComponent("cleanup", cl.ID, cl.GuildID, cl.Started.Add(cleanupTTL), cl); err != nil {
+		fmt.Println("Error saving cleanup: ", err)
+	}
+}
+
+func (b *Bot) dropCleanup(id string) {
+	if err := b.VoiceMemoManager.Metadata.DropComponent("cleanup", id); err != nil {
+		fmt.Println("Error saving cleanup: ", err)
+	}
+}
+
+// Deletes the picked memos, reports what happened in place of the cleanup message and logs it.
+func (b *Bot) finishCleanup(s *discordgo.Session, i *discordgo.InteractionCreate, names []string) {
+	// Deleting can take longer than Discord waits for a response, so answer first.
+	b.replaceComponentMessage(s, i, fmt.Sprintf("Deleting %d voice memos...", len(names)))
+
+	deleted := make([]string, 0, len(names))
+	failed := make([]string, 0)
+	for _, name := range names {
+		if _, err := b.VoiceMemoManager.Delete(name); err != nil {
+			fmt.Println("Error deleting ", name, ": ", err)
+			failed = append(failed, name)
+			continue
+		}
+		deleted = append(deleted, name)
+	}
+
+	summary := fmt.Sprintf("<@%s> deleted %d voice memos with !cleanup", i.Member.User.ID, len(deleted))
+	if len(deleted) > 0 {
+		summary += ": " + strings.Join(deleted, ", ")
+	}
+	if len(failed) > 0 {
+		summary += "\nCould not delete: " + strings.Join(failed, ", ")
+	}
+	if len(summary) > 2000 {
+		summary = summary[:1990] + "..."
+	}
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &summary}); err != nil {
+		fmt.Println("Error editing interaction response: ", err)
+	}
+	b.Audit(s, i.GuildID, summary)
+}
+
+// Replaces the message a component is on with an embed and takes its components away.
+func (b *Bot) replaceComponentMessageEmbed(s *discordgo.Session, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		fmt.Println("Error responding to interaction: ", err)
+	}
+}
+
+// Replaces the message a component is on with a plain notice and takes its components away.
+func (b *Bot) replaceComponentMessage(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Embeds:     []*discordgo.MessageEmbed{},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		fmt.Println("Error responding to interaction: ", err)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Buttons and menus have to keep working when the bot restarts in between showing and using them. Ones that
+// only need a little state, like confirmations, carry it in a signed custom ID. Ones that need more, like
+// !trim and !cleanup, keep it with the metadata as a ComponentState.
+
+// What a component whose state is saved with the metadata needs to carry on, keyed by "<kind>:<id>".
+type ComponentState struct {
+	GuildID string          `json:"guild_id"`
+	Expires time.Time       `json:"expires"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Saves the state of a component until expires, dropping the state of components that have expired.
+func (ms *MetadataStore) SaveComponent(kind, id, guildID string, expires time.Time, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for key, state := range ms.Components {
+		if time.Now().After(state.Expires) {
+			delete(ms.Components, key)
+		}
+	}
+	ms.Components[kind+":"+id] = &ComponentState{GuildID: guildID, Expires: expires, Data: data}
+	return ms.save()
+}
+
+// Loads the state of a component into v. Returns false if there's none, or it has expired.
+func (ms *MetadataStore) LoadComponent(kind, id string, v interface{}) bool {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	state, ok := ms.Components[kind+":"+id]
+	if !ok || time.Now().After(state.Expires) {
+		return false
+	}
+	return json.Unmarshal(state.Data, v) == nil
+}
+
+// Forgets the state of a component once it's done.
+func (ms *MetadataStore) DropComponent(kind, id string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.Components[kind+":"+id]; !ok {
+		return nil
+	}
+	delete(ms.Components, kind+":"+id)
+	return ms.save()
+}
+
+// Forgets the state of every component in a guild. Callers must hold ms.mu.
+func (ms *MetadataStore) dropGuildComponents(guildID string) {
+	for key, state := range ms.Components {
+		if state.GuildID == guildID {
+			delete(ms.Components, key)
+		}
+	}
+}
+
+// Derives the key custom IDs are signed with from the bot token, so it stays the same across restarts.
+func SigningKey(token string) []byte {
+	key := sha256.Sum256([]byte("custom ids:" + token))
+	return key[:]
+}
+
+// Returns id with when it stops working and a signature added, as "<id>|<expiry>|<signature>".
+func (b *Bot) SignCustomID(id string, ttl time.Duration) string {
+	signed := id + "|" + strconv.FormatInt(time.Now().Add(ttl).Unix(), 36)
+	return signed + "|" + b.signature(signed)
+}
+
+// Takes the expiry and signature off a custom ID made by SignCustomID. ok is false if it isn't signed, the
+// signature doesn't match, or it has expired.
+func (b *Bot) VerifyCustomID(customID string) (id string, ok bool) {
+	parts := strings.Split(customID, "|")
+	if len(parts) < 3 {
+		return customID, false
+	}
+	id = strings.Join(parts[:len(parts)-2], "|")
+	signed, signature := strings.Join(parts[:len(parts)-1], "|"), parts[len(parts)-1]
+	if !hmac.Equal([]byte(signature), []byte(b.signature(signed))) {
+		return id, false
+	}
+	expires, err := strconv.ParseInt(parts[len(parts)-2], 36, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return id, false
+	}
+	return id, true
+}
+
+// Custom IDs are limited to 100 characters, so the signature is a shortened HMAC.
+func (b *Bot) signature(s string) string {
+	mac := hmac.New(sha256.New, b.SigningKey)
+	mac.Write([]byte(s))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:12])
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyCustomID(t *testing.T) {
+	b := &Bot{SigningKey: SigningKey("token")}
+	other := &Bot{SigningKey: SigningKey("another token")}
+
+	tests := []struct {
+		name     string
+		customID string
+		wantID   string
+		wantOK   bool
+	}{
+		{"signed", b.SignCustomID("queue:skip", time.Hour), "queue:skip", true},
+		{"id with separators", b.SignCustomID("mydata|delete|keep", time.Hour), "mydata|delete|keep", true},
+		{"empty id", b.SignCustomID("", time.Hour), "", true},
+		{"expired", b.SignCustomID("queue:skip", -time.Minute), "queue:skip", false},
+		{"other key", other.SignCustomID("queue:skip", time.Hour), "queue:skip", false},
+		{"tampered id", strings.Replace(b.SignCustomID("queue:skip", time.Hour), "skip", "stop", 1), "queue:stop", false},
+		{"tampered expiry", tamperExpiry(b.SignCustomID("queue:skip", -time.Minute)), "queue:skip", false},
+		{"unsigned", "queue:skip", "queue:skip", false},
+		{"only a signature", "queue:skip|abc", "queue:skip|abc", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := b.VerifyCustomID(tt.customID)
+			if id != tt.wantID || ok != tt.wantOK {
+				t.Errorf("VerifyCustomID(%q) = %q, %v, want %q, %v", tt.customID, id, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
+
+// Pushes the expiry of a signed custom ID out to 2038, keeping its signature.
+func tamperExpiry(customID string) string {
+	parts := strings.Split(customID, "|")
+	parts[len(parts)-2] = "zzzzzz"
+	return strings.Join(parts, "|")
+}
+
+func TestSignCustomIDLength(t *testing.T) {
+	// Discord rejects custom IDs over 100 characters, so signing can only add so much.
+	id := strings.Repeat("x", 60)
+	if signed := (&Bot{SigningKey: SigningKey("token")}).SignCustomID(id, 24*time.Hour); len(signed) > 100 {
+		t.Errorf("signed custom ID is %d characters, want at most 100", len(signed))
+	}
+}
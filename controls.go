@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Identifies a memo in a button's custom ID, which is too short for some names.
+func nameToken(name string) string {
+	return strconv.FormatUint(uint64(crc32.ChecksumIEEE([]byte(name))), 36)
+}
+
+// Returns the pause, skip, stop and loop buttons for the now-playing message of vm, showing what's on. They
+// stop working once something else is playing.
+func (gs *GuildSession) PlaybackControls(vm *VoiceMemo) []discordgo.MessageComponent {
+	token := nameToken(vm.name)
+	pause, loop := discordgo.SecondaryButton, discordgo.SecondaryButton
+	if gs.Paused() {
+		pause = discordgo.PrimaryButton
+	}
+	if gs.LoopOne.Load() {
+		loop = discordgo.PrimaryButton
+	}
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Emoji: discordgo.ComponentEmoji{Name: "⏯️"}, Style: pause, CustomID: "playback:pause:" + token},
+		discordgo.Button{Emoji: discordgo.ComponentEmoji{Name: "⏭️"}, Style: discordgo.SecondaryButton, CustomID: "playback:skip:" + token},
+		discordgo.Button{Emoji: discordgo.ComponentEmoji{Name: "⏹️"}, Style: discordgo.SecondaryButton, CustomID: "playback:stop:" + token},
+		discordgo.Button{Emoji: discordgo.ComponentEmoji{Name: "🔁"}, Style: loop, CustomID: "playback:loop:" + token},
+	}}}
+}
+
+// Handles the buttons of a now-playing message. arg is "<action>:<token>". Whoever asked for the memo can
+// use them, as can admins and DJs. Only admins and DJs can stop, since that clears everyone's queue.
+func (b *Bot) HandlePlaybackButton(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	action, token, _ := strings.Cut(arg, ":")
+	gs, ok := b.Session(i.GuildID)
+	var current *VoiceMemo
+	requester := ""
+	if ok {
+		current, requester = gs.Current()
+	}
+	if current == nil || nameToken(current.name) != token {
+		b.updatePlaybackMessage(s, i, "That's done playing.", []discordgo.MessageComponent{})
+		return
+	}
+
+	userID := InteractionUserID(i)
+	if requester != userID && !b.IsDJ(s, i.GuildID, userID, i.ChannelID) {
+		RespondEphemeral(s, i, "Only admins, DJs and whoever asked for "+current.name+" can control it.")
+		return
+	}
+
+	notice := ""
+	switch action {
+	case "pause":
+		if gs.Resume() {
+			notice = fmt.Sprintf("<@%s> resumed.", userID)
+		} else if gs.Pause() {
+			notice = fmt.Sprintf("<@%s> paused.", userID)
+		}
+	case "skip":
+		if refusal := b.QueueRefusal(s, gs, i.ChannelID, userID, "skip"); refusal != "" {
+			RespondEphemeral(s, i, refusal)
+			return
+		}
+		if _, ok := gs.Skip(); ok {
+			b.updatePlaybackMessage(s, i, fmt.Sprintf("<@%s> skipped %s", userID, current.name), []discordgo.MessageComponent{})
+			return
+		}
+	case "stop":
+		if refusal := b.QueueRefusal(s, gs, i.ChannelID, userID, "stop"); refusal != "" {
+			RespondEphemeral(s, i, refusal)
+			return
+		}
+		stopped := gs.Stop()
+		// The player stops speaking once it notices, but make sure in case it never started.
+		gs.VoiceConnection.Speaking(false)
+		b.updatePlaybackMessage(s, i, fmt.Sprintf("<@%s> stopped playback and cleared %d voice memos.", userID, stopped), []discordgo.MessageComponent{})
+		return
+	case "loop":
+		on := !gs.LoopOne.Load()
+		gs.LoopOne.Store(on)
+		notice = fmt.Sprintf("<@%s> stopped looping %s.", userID, current.name)
+		if on {
+			notice = fmt.Sprintf("<@%s> is looping %s.", userID, current.name)
+		}
+	}
+	b.updatePlaybackMessage(s, i, notice, gs.PlaybackControls(current))
+}
+
+// Updates the now-playing message a button is on, keeping its embed.
+func (b *Bot) updatePlaybackMessage(s *discordgo.Session, i *discordgo.InteractionCreate, content string, components []discordgo.MessageComponent) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Embeds:     i.Message.Embeds,
+			Components: components,
+		},
+	})
+	if err != nil {
+		fmt.Println("Error responding to interaction: ", err)
+	}
+}
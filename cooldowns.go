@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Longest cooldown !cooldown accepts for a command.
+	maxCommandCooldown = time.Hour
+
+	// How often cooldowns that changed are written to the metadata file, so a restart doesn't reset them but a
+	// busy server doesn't rewrite the file on every command either.
+	cooldownFlushInterval = 10 * time.Second
+)
+
+// Keeps track of when things may happen again.
+type Cooldowns struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+
+	// Where the cooldowns are saved once Persist is called, and whether they changed since they last were.
+	store *MetadataStore
+	dirty bool
+
+	// Held for a whole Flush, so an older snapshot can't be written over a newer one.
+	flushMu sync.Mutex
+}
+
+func NewCooldowns() *Cooldowns {
+	return &Cooldowns{until: make(map[string]time.Time)}
+}
+
+// Reports whether key is off cooldown, and if so starts a new cooldown of d for it.
+func (c *Cooldowns) Try(key string, d time.Duration) bool {
+	return c.Take(key, d) == 0
+}
+
+// Starts a new cooldown of d for key if it's off cooldown. Otherwise returns how long is left of the one running.
+func (c *Cooldowns) Take(key string, d time.Duration) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if until := c.until[key]; now.Before(until) {
+		return until.Sub(now)
+	}
+	c.until[key] = now.Add(d)
+	c.dirty = true
+
+	// Forget cooldowns that have run out so the map doesn't grow forever.
+	for k, until := range c.until {
+		if now.After(until) {
+			delete(c.until, k)
+		}
+	}
+	return 0
+}
+
+// Drops the running cooldowns that belong to a user, so they aren't saved again after !mydata delete.
+func (c *Cooldowns) Forget(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.until {
+		if cooldownOwner(key) == userID {
+			delete(c.until, key)
+			c.dirty = true
+		}
+	}
+}
+
+// Returns who a cooldown belongs to, for the ones keyed by a user, like "command:<guild>:<command>:<user>".
+// Returns "" for the rest.
+func cooldownOwner(key string) string {
+	if !strings.HasPrefix(key, "command:") && !strings.HasPrefix(key, "nudge:") {
+		return ""
+	}
+	return key[strings.LastIndex(key, ":")+1:]
+}
+
+// Picks up the cooldowns the last run saved to ms that are still running, and writes changes back to it every
+// cooldownFlushInterval from then on. Call Flush before exiting so the last few aren't lost.
+func (c *Cooldowns) Persist(ms *MetadataStore) {
+	c.mu.Lock()
+	now := time.Now()
+	for key, until := range ms.SavedCooldowns() {
+		if now.Before(until) && until.After(c.until[key]) {
+			c.until[key] = until
+		}
+	}
+	c.store = ms
+	c.mu.Unlock()
+
+	go func() {
+		for range time.Tick(cooldownFlushInterval) {
+			c.Flush()
+		}
+	}()
+}
+
+// Saves the running cooldowns to the metadata file if they changed since they last were.
+func (c *Cooldowns) Flush() {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	c.mu.Lock()
+	if c.store == nil || !c.dirty {
+		c.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	running := make(map[string]time.Time, len(c.until))
+	for key, until := range c.until {
+		if now.Before(until) {
+			running[key] = until
+		}
+	}
+	c.dirty = false
+	store := c.store
+	c.mu.Unlock()
+
+	if err := store.SaveCooldowns(running); err != nil {
+		fmt.Println("Error saving cooldowns: ", err)
+		c.mu.Lock()
+		c.dirty = true
+		c.mu.Unlock()
+	}
+}
+
+// Returns a copy of the cooldowns saved by Cooldowns.Flush.
+func (ms *MetadataStore) SavedCooldowns() map[string]time.Time {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	saved := make(map[string]time.Time, len(ms.Cooldowns))
+	for key, until := range ms.Cooldowns {
+		saved[key] = until
+	}
+	return saved
+}
+
+// Replaces the saved cooldowns with running and writes the store to disk.
+func (ms *MetadataStore) SaveCooldowns(running map[string]time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.Cooldowns = running
+	return ms.save()
+}
+
+// Returns how long each person has to wait between uses of command in the guild, or 0 if they don't.
+func (gs GuildSettings) CommandCooldown(command string) time.Duration {
+	return time.Duration(gs.CommandCooldowns[command]) * time.Second
+}
+
+// Starts the guild's cooldown on command for a user, or returns how long they still have to wait if it's
+// already running. Admins and DJs never wait.
+func (b *Bot) CommandWait(s *discordgo.Session, guildID, channelID, userID, command string) time.Duration {
+	cooldown := b.VoiceMemoManager.Metadata.Guild(guildID).CommandCooldown(command)
+	if cooldown == 0 || b.IsDJ(s, guildID, userID, channelID) {
+		return 0
+	}
+	return b.Cooldowns.Take("command:"+guildID+":"+command+":"+userID, cooldown)
+}
+
+// Tells someone politely how long to wait before using command again, e.g. "!play again in 8s".
+func CooldownNotice(command string, wait time.Duration) string {
+	wait = (wait + time.Second - 1).Truncate(time.Second)
+	return fmt.Sprintf("Easy there! You can use %s again in %s.", command, wait)
+}
+
+func (b *Bot) HandleCooldown(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := "Usage: !cooldown [list] | !cooldown <command> <duration>|off"
+	metadata := b.VoiceMemoManager.Metadata
+	if len(args) == 0 || args[0] == "list" {
+		cooldowns := metadata.Guild(g.ID).CommandCooldowns
+		if len(cooldowns) == 0 {
+			s.ChannelMessageSend(c.ID, "Commands don't have cooldowns in "+g.Name)
+			return
+		}
+		commands := make([]string, 0, len(cooldowns))
+		for command := range cooldowns {
+			commands = append(commands, command)
+		}
+		sort.Strings(commands)
+		lines := make([]string, 0, len(commands))
+		for _, command := range commands {
+			lines = append(lines, fmt.Sprintf("!%s: once every %s", command, time.Duration(cooldowns[command])*time.Second))
+		}
+		s.ChannelMessageSend(c.ID, "Cooldowns per person in "+g.Name+", admins and DJs don't have them:\n"+strings.Join(lines, "\n"))
+		return
+	}
+	if len(args) < 2 {
+		s.ChannelMessageSend(c.ID, usage)
+		return
+	}
+
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can change command cooldowns.")
+		return
+	}
+
+	ch, ok := LookupCommand(args[0])
+	if !ok {
+		s.ChannelMessageSend(c.ID, "There's no command called "+args[0]+". !help lists them.")
+		return
+	}
+	command := ch.Name
+
+	seconds := 0
+	if args[1] != "off" {
+		d, err := time.ParseDuration(args[1])
+		if err != nil || d < time.Second || d > maxCommandCooldown {
+			s.ChannelMessageSend(c.ID, fmt.Sprintf("Cooldowns can be 1s to %s, like 10s or 2m.", maxCommandCooldown))
+			return
+		}
+		seconds = int(d / time.Second)
+	}
+
+	err := metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		if seconds == 0 {
+			delete(gs.CommandCooldowns, command)
+			return
+		}
+		if gs.CommandCooldowns == nil {
+			gs.CommandCooldowns = make(map[string]int)
+		}
+		gs.CommandCooldowns[command] = seconds
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+
+	if seconds == 0 {
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("!%s doesn't have a cooldown anymore.", command))
+		b.Audit(s, g.ID, fmt.Sprintf("<@%s> removed the cooldown on !%s.", m.Author.ID, command))
+		return
+	}
+	cooldown := time.Duration(seconds) * time.Second
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("Everyone but admins and DJs can use !%s once every %s now.", command, cooldown))
+	b.Audit(s, g.ID, fmt.Sprintf("<@%s> set the cooldown on !%s to %s.", m.Author.ID, command, cooldown))
+}
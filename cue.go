@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Queues a memo for someone in particular, like a call-out or a reminder, but only if they're in the voice
+// channel with the bot right now. Otherwise says where they are instead, going by the live voice states.
+func (b *Bot) HandleCue(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) != 2 {
+		s.ChannelMessageSend(c.ID, "Usage: !cue @user <name>")
+		return
+	}
+	userID := ParseUser(args[0])
+	if _, err := s.GuildMember(g.ID, userID); err != nil {
+		s.ChannelMessageSend(c.ID, "I can only cue voice memos for people in "+g.Name)
+		return
+	}
+	gs, ok := b.Session(g.ID)
+	if !ok {
+		s.ChannelMessageSend(c.ID, "I need to !join a voice channel before I can cue anything.")
+		return
+	}
+
+	voiceMemo := b.VoiceMemoManager.GuildMemo(g.ID, args[1])
+	if voiceMemo == nil {
+		b.Outbox.Error(s, c.ID, "Cannot find "+args[1])
+		return
+	}
+	if !b.CanPlay(s, g, c.ID, m.Author.ID, voiceMemo.name) {
+		b.Outbox.Error(s, c.ID, "You don't have a role that can play "+voiceMemo.name)
+		return
+	}
+	if reason := b.OutsideWindow(g.ID, voiceMemo.name); reason != "" {
+		b.Outbox.Error(s, c.ID, reason)
+		return
+	}
+
+	// The bot may have been moved since it joined, so compare against where it is now.
+	here := gs.VoiceConnection.ChannelID
+	switch where := UserVoiceChannel(g, userID); {
+	case where == "":
+		sendCueReply(s, c.ID, fmt.Sprintf("<@%s> isn't in a voice channel, so I didn't play %s.", userID, voiceMemo.name))
+		return
+	case where != here:
+		sendCueReply(s, c.ID, fmt.Sprintf("<@%s> is in <#%s>, not <#%s> with me, so I didn't play %s.", userID, where, here, voiceMemo.name))
+		return
+	}
+
+	eta := gs.QueueETA()
+	wait := gs.IsVoicePlaying.Load()
+	if !gs.EnqueueEntry(QueueEntry{Memo: voiceMemo, RequesterID: m.Author.ID, ChannelID: c.ID}) {
+		b.Outbox.Error(s, c.ID, "The queue is full. Try again later.")
+		return
+	}
+	b.VoiceMemoManager.RecordPlay(g.ID, m.Author.ID, voiceMemo.name)
+
+	reply := fmt.Sprintf("Cued %s for <@%s>.", voiceMemo.name, userID)
+	if gs.Paused() {
+		reply += " Playback is paused, !resume to carry on."
+	} else if wait {
+		reply += fmt.Sprintf(" Playing in about %s.", FormatDuration(eta))
+	}
+	sendCueReply(s, c.ID, reply)
+
+	// Playback outlives the command, so it doesn't count against the command timeout.
+	go gs.PlayFromQueue()
+}
+
+// Sends a !cue reply without pinging whoever it's about, since the point is to catch them in voice.
+func sendCueReply(s *discordgo.Session, channelID, content string) {
+	msg := &discordgo.MessageSend{
+		Content:         content,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+	if _, err := s.ChannelMessageSendComplex(channelID, msg); err != nil {
+		fmt.Println(err)
+	}
+}
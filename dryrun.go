@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Takes a -dry-run or --dry-run option off a command's arguments, wherever it is. Reports whether the
+// command should only say what it would change, which it always does when the bot runs with -dry-run.
+func ParseDryRun(args []string) (bool, []string) {
+	dry, rest := takeFlag(args, "dry-run")
+	return dry || dryRun, rest
+}
+
+// What deleting some memos would change, worked out without changing anything.
+type DeletionPlan struct {
+	// The memos that would be deleted, and the ones asked for that don't exist.
+	Memos   []string
+	Missing []string
+
+	// Files that would leave the object store because no other memo uses them, and how big they are together.
+	Files int
+	Bytes int64
+
+	// How many packs, playlists and play restrictions would lose a memo.
+	Packs        int
+	Playlists    int
+	Restrictions int
+
+	// Memos that are queued or playing somewhere, so their file would only go once they're done.
+	Pending []string
+}
+
+// Works out what deleting the named memos would change.
+func (m *VoiceMemoManager) PlanDeletion(names []string) DeletionPlan {
+	plan := DeletionPlan{Memos: make([]string, 0, len(names)), Missing: make([]string, 0), Pending: make([]string, 0)}
+	deleted := make(map[string]bool, len(names))
+	hashes := make(map[string]bool)
+	for _, name := range names {
+		vm := m.Get(name)
+		if vm == nil {
+			plan.Missing = append(plan.Missing, name)
+			continue
+		}
+		plan.Memos = append(plan.Memos, name)
+		deleted[name] = true
+		hashes[vm.hash] = true
+		if vm.Refs() > 0 {
+			plan.Pending = append(plan.Pending, name)
+		}
+	}
+
+	for hash := range hashes {
+		if m.Metadata.HashUsedBeyond(hash, deleted) {
+			continue
+		}
+		plan.Files++
+		if info, err := os.Stat(ObjectPath(hash)); err == nil {
+			plan.Bytes += info.Size()
+		}
+	}
+	plan.Packs, plan.Playlists, plan.Restrictions = m.Metadata.References(deleted)
+	return plan
+}
+
+// Reports whether a memo other than the ones in names has the audio with the given hash.
+func (ms *MetadataStore) HashUsedBeyond(hash string, names map[string]bool) bool {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for name, md := range ms.Memos {
+		if md.Hash == hash && !names[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// Counts the packs, playlists and play restrictions that mention any of names.
+func (ms *MetadataStore) References(names map[string]bool) (packs, playlists, restrictions int) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	mentions := func(memos []string) bool {
+		for _, name := range memos {
+			if names[name] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, pack := range ms.Packs {
+		if mentions(pack.Memos) {
+			packs++
+		}
+	}
+	for _, guildPlaylists := range ms.Playlists {
+		for _, pl := range guildPlaylists {
+			if mentions(pl.Memos) {
+				playlists++
+			}
+		}
+	}
+	for _, gs := range ms.Guilds {
+		for name := range gs.Restrictions {
+			if names[name] {
+				restrictions++
+			}
+		}
+	}
+	return packs, playlists, restrictions
+}
+
+// Lists the plan as embed fields.
+func (p DeletionPlan) Fields() []*discordgo.MessageEmbedField {
+	field := func(name string, names []string) *discordgo.MessageEmbedField {
+		value := strings.Join(names, ", ")
+		if len(value) > 1024 {
+			value = value[:1000] + "..."
+		}
+		return &discordgo.MessageEmbedField{Name: name, Value: value}
+	}
+
+	fields := make([]*discordgo.MessageEmbedField, 0)
+	if len(p.Memos) > 0 {
+		fields = append(fields, field(fmt.Sprintf("Voice memos (%d)", len(p.Memos)), p.Memos))
+	}
+	fields = append(fields,
+		&discordgo.MessageEmbedField{Name: "Files removed", Value: fmt.Sprintf("%d (%s)", p.Files, FormatSize(p.Bytes)), Inline: true},
+		&discordgo.MessageEmbedField{Name: "Sound packs changed", Value: fmt.Sprint(p.Packs), Inline: true},
+		&discordgo.MessageEmbedField{Name: "Playlists changed", Value: fmt.Sprint(p.Playlists), Inline: true},
+		&discordgo.MessageEmbedField{Name: "Restrictions lifted", Value: fmt.Sprint(p.Restrictions), Inline: true},
+	)
+	if len(p.Pending) > 0 {
+		fields = append(fields, field("Still queued, removed once they finish", p.Pending))
+	}
+	if len(p.Missing) > 0 {
+		fields = append(fields, field("Not found", p.Missing))
+	}
+	return fields
+}
+
+// Sends what a command would have deleted, making it clear nothing was.
+func SendDryRun(s *discordgo.Session, channelID, title string, fields []*discordgo.MessageEmbedField) {
+	embed := DryRunEmbed(title, fields)
+	if _, err := s.ChannelMessageSendEmbed(channelID, embed); err != nil {
+		fmt.Println(err)
+		return
+	}
+}
+
+func DryRunEmbed(title string, fields []*discordgo.MessageEmbedField) *discordgo.MessageEmbed {
+	if len(title) > 256 {
+		title = title[:250]
+	}
+	return &discordgo.MessageEmbed{
+		Title:  title,
+		Color:  65535,
+		Fields: fields,
+		Footer: &discordgo.MessageEmbedFooter{Text: "Dry run: nothing was changed."},
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// !duck is the percentage memos are turned down to while the bot talks over them. 0 waits for the memo to
+	// end instead, as speech used to.
+	defaultDuck = 30
+
+	// How many frames ahead of the player speech is mixed in, so ffmpeg is done by the time it gets there.
+	duckLead = 50
+)
+
+// Speech mixed over part of the memo that's playing, which the player sends in place of that part.
+type Duck struct {
+	memo   *VoiceMemo
+	at     int
+	speech QueueEntry
+
+	// Closed once the mix is done. frames is nil if it couldn't be made.
+	ready  chan struct{}
+	frames [][]byte
+
+	once sync.Once
+}
+
+// Queues the speech to play on its own after all, if that hasn't been done already.
+func (d *Duck) fallback(gs *GuildSession) bool {
+	queued := true
+	d.once.Do(func() {
+		queued = gs.queueSpeech(d.speech)
+	})
+	return queued
+}
+
+// Speaks speech over the memo that's playing with the memo turned down to percent, and back up afterwards.
+// Speech is queued like any other memo instead if nothing is playing, the player is paused, percent is 0 or
+// the memo is about to end. Returns false if it had to be queued and the queue is full.
+func (gs *GuildSession) Announce(ctx context.Context, speech *VoiceMemo, percent int, requesterID, channelID string) bool {
+	entry := QueueEntry{Memo: speech, RequesterID: requesterID, ChannelID: channelID}
+	d := gs.startDuck(entry, percent)
+	if d == nil {
+		return gs.queueSpeech(entry)
+	}
+
+	frames, err := MixOver(ctx, d.memo, d.at, speech, percent)
+	if err != nil {
+		fmt.Println("Error mixing speech over ", d.memo.name, ": ", err)
+		close(d.ready)
+		return d.fallback(gs)
+	}
+	d.frames = frames
+	close(d.ready)
+	return true
+}
+
+func (gs *GuildSession) queueSpeech(entry QueueEntry) bool {
+	if !gs.Enqueue(entry.Memo, entry.RequesterID, entry.ChannelID) {
+		return false
+	}
+	go gs.PlayFromQueue()
+	return true
+}
+
+// Sets up speech to be mixed over the memo that's playing, a little ahead of the player. Returns nil if it
+// can't be, including when other speech is already waiting to be.
+func (gs *GuildSession) startDuck(speech QueueEntry, percent int) *Duck {
+	if percent <= 0 || gs.Paused() {
+		return nil
+	}
+
+	gs.duckMu.Lock()
+	defer gs.duckMu.Unlock()
+
+	if gs.playing == nil || gs.duck != nil {
+		return nil
+	}
+	at := int(gs.position.Load()) + duckLead
+	if at >= gs.playingEnd {
+		return nil
+	}
+	gs.duck = &Duck{memo: gs.playing, at: at, speech: speech, ready: make(chan struct{})}
+	return gs.duck
+}
+
+// Records that the player started sending vm from frame start, and will stop at frame end.
+func (gs *GuildSession) startPlaying(vm *VoiceMemo, start, end int) {
+	gs.duckMu.Lock()
+	defer gs.duckMu.Unlock()
+
+	gs.playing, gs.playingEnd, gs.seeking = vm, end, false
+	gs.position.Store(int64(start))
+}
+
+// Returns the speech due to be mixed in at the frame the player is on, handing it over to the player.
+func (gs *GuildSession) takeDuck() *Duck {
+	gs.duckMu.Lock()
+	defer gs.duckMu.Unlock()
+
+	d := gs.duck
+	if d == nil || d.memo != gs.playing || int(gs.position.Load()) < d.at {
+		return nil
+	}
+	gs.duck = nil
+	return d
+}
+
+// Records that the player is done with the memo. Returns speech that was due to be mixed over it but the
+// player never got to.
+func (gs *GuildSession) stopPlaying() *Duck {
+	gs.duckMu.Lock()
+	defer gs.duckMu.Unlock()
+
+	d := gs.duck
+	gs.playing, gs.duck, gs.seeking = nil, nil, false
+	return d
+}
+
+// Mixes speech over the frames of vm from at on, with vm turned down to percent while it's heard. The result
+// lasts as long as the speech, or until vm ends if that's later.
+func MixOver(ctx context.Context, vm *VoiceMemo, at int, speech *VoiceMemo, percent int) ([][]byte, error) {
+	bed := make([][]byte, 0, speech.Frames())
+	i := 0
+	err := vm.EachFrame(func(frame []byte) bool {
+		if i >= at {
+			bed = append(bed, frame)
+		}
+		i++
+		return len(bed) < speech.Frames()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var bedOgg bytes.Buffer
+	if err := (&VoiceMemo{name: vm.name, buffer: bed}).WriteOgg(&bedOgg); err != nil {
+		return nil, err
+	}
+
+	// ffmpeg only gets one stdin, so the speech goes through a file.
+	speechFile, err := os.CreateTemp("", "voicememo-speech-*.ogg")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(speechFile.Name())
+	err = speech.WriteOgg(speechFile)
+	if closeErr := speechFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// amix halves both inputs, so the sum is doubled back up and limited to keep it from clipping.
+	mix := fmt.Sprintf("[0:a]volume=%.3f[bed];[bed][1:a]amix=inputs=2:duration=longest:dropout_transition=0,volume=2,alimiter=limit=0.95", float64(percent)/100)
+	args := []string{"-i", "pipe:0", "-i", speechFile.Name(), "-filter_complex", mix}
+	return encodeDCA(ctx, bedOgg.Bytes(), vm.Channels(), args)
+}
+
+// Returns the percentage memos are turned down to while the bot talks over them.
+func (gs GuildSettings) DuckPercent() int {
+	if gs.Duck == nil {
+		return defaultDuck
+	}
+	return *gs.Duck
+}
+
+func (b *Bot) HandleDuck(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		percent := b.VoiceMemoManager.Metadata.Guild(g.ID).DuckPercent()
+		if percent == 0 {
+			s.ChannelMessageSend(c.ID, "!say waits for the memo that's playing to end in "+g.Name+".")
+			return
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("!say talks over the memo that's playing, turning it down to %d%%, in %s.", percent, g.Name))
+		return
+	}
+	if !b.IsDJ(s, g.ID, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins and DJs can change ducking.")
+		return
+	}
+
+	percent, err := strconv.Atoi(strings.TrimSuffix(args[0], "%"))
+	if args[0] == "off" {
+		percent, err = 0, nil
+	}
+	if err != nil || percent < 0 || percent > 100 {
+		s.ChannelMessageSend(c.ID, "Usage: !duck [0-100|off]")
+		return
+	}
+
+	err = b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		gs.Duck = &percent
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+	if percent == 0 {
+		s.ChannelMessageSend(c.ID, "!say will wait for the memo that's playing to end.")
+		return
+	}
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("!say will talk over the memo that's playing, turning it down to %d%%.", percent))
+}
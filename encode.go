@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Encodes audio in any format ffmpeg understands into opus frames ready to send, without touching disk.
+// channels is 1 for mono or 2 for stereo, and filters are ffmpeg audio filters applied on the way, like "volume=3dB".
+func EncodeDCA(ctx context.Context, audio []byte, channels int, filters ...string) ([][]byte, error) {
+	args := []string{"-i", "pipe:0"}
+	if len(filters) > 0 {
+		args = append(args, "-af", strings.Join(filters, ","))
+	}
+	return encodeDCA(ctx, audio, channels, args)
+}
+
+// Runs ffmpeg with args, which say what to read and how to filter it, feeding it audio on stdin, and
+// encodes what comes out into opus frames.
+func encodeDCA(ctx context.Context, audio []byte, channels int, args []string) ([][]byte, error) {
+	args = append(args, "-f", "s16le", "-ar", "48000", "-ac", strconv.Itoa(channels), "pipe:1")
+	ffmpeg := exec.CommandContext(ctx, "ffmpeg", args...)
+	dca := exec.CommandContext(ctx, "dca", "-ac", strconv.Itoa(channels))
+
+	var ffmpegErr bytes.Buffer
+	ffmpeg.Stdin = bytes.NewReader(audio)
+	ffmpeg.Stderr = &ffmpegErr
+
+	pcm, err := ffmpeg.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	dca.Stdin = pcm
+
+	encoded, err := dca.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dca.Start(); err != nil {
+		return nil, err
+	}
+	if err := ffmpeg.Start(); err != nil {
+		dca.Wait()
+		return nil, err
+	}
+
+	frames, readErr := ReadDCA(encoded)
+	ffmpegWaitErr := ffmpeg.Wait()
+	dcaWaitErr := dca.Wait()
+
+	if ffmpegWaitErr != nil {
+		return nil, fmt.Errorf("ffmpeg: %w: %s", ffmpegWaitErr, ffmpegErr.String())
+	}
+	if dcaWaitErr != nil {
+		return nil, fmt.Errorf("dca: %w", dcaWaitErr)
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return frames, nil
+}
+
+// Returns how many channels the first audio stream of a file has, according to ffprobe.
+func ProbeChannels(ctx context.Context, path string) (int, error) {
+	out, err := exec.CommandContext(ctx, "ffprobe", "-v", "error", "-select_streams", "a:0", "-show_entries", "stream=channels", "-of", "csv=p=0", path).Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe: %w", err)
+	}
+	channels, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe didn't report the channels: %q", out)
+	}
+	return channels, nil
+}
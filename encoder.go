@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Uploads can be converted by separate -role=encoder processes, so ffmpeg doesn't compete for CPU with the
+// process sending audio. Jobs are handed over through an -encode-queue directory both can reach: each job is
+// a directory holding the file to convert and job.json, which moves from pending to working when an encoder
+// claims it and on to done once it's converted.
+const (
+	encodePending = "pending"
+	encodeWorking = "working"
+	encodeDone    = "done"
+
+	// How often the bot looks for its finished job, and an idle encoder for new ones.
+	encodePoll = 200 * time.Millisecond
+
+	// How long a conversion may take, from being queued to being done, before the bot gives up on it.
+	// Encoders clear out jobs left behind once they're well past it.
+	encodeTimeout = 10 * time.Minute
+)
+
+type EncodeJob struct {
+	// Name of the file to convert, in the job's directory.
+	Input    string           `json:"input"`
+	Preset   ConversionPreset `json:"preset"`
+	QueuedAt time.Time        `json:"queued_at"`
+}
+
+// What an encoder leaves in result.json next to converted.dca.
+type EncodeResult struct {
+	Error string `json:"error,omitempty"`
+}
+
+// Converts the file at original into dca at converted, in an encoder if there's an -encode-queue and in this
+// process otherwise.
+func ConvertUpload(ctx context.Context, preset ConversionPreset, original, converted string) error {
+	if encodeQueue == "" {
+		return ConvertDCA(ctx, preset, original, converted)
+	}
+	return QueueConversion(ctx, encodeQueue, preset, original, converted)
+}
+
+// Runs ffmpeg and dca to convert the file at original into dca at converted.
+func ConvertDCA(ctx context.Context, preset ConversionPreset, original, converted string) error {
+	out, err := os.Create(converted)
+	if err != nil {
+		return err
+	}
+
+	ffmpeg := exec.CommandContext(ctx, "ffmpeg", preset.FFmpegArgs(original)...)
+	dca := exec.CommandContext(ctx, "dca", preset.DCAArgs()...)
+
+	dca.Stdin, _ = ffmpeg.StdoutPipe()
+	dca.Stdout = out
+	dca.Start()
+	ffmpegErr := ffmpeg.Run()
+	dcaErr := dca.Wait()
+	out.Close()
+	if ffmpegErr != nil || dcaErr != nil {
+		return fmt.Errorf("ffmpeg: %v, dca: %v", ffmpegErr, dcaErr)
+	}
+	return nil
+}
+
+// Hands a conversion to the encoders through the queue in dir and waits for it to be done.
+func QueueConversion(ctx context.Context, dir string, preset ConversionPreset, original, converted string) error {
+	ctx, cancel := context.WithTimeout(ctx, encodeTimeout)
+	defer cancel()
+
+	// Names sort in the order jobs were queued, so encoders take the oldest first.
+	id := strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatInt(rng.Int63(), 36)
+	staging := filepath.Join(dir, id+".tmp")
+	pending := filepath.Join(dir, encodePending, id)
+	done := filepath.Join(dir, encodeDone, id)
+	defer func() {
+		for _, path := range []string{staging, pending, filepath.Join(dir, encodeWorking, id), done} {
+			if err := os.RemoveAll(path); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+
+	// The job is put together to the side and renamed into pending, so encoders never see half of it.
+	if err := os.MkdirAll(staging, 0755); err != nil {
+		return err
+	}
+	job := EncodeJob{Input: "original" + filepath.Ext(original), Preset: preset, QueuedAt: time.Now()}
+	if err := copyFile(original, filepath.Join(staging, job.Input)); err != nil {
+		return err
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(staging, "job.json"), data, 0644); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, encodePending), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(staging, pending); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(encodePoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if _, err := os.Stat(pending); err == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return errors.New("no encoder took the job, is one running on this -encode-queue?")
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		data, err := os.ReadFile(filepath.Join(done, "result.json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		var result EncodeResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return err
+		}
+		if result.Error != "" {
+			return errors.New(result.Error)
+		}
+		return copyFile(filepath.Join(done, "converted.dca"), converted)
+	}
+}
+
+func copyFile(from, to string) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Converts uploads queued in -encode-queue until the process is told to stop. It never connects to Discord.
+// Returns the exit code.
+func RunEncoder() int {
+	if encodeQueue == "" {
+		fmt.Println("Encoder mode needs an -encode-queue directory to take jobs from")
+		return 1
+	}
+	for _, sub := range []string{encodePending, encodeWorking, encodeDone} {
+		if err := os.MkdirAll(filepath.Join(encodeQueue, sub), 0755); err != nil {
+			fmt.Println("Error creating the encode queue: ", err)
+			return 1
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	fmt.Println("Voice memo encoder is now running.  Press CTRL-C to exit.")
+	for ctx.Err() == nil {
+		if RunEncodeJob(ctx, encodeQueue) {
+			continue
+		}
+		SweepEncodeQueue(encodeQueue)
+		select {
+		case <-ctx.Done():
+		case <-time.After(encodePoll):
+		}
+	}
+	return 0
+}
+
+// Claims the oldest pending job in the queue in dir and converts it. Returns false if there was none.
+// Encoders claim jobs by renaming them, so two of them can never take the same one.
+func RunEncodeJob(ctx context.Context, dir string) bool {
+	entries, err := os.ReadDir(filepath.Join(dir, encodePending))
+	if err != nil {
+		fmt.Println("Error reading the encode queue: ", err)
+		return false
+	}
+	for _, entry := range entries {
+		id := entry.Name()
+		working := filepath.Join(dir, encodeWorking, id)
+		if err := os.Rename(filepath.Join(dir, encodePending, id), working); err != nil {
+			continue
+		}
+
+		started := time.Now()
+		result := EncodeResult{}
+		if err := runEncodeJob(ctx, working); err != nil {
+			fmt.Println("Error encoding ", id, ": ", err)
+			result.Error = err.Error()
+		}
+		data, _ := json.Marshal(result)
+		if err := os.WriteFile(filepath.Join(working, "result.json"), data, 0644); err != nil {
+			fmt.Println("Error finishing ", id, ": ", err)
+		}
+		// The bot may have given up and removed the job in the meantime.
+		if err := os.Rename(working, filepath.Join(dir, encodeDone, id)); err != nil {
+			fmt.Println("Error finishing ", id, ": ", err)
+			os.RemoveAll(working)
+		}
+		fmt.Println("Finished encode job ", id, " in ", time.Since(started).Round(time.Millisecond))
+		return true
+	}
+	return false
+}
+
+func runEncodeJob(ctx context.Context, working string) error {
+	data, err := os.ReadFile(filepath.Join(working, "job.json"))
+	if err != nil {
+		return err
+	}
+	var job EncodeJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return err
+	}
+	deadline := job.QueuedAt.Add(encodeTimeout)
+	if time.Now().After(deadline) {
+		return errors.New("the job waited too long")
+	}
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	return ConvertDCA(ctx, job.Preset, filepath.Join(working, filepath.Base(job.Input)), filepath.Join(working, "converted.dca"))
+}
+
+// Removes finished jobs nobody collected and jobs an encoder stopped in the middle of, once they're twice as old
+// as encodeTimeout and the bot has long given up on them.
+func SweepEncodeQueue(dir string) {
+	for _, sub := range []string{encodeWorking, encodeDone} {
+		entries, err := os.ReadDir(filepath.Join(dir, sub))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || time.Since(info.ModTime()) < 2*encodeTimeout {
+				continue
+			}
+			if err := os.RemoveAll(filepath.Join(dir, sub, entry.Name())); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Subsystems that can be turned on and off per guild, so the heavy or risky ones can be rolled out to a few
+// servers at a time on a public instance. -features picks which are on by default, !feature overrides that.
+const (
+	FeatureRecording = "recording"
+	FeatureTriggers  = "triggers"
+	FeatureTTS       = "tts"
+)
+
+type Feature struct {
+	Name string
+
+	// What's turned off, as in "<Label> is turned off on this server."
+	Label string
+}
+
+// Every feature, in the order !feature lists them.
+var features = []Feature{
+	{Name: FeatureRecording, Label: "Recording with !record and !listen"},
+	{Name: FeatureTriggers, Label: "Playing memos bound to emojis"},
+	{Name: FeatureTTS, Label: "Text-to-speech with !say"},
+}
+
+// Default for -features, which turns everything on.
+func allFeatures() string {
+	names := make([]string, 0, len(features))
+	for _, f := range features {
+		names = append(names, f.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+// Every feature turned on, which is what the bot does until -features says otherwise.
+func everyFeature() map[string]bool {
+	on := make(map[string]bool, len(features))
+	for _, f := range features {
+		on[f.Name] = true
+	}
+	return on
+}
+
+func LookupFeature(name string) (Feature, bool) {
+	for _, f := range features {
+		if f.Name == strings.ToLower(name) {
+			return f, true
+		}
+	}
+	return Feature{}, false
+}
+
+// Parses a comma separated list of the features that are on, like -features takes.
+func ParseFeatures(list string) (map[string]bool, error) {
+	on := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		f, ok := LookupFeature(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown feature %q, expected one of %s", name, allFeatures())
+		}
+		on[f.Name] = true
+	}
+	return on, nil
+}
+
+// Parses a comma separated list of user IDs, like -owners takes.
+func ParseOwners(list string) map[string]bool {
+	owners := make(map[string]bool)
+	for _, id := range strings.Split(list, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			owners[id] = true
+		}
+	}
+	return owners
+}
+
+// Reports whether a user runs the bot, rather than just administrating it in a guild.
+func (b *Bot) IsOwner(userID string) bool {
+	return b.Owners[userID]
+}
+
+// Reports whether a feature is on in a guild: its override if !feature set one, else the bot-wide default.
+func (b *Bot) FeatureEnabled(guildID, name string) bool {
+	if on, ok := b.VoiceMemoManager.Metadata.Guild(guildID).Features[name]; ok {
+		return on
+	}
+	return b.Features[name]
+}
+
+// Reports whether a feature is on in the channel's guild, and says so in the channel if it isn't.
+func (b *Bot) RequireFeature(s *discordgo.Session, c *discordgo.Channel, name string) bool {
+	if b.FeatureEnabled(c.GuildID, name) {
+		return true
+	}
+	f, _ := LookupFeature(name)
+	s.ChannelMessageSend(c.ID, f.Label+" is turned off on this server.")
+	return false
+}
+
+func (b *Bot) HandleFeature(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if !b.IsOwner(m.Author.ID) {
+		s.ChannelMessageSend(c.ID, "Only the people running the bot can turn features on and off.")
+		return
+	}
+	usage := "Usage: !feature [list [server id]] | <feature> on|off|default [server id]"
+
+	if len(args) == 0 || args[0] == "list" {
+		guild := g
+		if len(args) > 1 {
+			var err error
+			if guild, err = s.State.Guild(args[1]); err != nil {
+				s.ChannelMessageSend(c.ID, "I'm not in a server with the ID "+args[1])
+				return
+			}
+		}
+		b.SendFeatures(s, c, guild)
+		return
+	}
+
+	f, ok := LookupFeature(args[0])
+	if !ok || len(args) < 2 {
+		s.ChannelMessageSend(c.ID, usage+"\nFeatures: "+allFeatures())
+		return
+	}
+	guild := g
+	if len(args) > 2 {
+		var err error
+		if guild, err = s.State.Guild(args[2]); err != nil {
+			s.ChannelMessageSend(c.ID, "I'm not in a server with the ID "+args[2])
+			return
+		}
+	}
+
+	var change string
+	var update func(gs *GuildSettings)
+	switch args[1] {
+	case "on", "off":
+		on := args[1] == "on"
+		change = "turned " + f.Name + " " + args[1]
+		update = func(gs *GuildSettings) {
+			if gs.Features == nil {
+				gs.Features = make(map[string]bool)
+			}
+			gs.Features[f.Name] = on
+		}
+	case "default":
+		change = "put " + f.Name + " back to the default"
+		update = func(gs *GuildSettings) { delete(gs.Features, f.Name) }
+	default:
+		s.ChannelMessageSend(c.ID, usage)
+		return
+	}
+
+	if err := b.VoiceMemoManager.Metadata.UpdateGuild(guild.ID, update); err != nil {
+		fmt.Println("Error saving features: ", err)
+		s.ChannelMessageSend(c.ID, "Error saving features")
+		return
+	}
+	b.Audit(s, guild.ID, fmt.Sprintf("<@%s> %s for this server.", m.Author.ID, change))
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("%s is now %s on %s.", f.Label, onOff(b.FeatureEnabled(guild.ID, f.Name)), guild.Name))
+}
+
+// Lists every feature and whether it's on in a guild.
+func (b *Bot) SendFeatures(s *discordgo.Session, c *discordgo.Channel, g *discordgo.Guild) {
+	overrides := b.VoiceMemoManager.Metadata.Guild(g.ID).Features
+	fields := make([]*discordgo.MessageEmbedField, 0, len(features))
+	for _, f := range features {
+		value := onOff(b.FeatureEnabled(g.ID, f.Name))
+		if _, ok := overrides[f.Name]; ok {
+			value += ", set for this server"
+		} else {
+			value += ", the default"
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: f.Name, Value: f.Label + ": " + value})
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:  "Features on " + g.Name,
+		Color:  65535,
+		Fields: fields,
+		Footer: &discordgo.MessageEmbedFooter{Text: "!feature <feature> on|off|default to change one"},
+	}
+	if len(embed.Title) > 256 {
+		embed.Title = embed.Title[:250] + "..."
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
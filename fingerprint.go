@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"os/exec"
+)
+
+const (
+	// Memos at least this similar are reported as likely duplicates.
+	duplicateSimilarity = 0.85
+
+	// How many fingerprint items (roughly 1/8s each) one clip may be shifted against another when comparing.
+	maxFingerprintOffset = 24
+)
+
+// Computes the chromaprint fingerprint of an audio file with fpcalc.
+func Fingerprint(ctx context.Context, path string) ([]uint32, error) {
+	out, err := exec.CommandContext(ctx, fpcalcPath, "-raw", "-json", path).Output()
+	if err != nil {
+		return nil, fmt.Errorf("fpcalc: %w", err)
+	}
+
+	var result struct {
+		Fingerprint []uint32 `json:"fingerprint"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, err
+	}
+	return result.Fingerprint, nil
+}
+
+// Scores how acoustically alike two fingerprints are, from 0 (unrelated) to 1 (identical).
+// Clips are slid a little against each other so a bit of leading silence doesn't hide a match.
+func FingerprintSimilarity(a, b []uint32) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	// Clips of very different lengths aren't duplicates, even if one contains the other.
+	shorter, longer := len(a), len(b)
+	if shorter > longer {
+		shorter, longer = longer, shorter
+	}
+	if float64(shorter) < float64(longer)*duplicateSimilarity {
+		return 0
+	}
+
+	best := 0.0
+	for offset := -maxFingerprintOffset; offset <= maxFingerprintOffset; offset++ {
+		errorBits, compared := 0, 0
+		for i := range a {
+			j := i + offset
+			if j < 0 || j >= len(b) {
+				continue
+			}
+			errorBits += bits.OnesCount32(a[i] ^ b[j])
+			compared++
+		}
+
+		// Ignore offsets that only line up a sliver of the clips.
+		if compared < shorter/2 {
+			continue
+		}
+		if similarity := 1 - float64(errorBits)/float64(compared*32); similarity > best {
+			best = similarity
+		}
+	}
+	return best
+}
+
+// Finds the memo that sounds most like a fingerprint, skipping the memo called exclude.
+// Returns false if nothing is similar enough to be a likely duplicate.
+func (m *VoiceMemoManager) FindNearDuplicate(fingerprint []uint32, exclude string) (MemoMetadata, float64, bool) {
+	var best MemoMetadata
+	bestSimilarity := 0.0
+	for _, name := range m.Names() {
+		if name == exclude {
+			continue
+		}
+
+		md := m.Metadata.Memo(name)
+		if similarity := FingerprintSimilarity(fingerprint, md.Fingerprint); similarity > bestSimilarity {
+			best, bestSimilarity = md, similarity
+		}
+	}
+
+	if bestSimilarity < duplicateSimilarity {
+		return MemoMetadata{}, 0, false
+	}
+	return best, bestSimilarity, true
+}
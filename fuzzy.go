@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Lowercases a name and drops everything but letters and digits, so "Duck Quack!" and "duck_quack" compare equal.
+func NormalizeName(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// Levenshtein edit distance between two strings.
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// Returns how many single character insertions, deletions, substitutions and swaps of neighbouring
+// characters it takes to turn term into some part of s. Zero means s contains term.
+func SubstringDistance(term, s string) int {
+	t, r := []rune(term), []rune(s)
+
+	// prev[j] is the distance between the term so far and the best part of s ending at j. Parts can
+	// start anywhere, so the first row is all zeros. Swaps look back a row further, at before.
+	before := make([]int, len(r)+1)
+	prev := make([]int, len(r)+1)
+	curr := make([]int, len(r)+1)
+	for i := 1; i <= len(t); i++ {
+		curr[0] = i
+		for j := 1; j <= len(r); j++ {
+			cost := 1
+			if t[i-1] == r[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && t[i-1] == r[j-2] && t[i-2] == r[j-1] {
+				curr[j] = minInt(curr[j], before[j-2]+1)
+			}
+		}
+		before, prev, curr = prev, curr, before
+	}
+
+	return minInt(prev...)
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// Finds the candidate closest to query, ignoring case and punctuation.
+// Returns false if nothing is close enough to be a plausible match.
+func FuzzyMatch(query string, candidates []string) (string, bool) {
+	q := NormalizeName(query)
+	if q == "" {
+		return "", false
+	}
+
+	best, bestDistance := "", -1
+	for _, c := range candidates {
+		d := EditDistance(q, NormalizeName(c))
+		if bestDistance == -1 || d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+
+	// Allow roughly one mistake every three characters.
+	if bestDistance == -1 || bestDistance > len(q)/3+1 {
+		return "", false
+	}
+	return best, true
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		candidates []string
+		want       string
+		wantOK     bool
+	}{
+		{"exact", "bruh", []string{"airhorn", "bruh"}, "bruh", true},
+		{"case and punctuation", "Duck Quack!", []string{"bruh", "duck_quack"}, "duck_quack", true},
+		{"misheard", "brew", []string{"airhorn", "bruh"}, "bruh", true},
+		{"closest wins", "airhorns", []string{"air", "airhorn"}, "airhorn", true},
+		{"first of equals wins", "bat", []string{"cat", "hat"}, "cat", true},
+		{"too far", "hello", []string{"airhorn", "bruh"}, "", false},
+		{"one mistake too many", "bark", []string{"bruh"}, "", false},
+		{"only punctuation", "?!", []string{"bruh"}, "", false},
+		{"no candidates", "bruh", nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := FuzzyMatch(tt.query, tt.candidates)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("FuzzyMatch(%q, %q) = %q, %v, want %q, %v", tt.query, tt.candidates, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
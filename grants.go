@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Temporary roles !grant can hand out. A DJ may do what admins do to playback: play restricted memos,
+// manage emoji bindings, auto-join and the default voice, and cancel anyone's jobs.
+const GrantDJ = "dj"
+
+const (
+	// Longest grant !grant hands out.
+	maxGrantLength = 7 * 24 * time.Hour
+
+	// How often expired grants are cleaned up and announced.
+	grantSweepInterval = time.Minute
+)
+
+// A temporary role given to a user with !grant.
+type Grant struct {
+	Role      string    `json:"role"`
+	Until     time.Time `json:"until"`
+	GrantedBy string    `json:"granted_by"`
+}
+
+// Reports whether a user currently holds a temporary role in a guild.
+func (b *Bot) HasGrant(guildID, userID, role string) bool {
+	grant, ok := b.VoiceMemoManager.Metadata.Guild(guildID).Grants[userID]
+	return ok && grant.Role == role && time.Now().Before(grant.Until)
+}
+
+// Reports whether a user may control playback like an admin, either as an admin or a DJ.
+func (b *Bot) IsDJ(s *discordgo.Session, guildID, userID, channelID string) bool {
+	return b.HasGrant(guildID, userID, GrantDJ) || IsAdmin(s, userID, channelID)
+}
+
+// Removes expired grants every minute and says so in the audit channel. Runs until the process exits.
+func (b *Bot) ExpireGrants(s *discordgo.Session) {
+	for range time.Tick(grantSweepInterval) {
+		for guildID, expired := range b.VoiceMemoManager.Metadata.ExpireGrants(time.Now()) {
+			for _, userID := range expired {
+				b.Audit(s, guildID, "<@"+userID+">'s temporary DJ access ran out.")
+			}
+		}
+	}
+}
+
+// Returns the ID of the user a "@user" mention or bare user ID refers to.
+func ParseUser(arg string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(arg, "<@"), "!"), ">")
+}
+
+func (b *Bot) HandleGrant(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := "Usage: !grant @user dj <duration, e.g. 2h> | !grant @user off | !grant list"
+	if len(args) == 0 || args[0] == "list" {
+		b.SendGrants(s, g, c)
+		return
+	}
+
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can grant temporary roles.")
+		return
+	}
+	if len(args) < 2 {
+		s.ChannelMessageSend(c.ID, usage)
+		return
+	}
+
+	userID := ParseUser(args[0])
+	if _, err := s.State.Member(g.ID, userID); err != nil {
+		if _, err := s.GuildMember(g.ID, userID); err != nil {
+			s.ChannelMessageSend(c.ID, "I can't find "+args[0]+" in "+g.Name)
+			return
+		}
+	}
+
+	if args[1] == "off" {
+		revoked := false
+		err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+			_, revoked = gs.Grants[userID]
+			delete(gs.Grants, userID)
+		})
+		if err != nil {
+			fmt.Println("Error saving guild settings: ", err)
+			return
+		}
+		if !revoked {
+			s.ChannelMessageSend(c.ID, "<@"+userID+"> doesn't have a temporary role.")
+			return
+		}
+		s.ChannelMessageSend(c.ID, "Took back <@"+userID+">'s temporary role.")
+		b.Audit(s, g.ID, fmt.Sprintf("<@%s> took back <@%s>'s temporary DJ access.", m.Author.ID, userID))
+		return
+	}
+
+	if args[1] != GrantDJ || len(args) < 3 {
+		s.ChannelMessageSend(c.ID, usage)
+		return
+	}
+	length, err := time.ParseDuration(args[2])
+	if err != nil || length <= 0 || length > maxGrantLength {
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Grants can last up to %d days, e.g. 2h or 90m.", int(maxGrantLength.Hours()/24)))
+		return
+	}
+
+	grant := Grant{Role: GrantDJ, Until: time.Now().Add(length), GrantedBy: m.Author.ID}
+	err = b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		if gs.Grants == nil {
+			gs.Grants = make(map[string]Grant)
+		}
+		gs.Grants[userID] = grant
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("<@%s> is a DJ until <t:%d:t>.", userID, grant.Until.Unix()))
+	b.Audit(s, g.ID, fmt.Sprintf("<@%s> made <@%s> a DJ until <t:%d:f>.", m.Author.ID, userID, grant.Until.Unix()))
+}
+
+func (b *Bot) SendGrants(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel) {
+	grants := b.VoiceMemoManager.Metadata.Guild(g.ID).Grants
+	lines := make([]string, 0, len(grants))
+	for userID, grant := range grants {
+		if time.Now().Before(grant.Until) {
+			lines = append(lines, fmt.Sprintf("<@%s> · %s until <t:%d:f>", userID, grant.Role, grant.Until.Unix()))
+		}
+	}
+	if len(lines) == 0 {
+		s.ChannelMessageSend(c.ID, "Nobody has a temporary role in "+g.Name)
+		return
+	}
+	sort.Strings(lines)
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Temporary roles",
+		Description: strings.Join(lines, "\n"),
+		Color:       65535,
+	}
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Greeting and farewell memos hold up joining and leaving, so they have to be short.
+	maxGreetingLength = 10 * time.Second
+
+	// How long !leave waits for the memo that was playing to wind down before the farewell.
+	farewellWait = 5 * time.Second
+
+	// Longest greeting message, so it fits in an embed field.
+	maxGreetingMessage = 1000
+)
+
+// What the bot says and plays when it joins and leaves a voice channel, set with !greeting.
+type Greeting struct {
+	// Posted when the bot joins with !join. "{server}" is replaced with the server's name.
+	// Empty posts the default hello, unless Quiet is set.
+	Message string `json:"message,omitempty"`
+	Quiet   bool   `json:"quiet,omitempty"`
+
+	// Memos played right after joining and right before !leave disconnects. Empty plays nothing.
+	JoinMemo  string `json:"join_memo,omitempty"`
+	LeaveMemo string `json:"leave_memo,omitempty"`
+}
+
+// Returns the message posted when the bot joins, or "" if it shouldn't post anything.
+func (gr Greeting) Text(guildName string) string {
+	if gr.Quiet {
+		return ""
+	}
+	if gr.Message == "" {
+		return fmt.Sprintf("Hello %s!", guildName)
+	}
+	return strings.ReplaceAll(gr.Message, "{server}", guildName)
+}
+
+// Greets a voice channel the bot just joined. The text only goes out if there's a channel to post it to.
+func (b *Bot) Greet(s *discordgo.Session, g *discordgo.Guild, gs *GuildSession, channelID string) {
+	greeting := b.VoiceMemoManager.Metadata.Guild(g.ID).Greeting
+	if text := greeting.Text(g.Name); text != "" && channelID != "" {
+		s.ChannelMessageSend(channelID, text)
+	}
+
+	if greeting.JoinMemo == "" {
+		return
+	}
+	vm := b.VoiceMemoManager.Get(greeting.JoinMemo)
+	if vm == nil {
+		fmt.Println("Cannot find greeting memo ", greeting.JoinMemo)
+		return
+	}
+	// The bot plays it on its own, so it isn't announced or credited to anyone.
+	if gs.Enqueue(vm, "", "") {
+		go gs.PlayFromQueue()
+	}
+}
+
+// Stops what's playing and plays the guild's farewell memo, returning once it has finished.
+// Does nothing if there's no farewell memo.
+func (b *Bot) Farewell(gs *GuildSession) {
+	name := b.VoiceMemoManager.Metadata.Guild(gs.ID).Greeting.LeaveMemo
+	if name == "" {
+		return
+	}
+	vm := b.VoiceMemoManager.Get(name)
+	if vm == nil {
+		fmt.Println("Cannot find farewell memo ", name)
+		return
+	}
+
+	// The player has to let go of the queue before the farewell can be played here.
+	gs.Stop()
+	deadline := time.Now().Add(farewellWait)
+	for gs.IsVoicePlaying.Load() && time.Now().Before(deadline) {
+		time.Sleep(playbackGap)
+	}
+	if gs.Enqueue(vm, "", "") {
+		gs.PlayFromQueue()
+	}
+}
+
+func (b *Bot) HandleGreeting(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := "Usage: !greeting | !greeting message <text>|default|off | !greeting join <memo>|off | !greeting leave <memo>|off"
+	if len(args) == 0 {
+		b.SendGreeting(s, g, c)
+		return
+	}
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can change the greeting.")
+		return
+	}
+	if len(args) < 2 {
+		s.ChannelMessageSend(c.ID, usage)
+		return
+	}
+
+	setting, value := args[0], strings.Join(args[1:], " ")
+	update := func(gr *Greeting) {}
+	reply := ""
+	switch setting {
+	case "message":
+		switch value {
+		case "off":
+			update = func(gr *Greeting) { gr.Message, gr.Quiet = "", true }
+			reply = "I won't say anything when I join."
+		case "default":
+			update = func(gr *Greeting) { gr.Message, gr.Quiet = "", false }
+			reply = "I'll say hello when I join."
+		default:
+			if len(value) > maxGreetingMessage {
+				s.ChannelMessageSend(c.ID, fmt.Sprintf("Greetings can be up to %d characters long.", maxGreetingMessage))
+				return
+			}
+			update = func(gr *Greeting) { gr.Message, gr.Quiet = value, false }
+			reply = "I'll say this when I join: " + Greeting{Message: value}.Text(g.Name)
+		}
+
+	case "join", "leave":
+		memo := value
+		if memo == "off" {
+			memo = ""
+		} else {
+			vm := b.VoiceMemoManager.Get(memo)
+			if vm == nil || !b.VoiceMemoManager.InLibrary(g.ID, memo) {
+				s.ChannelMessageSend(c.ID, "Cannot find "+memo)
+				return
+			}
+			if vm.Duration() > maxGreetingLength {
+				s.ChannelMessageSend(c.ID, fmt.Sprintf("%s is too long, greeting and farewell memos can be up to %s.", memo, FormatDuration(maxGreetingLength)))
+				return
+			}
+		}
+
+		when := "when I join"
+		if setting == "join" {
+			update = func(gr *Greeting) { gr.JoinMemo = memo }
+		} else {
+			when = "before I leave"
+			update = func(gr *Greeting) { gr.LeaveMemo = memo }
+		}
+		reply = "I'll play " + memo + " " + when + "."
+		if memo == "" {
+			reply = "I won't play anything " + when + "."
+		}
+
+	default:
+		s.ChannelMessageSend(c.ID, usage)
+		return
+	}
+
+	err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		update(&gs.Greeting)
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+	s.ChannelMessageSend(c.ID, reply)
+}
+
+func (b *Bot) SendGreeting(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel) {
+	greeting := b.VoiceMemoManager.Metadata.Guild(g.ID).Greeting
+
+	text := greeting.Text(g.Name)
+	if text == "" {
+		text = "Off"
+	}
+	joinMemo, leaveMemo := greeting.JoinMemo, greeting.LeaveMemo
+	if joinMemo == "" {
+		joinMemo = "None"
+	}
+	if leaveMemo == "" {
+		leaveMemo = "None"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Greeting",
+		Color: 65535,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Message", Value: text},
+			{Name: "Memo on join", Value: joinMemo, Inline: true},
+			{Name: "Memo before leaving", Value: leaveMemo, Inline: true},
+		},
+	}
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
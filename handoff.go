@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Has the player run move once the memo it's on has finished, or straight away if nothing is playing. A move
+// asked for while another is waiting replaces it.
+func (gs *GuildSession) MoveAfterCurrent(move func()) {
+	gs.moveMu.Lock()
+	gs.move = move
+	gs.moveMu.Unlock()
+
+	// Starts the player if it's idle, which runs the move before looking at the queue.
+	go gs.PlayFromQueue()
+}
+
+func (gs *GuildSession) movePending() bool {
+	gs.moveMu.Lock()
+	defer gs.moveMu.Unlock()
+	return gs.move != nil
+}
+
+// Runs the move waiting for the player, if there is one.
+func (gs *GuildSession) runMove() {
+	gs.moveMu.Lock()
+	move := gs.move
+	gs.move = nil
+	gs.moveMu.Unlock()
+
+	if move != nil {
+		move()
+	}
+}
+
+func (b *Bot) HandleHandoff(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) != 1 {
+		s.ChannelMessageSend(c.ID, "Usage: !handoff <#voice-channel>")
+		return
+	}
+	if !b.IsDJ(s, g.ID, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins and DJs can move the bot to another voice channel.")
+		return
+	}
+	gs, ok := b.Session(g.ID)
+	if !ok {
+		s.ChannelMessageSend(c.ID, "I'm not in a voice channel. !join first.")
+		return
+	}
+
+	toID, ok := ParseChannel(s, g, args[0])
+	var to *discordgo.Channel
+	if ok {
+		to, _ = s.State.Channel(toID)
+	}
+	if to == nil || (to.Type != discordgo.ChannelTypeGuildVoice && to.Type != discordgo.ChannelTypeGuildStageVoice) {
+		s.ChannelMessageSend(c.ID, "I can only move to a voice channel in "+g.Name)
+		return
+	}
+	fromID := gs.VoiceConnection.ChannelID
+	if toID == fromID {
+		s.ChannelMessageSend(c.ID, "I'm already in <#"+toID+">")
+		return
+	}
+	if perms, err := s.State.UserChannelPermissions(s.State.User.ID, toID); err != nil || perms&discordgo.PermissionVoiceConnect == 0 {
+		s.ChannelMessageSend(c.ID, "I'm not allowed to join <#"+toID+">")
+		return
+	}
+
+	gs.MoveAfterCurrent(func() {
+		if err := gs.VoiceConnection.ChangeChannel(toID, false, false); err != nil {
+			fmt.Println("Error moving to voice channel ", toID, ": ", err)
+			s.ChannelMessageSend(c.ID, "Could not move to <#"+toID+">")
+			return
+		}
+		if gs.OnMove != nil {
+			gs.OnMove(toID)
+		}
+
+		// Voice channels have their own text chat, which is where the people in them will look.
+		queued := gs.PlayQueue.Len()
+		if _, err := s.ChannelMessageSend(fromID, fmt.Sprintf("Moved to <#%s>, taking %d queued voice memos along. See you there!", toID, queued)); err != nil {
+			fmt.Println("Error posting handoff notice: ", err)
+		}
+		if _, err := s.ChannelMessageSend(toID, fmt.Sprintf("Hi! Carrying on here from <#%s> with %d voice memos queued.", fromID, queued)); err != nil {
+			fmt.Println("Error posting handoff notice: ", err)
+		}
+		b.Audit(s, g.ID, fmt.Sprintf("<@%s> moved the bot from <#%s> to <#%s>.", m.Author.ID, fromID, toID))
+	})
+
+	current, _ := gs.Current()
+	switch {
+	case current == nil:
+		s.ChannelMessageSend(c.ID, "Moving to <#"+toID+">.")
+	case gs.Paused():
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("I'll move to <#%s> once %s has finished. Playback is paused, !resume to carry on.", toID, current.name))
+	default:
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("I'll move to <#%s> once %s has finished.", toID, current.name))
+	}
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Subsystems the bot keeps an eye on, and restarts on its own when they stay degraded.
+const (
+	SubsystemGateway = "gateway"
+	SubsystemVoice   = "voice"
+	SubsystemJobs    = "jobs"
+	SubsystemStorage = "storage"
+)
+
+const (
+	// How often the subsystems are checked.
+	healthInterval = 30 * time.Second
+
+	// How long something has to stay degraded before it's restarted, so blips discordgo recovers from by
+	// itself are left to it, and how long to wait before restarting the same thing again.
+	healAfter   = time.Minute
+	healBackoff = 5 * time.Minute
+
+	// Discord acks a heartbeat every 40 seconds or so. Going this long without one means the gateway is gone.
+	gatewayStaleAfter = 2 * time.Minute
+
+	// How long a job may run before it counts as stuck and is cancelled. Conversions give up well before this.
+	stuckJobAfter = 3 * encodeTimeout
+
+	// How long an upload may wait in the -encode-queue before the encoders count as stuck.
+	encodeStallAfter = time.Minute
+)
+
+// How one subsystem is doing. Score goes from 0, nothing works, to 1, everything does.
+type SubsystemHealth struct {
+	Name     string     `json:"name"`
+	Score    float64    `json:"score"`
+	Problems []string   `json:"problems,omitempty"`
+	Restarts int        `json:"restarts"`
+	Restart  *time.Time `json:"last_restart,omitempty"`
+}
+
+// How every subsystem is doing. Score is their average, out of 100.
+type HealthReport struct {
+	Score      int               `json:"score"`
+	Subsystems []SubsystemHealth `json:"subsystems"`
+}
+
+// One thing a subsystem is made of, like the voice connection of a guild, and how to restart it if it's
+// degraded. restart is nil for things the bot can't restart, like encoders running in other processes.
+type healthProbe struct {
+	subsystem string
+	key       string
+	problem   string
+	restart   func() error
+}
+
+// Remembers what has been degraded for how long and what was restarted, between checks.
+type HealthMonitor struct {
+	mu sync.Mutex
+
+	// When each probe that's degraded first came up degraded, and when it was last restarted, by probe key.
+	degradedSince map[string]time.Time
+	restartedAt   map[string]time.Time
+
+	// How many restarts each subsystem has had, and when it last had one.
+	restarts    map[string]int
+	lastRestart map[string]time.Time
+}
+
+func NewHealthMonitor() *HealthMonitor {
+	return &HealthMonitor{
+		degradedSince: make(map[string]time.Time),
+		restartedAt:   make(map[string]time.Time),
+		restarts:      make(map[string]int),
+		lastRestart:   make(map[string]time.Time),
+	}
+}
+
+// Checks the subsystems and restarts whatever has been degraded for longer than healAfter. Runs until the
+// process exits.
+func (b *Bot) MonitorHealth(s *discordgo.Session) {
+	for range time.Tick(healthInterval) {
+		b.Heal(s, time.Now())
+	}
+}
+
+// Restarts the parts of subsystems that have been degraded for longer than healAfter, one at a time, and logs
+// what it did.
+func (b *Bot) Heal(s *discordgo.Session, now time.Time) {
+	probes, _ := b.probeHealth(s, now)
+	for _, p := range b.Health.due(probes, now) {
+		fmt.Println("Restarting ", p.subsystem, ": ", p.problem)
+		if err := p.restart(); err != nil {
+			fmt.Println("Error restarting ", p.subsystem, ": ", err)
+			continue
+		}
+		fmt.Println("Restarted ", p.subsystem)
+	}
+}
+
+// Returns the degraded probes that are due a restart, and counts the restarts.
+func (hm *HealthMonitor) due(probes []healthProbe, now time.Time) []healthProbe {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	since := make(map[string]time.Time, len(probes))
+	due := make([]healthProbe, 0)
+	for _, p := range probes {
+		if p.problem == "" {
+			continue
+		}
+		first, ok := hm.degradedSince[p.key]
+		if !ok {
+			first = now
+		}
+		since[p.key] = first
+		if p.restart == nil || now.Sub(first) < healAfter || now.Sub(hm.restartedAt[p.key]) < healBackoff {
+			continue
+		}
+		hm.restartedAt[p.key] = now
+		hm.restarts[p.subsystem]++
+		hm.lastRestart[p.subsystem] = now
+		due = append(due, p)
+	}
+	// Forget what recovered, and restarts that no longer hold anything back.
+	hm.degradedSince = since
+	for key, at := range hm.restartedAt {
+		if now.Sub(at) >= healBackoff {
+			delete(hm.restartedAt, key)
+		}
+	}
+	return due
+}
+
+// Reports how each subsystem is doing right now, and how often it had to be restarted.
+func (b *Bot) HealthReport(s *discordgo.Session) HealthReport {
+	probes, subsystems := b.probeHealth(s, time.Now())
+
+	b.Health.mu.Lock()
+	defer b.Health.mu.Unlock()
+
+	report := HealthReport{Subsystems: make([]SubsystemHealth, 0, len(subsystems))}
+	total := 0.0
+	for _, name := range subsystems {
+		health := SubsystemHealth{Name: name, Score: 1, Restarts: b.Health.restarts[name]}
+		if at, ok := b.Health.lastRestart[name]; ok {
+			health.Restart = &at
+		}
+		parts, degraded := 0, 0
+		for _, p := range probes {
+			if p.subsystem != name {
+				continue
+			}
+			parts++
+			if p.problem != "" {
+				degraded++
+				health.Problems = append(health.Problems, p.problem)
+			}
+		}
+		if parts > 0 {
+			health.Score = float64(parts-degraded) / float64(parts)
+		}
+		total += health.Score
+		report.Subsystems = append(report.Subsystems, health)
+	}
+	report.Score = int(math.Round(100 * total / float64(len(subsystems))))
+	return report
+}
+
+// Looks at every subsystem. Healthy parts come back as probes without a problem, so they count toward the
+// score. Also returns the subsystems in the order to report them.
+func (b *Bot) probeHealth(s *discordgo.Session, now time.Time) ([]healthProbe, []string) {
+	probes := []healthProbe{b.probeGateway(s, now)}
+	probes = append(probes, b.probeVoice(s)...)
+	probes = append(probes, b.probeJobs(now)...)
+	probes = append(probes, b.probeStorage())
+	return probes, []string{SubsystemGateway, SubsystemVoice, SubsystemJobs, SubsystemStorage}
+}
+
+func (b *Bot) probeGateway(s *discordgo.Session, now time.Time) healthProbe {
+	p := healthProbe{subsystem: SubsystemGateway, key: SubsystemGateway}
+	s.RLock()
+	ack := s.LastHeartbeatAck
+	s.RUnlock()
+	if ack.IsZero() {
+		// Not opened yet. There's nothing to restart until it is.
+		p.problem = "not connected to Discord"
+		return p
+	}
+	if since := now.Sub(ack); since > gatewayStaleAfter {
+		p.problem = fmt.Sprintf("no heartbeat from Discord for %s", since.Round(time.Second))
+		p.restart = func() error {
+			// Voice connections have their own websockets and carry on meanwhile.
+			if err := s.Close(); err != nil {
+				fmt.Println("Error closing Discord session: ", err)
+			}
+			return s.Open()
+		}
+	}
+	return p
+}
+
+// Probes the voice connection of every guild session. A connection discordgo couldn't bring back is closed and
+// joined again, keeping the session and its queue.
+func (b *Bot) probeVoice(s *discordgo.Session) []healthProbe {
+	b.sessionsMu.RLock()
+	sessions := make([]*GuildSession, 0, len(b.GuildSessions))
+	for _, gs := range b.GuildSessions {
+		sessions = append(sessions, gs)
+	}
+	b.sessionsMu.RUnlock()
+
+	probes := make([]healthProbe, 0, len(sessions))
+	for _, gs := range sessions {
+		gs := gs
+		p := healthProbe{subsystem: SubsystemVoice, key: "voice:" + gs.ID}
+		vc := gs.VoiceConnection
+		vc.RLock()
+		ready, channelID := vc.Ready, vc.ChannelID
+		vc.RUnlock()
+		if !ready {
+			p.problem = "the voice connection in " + gs.GuildName + " is down"
+			p.restart = func() error {
+				s.RLock()
+				gatewayReady := s.DataReady
+				s.RUnlock()
+				if !gatewayReady {
+					return errors.New("the gateway isn't ready to rejoin " + gs.GuildName)
+				}
+				// The same way discordgo reconnects, so gs.VoiceConnection stays the one in use.
+				vc.Close()
+				if _, err := s.ChannelVoiceJoin(gs.ID, channelID, false, false); err != nil {
+					b.Alerts.Report(gs.ID, AlertVoice, err)
+					return err
+				}
+				return nil
+			}
+		}
+		probes = append(probes, p)
+	}
+	return probes
+}
+
+// Probes the running jobs, cancelling the ones that are stuck, and whether the encoders are taking uploads.
+func (b *Bot) probeJobs(now time.Time) []healthProbe {
+	running := b.Jobs.Running()
+	probes := make([]healthProbe, 0, len(running)+1)
+	for _, job := range running {
+		job := job
+		p := healthProbe{subsystem: SubsystemJobs, key: "job:" + strconv.Itoa(job.ID)}
+		if age := now.Sub(job.Started); age > stuckJobAfter {
+			p.problem = fmt.Sprintf("%s job %d (%s) has been running for %s", job.Kind, job.ID, job.Description, age.Round(time.Minute))
+			p.restart = func() error {
+				// Kills the ffmpeg, whisper, ... it started.
+				job.Cancel()
+				return nil
+			}
+		}
+		probes = append(probes, p)
+	}
+
+	// Encoders run in their own processes, so they can only be reported.
+	if encodeQueue != "" {
+		p := healthProbe{subsystem: SubsystemJobs, key: "encoders"}
+		if wait := oldestEncodeJob(encodeQueue, now); wait > encodeStallAfter {
+			p.problem = fmt.Sprintf("no encoder has taken an upload for %s", wait.Round(time.Second))
+		}
+		probes = append(probes, p)
+	}
+	return probes
+}
+
+// Returns how long the oldest job in the -encode-queue in dir has been waiting for an encoder.
+func oldestEncodeJob(dir string, now time.Time) time.Duration {
+	entries, err := os.ReadDir(filepath.Join(dir, encodePending))
+	if err != nil {
+		return 0
+	}
+	var oldest time.Duration
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if wait := now.Sub(info.ModTime()); wait > oldest {
+			oldest = wait
+		}
+	}
+	return oldest
+}
+
+// Probes whether the metadata could be saved last time. Saving again is the restart, e.g. once the disk has
+// room again, so changes that only made it into memory aren't lost on the next restart.
+func (b *Bot) probeStorage() healthProbe {
+	p := healthProbe{subsystem: SubsystemStorage, key: SubsystemStorage}
+	metadata := b.VoiceMemoManager.Metadata
+	if err := metadata.SaveError(); err != nil {
+		p.problem = "saving metadata failed: " + err.Error()
+		p.restart = metadata.Resave
+	}
+	return p
+}
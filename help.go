@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A prefix command as !help explains it.
+type CommandHelp struct {
+	Name string
+
+	// Arguments after the command name, e.g. "<name> [x1-x10]". Empty for commands without any.
+	Usage string
+
+	// One line for the command list, and anything more that !help <command> shows below it.
+	Summary string
+	Details string
+
+	// Heading the command is listed under.
+	Group string
+}
+
+// Headings of the command list, in the order they're shown.
+var helpGroups = []string{"Playback", "Voice memos", "Speech", "Server settings"}
+
+// Every prefix command. Dispatch handles each of these, and anything new there belongs here too.
+var commandRegistry = []CommandHelp{
+	{Name: "join", Group: "Playback", Summary: "Join your voice channel"},
+	{Name: "leave", Group: "Playback", Summary: "Leave the voice channel", Details: "Plays the farewell memo first if the server has one."},
+	{Name: "handoff", Group: "Playback", Usage: "<#voice-channel>", Summary: "Move the bot and its queue to another voice channel",
+		Details: "Admins and DJs only. The memo that's playing finishes first, then the bot moves and lets both channels' text chats know, e.g. for events moving between rooms."},
+	{Name: "play", Group: "Playback", Usage: fmt.Sprintf("[-full] <name> [x1-x%d] [m:ss] [<name> ...]", maxPlayRepeat), Summary: "Play a voice memo",
+		Details: "Adds the memo to the end of the queue, as many times in a row as you ask for, e.g. !play hello x3. A timestamp starts it part way in, e.g. !play hello 0:15. Name more memos to queue them in order, e.g. !play hello x2 bruh airhorn. Admins and DJs can add -full to play past the server's !maxplay. If I'm not in a voice channel yet, I join yours first."},
+	{Name: "playnext", Group: "Playback", Usage: "[-full] <name> [m:ss]", Summary: "Play a voice memo straight after the one that's on",
+		Details: "Admins and DJs only. Puts the memo at the front of the queue instead of the end, for when timing matters."},
+	{Name: "cue", Group: "Playback", Usage: "@user <name>", Summary: "Play a voice memo for someone, if they're in the voice channel",
+		Details: "For call-outs and reminders. It's only queued if they're in the voice channel with the bot right now, otherwise you're told where they are. They aren't pinged either way."},
+	{Name: "skip", Group: "Playback", Summary: "Skip the voice memo that's playing",
+		Details: "Admins, DJs and whoever asked for the memo can skip it."},
+	{Name: "stop", Group: "Playback", Summary: "Stop playing and clear the queue", Details: "Admins and DJs only."},
+	{Name: "seek", Group: "Playback", Usage: "<m:ss>", Summary: "Jump to a point in the voice memo that's playing",
+		Details: "Admins, DJs and whoever asked for the memo can seek in it, backwards or forwards, e.g. !seek 0:15."},
+	{Name: "pause", Group: "Playback", Summary: "Pause the voice memo that's playing"},
+	{Name: "resume", Group: "Playback", Summary: "Carry on playing where playback was paused"},
+	{Name: "loop", Group: "Playback", Usage: "[on|off]", Summary: "Repeat the voice memo that's playing", Details: "Leave out on or off to toggle it."},
+	{Name: "loopqueue", Group: "Playback", Usage: "[on|off]", Summary: "Keep replaying the whole queue",
+		Details: "Memos go back to the end of the queue once they've played. Leave out on or off to toggle it."},
+	{Name: "shuffle", Group: "Playback", Summary: "Put the queued voice memos in a random order", Details: "Admins and DJs only."},
+	{Name: "remove", Group: "Playback", Usage: "<number>", Summary: "Take one voice memo off the queue",
+		Details: "Uses the number the memo has in !queue, e.g. !remove 2. Anyone can remove what they asked for, admins and DJs anything."},
+	{Name: "move", Group: "Playback", Usage: "<from> <to>", Summary: "Move a voice memo to another place in the queue",
+		Details: "Admins and DJs only. Uses the numbers in !queue, e.g. !move 4 1 plays the fourth memo next."},
+	{Name: "clearqueue", Group: "Playback", Summary: "Throw away everything waiting in the queue", Details: "Admins and DJs only."},
+	{Name: "queue", Group: "Playback", Summary: "Show what's queued up",
+		Details: "Buttons under the queue skip, shuffle and remove voice memos. Anyone can skip or remove what they asked for, admins and DJs anything."},
+	{Name: "playlist", Group: "Playback", Usage: "[list] | show|play|delete <playlist> | create|add|remove <playlist> <memos...>",
+		Summary: "Make playlists of voice memos and queue them in one go",
+		Details: fmt.Sprintf("A playlist holds up to %d memos and takes one spot in the queue. Anyone can make and play playlists, but only whoever made one and admins can change it.", maxPlaylistLength)},
+	{Name: "listen", Group: "Playback", Summary: "Listen for a spoken \"play <name>\" command",
+		Details: fmt.Sprintf("Say \"play <name>\" within %d seconds.", int(listenWindow.Seconds()))},
+	{Name: "random", Group: "Playback", Usage: fmt.Sprintf("[-tag=<tags>] | avoid [0-%d]", maxRandomAvoid), Summary: "Play a random voice memo",
+		Details: fmt.Sprintf("Long-form memos are never picked, and memos from the last %d plays only when there's nothing else. -tag= only picks from memos with those tags. Admins and DJs can change how many plays it looks back on with avoid.", defaultRandomAvoid)},
+	{Name: "history", Group: "Playback", Usage: fmt.Sprintf("[@user] [1-%d]", maxHistoryLength), Summary: "Show what was played lately, and who asked for it",
+		Details: fmt.Sprintf("Shows the last %d plays unless you ask for more. The server's last %d plays are kept.", defaultHistoryLength, maxHistory)},
+	{Name: "suggest", Group: "Playback", Summary: "Recommend voice memos you haven't played lately"},
+
+	{Name: "list", Group: "Voice memos", Usage: "[-sort=name|date|plays|size] [-tag=<tags>] [page]", Summary: "List all voice memos",
+		Details: "Memos are in alphabetical order, or the newest, most played here or largest first with -sort=. -tag=meme,intro lists only the memos with all of those tags."},
+	{Name: "search", Group: "Voice memos", Usage: "[-tag=<tags>] <term>", Summary: "Find voice memos by name, even if it's misspelled",
+		Details: "Names that are the term, start with it or contain it come first, then ones that are a typo or two off. -tag= only searches memos with those tags."},
+	{Name: "recent", Group: "Voice memos", Usage: fmt.Sprintf("[1-%d]", maxRecentLength), Summary: "Show the newest voice memos and who added them",
+		Details: fmt.Sprintf("Shows the last %d unless you ask for more.", defaultRecentLength)},
+	{Name: "tag", Group: "Voice memos", Usage: "add|remove <name> <tags...> | list", Summary: "Tag voice memos so they can be found and picked by tag",
+		Details: fmt.Sprintf("Only whoever uploaded a memo and admins can change its tags. A memo can have up to %d.", maxTags)},
+	{Name: "upload", Group: "Voice memos", Usage: "[-longform] [-mono] [-tags=<tags>]", Summary: "Upload a voice memo",
+		Details: "Attach an audio file, or reply to a message that has one. Long-form memos are streamed from disk and left out of random picks. Mono files are always encoded in mono, -mono does the same for stereo ones. Admins can also right-click a message and pick Apps > Save as voice memo."},
+	{Name: "record", Group: "Voice memos", Usage: "<name> [seconds]", Summary: "Record yourself in the voice channel as a new voice memo",
+		Details: fmt.Sprintf("Records for up to %d seconds.", int(maxRecordLength.Seconds()))},
+	{Name: "info", Group: "Voice memos", Usage: "<name>", Summary: "Show everything known about a voice memo"},
+	{Name: "stats", Group: "Voice memos", Usage: "<name>", Summary: "Show how big a voice memo is and how often it's played here"},
+	{Name: "describe", Group: "Voice memos", Usage: "<name> [description]", Summary: "Add a description or credit to a voice memo",
+		Details: "Leave out the description to clear it."},
+	{Name: "trim", Group: "Voice memos", Usage: "<name> [<start seconds> <end seconds>]", Summary: "Cut the start or end off a voice memo",
+		Details: "Leave out the seconds to pick them with buttons."},
+	{Name: "link", Group: "Voice memos", Usage: "<name>", Summary: "Get a temporary link to listen to a voice memo outside Discord"},
+	{Name: "rename", Group: "Voice memos", Usage: "<name> <new name>", Summary: "Give a voice memo a new name",
+		Details: "Packs, playlists, bindings and greetings that use it follow along. Only whoever uploaded it and admins can rename it."},
+	{Name: "transfer", Group: "Voice memos", Usage: "<name> @user", Summary: "Give a voice memo you uploaded to someone else",
+		Details: "They have a day to accept, and can edit and delete it from then on. Admins can transfer any memo."},
+	{Name: "delete", Group: "Voice memos", Usage: "[-dry-run] <name>", Summary: "Delete a voice memo",
+		Details: "Only whoever uploaded it and admins can delete it. -dry-run shows what deleting it would change instead."},
+	{Name: "pack", Group: "Voice memos", Usage: "[list] | show <pack> | create|add|remove <pack> <memos...> | publish|unpublish|delete|subscribe|unsubscribe <pack>",
+		Summary: "Share groups of voice memos between servers"},
+	{Name: "cleanup", Group: "Voice memos", Usage: "[-dry-run] [never|oldest|largest]", Summary: "Pick voice memos to delete in one go",
+		Details: "Admins only. With -dry-run, deleting your picks only shows what it would change."},
+	{Name: "jobs", Group: "Voice memos", Usage: "[cancel <id> | clear]", Summary: "List running uploads and transcriptions, and memos that failed to play",
+		Details: "Admins and DJs can clear the memos that failed to play."},
+
+	{Name: "say", Group: "Speech", Usage: "[-voice=<voice>] <text>", Summary: "Say something in the voice channel",
+		Details: "If a memo is playing, it's turned down while the bot talks over it. !duck changes how far."},
+	{Name: "voices", Group: "Speech", Summary: "List the voices !say can use"},
+	{Name: "voice", Group: "Speech", Usage: "[<voice>|default]", Summary: "Show or change this server's default !say voice",
+		Details: "Admins and DJs can change it."},
+	{Name: "duck", Group: "Speech", Usage: "[0-100|off]", Summary: "Show or change how far memos are turned down while !say talks over them, in percent",
+		Details: fmt.Sprintf("Admins and DJs can change it. It's %d%% unless changed, and off makes !say wait for the memo to end.", defaultDuck)},
+
+	{Name: "volume", Group: "Server settings", Usage: fmt.Sprintf("[0-%d] | reactions [<min>-<max>|off]", maxVolume), Summary: "Show or change how loud memos play, in percent",
+		Details: fmt.Sprintf("Admins and DJs can change it, and it applies to the memo that's playing too. 100 plays memos as they are. Anyone can react to the now playing message with %s or %s to turn it down or up by %d%%, between %d%% and %d%% unless admins change that with !volume reactions.",
+			volumeDownEmoji, volumeUpEmoji, volumeStep, defaultVolumeBounds.Min, defaultVolumeBounds.Max)},
+	{Name: "loudness", Group: "Server settings", Usage: "[off|<LUFS>]", Summary: "Show or change how loud memos play",
+		Details: fmt.Sprintf("Admins can set a target from %d to %d LUFS that every memo is turned up or down to, e.g. -14.", minTargetLoudness, maxTargetLoudness)},
+	{Name: "namepolicy", Group: "Server settings", Usage: "[reject|suffix|version|prompt]", Summary: "Show or change what happens when a new memo's name is taken",
+		Details: "Admins only. Applies to uploads and recordings. reject refuses the memo, suffix calls it name-2, version replaces the old memo and keeps it as name-v1, and prompt asks the uploader. Memos from other servers are never replaced."},
+	{Name: "preset", Group: "Server settings", Usage: "show | <default|meme|music> | <bitrate|mono|normalize|trim> <value>", Summary: "Show or change how new uploads are encoded",
+		Details: "Admins only."},
+	{Name: "maxmemos", Group: "Server settings", Usage: "[number]", Summary: "Show or change how many voice memos this server can have",
+		Details: "Admins can change it, 0 goes back to the default."},
+	{Name: "prefix", Group: "Server settings", Usage: "[<prefix>|default]", Summary: "Show or change what commands start with",
+		Details: fmt.Sprintf("Admins only. Prefixes can be up to %d characters, without letters, digits or spaces, like ? or !!. Mentioning the bot in place of the prefix always works.", maxPrefixLength)},
+	{Name: "nudge", Group: "Server settings", Usage: "[on|off|default] | message <text>|default", Summary: "Point people typing prefix commands to the slash command",
+		Details: "Admins only. Each person gets the tip at most once a day. {slash} in the message stands for the slash command, e.g. /play name:<name>."},
+	{Name: "cooldown", Group: "Server settings", Usage: "[list] | <command> <duration>|off", Summary: "Show or change how often each person can use a command",
+		Details: fmt.Sprintf("Admins only, and admins and DJs don't have cooldowns. Up to %s, e.g. !cooldown play 10s lets everyone queue one memo every 10 seconds. The soundboard shares the cooldown of !play.", maxCommandCooldown)},
+	{Name: "maxplay", Group: "Server settings", Usage: "[<seconds>|off]", Summary: "Show or change how long voice memos play before they fade out",
+		Details: "Admins only. It applies however a memo is played, from emoji bindings and voice commands to !random. Admins and DJs can !play -full to hear one in full."},
+	{Name: "greeting", Group: "Server settings", Usage: "[message <text>|default|off | join <memo>|off | leave <memo>|off]",
+		Summary: "Show or change what the bot says and plays when it joins and leaves",
+		Details: fmt.Sprintf("Admins only. \"{server}\" in the message is replaced with the server's name. Memos can be up to %s long.", FormatDuration(maxGreetingLength))},
+	{Name: "bind", Group: "Server settings", Usage: "emoji <emoji> <name[=weight]>... [-chance=<percent>] [cooldown] | remove <emoji> | list",
+		Summary: "Play a voice memo when an emoji is posted or reacted with in this channel",
+		Details: "Admins and DJs only. Give several memos to pick one at random each time, e.g. !bind emoji 🦆 quack=3 honk plays quack three times as often as honk. Weights go up to 100. -chance=30 only plays something 30% of the time."},
+	{Name: "alias", Group: "Server settings", Usage: "add <alias> <name> | remove <alias> | list", Summary: "Give a voice memo a short name to play it by",
+		Details: "Admins and DJs only. Aliases work in !play and !info, e.g. !alias add w wilhelm-scream lets you !play w."},
+	{Name: "soundboard", Group: "Server settings", Usage: "[add|remove <names...> | clear]", Summary: "Post buttons that queue voice memos when pressed",
+		Details: fmt.Sprintf("Up to %d memos. Admins and DJs can change which memos are on it, and restricted memos only play for the roles allowed to play them.", maxSoundboard)},
+	{Name: "autojoin", Group: "Server settings", Usage: "[<members>|off]", Summary: "Show or change when the bot joins the busiest voice channel by itself",
+		Details: "Admins and DJs can change it."},
+	{Name: "idle", Group: "Server settings", Usage: "[<duration>|off]", Summary: "Show or change how long the bot stays when nothing is playing",
+		Details: fmt.Sprintf("Admins and DJs can change it. The bot leaves the voice channel once nothing has played, been queued or recorded for that long, %s unless changed, e.g. !idle 30m. Off stays until !leave.", formatIdleTimeout(defaultIdleTimeout))},
+	{Name: "intro", Group: "Server settings", Usage: "exempt [list] | exempt add|remove @user|@role...",
+		Summary: "Let people come and go from voice channels without the bot joining or greeting them",
+		Details: "Admins only. Exempt people don't count toward auto-join, so streamers or moderators popping in don't set it off."},
+	{Name: "grant", Group: "Server settings", Usage: "@user dj <duration> | @user off | list", Summary: "Make someone a DJ for a while",
+		Details: "Admins only. DJs can change the volume, bindings and auto-join, and play restricted memos, e.g. !grant @someone dj 2h."},
+	{Name: "window", Group: "Server settings", Usage: "<name> <when...> | <name> off | list", Summary: "Only let a voice memo play at certain times",
+		Details: "Admins only. Combine months, weekdays, a time of day and a time zone, e.g. !window jingle dec, or !window fanfare fri-sat 19:00-23:00 Europe/Berlin. Times are UTC unless a zone is given. It applies to !play, !random, playlists, the soundboard and emoji bindings."},
+	{Name: "warnwords", Group: "Server settings", Usage: "[list] | add|remove <word or phrase>, ... | block on|off", Summary: "Give voice memos that say certain words a content warning",
+		Details: "Admins only. Memos are checked by their transcript, which new memos get when the bot runs with -transcribe-memos. Memos with a warning are marked in !list and !info, and block on keeps them out of !random and emoji bindings, e.g. !warnwords add damn, shut up."},
+	{Name: "restrict", Group: "Server settings", Usage: "<name> @role... | <name> off | list", Summary: "Reserve a voice memo for certain roles",
+		Details: "Admins only."},
+	{Name: "audit", Group: "Server settings", Usage: "#channel | off", Summary: "Show or change the channel admin actions are logged to", Details: "Admins only."},
+	{Name: "alerts", Group: "Server settings", Usage: "#channel | off", Summary: "Show or change the channel that repeated failures are posted to",
+		Details: "Admins only."},
+	{Name: "purge-guild-data", Group: "Server settings", Usage: "[-dry-run]", Summary: "Delete everything the bot stored for this server",
+		Details: "Admins only. Deletes every voice memo uploaded or recorded here, and the server's playlists, sound packs, settings, play history and stats, after you confirm. -dry-run only counts them."},
+	{Name: "mydata", Group: "Server settings", Usage: "[export|delete [-dry-run]]", Summary: "Get or delete everything the bot stores about you",
+		Details: "Export sends it to you in a DM. Delete removes your plays, playlists and temporary roles everywhere, and the memos you uploaded, though you can leave the ones others still use to the server. -dry-run shows what it would delete."},
+	{Name: "sysinfo", Group: "Server settings", Summary: "Show how the bot is doing, and where playback is choppy",
+		Details: fmt.Sprintf("Only the people running the bot can use it, so it has no slash command. Voice sessions where sending audio blocks for more than %s at the 95th percentile are flagged as starved.", starvedSend)},
+	{Name: "feature", Group: "Server settings", Usage: "[list [server id]] | <feature> on|off|default [server id]",
+		Summary: "Turn recording, emoji triggers or text-to-speech on or off for a server",
+		Details: "Only the people running the bot can use it, so it has no slash command. Features follow -features unless they're set for a server, default goes back to that."},
+
+	{Name: "help", Usage: "[command]", Summary: "List the commands, or explain one of them"},
+}
+
+// Finds a command in the registry. A leading "!" or "/" is optional.
+func LookupCommand(name string) (CommandHelp, bool) {
+	name = strings.TrimLeft(strings.ToLower(name), "!/")
+	for _, ch := range commandRegistry {
+		if ch.Name == name {
+			return ch, true
+		}
+	}
+	return CommandHelp{}, false
+}
+
+// How the command is typed with prefix, e.g. "!play <name> [x1-x10]".
+func (ch CommandHelp) Syntax(prefix string) string {
+	if ch.Usage == "" {
+		return prefix + ch.Name
+	}
+	return prefix + ch.Name + " " + ch.Usage
+}
+
+// How a slash command is typed, e.g. "/play name:<name> [times:<times>]".
+func SlashSyntax(def *discordgo.ApplicationCommand) string {
+	parts := []string{"/" + def.Name}
+	for _, opt := range def.Options {
+		if opt.Required {
+			parts = append(parts, opt.Name+":<"+opt.Name+">")
+			continue
+		}
+		parts = append(parts, "["+opt.Name+":<"+opt.Name+">]")
+	}
+	return strings.Join(parts, " ")
+}
+
+func (b *Bot) HandleHelp(s *discordgo.Session, c *discordgo.Channel, args []string) {
+	if len(args) == 0 {
+		b.SendHelp(s, c)
+		return
+	}
+	prefix := b.VoiceMemoManager.Metadata.Guild(c.GuildID).CommandPrefix()
+	ch, ok := LookupCommand(strings.TrimPrefix(args[0], prefix))
+	if !ok {
+		s.ChannelMessageSend(c.ID, "There's no command called "+args[0]+". "+b.CommandStart(s, c.GuildID, "help")+"help lists them all.")
+		return
+	}
+	start := b.CommandStart(s, c.GuildID, ch.Name)
+	syntax := ch.Syntax(start)
+	sc, slash := slashCommands[ch.Name]
+	if slash && start == "/" {
+		syntax = SlashSyntax(sc.Definition)
+	}
+
+	description := ch.Summary + "."
+	if ch.Details != "" {
+		description += "\n" + ch.Details
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       start + ch.Name,
+		Description: description,
+		Color:       65535,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Usage", Value: "`" + syntax + "`"},
+		},
+	}
+	if slash && start != "/" {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "Also available as /" + ch.Name}
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
+
+func (b *Bot) SendHelp(s *discordgo.Session, c *discordgo.Channel) {
+	grouped := make(map[string][]string)
+	for _, ch := range commandRegistry {
+		grouped[ch.Group] = append(grouped[ch.Group], fmt.Sprintf("`%s%s` %s", b.CommandStart(s, c.GuildID, ch.Name), ch.Name, ch.Summary))
+	}
+
+	fields := make([]*discordgo.MessageEmbedField, 0, len(helpGroups))
+	for _, group := range helpGroups {
+		value := strings.Join(grouped[group], "\n")
+		if len(value) > 1024 {
+			value = value[:1000] + "..."
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: group, Value: value})
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:  "Commands",
+		Color:  65535,
+		Fields: fields,
+		Footer: &discordgo.MessageEmbedFooter{Text: b.CommandStart(s, c.GuildID, "help") + "help <command> for how to use one"},
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Plays !history shows when it isn't given a number, and the most it shows.
+	defaultHistoryLength = 10
+	maxHistoryLength     = 25
+)
+
+// A guild's most recent plays, up to maxHistory of them. Once it's full each new play takes the place of the
+// oldest one. It's saved as a list of plays, oldest first.
+//
+// Everything that looks back at what was played, like !history, !suggest and !random, reads it through
+// MetadataStore so it's always under ms.mu. The methods treat a nil history as an empty one.
+type PlayHistory struct {
+	plays []PlayRecord
+
+	// Index of the oldest play once the history is full. Zero until then.
+	start int
+}
+
+// Adds a play, dropping the oldest one if the history is full.
+func (h *PlayHistory) Add(play PlayRecord) {
+	if len(h.plays) < maxHistory {
+		h.plays = append(h.plays, play)
+		return
+	}
+	h.plays[h.start] = play
+	h.start = (h.start + 1) % len(h.plays)
+}
+
+func (h *PlayHistory) Len() int {
+	if h == nil {
+		return 0
+	}
+	return len(h.plays)
+}
+
+// Returns the i-th play, counting from the oldest.
+func (h *PlayHistory) at(i int) *PlayRecord {
+	return &h.plays[(h.start+i)%len(h.plays)]
+}
+
+// Returns a copy of every play, oldest first.
+func (h *PlayHistory) Plays() []PlayRecord {
+	plays := make([]PlayRecord, 0, h.Len())
+	for i := 0; i < h.Len(); i++ {
+		plays = append(plays, *h.at(i))
+	}
+	return plays
+}
+
+// Returns up to n of the latest plays, newest first.
+func (h *PlayHistory) Recent(n int) []PlayRecord {
+	n = minInt(n, h.Len())
+	plays := make([]PlayRecord, 0, n)
+	for i := h.Len() - 1; i >= h.Len()-n; i-- {
+		plays = append(plays, *h.at(i))
+	}
+	return plays
+}
+
+// Calls fn on every play so it can change it in place, oldest first.
+func (h *PlayHistory) Update(fn func(play *PlayRecord)) {
+	for i := 0; i < h.Len(); i++ {
+		fn(h.at(i))
+	}
+}
+
+// Removes the plays fn returns true for and returns how many it removed.
+func (h *PlayHistory) Remove(fn func(play PlayRecord) bool) int {
+	if h == nil {
+		return 0
+	}
+	kept := make([]PlayRecord, 0, len(h.plays))
+	for _, play := range h.Plays() {
+		if !fn(play) {
+			kept = append(kept, play)
+		}
+	}
+	removed := len(h.plays) - len(kept)
+	h.plays, h.start = kept, 0
+	return removed
+}
+
+func (h *PlayHistory) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Plays())
+}
+
+func (h *PlayHistory) UnmarshalJSON(data []byte) error {
+	var plays []PlayRecord
+	if err := json.Unmarshal(data, &plays); err != nil {
+		return err
+	}
+	// maxHistory may have shrunk since the history was saved.
+	if len(plays) > maxHistory {
+		plays = plays[len(plays)-maxHistory:]
+	}
+	h.plays, h.start = plays, 0
+	return nil
+}
+
+// GuildHistory returns a copy of a guild's play history, oldest first.
+func (ms *MetadataStore) GuildHistory(guildID string) []PlayRecord {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return ms.History[guildID].Plays()
+}
+
+// RecentPlays returns up to n of a guild's latest plays, newest first.
+func (ms *MetadataStore) RecentPlays(guildID string, n int) []PlayRecord {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return ms.History[guildID].Recent(n)
+}
+
+func (b *Bot) HandleHistory(s *discordgo.Session, c *discordgo.Channel, args []string) {
+	usage := fmt.Sprintf("Usage: !history [@user] [1-%d]", maxHistoryLength)
+	n, userID := defaultHistoryLength, ""
+	for _, arg := range args {
+		if count, err := strconv.Atoi(arg); err == nil {
+			if count < 1 || count > maxHistoryLength {
+				s.ChannelMessageSend(c.ID, usage)
+				return
+			}
+			n = count
+			continue
+		}
+		userID = ParseUser(arg)
+	}
+
+	plays := b.VoiceMemoManager.Metadata.RecentPlays(c.GuildID, maxHistory)
+	lines := make([]string, 0, n)
+	for _, play := range plays {
+		if len(lines) == n {
+			break
+		}
+		if userID != "" && play.UserID != userID {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s%s <t:%d:R>", play.Memo, requestedBy(play.UserID), play.At.Unix()))
+	}
+	if len(lines) == 0 {
+		if userID != "" {
+			s.ChannelMessageSend(c.ID, "<@"+userID+"> hasn't played anything lately.")
+			return
+		}
+		s.ChannelMessageSend(c.ID, "Nothing has been played here yet.")
+		return
+	}
+
+	description := strings.Join(lines, "\n")
+	if userID != "" {
+		description = "Played by <@" + userID + ">:\n" + description
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "Recently played",
+		Description: description,
+		Color:       65535,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Newest first, out of the last %d plays", len(plays))},
+	}
+	if len(embed.Description) > 4096 {
+		embed.Description = embed.Description[:4000] + "..."
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+)
+
+// Returns a history that has had plays of memos "0", "1", ... added to it, n in all.
+func testHistory(n int) *PlayHistory {
+	h := &PlayHistory{}
+	for i := 0; i < n; i++ {
+		h.Add(PlayRecord{Memo: strconv.Itoa(i)})
+	}
+	return h
+}
+
+// Returns the memos of plays, in order.
+func playedMemos(plays []PlayRecord) []string {
+	names := make([]string, 0, len(plays))
+	for _, play := range plays {
+		names = append(names, play.Memo)
+	}
+	return names
+}
+
+// Returns the memos "from" up to but not including "to", counting down if to is below from.
+func memoRange(from, to int) []string {
+	names := make([]string, 0)
+	for i := from; i != to; {
+		names = append(names, strconv.Itoa(i))
+		if to > from {
+			i++
+		} else {
+			i--
+		}
+	}
+	return names
+}
+
+func TestPlayHistory(t *testing.T) {
+	tests := []struct {
+		name  string
+		added int
+		// The oldest play that should still be kept.
+		oldest int
+	}{
+		{"empty", 0, 0},
+		{"one", 1, 0},
+		{"almost full", maxHistory - 1, 0},
+		{"full", maxHistory, 0},
+		{"one over", maxHistory + 1, 1},
+		{"wrapped twice", 2*maxHistory + 7, maxHistory + 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := testHistory(tt.added)
+			want := memoRange(tt.oldest, tt.added)
+			if got := playedMemos(h.Plays()); !equalStrings(got, want) {
+				t.Fatalf("Plays() holds %d plays, want the %d from %d on, oldest first", len(got), len(want), tt.oldest)
+			}
+			if h.Len() != len(want) {
+				t.Errorf("Len() = %d, want %d", h.Len(), len(want))
+			}
+
+			recent := minInt(3, len(want))
+			if got, want := playedMemos(h.Recent(3)), memoRange(tt.added-1, tt.added-1-recent); !equalStrings(got, want) {
+				t.Errorf("Recent(3) = %v, want %v", got, want)
+			}
+
+			// Saved oldest first, and the same once loaded again.
+			data, err := json.Marshal(h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			loaded := &PlayHistory{}
+			if err := json.Unmarshal(data, loaded); err != nil {
+				t.Fatal(err)
+			}
+			if got := playedMemos(loaded.Plays()); !equalStrings(got, want) {
+				t.Errorf("history saved and loaded holds %d plays, want %d", len(got), len(want))
+			}
+
+			// Removing plays keeps the rest in order, and the ring carries on from there.
+			removed := h.Remove(func(play PlayRecord) bool {
+				n, _ := strconv.Atoi(play.Memo)
+				return n%2 == 0
+			})
+			kept := make([]string, 0, len(want))
+			for _, name := range want {
+				if n, _ := strconv.Atoi(name); n%2 != 0 {
+					kept = append(kept, name)
+				}
+			}
+			if removed != len(want)-len(kept) {
+				t.Errorf("Remove removed %d plays, want %d", removed, len(want)-len(kept))
+			}
+			h.Add(PlayRecord{Memo: "new"})
+			kept = append(kept, "new")
+			if got := playedMemos(h.Plays()); !equalStrings(got, kept) {
+				t.Errorf("after Remove and Add, history holds %d plays, want %d", len(got), len(kept))
+			}
+		})
+	}
+}
+
+func TestNilPlayHistory(t *testing.T) {
+	var h *PlayHistory
+	if h.Len() != 0 || len(h.Plays()) != 0 || len(h.Recent(5)) != 0 || h.Remove(func(PlayRecord) bool { return true }) != 0 {
+		t.Error("a nil history isn't empty")
+	}
+}
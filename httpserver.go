@@ -0,0 +1,250 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// HTTPServer exposes the library over HTTP for the web dashboard and stream overlays.
+type HTTPServer struct {
+	VoiceMemoManager *VoiceMemoManager
+	token            string
+
+	// Where the server is reachable from outside, used to build shareable links.
+	publicURL string
+
+	// Deletes everything stored for a guild. Nil where the server can't change anything, like in a mirror.
+	PurgeGuild func(guildID string) (PurgeReport, error)
+
+	// Reports how playback is doing in the bot's voice sessions. Nil where there are none, like in a mirror.
+	Metrics func() PlaybackMetrics
+
+	// Reports how the bot's subsystems are doing. Nil in a mirror, like Metrics.
+	Health func() HealthReport
+}
+
+// Previews are kept in vm's artifact cache, so it needs one.
+func NewHTTPServer(vm *VoiceMemoManager, token, publicURL string) (*HTTPServer, error) {
+	if token == "" {
+		return nil, errors.New("an -http-token is required to serve the library")
+	}
+	if vm.Artifacts == nil {
+		return nil, errors.New("previews need an artifact cache")
+	}
+
+	return &HTTPServer{
+		VoiceMemoManager: vm,
+		token:            token,
+		publicURL:        publicURL,
+	}, nil
+}
+
+func (h *HTTPServer) ListenAndServe(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/memos", h.authorized(h.HandleMemoList))
+	mux.HandleFunc("/memos/", h.HandleMemo)
+	mux.HandleFunc("/artifacts", h.authorized(h.HandleArtifactStats))
+	mux.HandleFunc("/guilds/", h.authorized(h.HandleGuild))
+	mux.HandleFunc("/metrics", h.authorized(h.HandleMetrics))
+	mux.HandleFunc("/health", h.authorized(h.HandleHealth))
+	return http.ListenAndServe(addr, mux)
+}
+
+// Rejects requests without the server token.
+func (h *HTTPServer) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !h.validToken(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Serves GET /memos: every memo's info as JSON.
+func (h *HTTPServer) HandleMemoList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	memos := make([]MemoInfo, 0)
+	for _, name := range h.VoiceMemoManager.Names() {
+		if info, ok := h.VoiceMemoManager.Info(name); ok {
+			memos = append(memos, info)
+		}
+	}
+	writeJSON(w, memos)
+}
+
+// Serves GET /artifacts: how full the artifact cache is and how often it saved rebuilding something.
+func (h *HTTPServer) HandleArtifactStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, h.VoiceMemoManager.Artifacts.Stats())
+}
+
+// Serves GET /metrics: how long sending audio to each voice session blocked, and which are starved.
+func (h *HTTPServer) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Metrics == nil {
+		http.Error(w, "this server doesn't play anything", http.StatusNotImplemented)
+		return
+	}
+	writeJSON(w, h.Metrics())
+}
+
+// Serves GET /health: each subsystem's health and restarts, and the overall score out of 100. Responds with
+// 503 Service Unavailable while any subsystem is fully down, for load balancers and uptime checks.
+func (h *HTTPServer) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Health == nil {
+		http.Error(w, "this server doesn't run the bot", http.StatusNotImplemented)
+		return
+	}
+	report := h.Health()
+	for _, subsystem := range report.Subsystems {
+		if subsystem.Score == 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			break
+		}
+	}
+	writeJSON(w, report)
+}
+
+// Serves DELETE /guilds/<id>: deletes everything stored for the guild, for data requests that come in
+// outside Discord. Responds with what was deleted, or with -dry-run what would have been.
+func (h *HTTPServer) HandleGuild(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	guildID := strings.TrimPrefix(r.URL.Path, "/guilds/")
+	if guildID == "" || strings.Contains(guildID, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if h.PurgeGuild == nil {
+		http.Error(w, "this server can't delete anything", http.StatusNotImplemented)
+		return
+	}
+
+	report, err := h.PurgeGuild(guildID)
+	if err != nil {
+		fmt.Println("Error purging guild data: ", err)
+		http.Error(w, "could not save the purge", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, report)
+}
+
+// Routes /memos/<name> to the memo's info and /memos/<name>/preview.ogg to its preview.
+// Previews check their own authorization since they also accept signed links.
+func (h *HTTPServer) HandleMemo(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/preview.ogg") {
+		h.HandlePreview(w, r)
+		return
+	}
+	h.authorized(h.HandleMemoInfo)(w, r)
+}
+
+// Serves GET /memos/<name>: the memo's info as JSON.
+func (h *HTTPServer) HandleMemoInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	info, ok := h.VoiceMemoManager.Info(strings.TrimPrefix(r.URL.Path, "/memos/"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, info)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Println("Error writing JSON response: ", err)
+	}
+}
+
+// Reports whether the request carries the server token. Overlays often can't set headers, so the token
+// may also be passed as a ?token= query parameter.
+func (h *HTTPServer) validToken(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+// Serves GET /memos/<name>/preview.ogg to token holders and signed links. Range requests are supported
+// so players can scrub.
+func (h *HTTPServer) HandlePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/memos/")
+	name := strings.TrimSuffix(path, "/preview.ogg")
+	if name == path || name == "" || strings.Contains(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if !h.validToken(r) && !h.validSignature(r, name) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vm := h.VoiceMemoManager.Get(name)
+	if vm == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	preview, err := h.EnsurePreview(vm)
+	if err != nil {
+		fmt.Println("Error generating preview for ", name, ": ", err)
+		http.Error(w, "could not generate preview", http.StatusInternalServerError)
+		return
+	}
+
+	f, err := os.Open(preview)
+	if err != nil {
+		http.Error(w, "could not open preview", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, "could not open preview", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "audio/ogg")
+	http.ServeContent(w, r, name+".ogg", info.ModTime(), f)
+}
+
+// Returns the path of the memo's Ogg preview, writing it first if it isn't cached.
+// The opus frames are already encoded, so this only rewraps them and never runs ffmpeg.
+func (h *HTTPServer) EnsurePreview(vm *VoiceMemo) (string, error) {
+	return h.VoiceMemoManager.Artifacts.Path(vm.hash, "ogg", vm.WriteOgg)
+}
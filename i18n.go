@@ -0,0 +1,775 @@
+package main
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// Translations by locale and key. English lives next to the code that uses it and is never listed here;
+// anything missing from a locale falls back to it.
+//
+// Keys:
+//
+//	command.<name>.name                       localized slash command name (lowercase, no spaces)
+//	command.<name>.description                slash command description
+//	command.<name>.option.<option>            slash command option description
+//	response.<key>                            text the bot replies with
+var catalog = map[discordgo.Locale]map[string]string{
+	discordgo.German: {
+		"command.join.name":                       "beitreten",
+		"command.join.description":                "Deinem Sprachkanal beitreten",
+		"command.leave.name":                      "verlassen",
+		"command.leave.description":               "Den Sprachkanal verlassen",
+		"command.handoff.description":             "Den Bot und seine Warteschlange in einen anderen Sprachkanal verschieben",
+		"command.handoff.option.channel":          "Sprachkanal, in den gewechselt wird",
+		"command.play.name":                       "abspielen",
+		"command.play.description":                "Ein Sprachmemo abspielen",
+		"command.play.option.name":                "Sprachmemo, das abgespielt werden soll",
+		"command.play.option.times":               "Wie oft es hintereinander abgespielt werden soll, bis zu 10",
+		"command.play.option.full":                "Über das Zeitlimit des Servers hinaus abspielen, für Admins und DJs",
+		"command.play.option.start":               "Wo die Wiedergabe beginnen soll, z. B. 0:15",
+		"command.playnext.description":            "Ein Sprachmemo direkt nach dem laufenden abspielen",
+		"command.playnext.option.name":            "Sprachmemo, das als Nächstes abgespielt werden soll",
+		"command.playnext.option.full":            "Über das Zeitlimit des Servers hinaus abspielen",
+		"command.playnext.option.start":           "Wo die Wiedergabe beginnen soll, z. B. 0:15",
+		"command.cue.description":                 "Ein Sprachmemo für jemanden abspielen, wenn die Person im Sprachkanal ist",
+		"command.cue.option.user":                 "Für wen es ist",
+		"command.cue.option.name":                 "Sprachmemo, das abgespielt werden soll",
+		"command.skip.name":                       "überspringen",
+		"command.skip.description":                "Das laufende Sprachmemo überspringen",
+		"command.stop.name":                       "stopp",
+		"command.stop.description":                "Wiedergabe anhalten und die Warteschlange leeren",
+		"command.seek.description":                "Zu einer Stelle im gerade laufenden Sprachmemo springen",
+		"command.seek.option.to":                  "Wo die Wiedergabe weitergehen soll, z. B. 0:15",
+		"command.pause.name":                      "pause",
+		"command.pause.description":               "Das laufende Sprachmemo pausieren",
+		"command.resume.name":                     "fortsetzen",
+		"command.resume.description":              "Die Wiedergabe dort fortsetzen, wo sie pausiert wurde",
+		"command.queue.name":                      "warteschlange",
+		"command.queue.description":               "Zeigen, was als Nächstes kommt",
+		"command.shuffle.name":                    "mischen",
+		"command.shuffle.description":             "Die Sprachmemos in der Warteschlange zufällig anordnen",
+		"command.loop.name":                       "wiederholen",
+		"command.loop.description":                "Das laufende Sprachmemo wiederholen",
+		"command.loop.option.mode":                "\"on\" oder \"off\", weglassen zum Umschalten",
+		"command.loopqueue.name":                  "warteschlange-wiederholen",
+		"command.loopqueue.description":           "Die ganze Warteschlange immer wieder abspielen",
+		"command.loopqueue.option.mode":           "\"on\" oder \"off\", weglassen zum Umschalten",
+		"command.clearqueue.name":                 "warteschlange-leeren",
+		"command.clearqueue.description":          "Alles verwerfen, was in der Warteschlange wartet",
+		"command.remove.description":              "Ein Sprachmemo aus der Warteschlange nehmen",
+		"command.remove.option.number":            "Die Nummer des Memos in der Warteschlange",
+		"command.move.description":                "Ein Sprachmemo an eine andere Stelle der Warteschlange verschieben",
+		"command.move.option.from":                "Die Nummer des Memos in der Warteschlange",
+		"command.move.option.to":                  "Die Nummer, die es haben soll",
+		"command.list.name":                       "liste",
+		"command.list.description":                "Alle Sprachmemos auflisten",
+		"command.list.option.page":                "Anzuzeigende Seite",
+		"command.list.option.tag":                 "Nur Memos mit diesen kommagetrennten Tags auflisten",
+		"command.list.option.sort":                "Wonach die Sprachmemos sortiert werden",
+		"command.search.name":                     "suchen",
+		"command.search.description":              "Sprachmemos nach Namen finden, auch falsch geschrieben",
+		"command.search.option.term":              "Der ganze Name oder ein Teil davon",
+		"command.search.option.tag":               "Nur Memos mit diesen kommagetrennten Tags durchsuchen",
+		"command.recent.name":                     "neu",
+		"command.recent.description":              "Die neuesten Sprachmemos zeigen und wer sie hinzugefügt hat",
+		"command.recent.option.count":             "Wie viele angezeigt werden",
+		"command.tag.name":                        "tag",
+		"command.tag.description":                 "Sprachmemos taggen, damit man sie nach Tag finden und auswählen kann",
+		"command.tag.option.action":               "Was zu tun ist",
+		"command.tag.option.name":                 "Sprachmemo, das getaggt wird",
+		"command.tag.option.tags":                 "Kommagetrennte Tags",
+		"command.random.name":                     "zufall",
+		"command.random.description":              "Ein zufälliges Sprachmemo abspielen",
+		"command.random.option.tag":               "Nur Memos mit diesen kommagetrennten Tags auswählen",
+		"command.random.option.avoid":             "Statt zu spielen ändern, wie viele der letzten Wiedergaben gemieden werden",
+		"command.history.name":                    "verlauf",
+		"command.history.description":             "Zeigen, was zuletzt gespielt wurde und wer es wollte",
+		"command.history.option.user":             "Nur zeigen, was diese Person gespielt hat",
+		"command.history.option.count":            "Wie viele Wiedergaben angezeigt werden",
+		"command.delete.name":                     "löschen",
+		"command.delete.description":              "Ein Sprachmemo löschen",
+		"command.delete.option.name":              "Sprachmemo, das gelöscht werden soll",
+		"command.delete.option.dry_run":           "Nur zeigen, was sich ändern würde",
+		"command.rename.name":                     "umbenennen",
+		"command.rename.description":              "Einem Sprachmemo einen neuen Namen geben",
+		"command.rename.option.name":              "Umzubenennendes Sprachmemo",
+		"command.rename.option.new_name":          "Sein neuer Name",
+		"command.transfer.description":            "Ein Sprachmemo, das du hochgeladen hast, jemand anderem geben",
+		"command.transfer.option.name":            "Abzugebendes Sprachmemo",
+		"command.transfer.option.user":            "Wer es bekommt",
+		"command.maxmemos.description":            "Anzeigen oder ändern, wie viele Sprachmemos dieser Server haben darf",
+		"command.maxmemos.option.limit":           "Neues Limit, 0 für den Standardwert",
+		"command.maxplay.description":             "Anzeigen oder ändern, wie lange Sprachmemos spielen, bevor sie ausgeblendet werden",
+		"command.maxplay.option.seconds":          "Sekunden, bis Memos ausgeblendet werden, oder \"off\", um sie ganz abzuspielen",
+		"command.prefix.name":                     "präfix",
+		"command.prefix.description":              "Anzeigen oder ändern, womit Befehle beginnen",
+		"command.prefix.option.prefix":            "Neues Präfix, oder \"default\" für !",
+		"command.nudge.description":               "Leute, die Präfixbefehle tippen, auf den Slash-Befehl hinweisen",
+		"command.nudge.option.tips":               "Hinweise ein- oder ausschalten oder der Voreinstellung des Bots folgen",
+		"command.nudge.option.message":            "Neuer Hinweis, {slash} steht für den Slash-Befehl, oder \"default\"",
+		"command.cooldown.description":            "Anzeigen oder ändern, wie oft jede Person einen Befehl verwenden kann",
+		"command.cooldown.option.command":         "Befehl, dessen Abklingzeit geändert wird, z. B. play",
+		"command.cooldown.option.duration":        "Zeit zwischen zwei Verwendungen, z. B. 10s oder 2m, oder \"off\"",
+		"command.namepolicy.description":          "Anzeigen oder ändern, was passiert, wenn der Name eines neuen Memos vergeben ist",
+		"command.namepolicy.option.policy":        "Was mit dem neuen Memo passieren soll",
+		"command.preset.name":                     "voreinstellung",
+		"command.preset.description":              "Anzeigen oder ändern, wie neue Uploads kodiert werden",
+		"command.preset.option.setting":           "show, eine Voreinstellung (default, meme, music) oder bitrate, mono, normalize oder trim",
+		"command.preset.option.value":             "Neuer Wert der Einstellung",
+		"command.loudness.name":                   "lautheit",
+		"command.loudness.description":            "Anzeigen oder ändern, wie laut Memos abgespielt werden",
+		"command.loudness.option.target":          "Ziel in LUFS von -30 bis -6, z. B. -14, oder off",
+		"command.greeting.name":                   "begrüßung",
+		"command.greeting.description":            "Anzeigen oder ändern, was der Bot beim Beitreten und Verlassen sagt und abspielt",
+		"command.greeting.option.setting":         "message, join oder leave",
+		"command.greeting.option.value":           "Begrüßungstext oder ein Sprachmemo, default oder off",
+		"command.volume.name":                     "lautstärke",
+		"command.volume.description":              "Anzeigen oder ändern, wie laut Memos abgespielt werden, in Prozent",
+		"command.volume.option.percent":           "0 bis 200, 100 spielt Memos unverändert ab",
+		"command.volume.option.reactions":         "Bereich, in dem die Reaktionen 🔉 und 🔊 sie ändern, z. B. 50-150, oder off",
+		"command.duck.description":                "Anzeigen oder ändern, wie weit Memos leiser werden, während der Bot darüber spricht, in Prozent",
+		"command.duck.option.percent":             "0 bis 100, 0 wartet stattdessen auf das Ende des Memos",
+		"command.jobs.name":                       "aufträge",
+		"command.jobs.description":                "Laufende Uploads und Transkriptionen sowie fehlgeschlagene Wiedergaben auflisten",
+		"command.jobs.option.cancel":              "ID eines Auftrags, der abgebrochen werden soll",
+		"command.jobs.option.clear":               "Die fehlgeschlagenen Wiedergaben vergessen",
+		"command.link.name":                       "link",
+		"command.link.description":                "Einen befristeten Link zum Anhören eines Sprachmemos außerhalb von Discord erhalten",
+		"command.link.option.name":                "Sprachmemo, das geteilt werden soll",
+		"command.bind.name":                       "verknüpfen",
+		"command.bind.description":                "Ein Sprachmemo abspielen, wenn in diesem Kanal ein Emoji gepostet oder als Reaktion verwendet wird",
+		"command.bind.option.emoji":               "Zu verknüpfendes Emoji, weglassen, um die Verknüpfungen aufzulisten",
+		"command.bind.option.name":                "Abzuspielendes Sprachmemo, weglassen, um die Verknüpfung zu entfernen",
+		"command.bind.option.cooldown":            "Wie lange es dauert, bis es wieder abgespielt werden kann, z. B. 30s",
+		"command.bind.option.pool":                "Weitere Sprachmemos zur zufälligen Auswahl, optional gewichtet, z. B. honk oof=2",
+		"command.bind.option.chance":              "Wahrscheinlichkeit in Prozent, dass überhaupt etwas abgespielt wird",
+		"command.alias.description":               "Einem Sprachmemo einen kurzen Namen geben, unter dem es abgespielt wird",
+		"command.alias.option.alias":              "Hinzuzufügender oder zu entfernender Alias, weglassen, um die Aliase aufzulisten",
+		"command.alias.option.name":               "Sprachmemo, das er abspielt, weglassen, um den Alias zu entfernen",
+		"command.soundboard.description":          "Schaltflächen posten, die beim Drücken Sprachmemos einreihen",
+		"command.soundboard.option.action":        "Was getan werden soll, weglassen, um das Soundboard zu posten",
+		"command.soundboard.option.memos":         "Sprachmemos zum Hinzufügen oder Entfernen, durch Leerzeichen getrennt",
+		"command.autojoin.name":                   "autobeitritt",
+		"command.autojoin.description":            "Anzeigen oder ändern, wann der Bot selbst dem vollsten Sprachkanal beitritt",
+		"command.autojoin.option.members":         "Personen, die in einem Kanal sein müssen, bevor er beitritt, oder „off“",
+		"command.idle.description":                "Anzeigen oder ändern, wie lange der Bot bleibt, wenn nichts abgespielt wird",
+		"command.idle.option.after":               "Wie lange er wartet, bevor er geht, z. B. 30m, oder „off“",
+		"command.pack.name":                       "paket",
+		"command.pack.description":                "Gruppen von Sprachmemos zwischen Servern teilen",
+		"command.pack.option.action":              "Was getan werden soll",
+		"command.pack.option.pack":                "Name des Soundpakets",
+		"command.pack.option.memos":               "Sprachmemos zum Hinzufügen oder Entfernen, durch Leerzeichen getrennt",
+		"command.playlist.name":                   "wiedergabeliste",
+		"command.playlist.description":            "Wiedergabelisten aus Sprachmemos erstellen und auf einmal einreihen",
+		"command.playlist.option.action":          "Was getan werden soll",
+		"command.playlist.option.playlist":        "Name der Wiedergabeliste",
+		"command.playlist.option.memos":           "Sprachmemos zum Hinzufügen oder Entfernen, durch Leerzeichen getrennt",
+		"command.grant.name":                      "dj-rechte",
+		"command.grant.description":               "Jemanden für eine Weile zum DJ machen",
+		"command.grant.option.user":               "Wer DJ-Rechte bekommt",
+		"command.grant.option.duration":           "Wie lange sie gelten, z. B. 2h, oder „off“ zum Entziehen",
+		"command.intro.name":                      "intro",
+		"command.intro.description":               "Leute Sprachkanäle betreten und verlassen lassen, ohne dass der Bot beitritt oder grüßt",
+		"command.intro.option.action":             "Was zu tun ist",
+		"command.intro.option.who":                "Person oder Rolle, die ausgenommen wird oder wieder zählt",
+		"command.restrict.name":                   "beschraenken",
+		"command.restrict.description":            "Ein Sprachmemo für bestimmte Rollen reservieren",
+		"command.restrict.option.name":            "Sprachmemo, das beschränkt werden soll",
+		"command.restrict.option.role":            "Rolle, die es abspielen darf",
+		"command.restrict.option.off":             "Alle dürfen es wieder abspielen",
+		"command.window.description":              "Ein Sprachmemo nur zu bestimmten Zeiten abspielen lassen",
+		"command.window.option.name":              "Zu änderndes Sprachmemo",
+		"command.window.option.when":              "Monate, Wochentage, Uhrzeit und Zeitzone, z. B. fri-sat 19:00-23:00, oder \"off\"",
+		"command.warnwords.description":           "Sprachmemos, die bestimmte Wörter sagen, eine Inhaltswarnung geben",
+		"command.warnwords.option.action":         "Was getan werden soll, weglassen, um die Wörter aufzulisten",
+		"command.warnwords.option.words":          "Hinzuzufügende oder zu entfernende Wörter oder Wendungen, durch Kommas getrennt",
+		"command.cleanup.name":                    "aufraeumen",
+		"command.cleanup.description":             "Sprachmemos auswählen und auf einmal löschen",
+		"command.cleanup.option.sort":             "Welche Sprachmemos zuerst angeboten werden",
+		"command.cleanup.option.dry_run":          "Nur zeigen, was das Löschen der Auswahl ändern würde",
+		"command.audit.name":                      "protokoll",
+		"command.audit.description":               "Den Kanal für das Protokoll von Admin-Aktionen anzeigen oder ändern",
+		"command.audit.option.channel":            "Kanal, in dem Admin-Aktionen protokolliert werden",
+		"command.audit.option.off":                "Admin-Aktionen nicht mehr protokollieren",
+		"command.alerts.name":                     "warnungen",
+		"command.alerts.description":              "Den Kanal für wiederholte Fehler anzeigen oder ändern",
+		"command.alerts.option.channel":           "Kanal, in den Warnungen gepostet werden",
+		"command.alerts.option.off":               "Keine Warnungen mehr posten",
+		"command.purge-guild-data.name":           "serverdaten-löschen",
+		"command.purge-guild-data.description":    "Alles löschen, was der Bot für diesen Server gespeichert hat",
+		"command.purge-guild-data.option.dry_run": "Nur zählen, was gelöscht würde",
+		"command.mydata.name":                     "meinedaten",
+		"command.mydata.description":              "Alles abrufen oder löschen, was der Bot über dich speichert",
+		"command.mydata.option.action":            "Was mit deinen Daten passieren soll",
+		"command.mydata.option.dry_run":           "Nur zeigen, was gelöscht würde",
+		"command.suggest.name":                    "vorschlagen",
+		"command.suggest.description":             "Sprachmemos empfehlen, die du länger nicht abgespielt hast",
+		"command.record.name":                     "aufnehmen",
+		"command.record.description":              "Dich im Sprachkanal als neues Sprachmemo aufnehmen",
+		"command.record.option.name":              "Name des neuen Sprachmemos",
+		"command.record.option.seconds":           "Wie lange aufgenommen wird, bis zu 60 Sekunden",
+		"command.info.name":                       "info",
+		"command.info.description":                "Alles zu einem Sprachmemo anzeigen",
+		"command.info.option.name":                "Sprachmemo, das nachgeschlagen werden soll",
+		"command.stats.name":                      "statistik",
+		"command.stats.description":               "Zeigen, wie groß ein Sprachmemo ist und wie oft es hier gespielt wird",
+		"command.stats.option.name":               "Sprachmemo, das nachgeschlagen werden soll",
+		"command.describe.name":                   "beschreiben",
+		"command.describe.description":            "Einem Sprachmemo eine Beschreibung oder einen Credit hinzufügen",
+		"command.describe.option.name":            "Sprachmemo, das beschrieben werden soll",
+		"command.trim.name":                       "kuerzen",
+		"command.trim.description":                "Den Anfang oder das Ende eines Sprachmemos abschneiden",
+		"command.trim.option.name":                "Sprachmemo, das gekürzt werden soll",
+		"command.trim.option.start":               "Sekunde, bei der es anfängt; beide weglassen, um mit Buttons zu wählen",
+		"command.trim.option.end":                 "Sekunde, bei der es endet",
+		"command.describe.option.description":     "Weglassen, um die Beschreibung zu entfernen",
+		"command.listen.name":                     "zuhören",
+		"command.listen.description":              "Auf einen gesprochenen „play <Name>“-Befehl hören",
+		"command.say.name":                        "sagen",
+		"command.say.description":                 "Etwas im Sprachkanal sagen",
+		"command.say.option.text":                 "Was gesagt werden soll",
+		"command.say.option.voice":                "Stimme, mit der es gesagt wird",
+		"command.voices.name":                     "stimmen",
+		"command.voices.description":              "Stimmen auflisten, die /say verwenden kann",
+		"command.voice.name":                      "stimme",
+		"command.voice.description":               "Die Standardstimme dieses Servers anzeigen oder ändern",
+		"command.voice.option.name":               "Neue Standardstimme oder „default“",
+		"command.help.name":                       "hilfe",
+		"command.help.description":                "Die Befehle auflisten oder einen davon erklären",
+		"command.Save as voice memo.name":         "Als Sprachmemo speichern",
+		"command.help.option.command":             "Zu erklärender Befehl",
+		"command.upload.name":                     "hochladen",
+		"command.upload.description":              "Ein Sprachmemo hochladen",
+		"command.upload.option.file":              "Audiodatei zum Hochladen",
+		"command.upload.option.name":              "Name des Sprachmemos, standardmäßig der Dateiname",
+		"command.upload.option.tags":              "Kommagetrennte Tags",
+		"command.upload.option.longform":          "Von der Festplatte streamen und bei Zufallsauswahl auslassen",
+		"command.upload.option.mono":              "In Mono kodieren, das halbiert die Größe",
+		"response.running":                        "Führe /%s aus",
+		"response.unknown_command":                "Diesen Befehl kenne ich nicht mehr.",
+		"response.unknown_button":                 "Dieser Knopf macht nichts mehr.",
+		"response.admins_only_prune":              "Nur Admins können hier Sprachmemos löschen.",
+	},
+	discordgo.French: {
+		"command.join.name":                       "rejoindre",
+		"command.join.description":                "Rejoindre ton salon vocal",
+		"command.leave.name":                      "quitter",
+		"command.leave.description":               "Quitter le salon vocal",
+		"command.handoff.description":             "Déplacer le bot et sa file d’attente vers un autre salon vocal",
+		"command.handoff.option.channel":          "Salon vocal où aller",
+		"command.play.name":                       "jouer",
+		"command.play.description":                "Jouer un mémo vocal",
+		"command.play.option.name":                "Mémo vocal à jouer",
+		"command.play.option.times":               "Combien de fois le jouer d’affilée, jusqu’à 10",
+		"command.play.option.full":                "Le jouer au-delà de la limite de durée du serveur, pour les admins et DJ",
+		"command.play.option.start":               "Où commencer la lecture, par ex. 0:15",
+		"command.playnext.description":            "Jouer un mémo vocal juste après celui en cours",
+		"command.playnext.option.name":            "Mémo vocal à jouer ensuite",
+		"command.playnext.option.full":            "Le jouer au-delà de la limite de durée du serveur",
+		"command.playnext.option.start":           "Où commencer la lecture, par ex. 0:15",
+		"command.cue.description":                 "Jouer un mémo vocal pour quelqu’un, s’il est dans le salon vocal",
+		"command.cue.option.user":                 "Pour qui c’est",
+		"command.cue.option.name":                 "Mémo vocal à jouer",
+		"command.skip.name":                       "passer",
+		"command.skip.description":                "Passer le mémo vocal en cours",
+		"command.stop.name":                       "arreter",
+		"command.stop.description":                "Arrêter la lecture et vider la file d’attente",
+		"command.seek.description":                "Aller à un moment du mémo vocal en cours de lecture",
+		"command.seek.option.to":                  "Où reprendre la lecture, par ex. 0:15",
+		"command.pause.name":                      "pause",
+		"command.pause.description":               "Mettre en pause le mémo vocal en cours",
+		"command.resume.name":                     "reprendre",
+		"command.resume.description":              "Reprendre la lecture là où elle a été mise en pause",
+		"command.queue.name":                      "file",
+		"command.queue.description":               "Afficher la file d'attente",
+		"command.shuffle.name":                    "melanger",
+		"command.shuffle.description":             "Mettre les mémos vocaux de la file dans un ordre aléatoire",
+		"command.loop.name":                       "boucle",
+		"command.loop.description":                "Répéter le mémo vocal en cours",
+		"command.loop.option.mode":                "\"on\" ou \"off\", omettre pour basculer",
+		"command.loopqueue.name":                  "boucle-file",
+		"command.loopqueue.description":           "Rejouer toute la file en boucle",
+		"command.loopqueue.option.mode":           "\"on\" ou \"off\", omettre pour basculer",
+		"command.clearqueue.name":                 "vider-file",
+		"command.clearqueue.description":          "Jeter tout ce qui attend dans la file d’attente",
+		"command.remove.description":              "Retirer un mémo vocal de la file d’attente",
+		"command.remove.option.number":            "Le numéro du mémo dans la file d’attente",
+		"command.move.description":                "Déplacer un mémo vocal ailleurs dans la file d’attente",
+		"command.move.option.from":                "Le numéro du mémo dans la file d’attente",
+		"command.move.option.to":                  "Le numéro qu’il doit avoir",
+		"command.list.name":                       "liste",
+		"command.list.description":                "Lister tous les mémos vocaux",
+		"command.list.option.page":                "Page à afficher",
+		"command.list.option.tag":                 "Ne lister que les mémos avec ces tags séparés par des virgules",
+		"command.list.option.sort":                "Comment trier les mémos vocaux",
+		"command.search.name":                     "chercher",
+		"command.search.description":              "Trouver des mémos vocaux par nom, même mal orthographié",
+		"command.search.option.term":              "Tout ou partie d'un nom",
+		"command.search.option.tag":               "Ne chercher que les mémos avec ces tags séparés par des virgules",
+		"command.recent.name":                     "récents",
+		"command.recent.description":              "Montrer les mémos vocaux les plus récents et qui les a ajoutés",
+		"command.recent.option.count":             "Combien en montrer",
+		"command.tag.name":                        "tag",
+		"command.tag.description":                 "Taguer des mémos vocaux pour les trouver et les choisir par tag",
+		"command.tag.option.action":               "Que faire",
+		"command.tag.option.name":                 "Mémo vocal à taguer",
+		"command.tag.option.tags":                 "Tags séparés par des virgules",
+		"command.random.name":                     "aléatoire",
+		"command.random.description":              "Jouer un mémo vocal au hasard",
+		"command.random.option.tag":               "Ne choisir que parmi les mémos avec ces tags séparés par des virgules",
+		"command.random.option.avoid":             "Changer combien des dernières lectures sont évitées, au lieu de jouer",
+		"command.history.name":                    "historique",
+		"command.history.description":             "Montrer ce qui a été joué récemment, et qui l’a demandé",
+		"command.history.option.user":             "Ne montrer que ce que cette personne a joué",
+		"command.history.option.count":            "Combien de lectures montrer",
+		"command.delete.name":                     "supprimer",
+		"command.delete.description":              "Supprimer un mémo vocal",
+		"command.delete.option.name":              "Mémo vocal à supprimer",
+		"command.delete.option.dry_run":           "Montrer seulement ce qui changerait",
+		"command.rename.name":                     "renommer",
+		"command.rename.description":              "Donner un nouveau nom à un mémo vocal",
+		"command.rename.option.name":              "Mémo vocal à renommer",
+		"command.rename.option.new_name":          "Son nouveau nom",
+		"command.transfer.description":            "Donner à quelqu’un d’autre un mémo vocal que vous avez envoyé",
+		"command.transfer.option.name":            "Mémo vocal à donner",
+		"command.transfer.option.user":            "Qui le reçoit",
+		"command.maxmemos.description":            "Afficher ou modifier le nombre de mémos vocaux autorisés sur ce serveur",
+		"command.maxmemos.option.limit":           "Nouvelle limite, 0 pour la valeur par défaut",
+		"command.maxplay.description":             "Voir ou changer combien de temps les mémos vocaux jouent avant de s’estomper",
+		"command.maxplay.option.seconds":          "Secondes avant que les mémos s’estompent, ou « off » pour les jouer en entier",
+		"command.prefix.name":                     "préfixe",
+		"command.prefix.description":              "Voir ou changer ce par quoi les commandes commencent",
+		"command.prefix.option.prefix":            "Nouveau préfixe, ou « default » pour !",
+		"command.nudge.description":               "Orienter vers la commande slash ceux qui tapent des commandes à préfixe",
+		"command.nudge.option.tips":               "Activer ou désactiver les conseils, ou suivre le réglage par défaut du bot",
+		"command.nudge.option.message":            "Nouveau conseil, {slash} représente la commande slash, ou « default »",
+		"command.cooldown.description":            "Afficher ou modifier la fréquence à laquelle chacun peut utiliser une commande",
+		"command.cooldown.option.command":         "Commande dont modifier le délai, par ex. play",
+		"command.cooldown.option.duration":        "Temps entre deux utilisations, comme 10s ou 2m, ou « off »",
+		"command.namepolicy.description":          "Afficher ou modifier ce qui se passe quand le nom d’un nouveau mémo est pris",
+		"command.namepolicy.option.policy":        "Que faire du nouveau mémo",
+		"command.preset.name":                     "préréglage",
+		"command.preset.description":              "Afficher ou modifier l'encodage des nouveaux envois",
+		"command.preset.option.setting":           "show, un préréglage (default, meme, music), ou bitrate, mono, normalize ou trim",
+		"command.preset.option.value":             "Nouvelle valeur du réglage",
+		"command.loudness.name":                   "volume-cible",
+		"command.loudness.description":            "Afficher ou changer le volume de lecture des mémos",
+		"command.loudness.option.target":          "Cible en LUFS de -30 à -6, par ex. -14, ou off",
+		"command.greeting.name":                   "accueil",
+		"command.greeting.description":            "Afficher ou changer ce que le bot dit et joue en arrivant et en partant",
+		"command.greeting.option.setting":         "message, join ou leave",
+		"command.greeting.option.value":           "Texte d’accueil ou un mémo vocal, default ou off",
+		"command.volume.name":                     "volume",
+		"command.volume.description":              "Afficher ou changer le volume des mémos, en pourcentage",
+		"command.volume.option.percent":           "0 à 200, 100 joue les mémos tels quels",
+		"command.volume.option.reactions":         "Plage dans laquelle les réactions 🔉 et 🔊 le changent, par ex. 50-150, ou off",
+		"command.duck.description":                "Voir ou changer de combien les mémos baissent quand le bot parle par-dessus, en pourcentage",
+		"command.duck.option.percent":             "0 à 100, 0 attend plutôt la fin du mémo",
+		"command.jobs.name":                       "tâches",
+		"command.jobs.description":                "Lister les envois et transcriptions en cours, et les mémos qui n’ont pas pu être joués",
+		"command.jobs.option.cancel":              "ID d’une tâche à annuler",
+		"command.jobs.option.clear":               "Oublier les mémos qui n’ont pas pu être joués",
+		"command.link.name":                       "lien",
+		"command.link.description":                "Obtenir un lien temporaire pour écouter un mémo vocal hors de Discord",
+		"command.link.option.name":                "Mémo vocal à partager",
+		"command.bind.name":                       "associer",
+		"command.bind.description":                "Jouer un mémo vocal quand un emoji est posté ou ajouté en réaction dans ce salon",
+		"command.bind.option.emoji":               "Emoji à associer, laisser vide pour lister les associations",
+		"command.bind.option.name":                "Mémo vocal à jouer, laisser vide pour supprimer l’association",
+		"command.bind.option.cooldown":            "Délai avant de pouvoir le rejouer, par ex. 30s",
+		"command.bind.option.pool":                "Autres mémos vocaux tirés au hasard, avec poids facultatifs, par ex. honk oof=2",
+		"command.bind.option.chance":              "Probabilité en pourcentage de jouer quelque chose",
+		"command.alias.description":               "Donner à un mémo vocal un nom court pour le jouer",
+		"command.alias.option.alias":              "Alias à ajouter ou supprimer, laisser vide pour lister les alias",
+		"command.alias.option.name":               "Mémo vocal qu’il joue, laisser vide pour supprimer l’alias",
+		"command.soundboard.description":          "Publier des boutons qui mettent des mémos vocaux en file d’attente",
+		"command.soundboard.option.action":        "Que faire, laisser vide pour publier la table de sons",
+		"command.soundboard.option.memos":         "Mémos vocaux à ajouter ou supprimer, séparés par des espaces",
+		"command.autojoin.name":                   "rejoindre-auto",
+		"command.autojoin.description":            "Afficher ou modifier quand le bot rejoint seul le salon vocal le plus fréquenté",
+		"command.autojoin.option.members":         "Personnes nécessaires dans un salon avant de le rejoindre, ou « off »",
+		"command.idle.description":                "Afficher ou modifier combien de temps le bot reste quand rien ne joue",
+		"command.idle.option.after":               "Combien de temps attendre avant de partir, par ex. 30m, ou « off »",
+		"command.pack.name":                       "pack",
+		"command.pack.description":                "Partager des groupes de mémos vocaux entre serveurs",
+		"command.pack.option.action":              "Que faire",
+		"command.pack.option.pack":                "Nom du pack de sons",
+		"command.pack.option.memos":               "Mémos vocaux à ajouter ou retirer, séparés par des espaces",
+		"command.playlist.name":                   "playlist",
+		"command.playlist.description":            "Créer des playlists de mémos vocaux et les mettre en file d’un coup",
+		"command.playlist.option.action":          "Que faire",
+		"command.playlist.option.playlist":        "Nom de la playlist",
+		"command.playlist.option.memos":           "Mémos vocaux à ajouter ou retirer, séparés par des espaces",
+		"command.grant.name":                      "accorder",
+		"command.grant.description":               "Faire de quelqu’un un DJ pour un moment",
+		"command.grant.option.user":               "Qui reçoit l’accès DJ",
+		"command.grant.option.duration":           "Combien de temps il dure, par ex. 2h, ou « off » pour le retirer",
+		"command.intro.name":                      "intro",
+		"command.intro.description":               "Laisser les gens aller et venir sans que le bot rejoigne ou salue le salon",
+		"command.intro.option.action":             "Que faire",
+		"command.intro.option.who":                "Membre ou rôle à exempter ou à compter de nouveau",
+		"command.restrict.name":                   "restreindre",
+		"command.restrict.description":            "Réserver un mémo vocal à certains rôles",
+		"command.restrict.option.name":            "Mémo vocal à restreindre",
+		"command.restrict.option.role":            "Rôle autorisé à le jouer",
+		"command.restrict.option.off":             "Laisser tout le monde le jouer à nouveau",
+		"command.window.description":              "Ne laisser jouer un mémo vocal qu’à certains moments",
+		"command.window.option.name":              "Mémo vocal à modifier",
+		"command.window.option.when":              "Mois, jours, heure et fuseau, par ex. fri-sat 19:00-23:00, ou « off »",
+		"command.warnwords.description":           "Donner un avertissement aux mémos vocaux qui disent certains mots",
+		"command.warnwords.option.action":         "Que faire, laisser vide pour lister les mots",
+		"command.warnwords.option.words":          "Mots ou expressions à ajouter ou retirer, séparés par des virgules",
+		"command.cleanup.name":                    "nettoyer",
+		"command.cleanup.description":             "Choisir des mémos vocaux à supprimer d’un coup",
+		"command.cleanup.option.sort":             "Quels mémos vocaux proposer en premier",
+		"command.cleanup.option.dry_run":          "Montrer seulement ce que supprimer la sélection changerait",
+		"command.audit.name":                      "journal",
+		"command.audit.description":               "Afficher ou modifier le salon où les actions des admins sont consignées",
+		"command.audit.option.channel":            "Salon où consigner les actions des admins",
+		"command.audit.option.off":                "Ne plus consigner les actions des admins",
+		"command.alerts.name":                     "alertes",
+		"command.alerts.description":              "Afficher ou modifier le salon où les échecs répétés sont publiés",
+		"command.alerts.option.channel":           "Salon où publier les alertes",
+		"command.alerts.option.off":               "Ne plus publier d’alertes",
+		"command.purge-guild-data.name":           "effacer-données-serveur",
+		"command.purge-guild-data.description":    "Supprimer tout ce que le bot a enregistré pour ce serveur",
+		"command.purge-guild-data.option.dry_run": "Compter seulement ce qui serait supprimé",
+		"command.mydata.name":                     "mesdonnées",
+		"command.mydata.description":              "Obtenir ou supprimer tout ce que le bot enregistre sur toi",
+		"command.mydata.option.action":            "Que faire de tes données",
+		"command.mydata.option.dry_run":           "Montrer seulement ce qui serait supprimé",
+		"command.suggest.name":                    "suggérer",
+		"command.suggest.description":             "Recommander des mémos vocaux que tu n’as pas joués récemment",
+		"command.record.name":                     "enregistrer",
+		"command.record.description":              "T’enregistrer dans le salon vocal comme nouveau mémo vocal",
+		"command.record.option.name":              "Nom du nouveau mémo vocal",
+		"command.record.option.seconds":           "Durée de l’enregistrement, jusqu’à 60 secondes",
+		"command.info.name":                       "infos",
+		"command.info.description":                "Afficher tout ce qu’on sait d’un mémo vocal",
+		"command.info.option.name":                "Mémo vocal à consulter",
+		"command.stats.name":                      "stats",
+		"command.stats.description":               "Montrer la taille d’un mémo vocal et combien de fois il est joué ici",
+		"command.stats.option.name":               "Mémo vocal à consulter",
+		"command.describe.name":                   "décrire",
+		"command.describe.description":            "Ajouter une description ou un crédit à un mémo vocal",
+		"command.describe.option.name":            "Mémo vocal à décrire",
+		"command.trim.name":                       "couper",
+		"command.trim.description":                "Couper le début ou la fin d’un mémo vocal",
+		"command.trim.option.name":                "Mémo vocal à couper",
+		"command.trim.option.start":               "Seconde de début ; omettre les deux pour choisir avec des boutons",
+		"command.trim.option.end":                 "Seconde de fin",
+		"command.describe.option.description":     "Laisser vide pour effacer la description",
+		"command.listen.name":                     "écouter",
+		"command.listen.description":              "Écouter une commande parlée « play <nom> »",
+		"command.say.name":                        "dire",
+		"command.say.description":                 "Dire quelque chose dans le salon vocal",
+		"command.say.option.text":                 "Ce qu'il faut dire",
+		"command.say.option.voice":                "Voix à utiliser",
+		"command.voices.name":                     "voix",
+		"command.voices.description":              "Lister les voix utilisables par /say",
+		"command.voice.name":                      "voix-par-défaut",
+		"command.voice.description":               "Afficher ou modifier la voix par défaut de ce serveur",
+		"command.voice.option.name":               "Nouvelle voix par défaut, ou « default »",
+		"command.help.name":                       "aide",
+		"command.help.description":                "Lister les commandes ou en expliquer une",
+		"command.Save as voice memo.name":         "Enregistrer comme mémo vocal",
+		"command.help.option.command":             "Commande à expliquer",
+		"command.upload.name":                     "envoyer",
+		"command.upload.description":              "Envoyer un mémo vocal",
+		"command.upload.option.file":              "Fichier audio à envoyer",
+		"command.upload.option.name":              "Nom du mémo vocal, par défaut le nom du fichier",
+		"command.upload.option.tags":              "Tags séparés par des virgules",
+		"command.upload.option.longform":          "Le lire depuis le disque et l'exclure des choix aléatoires",
+		"command.upload.option.mono":              "L'encoder en mono, ce qui divise sa taille par deux",
+		"response.running":                        "Exécution de /%s",
+		"response.unknown_command":                "Je ne connais plus cette commande.",
+		"response.unknown_button":                 "Ce bouton ne fait plus rien.",
+		"response.admins_only_prune":              "Seuls les admins peuvent supprimer des mémos vocaux ici.",
+	},
+	discordgo.SpanishES: {
+		"command.join.name":                       "unirse",
+		"command.join.description":                "Unirse a tu canal de voz",
+		"command.leave.name":                      "salir",
+		"command.leave.description":               "Salir del canal de voz",
+		"command.handoff.description":             "Mover el bot y su cola a otro canal de voz",
+		"command.handoff.option.channel":          "Canal de voz al que moverse",
+		"command.play.name":                       "reproducir",
+		"command.play.description":                "Reproducir una nota de voz",
+		"command.play.option.name":                "Nota de voz que reproducir",
+		"command.play.option.times":               "Cuántas veces seguidas reproducirla, hasta 10",
+		"command.play.option.full":                "Reproducirla más allá del límite de tiempo del servidor, para admins y DJ",
+		"command.play.option.start":               "Dónde empezar a reproducirla, p. ej. 0:15",
+		"command.playnext.description":            "Reproducir una nota de voz justo después de la que está sonando",
+		"command.playnext.option.name":            "Nota de voz que reproducir a continuación",
+		"command.playnext.option.full":            "Reproducirla más allá del límite de tiempo del servidor",
+		"command.playnext.option.start":           "Dónde empezar a reproducirla, p. ej. 0:15",
+		"command.cue.description":                 "Reproducir una nota de voz para alguien, si está en el canal de voz",
+		"command.cue.option.user":                 "Para quién es",
+		"command.cue.option.name":                 "Nota de voz que reproducir",
+		"command.skip.name":                       "saltar",
+		"command.skip.description":                "Saltar la nota de voz que está sonando",
+		"command.stop.name":                       "detener",
+		"command.stop.description":                "Detener la reproducción y vaciar la cola",
+		"command.seek.description":                "Saltar a un punto de la nota de voz que está sonando",
+		"command.seek.option.to":                  "Dónde seguir reproduciendo, p. ej. 0:15",
+		"command.pause.name":                      "pausar",
+		"command.pause.description":               "Pausar la nota de voz que se está reproduciendo",
+		"command.resume.name":                     "reanudar",
+		"command.resume.description":              "Seguir reproduciendo donde se pausó",
+		"command.queue.name":                      "cola",
+		"command.queue.description":               "Mostrar lo que hay en la cola",
+		"command.shuffle.name":                    "mezclar",
+		"command.shuffle.description":             "Poner las notas de voz de la cola en orden aleatorio",
+		"command.loop.name":                       "repetir",
+		"command.loop.description":                "Repetir la nota de voz que está sonando",
+		"command.loop.option.mode":                "\"on\" u \"off\", omitir para alternar",
+		"command.loopqueue.name":                  "repetir-cola",
+		"command.loopqueue.description":           "Volver a reproducir toda la cola una y otra vez",
+		"command.loopqueue.option.mode":           "\"on\" u \"off\", omitir para alternar",
+		"command.clearqueue.name":                 "vaciar-cola",
+		"command.clearqueue.description":          "Descartar todo lo que espera en la cola",
+		"command.remove.description":              "Quitar una nota de voz de la cola",
+		"command.remove.option.number":            "El número que tiene la nota en la cola",
+		"command.move.description":                "Mover una nota de voz a otro lugar de la cola",
+		"command.move.option.from":                "El número que tiene la nota en la cola",
+		"command.move.option.to":                  "El número que debe tener",
+		"command.list.name":                       "lista",
+		"command.list.description":                "Listar todas las notas de voz",
+		"command.list.option.page":                "Página que mostrar",
+		"command.list.option.tag":                 "Listar solo notas con estas etiquetas separadas por comas",
+		"command.list.option.sort":                "Por qué ordenar las notas de voz",
+		"command.search.name":                     "buscar",
+		"command.search.description":              "Encontrar notas de voz por nombre, aunque esté mal escrito",
+		"command.search.option.term":              "El nombre entero o una parte",
+		"command.search.option.tag":               "Buscar solo notas con estas etiquetas separadas por comas",
+		"command.recent.name":                     "recientes",
+		"command.recent.description":              "Mostrar las notas de voz más nuevas y quién las añadió",
+		"command.recent.option.count":             "Cuántas mostrar",
+		"command.tag.name":                        "etiqueta",
+		"command.tag.description":                 "Etiquetar notas de voz para encontrarlas y elegirlas por etiqueta",
+		"command.tag.option.action":               "Qué hacer",
+		"command.tag.option.name":                 "Nota de voz a etiquetar",
+		"command.tag.option.tags":                 "Etiquetas separadas por comas",
+		"command.random.name":                     "aleatorio",
+		"command.random.description":              "Reproducir una nota de voz al azar",
+		"command.random.option.tag":               "Elegir solo entre notas con estas etiquetas separadas por comas",
+		"command.random.option.avoid":             "Cambiar cuántas de las últimas reproducciones se evitan, en vez de reproducir",
+		"command.history.name":                    "historial",
+		"command.history.description":             "Mostrar lo que se reprodujo hace poco y quién lo pidió",
+		"command.history.option.user":             "Mostrar solo lo que reprodujo esa persona",
+		"command.history.option.count":            "Cuántas reproducciones mostrar",
+		"command.delete.name":                     "eliminar",
+		"command.delete.description":              "Eliminar una nota de voz",
+		"command.delete.option.name":              "Nota de voz que eliminar",
+		"command.delete.option.dry_run":           "Solo mostrar qué cambiaría",
+		"command.rename.name":                     "renombrar",
+		"command.rename.description":              "Darle un nombre nuevo a una nota de voz",
+		"command.rename.option.name":              "Nota de voz que renombrar",
+		"command.rename.option.new_name":          "Su nombre nuevo",
+		"command.transfer.description":            "Dar a otra persona una nota de voz que subiste",
+		"command.transfer.option.name":            "Nota de voz que dar",
+		"command.transfer.option.user":            "Quién la recibe",
+		"command.maxmemos.description":            "Mostrar o cambiar cuántas notas de voz puede tener este servidor",
+		"command.maxmemos.option.limit":           "Nuevo límite, 0 para el valor predeterminado",
+		"command.maxplay.description":             "Ver o cambiar cuánto suenan las notas de voz antes de desvanecerse",
+		"command.maxplay.option.seconds":          "Segundos antes de que las notas se desvanezcan, u «off» para reproducirlas enteras",
+		"command.prefix.name":                     "prefijo",
+		"command.prefix.description":              "Ver o cambiar con qué empiezan los comandos",
+		"command.prefix.option.prefix":            "Nuevo prefijo, o «default» para !",
+		"command.nudge.description":               "Indicar el comando de barra a quien escribe comandos con prefijo",
+		"command.nudge.option.tips":               "Activar o desactivar los consejos, o seguir el valor por defecto del bot",
+		"command.nudge.option.message":            "Nuevo consejo, {slash} representa el comando de barra, o \"default\"",
+		"command.cooldown.description":            "Ver o cambiar cada cuánto puede usar cada persona un comando",
+		"command.cooldown.option.command":         "Comando cuyo tiempo de espera cambiar, p. ej. play",
+		"command.cooldown.option.duration":        "Tiempo entre usos, como 10s o 2m, o \"off\"",
+		"command.namepolicy.description":          "Mostrar o cambiar qué pasa cuando el nombre de una nota nueva ya existe",
+		"command.namepolicy.option.policy":        "Qué hacer con la nota nueva",
+		"command.preset.name":                     "preajuste",
+		"command.preset.description":              "Mostrar o cambiar cómo se codifican las nuevas subidas",
+		"command.preset.option.setting":           "show, un preajuste (default, meme, music), o bitrate, mono, normalize o trim",
+		"command.preset.option.value":             "Nuevo valor del ajuste",
+		"command.loudness.name":                   "sonoridad",
+		"command.loudness.description":            "Ver o cambiar lo fuerte que suenan las notas",
+		"command.loudness.option.target":          "Objetivo en LUFS de -30 a -6, p. ej. -14, u off",
+		"command.greeting.name":                   "saludo",
+		"command.greeting.description":            "Ver o cambiar lo que el bot dice y reproduce al entrar y al salir",
+		"command.greeting.option.setting":         "message, join o leave",
+		"command.greeting.option.value":           "Texto de saludo o una nota de voz, default u off",
+		"command.volume.name":                     "volumen",
+		"command.volume.description":              "Ver o cambiar lo fuerte que suenan las notas, en porcentaje",
+		"command.volume.option.percent":           "0 a 200, 100 las reproduce tal cual",
+		"command.volume.option.reactions":         "Rango en el que lo cambian las reacciones 🔉 y 🔊, p. ej. 50-150, u off",
+		"command.duck.description":                "Ver o cambiar cuánto bajan las notas cuando el bot habla encima, en porcentaje",
+		"command.duck.option.percent":             "0 a 100, 0 espera a que termine la nota",
+		"command.jobs.name":                       "tareas",
+		"command.jobs.description":                "Listar las subidas y transcripciones en curso, y las notas que no se pudieron reproducir",
+		"command.jobs.option.cancel":              "ID de una tarea para cancelar",
+		"command.jobs.option.clear":               "Olvidar las notas que no se pudieron reproducir",
+		"command.link.name":                       "enlace",
+		"command.link.description":                "Obtener un enlace temporal para escuchar una nota de voz fuera de Discord",
+		"command.link.option.name":                "Nota de voz para compartir",
+		"command.bind.name":                       "vincular",
+		"command.bind.description":                "Reproducir una nota de voz cuando se publica o se reacciona con un emoji en este canal",
+		"command.bind.option.emoji":               "Emoji para vincular, omítelo para listar los vínculos",
+		"command.bind.option.name":                "Nota de voz para reproducir, omítela para quitar el vínculo",
+		"command.bind.option.cooldown":            "Cuánto tiempo pasa antes de que pueda volver a sonar, p. ej. 30s",
+		"command.bind.option.pool":                "Más notas de voz para elegir al azar, con pesos opcionales, p. ej. honk oof=2",
+		"command.bind.option.chance":              "Probabilidad en porcentaje de reproducir algo",
+		"command.alias.description":               "Dar a una nota de voz un nombre corto para reproducirla",
+		"command.alias.option.alias":              "Alias que añadir o quitar, omítelo para ver la lista de alias",
+		"command.alias.option.name":               "Nota de voz que reproduce, omítela para quitar el alias",
+		"command.soundboard.description":          "Publicar botones que ponen notas de voz en la cola al pulsarlos",
+		"command.soundboard.option.action":        "Qué hacer, omítelo para publicar la botonera",
+		"command.soundboard.option.memos":         "Notas de voz que añadir o quitar, separadas por espacios",
+		"command.autojoin.name":                   "unirse-auto",
+		"command.autojoin.description":            "Mostrar o cambiar cuándo el bot se une solo al canal de voz más concurrido",
+		"command.autojoin.option.members":         "Personas necesarias en un canal antes de unirse, o «off»",
+		"command.idle.description":                "Mostrar o cambiar cuánto se queda el bot cuando no suena nada",
+		"command.idle.option.after":               "Cuánto esperar antes de irse, p. ej. 30m, u «off»",
+		"command.pack.name":                       "paquete",
+		"command.pack.description":                "Compartir grupos de notas de voz entre servidores",
+		"command.pack.option.action":              "Qué hacer",
+		"command.pack.option.pack":                "Nombre del paquete de sonidos",
+		"command.pack.option.memos":               "Notas de voz para añadir o quitar, separadas por espacios",
+		"command.playlist.name":                   "lista",
+		"command.playlist.description":            "Crear listas de notas de voz y ponerlas en cola de una vez",
+		"command.playlist.option.action":          "Qué hacer",
+		"command.playlist.option.playlist":        "Nombre de la lista",
+		"command.playlist.option.memos":           "Notas de voz para añadir o quitar, separadas por espacios",
+		"command.grant.name":                      "conceder",
+		"command.grant.description":               "Hacer DJ a alguien durante un tiempo",
+		"command.grant.option.user":               "Quién recibe el acceso de DJ",
+		"command.grant.option.duration":           "Cuánto dura, p. ej. 2h, u «off» para retirarlo",
+		"command.intro.name":                      "intro",
+		"command.intro.description":               "Dejar que la gente entre y salga de los canales de voz sin que el bot se una o salude",
+		"command.intro.option.action":             "Qué hacer",
+		"command.intro.option.who":                "Usuario o rol a eximir o a contar de nuevo",
+		"command.restrict.name":                   "restringir",
+		"command.restrict.description":            "Reservar una nota de voz para ciertos roles",
+		"command.restrict.option.name":            "Nota de voz que restringir",
+		"command.restrict.option.role":            "Rol que puede reproducirla",
+		"command.restrict.option.off":             "Dejar que todos la reproduzcan de nuevo",
+		"command.window.description":              "Permitir una nota de voz solo en ciertos momentos",
+		"command.window.option.name":              "Nota de voz a cambiar",
+		"command.window.option.when":              "Meses, días, hora y zona horaria, p. ej. fri-sat 19:00-23:00, o \"off\"",
+		"command.warnwords.description":           "Poner un aviso de contenido a las notas de voz que dicen ciertas palabras",
+		"command.warnwords.option.action":         "Qué hacer, omítelo para ver la lista de palabras",
+		"command.warnwords.option.words":          "Palabras o frases que añadir o quitar, separadas por comas",
+		"command.cleanup.name":                    "limpiar",
+		"command.cleanup.description":             "Elegir notas de voz para borrarlas de una vez",
+		"command.cleanup.option.sort":             "Qué notas de voz ofrecer primero",
+		"command.cleanup.option.dry_run":          "Solo mostrar qué cambiaría borrar lo elegido",
+		"command.audit.name":                      "registro",
+		"command.audit.description":               "Mostrar o cambiar el canal donde se registran las acciones de los admins",
+		"command.audit.option.channel":            "Canal donde registrar las acciones de los admins",
+		"command.audit.option.off":                "Dejar de registrar las acciones de los admins",
+		"command.alerts.name":                     "alertas",
+		"command.alerts.description":              "Mostrar o cambiar el canal donde se publican los fallos repetidos",
+		"command.alerts.option.channel":           "Canal donde publicar las alertas",
+		"command.alerts.option.off":               "Dejar de publicar alertas",
+		"command.purge-guild-data.name":           "borrar-datos-servidor",
+		"command.purge-guild-data.description":    "Borrar todo lo que el bot guardó para este servidor",
+		"command.purge-guild-data.option.dry_run": "Solo contar lo que se borraría",
+		"command.mydata.name":                     "misdatos",
+		"command.mydata.description":              "Obtener o borrar todo lo que el bot guarda sobre ti",
+		"command.mydata.option.action":            "Qué hacer con tus datos",
+		"command.mydata.option.dry_run":           "Solo mostrar lo que se borraría",
+		"command.suggest.name":                    "sugerir",
+		"command.suggest.description":             "Recomendar notas de voz que no has reproducido últimamente",
+		"command.record.name":                     "grabar",
+		"command.record.description":              "Grabarte en el canal de voz como una nueva nota de voz",
+		"command.record.option.name":              "Nombre de la nueva nota de voz",
+		"command.record.option.seconds":           "Cuánto tiempo grabar, hasta 60 segundos",
+		"command.info.name":                       "info",
+		"command.info.description":                "Mostrar todo lo que se sabe de una nota de voz",
+		"command.info.option.name":                "Nota de voz para consultar",
+		"command.stats.name":                      "estadísticas",
+		"command.stats.description":               "Mostrar cuánto ocupa una nota de voz y cuántas veces se reproduce aquí",
+		"command.stats.option.name":               "Nota de voz para consultar",
+		"command.describe.name":                   "describir",
+		"command.describe.description":            "Añadir una descripción o un crédito a una nota de voz",
+		"command.describe.option.name":            "Nota de voz para describir",
+		"command.trim.name":                       "recortar",
+		"command.trim.description":                "Cortar el principio o el final de una nota de voz",
+		"command.trim.option.name":                "Nota de voz que recortar",
+		"command.trim.option.start":               "Segundo en que empieza; omite ambos para elegir con botones",
+		"command.trim.option.end":                 "Segundo en que termina",
+		"command.describe.option.description":     "Omítela para borrar la descripción",
+		"command.listen.name":                     "escuchar",
+		"command.listen.description":              "Escuchar un comando hablado «play <nombre>»",
+		"command.say.name":                        "decir",
+		"command.say.description":                 "Decir algo en el canal de voz",
+		"command.say.option.text":                 "Qué decir",
+		"command.say.option.voice":                "Voz con la que decirlo",
+		"command.voices.name":                     "voces",
+		"command.voices.description":              "Listar las voces que puede usar /say",
+		"command.voice.name":                      "voz",
+		"command.voice.description":               "Mostrar o cambiar la voz predeterminada de este servidor",
+		"command.voice.option.name":               "Nueva voz predeterminada, o «default»",
+		"command.help.name":                       "ayuda",
+		"command.help.description":                "Ver los comandos o explicar uno de ellos",
+		"command.Save as voice memo.name":         "Guardar como nota de voz",
+		"command.help.option.command":             "Comando que explicar",
+		"command.upload.name":                     "subir",
+		"command.upload.description":              "Subir una nota de voz",
+		"command.upload.option.file":              "Archivo de audio para subir",
+		"command.upload.option.name":              "Nombre de la nota de voz, por defecto el nombre del archivo",
+		"command.upload.option.tags":              "Etiquetas separadas por comas",
+		"command.upload.option.longform":          "Reproducirla desde el disco y excluirla de las selecciones aleatorias",
+		"command.upload.option.mono":              "Codificarla en mono, lo que reduce su tamaño a la mitad",
+		"response.running":                        "Ejecutando /%s",
+		"response.unknown_command":                "Ya no conozco ese comando.",
+		"response.unknown_button":                 "Este botón ya no hace nada.",
+		"response.admins_only_prune":              "Solo los admins pueden eliminar notas de voz desde aquí.",
+	},
+}
+
+// Looks up a translation, falling back to the English text.
+func T(locale discordgo.Locale, key, english string) string {
+	if text, ok := catalog[locale][key]; ok {
+		return text
+	}
+	return english
+}
+
+// Returns every translation of a key, or nil if there are none.
+func Localizations(key string) map[discordgo.Locale]string {
+	var localized map[discordgo.Locale]string
+	for locale, strings := range catalog {
+		if text, ok := strings[key]; ok {
+			if localized == nil {
+				localized = make(map[discordgo.Locale]string)
+			}
+			localized[locale] = text
+		}
+	}
+	return localized
+}
+
+// Lists the catalog keys a locale is missing from the given set, so gaps show up in the logs
+// instead of as untranslated commands.
+func MissingTranslations(keys []string) map[discordgo.Locale][]string {
+	missing := make(map[discordgo.Locale][]string)
+	for locale, strings := range catalog {
+		for _, key := range keys {
+			if _, ok := strings[key]; !ok {
+				missing[locale] = append(missing[locale], key)
+			}
+		}
+	}
+	return missing
+}
+
+// Fills in a slash command's name and description localizations from the catalog.
+func LocalizeCommand(cmd *discordgo.ApplicationCommand) {
+	prefix := "command." + cmd.Name + "."
+	if names := Localizations(prefix + "name"); names != nil {
+		cmd.NameLocalizations = &names
+	}
+	if descriptions := Localizations(prefix + "description"); descriptions != nil {
+		cmd.DescriptionLocalizations = &descriptions
+	}
+	for _, opt := range cmd.Options {
+		opt.DescriptionLocalizations = Localizations(prefix + "option." + opt.Name)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// How long the bot stays in a voice channel with nothing playing, unless !idle changes it.
+	defaultIdleTimeout = 15 * time.Minute
+
+	// Range !idle accepts.
+	minIdleTimeout = time.Minute
+	maxIdleTimeout = 24 * time.Hour
+
+	// How often sessions are checked for having been idle too long.
+	idleSweepInterval = time.Minute
+)
+
+// Returns how long the bot stays in a voice channel with nothing playing before it leaves, or 0 if it stays.
+func (gs GuildSettings) IdleTimeout() time.Duration {
+	if gs.IdleSeconds == nil {
+		return defaultIdleTimeout
+	}
+	return time.Duration(*gs.IdleSeconds) * time.Second
+}
+
+// Formats an !idle timeout like "15 minutes" or "2 hours".
+func formatIdleTimeout(d time.Duration) string {
+	switch {
+	case d == time.Hour:
+		return "an hour"
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%d hours", d/time.Hour)
+	case d == time.Minute:
+		return "a minute"
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%d minutes", d/time.Minute)
+	}
+	return d.String()
+}
+
+// Records that the session is in use, which holds off leaving for being idle.
+func (gs *GuildSession) Touch() {
+	gs.lastActive.Store(time.Now().UnixNano())
+}
+
+// Returns how long nothing has been playing, queued or recorded, or 0 if something is. A paused memo counts as
+// playing.
+func (gs *GuildSession) IdleFor(now time.Time) time.Duration {
+	if current, _ := gs.Current(); current != nil || gs.IsVoicePlaying.Load() || gs.PlayQueue.Len() > 0 {
+		return 0
+	}
+	return now.Sub(time.Unix(0, gs.lastActive.Load()))
+}
+
+// Leaves the voice channels of guilds where nothing has played for longer than their !idle. Runs until the
+// process exits.
+func (b *Bot) LeaveIdleSessions() {
+	for range time.Tick(idleSweepInterval) {
+		b.sessionsMu.RLock()
+		sessions := make([]*GuildSession, 0, len(b.GuildSessions))
+		for _, gs := range b.GuildSessions {
+			sessions = append(sessions, gs)
+		}
+		b.sessionsMu.RUnlock()
+
+		now := time.Now()
+		for _, gs := range sessions {
+			timeout := b.VoiceMemoManager.Metadata.Guild(gs.ID).IdleTimeout()
+			if timeout <= 0 || gs.IdleFor(now) < timeout {
+				continue
+			}
+			fmt.Println("Leaving idle voice channel in ", gs.GuildName)
+			b.LeaveGuild(gs.ID)
+		}
+	}
+}
+
+func (b *Bot) HandleIdle(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		timeout := b.VoiceMemoManager.Metadata.Guild(g.ID).IdleTimeout()
+		if timeout <= 0 {
+			s.ChannelMessageSend(c.ID, "I stay in the voice channel until someone tells me to !leave in "+g.Name)
+			return
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("I leave the voice channel once nothing has played for %s in %s.", formatIdleTimeout(timeout), g.Name))
+		return
+	}
+	if !b.IsDJ(s, g.ID, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins and DJs can change how long I stay when nothing is playing.")
+		return
+	}
+
+	var timeout time.Duration
+	if args[0] != "off" {
+		var err error
+		timeout, err = time.ParseDuration(args[0])
+		if err != nil || timeout < minIdleTimeout || timeout > maxIdleTimeout {
+			s.ChannelMessageSend(c.ID, fmt.Sprintf("Usage: !idle <%s to %s, e.g. 30m> | !idle off", formatIdleTimeout(minIdleTimeout), formatIdleTimeout(maxIdleTimeout)))
+			return
+		}
+	}
+
+	seconds := int(timeout / time.Second)
+	err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		gs.IdleSeconds = &seconds
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+	if timeout <= 0 {
+		s.ChannelMessageSend(c.ID, "I'll stay in the voice channel until someone tells me to !leave.")
+		return
+	}
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("I'll leave the voice channel once nothing has played for %s.", formatIdleTimeout(timeout)))
+}
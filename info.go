@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Longest description !describe accepts.
+const maxDescriptionLength = 300
+
+// What !info and the dashboard show about a memo.
+type MemoInfo struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Type        MemoType  `json:"type,omitempty"`
+	Seconds     float64   `json:"seconds"`
+	Channels    int       `json:"channels"`
+	UploaderID  string    `json:"uploader_id,omitempty"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+	PlayCount   int       `json:"play_count"`
+	Tags        []string  `json:"tags,omitempty"`
+	MessageLink string    `json:"message_link,omitempty"`
+}
+
+// Returns what we know about a memo, or false if there's no such memo.
+func (m *VoiceMemoManager) Info(name string) (MemoInfo, bool) {
+	vm := m.Get(name)
+	if vm == nil {
+		return MemoInfo{}, false
+	}
+
+	md := m.Metadata.Memo(name)
+	return MemoInfo{
+		Name:        vm.name,
+		Description: md.Description,
+		Type:        md.Type,
+		Seconds:     vm.Duration().Seconds(),
+		Channels:    vm.Channels(),
+		UploaderID:  md.UploaderID,
+		UploadedAt:  md.UploadedAt,
+		PlayCount:   md.PlayCount,
+		Tags:        md.Tags,
+		MessageLink: md.MessageLink,
+	}, true
+}
+
+func (b *Bot) HandleDescribe(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		s.ChannelMessageSend(c.ID, "Usage: !describe <name> \"description\" (leave the description out to clear it)")
+		return
+	}
+	name := args[0]
+	if b.VoiceMemoManager.Get(name) == nil {
+		s.ChannelMessageSend(c.ID, "Cannot find "+name)
+		return
+	}
+
+	// Only admins and the original uploader may describe a memo.
+	md := b.VoiceMemoManager.Metadata.Memo(name)
+	if md.OtherGuild(g.ID) {
+		s.ChannelMessageSend(c.ID, name+" belongs to another server.")
+		return
+	}
+	if md.UploaderID != m.Author.ID && !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins or the uploader can describe "+name)
+		return
+	}
+
+	description := strings.Trim(strings.Join(args[1:], " "), "\"“”")
+	if len(description) > maxDescriptionLength {
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Descriptions can be up to %d characters long.", maxDescriptionLength))
+		return
+	}
+
+	err := b.VoiceMemoManager.Metadata.UpdateMemo(name, func(md *MemoMetadata) {
+		md.Description = description
+	})
+	if err != nil {
+		fmt.Println("Error saving metadata for ", name, ": ", err)
+		return
+	}
+
+	if description == "" {
+		s.ChannelMessageSend(c.ID, "Cleared the description of "+name)
+		return
+	}
+	s.ChannelMessageSend(c.ID, "Updated the description of "+name)
+}
+
+func (b *Bot) HandleInfo(s *discordgo.Session, c *discordgo.Channel, args []string) {
+	if len(args) == 0 {
+		s.ChannelMessageSend(c.ID, "Usage: !info <name>")
+		return
+	}
+	vm := b.VoiceMemoManager.GuildMemo(c.GuildID, args[0])
+	if vm == nil {
+		s.ChannelMessageSend(c.ID, "Cannot find "+args[0])
+		return
+	}
+	info, ok := b.VoiceMemoManager.Info(vm.name)
+	if !ok {
+		s.ChannelMessageSend(c.ID, "Cannot find "+args[0])
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       info.Name,
+		Description: info.Description,
+		Color:       65535,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Length", Value: FormatDuration(time.Duration(info.Seconds * float64(time.Second))), Inline: true},
+			{Name: "Plays", Value: fmt.Sprint(info.PlayCount), Inline: true},
+		},
+	}
+	if info.Channels == 1 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Channels", Value: "Mono", Inline: true})
+	}
+	if info.Type == MemoTypeLongForm {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Type", Value: "Long-form", Inline: true})
+	}
+	if info.UploaderID != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Uploaded by", Value: "<@" + info.UploaderID + ">", Inline: true})
+	}
+	if !info.UploadedAt.IsZero() {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Uploaded", Value: fmt.Sprintf("<t:%d:D>", info.UploadedAt.Unix()), Inline: true})
+	}
+	if len(info.Tags) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Tags", Value: strings.Join(info.Tags, ", "), Inline: true})
+	}
+	if warnings := b.ContentWarnings(c.GuildID, info.Name); len(warnings) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "⚠️ Content warning", Value: "Says " + spoilerList(warnings), Inline: true})
+	}
+	if aliases := b.VoiceMemoManager.Metadata.Guild(c.GuildID).AliasesOf(info.Name); len(aliases) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Aliases", Value: strings.Join(aliases, ", "), Inline: true})
+	}
+	if info.MessageLink != "" {
+		embed.URL = info.MessageLink
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func (b *Bot) InteractionCenter(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		b.HandleSlashCommand(s, i)
+		return
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		b.HandleAutocomplete(s, i)
+		return
+	case discordgo.InteractionModalSubmit:
+		b.HandleModalSubmit(s, i)
+		return
+	case discordgo.InteractionMessageComponent:
+	default:
+		return
+	}
+
+	// Custom IDs are of the form "<action>:<argument>".
+	customID := i.MessageComponentData().CustomID
+	fmt.Println("Interaction: ", customID)
+	action, arg, _ := strings.Cut(customID, ":")
+
+	switch action {
+	case "prune":
+		b.HandlePruneButton(s, i, arg)
+	case "trim":
+		b.HandleTrimInteraction(s, i, arg)
+	case "cleanup":
+		b.HandleCleanupInteraction(s, i, arg)
+	case "purge":
+		b.HandlePurgeButton(s, i, arg)
+	case "mydata":
+		b.HandleMyDataButton(s, i, arg)
+	case "name":
+		b.HandleNameClashButton(s, i, arg)
+	case "queue":
+		b.HandleQueueInteraction(s, i, arg)
+	case "playback":
+		b.HandlePlaybackButton(s, i, arg)
+	case "soundboard":
+		b.HandleSoundboardButton(s, i, arg)
+	case "transfer":
+		b.HandleTransferButton(s, i, arg)
+	default:
+		RespondEphemeral(s, i, T(i.Locale, "response.unknown_button", "This button doesn't do anything anymore."))
+	}
+}
+
+// Routes a submitted modal by its custom ID, of the form "<action>:<argument>" like those of components.
+func (b *Bot) HandleModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	customID := i.ModalSubmitData().CustomID
+	fmt.Println("Modal: ", customID)
+	action, arg, _ := strings.Cut(customID, ":")
+
+	switch action {
+	case "savememo":
+		b.HandleSaveMemoModal(s, i, arg)
+	default:
+		RespondEphemeral(s, i, "This form doesn't do anything anymore.")
+	}
+}
+
+// Replies to an interaction with a message only the interacting user can see.
+func RespondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		fmt.Println("Error responding to interaction: ", err)
+	}
+}
+
+// Returns the ID of the user behind an interaction, whether it happened in a guild or a DM.
+func InteractionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil {
+		return i.Member.User.ID
+	}
+	return i.User.ID
+}
+
+// Reports whether a set of permissions is enough to administrate the bot in a guild.
+func HasAdminPermissions(perms int64) bool {
+	return perms&(discordgo.PermissionAdministrator|discordgo.PermissionManageServer) != 0
+}
+
+// Reports whether a user can administrate the bot in the guild the channel belongs to.
+func IsAdmin(s *discordgo.Session, userID, channelID string) bool {
+	perms, err := s.UserChannelPermissions(userID, channelID)
+	if err != nil {
+		fmt.Println("Error looking up permissions: ", err)
+		return false
+	}
+	return HasAdminPermissions(perms)
+}
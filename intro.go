@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Users and roles that come and go from voice channels without the bot reacting, set with !intro exempt.
+// They don't count toward auto-join, so they never make the bot join and greet the channel, or keep it there.
+type Exemptions struct {
+	Users []string `json:"users,omitempty"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+func (e Exemptions) clone() Exemptions {
+	e.Users = append([]string(nil), e.Users...)
+	e.Roles = append([]string(nil), e.Roles...)
+	return e
+}
+
+// Reports whether a user, or one of their roles, is exempt in a guild.
+func (b *Bot) IsIntroExempt(s *discordgo.Session, g *discordgo.Guild, userID string) bool {
+	exempt := b.VoiceMemoManager.Metadata.Guild(g.ID).IntroExempt
+	if containsString(exempt.Users, userID) {
+		return true
+	}
+	if len(exempt.Roles) == 0 {
+		return false
+	}
+	member, err := s.State.Member(g.ID, userID)
+	if err != nil {
+		return false
+	}
+	for _, role := range member.Roles {
+		if containsString(exempt.Roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Bot) HandleIntro(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := "Usage: !intro exempt [list] | !intro exempt add|remove @user|@role..."
+	if len(args) == 0 || args[0] != "exempt" {
+		s.ChannelMessageSend(c.ID, usage)
+		return
+	}
+	args = args[1:]
+	if len(args) == 0 || args[0] == "list" {
+		b.SendIntroExempt(s, g, c)
+		return
+	}
+
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can change who is exempt.")
+		return
+	}
+	if (args[0] != "add" && args[0] != "remove") || len(args) < 2 {
+		s.ChannelMessageSend(c.ID, usage)
+		return
+	}
+
+	users, roles := make([]string, 0), make([]string, 0)
+	for _, arg := range args[1:] {
+		if role, ok := ParseRole(g, arg); ok {
+			roles = append(roles, role)
+			continue
+		}
+		userID := ParseUser(arg)
+		if _, err := s.State.Member(g.ID, userID); err != nil {
+			s.ChannelMessageSend(c.ID, "I can't find "+arg+" in "+g.Name+". "+usage)
+			return
+		}
+		users = append(users, userID)
+	}
+
+	add := args[0] == "add"
+	update := func(list, changed []string) []string {
+		kept := make([]string, 0, len(list)+len(changed))
+		for _, id := range list {
+			if !containsString(changed, id) {
+				kept = append(kept, id)
+			}
+		}
+		if add {
+			kept = append(kept, changed...)
+		}
+		return kept
+	}
+	err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		gs.IntroExempt.Users = update(gs.IntroExempt.Users, users)
+		gs.IntroExempt.Roles = update(gs.IntroExempt.Roles, roles)
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+
+	mentions := make([]string, 0, len(users)+len(roles))
+	for _, userID := range users {
+		mentions = append(mentions, "<@"+userID+">")
+	}
+	if len(roles) > 0 {
+		mentions = append(mentions, RoleMentions(roles))
+	}
+	if add {
+		s.ChannelMessageSend(c.ID, strings.Join(mentions, ", ")+" can come and go from voice channels without the bot joining or greeting them.")
+		b.Audit(s, g.ID, fmt.Sprintf("<@%s> made %s exempt from auto-join and greetings.", m.Author.ID, strings.Join(mentions, ", ")))
+		return
+	}
+	s.ChannelMessageSend(c.ID, strings.Join(mentions, ", ")+" count toward auto-join again.")
+	b.Audit(s, g.ID, fmt.Sprintf("<@%s> made %s count toward auto-join again.", m.Author.ID, strings.Join(mentions, ", ")))
+}
+
+func (b *Bot) SendIntroExempt(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel) {
+	exempt := b.VoiceMemoManager.Metadata.Guild(g.ID).IntroExempt
+	if len(exempt.Users) == 0 && len(exempt.Roles) == 0 {
+		s.ChannelMessageSend(c.ID, "Nobody is exempt from auto-join and greetings in "+g.Name)
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:  "Exempt from auto-join and greetings",
+		Color:  65535,
+		Fields: []*discordgo.MessageEmbedField{},
+	}
+	if len(exempt.Users) > 0 {
+		users := make([]string, 0, len(exempt.Users))
+		for _, userID := range exempt.Users {
+			users = append(users, "<@"+userID+">")
+		}
+		value := strings.Join(users, ", ")
+		if len(value) > 1024 {
+			value = value[:1000] + "..."
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Users", Value: value})
+	}
+	if len(exempt.Roles) > 0 {
+		value := RoleMentions(exempt.Roles)
+		if len(value) > 1024 {
+			value = value[:1000] + "..."
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Roles", Value: value})
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// How many times a transient failure is retried before giving up, and how long the first retry waits.
+	// Each retry waits twice as long as the one before.
+	jobRetries      = 3
+	jobRetryBackoff = time.Second
+
+	// How many memos that couldn't be played each guild's dead letters keep.
+	maxDeadLetters = 10
+)
+
+// Long-running work started by a command, such as converting an upload or transcribing speech.
+type Job struct {
+	ID          int
+	Kind        string
+	Description string
+	GuildID     string
+	UserID      string
+	Started     time.Time
+
+	cancel context.CancelFunc
+}
+
+// A memo the player gave up on after the voice connection stalled every time it tried.
+type DeadLetter struct {
+	Memo        string
+	RequesterID string
+	Attempts    int
+	Err         string
+	At          time.Time
+}
+
+// Keeps track of the jobs that are running so they can be listed and cancelled, and of playback that failed
+// for good so it can be looked into.
+type JobRegistry struct {
+	mu     sync.Mutex
+	nextID int
+	jobs   map[int]*Job
+
+	// Each guild's most recent dead letters, oldest first.
+	deadLetters map[string][]DeadLetter
+}
+
+func NewJobRegistry() *JobRegistry {
+	return &JobRegistry{nextID: 1, jobs: make(map[int]*Job), deadLetters: make(map[string][]DeadLetter)}
+}
+
+// Parks a memo the player gave up on, dropping the oldest once the guild has too many.
+func (r *JobRegistry) Park(guildID string, dl DeadLetter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	letters := append(r.deadLetters[guildID], dl)
+	if len(letters) > maxDeadLetters {
+		letters = append([]DeadLetter(nil), letters[len(letters)-maxDeadLetters:]...)
+	}
+	r.deadLetters[guildID] = letters
+}
+
+// Returns a copy of a guild's dead letters, oldest first.
+func (r *JobRegistry) DeadLetters(guildID string) []DeadLetter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]DeadLetter(nil), r.deadLetters[guildID]...)
+}
+
+// Forgets a guild's dead letters. Returns how many there were.
+func (r *JobRegistry) ClearDeadLetters(guildID string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := len(r.deadLetters[guildID])
+	delete(r.deadLetters, guildID)
+	return n
+}
+
+// Registers a job. The returned context is cancelled when the job is cancelled, and done must be called
+// once the job has finished.
+func (r *JobRegistry) Start(ctx context.Context, kind, guildID, userID, description string) (context.Context, *Job, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	r.mu.Lock()
+	job := &Job{
+		ID:          r.nextID,
+		Kind:        kind,
+		Description: description,
+		GuildID:     guildID,
+		UserID:      userID,
+		Started:     time.Now(),
+		cancel:      cancel,
+	}
+	r.nextID++
+	r.jobs[job.ID] = job
+	r.mu.Unlock()
+
+	done := func() {
+		r.mu.Lock()
+		delete(r.jobs, job.ID)
+		r.mu.Unlock()
+		cancel()
+	}
+	return ctx, job, done
+}
+
+// Returns a guild's running jobs, oldest first.
+func (r *JobRegistry) List(guildID string) []*Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	jobs := make([]*Job, 0)
+	for _, job := range r.jobs {
+		if job.GuildID == guildID {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+	return jobs
+}
+
+// Returns every guild's running jobs, oldest first.
+func (r *JobRegistry) Running() []*Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	jobs := make([]*Job, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+	return jobs
+}
+
+// Returns one of a guild's running jobs, or nil if there's no such job.
+func (r *JobRegistry) Get(guildID string, id int) *Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if job, ok := r.jobs[id]; ok && job.GuildID == guildID {
+		return job
+	}
+	return nil
+}
+
+// Cancels a running job. It disappears from the registry once it has noticed.
+func (j *Job) Cancel() {
+	j.cancel()
+}
+
+// Error for failures that are worth trying again, like a dropped connection.
+type TransientError struct {
+	Err error
+}
+
+func (e *TransientError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransientError) Unwrap() error {
+	return e.Err
+}
+
+// Reports whether a failed HTTP request is worth retrying.
+func IsTransientHTTP(res *http.Response, err error) bool {
+	if err != nil {
+		// Cancelled and timed out requests were stopped on purpose, everything else is a network problem.
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+}
+
+// Runs fn until it succeeds, fails with an error that isn't a TransientError, or runs out of retries,
+// backing off between attempts.
+func Retry(ctx context.Context, what string, fn func() error) error {
+	backoff := jobRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		var transient *TransientError
+		if err == nil || !errors.As(err, &transient) || attempt == jobRetries {
+			return err
+		}
+
+		fmt.Printf("Error %s, retrying in %s: %v\n", what, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+func (b *Bot) HandleJobs(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) > 0 && args[0] == "cancel" {
+		if len(args) < 2 {
+			s.ChannelMessageSend(c.ID, "Usage: !jobs cancel <id>")
+			return
+		}
+		id, err := strconv.Atoi(args[1])
+		job := b.Jobs.Get(g.ID, id)
+		if err != nil || job == nil {
+			s.ChannelMessageSend(c.ID, "There's no job "+args[1]+" running in "+g.Name)
+			return
+		}
+
+		// Only admins, DJs and whoever started a job may cancel it.
+		if job.UserID != m.Author.ID && !b.IsDJ(s, g.ID, m.Author.ID, c.ID) {
+			s.ChannelMessageSend(c.ID, "Only admins, DJs or whoever started a job can cancel it.")
+			return
+		}
+		job.Cancel()
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Cancelling job %d (%s).", job.ID, job.Description))
+		return
+	}
+
+	if len(args) > 0 && args[0] == "clear" {
+		if !b.IsDJ(s, g.ID, m.Author.ID, c.ID) {
+			s.ChannelMessageSend(c.ID, "Only admins and DJs can clear failed playback.")
+			return
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Cleared %d failed memos.", b.Jobs.ClearDeadLetters(g.ID)))
+		return
+	}
+
+	jobs := b.Jobs.List(g.ID)
+	letters := b.Jobs.DeadLetters(g.ID)
+	if len(jobs) == 0 && len(letters) == 0 {
+		s.ChannelMessageSend(c.ID, "Nothing is running in "+g.Name)
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:  "Running jobs",
+		Color:  65535,
+		Fields: []*discordgo.MessageEmbedField{},
+		Footer: &discordgo.MessageEmbedFooter{Text: "!jobs cancel <id> to stop one"},
+	}
+	for _, job := range jobs {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("#%d %s", job.ID, job.Kind),
+			Value: fmt.Sprintf("%s\nStarted by <@%s> %s ago", job.Description, job.UserID, time.Since(job.Started).Round(time.Second)),
+		})
+	}
+	if len(jobs) == 0 {
+		embed.Description = "Nothing is running."
+	}
+
+	if len(letters) > 0 {
+		lines := make([]string, 0, len(letters))
+		for i := len(letters) - 1; i >= 0; i-- {
+			dl := letters[i]
+			lines = append(lines, fmt.Sprintf("%s%s, gave up after %d tries <t:%d:R>: %s", dl.Memo, requestedBy(dl.RequesterID), dl.Attempts, dl.At.Unix(), dl.Err))
+		}
+		// Field values are capped at 1024 characters.
+		value := strings.Join(lines, "\n")
+		if len(value) > 1024 {
+			value = value[:1000] + "..."
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Failed to play", Value: value})
+		embed.Footer.Text += " · !jobs clear to forget failed playback"
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
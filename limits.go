@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Number of least played memos suggested for deletion when a guild hits its memo limit.
+const pruneSuggestionCount = 5
+
+// How long the delete buttons of a memo limit notice keep working.
+const pruneButtonTTL = 24 * time.Hour
+
+// Returns the maximum number of memos a guild may have, or 0 if there is no limit.
+func (b *Bot) MemoLimit(guildID string) int {
+	if limit := b.VoiceMemoManager.Metadata.Guild(guildID).MaxMemos; limit > 0 {
+		return limit
+	}
+	return maxMemos
+}
+
+// Counts the memos that were uploaded in a guild.
+func (m *VoiceMemoManager) GuildMemoCount(guildID string) int {
+	count := 0
+	for _, md := range m.Metadata.GuildMemos(guildID) {
+		if m.Get(md.Name) != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Returns up to n of a guild's memos that are the best candidates for deletion:
+// the least played ones, oldest first when play counts are tied.
+func (m *VoiceMemoManager) PruneCandidates(guildID string, n int) []MemoMetadata {
+	candidates := make([]MemoMetadata, 0)
+	for _, md := range m.Metadata.GuildMemos(guildID) {
+		if m.Get(md.Name) != nil {
+			candidates = append(candidates, md)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].PlayCount != candidates[j].PlayCount {
+			return candidates[i].PlayCount < candidates[j].PlayCount
+		}
+		return candidates[i].UploadedAt.Before(candidates[j].UploadedAt)
+	})
+
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	return candidates
+}
+
+// Builds the notice that the guild is out of memo slots, with buttons for admins to delete the least played memos.
+func (b *Bot) MemoLimitMessage(guildID string, limit int) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	candidates := b.VoiceMemoManager.PruneCandidates(guildID, pruneSuggestionCount)
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Voice memo limit reached",
+		Description: fmt.Sprintf("This server already has %d voice memos. An admin can delete one of these to make room:", limit),
+		Color:       16711680,
+		Fields:      []*discordgo.MessageEmbedField{},
+	}
+
+	buttons := []discordgo.MessageComponent{}
+	for _, c := range candidates {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   c.Name,
+			Value:  fmt.Sprintf("Played %d times", c.PlayCount),
+			Inline: true,
+		})
+		buttons = append(buttons, discordgo.Button{
+			Label:    "Delete " + c.Name,
+			Style:    discordgo.DangerButton,
+			CustomID: b.pruneCustomID(guildID, c.Name),
+		})
+	}
+
+	components := []discordgo.MessageComponent{}
+	if len(buttons) > 0 {
+		components = append(components, discordgo.ActionsRow{Components: buttons})
+	}
+	return embed, components
+}
+
+// Tells the channel the guild is out of memo slots.
+func (b *Bot) SendMemoLimitReached(s *discordgo.Session, channelID, guildID string, limit int) {
+	embed, components := b.MemoLimitMessage(guildID, limit)
+	msg := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}, Components: components}
+
+	_, err := s.ChannelMessageSendComplex(channelID, msg)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
+
+// Returns the custom ID of the button that deletes name from guildID. Names can be too long for a custom ID,
+// so it holds the memo's nameToken, signed so it can't be made up for another guild's memo.
+func (b *Bot) pruneCustomID(guildID, name string) string {
+	return "prune:" + b.SignCustomID(guildID+":"+nameToken(name), pruneButtonTTL)
+}
+
+// Finds the memo a delete button of guildID's memo limit notice is for. arg is what follows "prune:" in its
+// custom ID. ok is false if the button has expired, wasn't made for guildID, or the memo is gone or now
+// belongs to another guild.
+func (b *Bot) PruneTarget(guildID, arg string) (name string, ok bool) {
+	id, ok := b.VerifyCustomID(arg)
+	buttonGuildID, token, _ := strings.Cut(id, ":")
+	if !ok || buttonGuildID != guildID {
+		return "", false
+	}
+	for _, vm := range b.VoiceMemoManager.List() {
+		if nameToken(vm.name) == token && !b.VoiceMemoManager.Metadata.Memo(vm.name).OtherGuild(guildID) {
+			return vm.name, true
+		}
+	}
+	return "", false
+}
+
+func (b *Bot) HandlePruneButton(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	if i.Member == nil || !HasAdminPermissions(i.Member.Permissions) {
+		RespondEphemeral(s, i, T(i.Locale, "response.admins_only_prune", "Only admins can delete voice memos from here."))
+		return
+	}
+	name, ok := b.PruneTarget(i.GuildID, arg)
+	if !ok {
+		RespondEphemeral(s, i, "This button has expired or its voice memo is gone. !delete still works.")
+		return
+	}
+	if dryRun {
+		plan := b.VoiceMemoManager.PlanDeletion([]string{name})
+		RespondEphemeral(s, i, fmt.Sprintf("Dry run, nothing was deleted. Deleting %s would remove %d files (%s) and change %d sound packs and %d playlists.",
+			name, plan.Files, FormatSize(plan.Bytes), plan.Packs, plan.Playlists))
+		return
+	}
+
+	if _, err := b.VoiceMemoManager.Delete(name); err != nil {
+		fmt.Println("Error deleting ", name, ": ", err)
+		RespondEphemeral(s, i, "Could not delete "+name)
+		return
+	}
+
+	// Disable the button that was just used so it can't be pressed twice.
+	components := i.Message.Components
+	for _, row := range components {
+		if r, ok := row.(*discordgo.ActionsRow); ok {
+			for _, c := range r.Components {
+				if button, ok := c.(*discordgo.Button); ok && button.CustomID == i.MessageComponentData().CustomID {
+					button.Disabled = true
+					button.Label = "Deleted " + name
+				}
+			}
+		}
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    fmt.Sprintf("<@%s> deleted %s.", i.Member.User.ID, name),
+			Components: components,
+			Embeds:     i.Message.Embeds,
+		},
+	})
+	if err != nil {
+		fmt.Println("Error responding to interaction: ", err)
+	}
+}
+
+func (b *Bot) HandleMaxMemos(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		limit := b.MemoLimit(g.ID)
+		if limit == 0 {
+			s.ChannelMessageSend(c.ID, "There's no voice memo limit in "+g.Name)
+			return
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("%s can have up to %d voice memos.", g.Name, limit))
+		return
+	}
+
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can change the voice memo limit.")
+		return
+	}
+
+	limit, err := strconv.Atoi(args[0])
+	if err != nil || limit < 0 {
+		s.ChannelMessageSend(c.ID, "Usage: !maxmemos <number> (0 to use the default)")
+		return
+	}
+
+	err = b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		gs.MaxMemos = limit
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("Voice memo limit for %s is now %d.", g.Name, b.MemoLimit(g.ID)))
+}
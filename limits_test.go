@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPruneCandidates(t *testing.T) {
+	ms := testMetadataStore(t)
+	m := &VoiceMemoManager{store: make(map[string]*VoiceMemo), Metadata: ms}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	memos := []struct {
+		guildID string
+		name    string
+		plays   int
+		age     int
+		loaded  bool
+	}{
+		{"1", "played", 10, 0, true},
+		{"1", "old", 1, 5, true},
+		{"1", "new", 1, 1, true},
+		{"1", "never", 0, 0, true},
+		{"1", "gone", 0, 9, false},
+		{"2", "elsewhere", 0, 9, true},
+	}
+	for _, memo := range memos {
+		memo := memo
+		err := ms.UpdateMemo(memo.name, func(md *MemoMetadata) {
+			md.GuildID = memo.guildID
+			md.PlayCount = memo.plays
+			md.UploadedAt = start.AddDate(0, 0, -memo.age)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if memo.loaded {
+			m.store[memo.name] = &VoiceMemo{name: memo.name}
+		}
+	}
+
+	tests := []struct {
+		name    string
+		guildID string
+		n       int
+		want    []string
+	}{
+		{"least played first, oldest first on ties", "1", 5, []string{"never", "old", "new", "played"}},
+		{"only n", "1", 2, []string{"never", "old"}},
+		{"other guild", "2", 5, []string{"elsewhere"}},
+		{"no memos", "3", 5, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, md := range m.PruneCandidates(tt.guildID, tt.n) {
+				got = append(got, md.Name)
+			}
+			if !equalStrings(got, tt.want) {
+				t.Errorf("PruneCandidates(%s, %d) = %v, want %v", tt.guildID, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPruneTarget(t *testing.T) {
+	ms := testMetadataStore(t)
+	m := &VoiceMemoManager{store: make(map[string]*VoiceMemo), Metadata: ms}
+	long := strings.Repeat("a", 200)
+	for _, memo := range []struct{ guildID, name string }{{"1", "bruh"}, {"1", long}, {"2", "honk"}, {"", "legacy"}} {
+		addTestMemo(t, ms, memo.guildID, memo.name)
+		m.store[memo.name] = &VoiceMemo{name: memo.name}
+	}
+	b := &Bot{SigningKey: SigningKey("token"), VoiceMemoManager: m}
+	other := &Bot{SigningKey: SigningKey("another token")}
+
+	tests := []struct {
+		name     string
+		customID string
+		want     string
+		wantOK   bool
+	}{
+		{"own memo", b.pruneCustomID("1", "bruh"), "bruh", true},
+		{"long name", b.pruneCustomID("1", long), long, true},
+		{"untracked memo", b.pruneCustomID("1", "legacy"), "legacy", true},
+		{"another guild's memo", b.pruneCustomID("1", "honk"), "", false},
+		{"button from another guild", b.pruneCustomID("2", "honk"), "", false},
+		{"deleted memo", b.pruneCustomID("1", "oof"), "", false},
+		{"forged", other.pruneCustomID("1", "bruh"), "", false},
+		{"unsigned", "prune:bruh", "", false},
+		{"expired", "prune:" + b.SignCustomID("1:"+nameToken("bruh"), -time.Minute), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.customID) > 100 {
+				t.Errorf("custom ID is %d characters, Discord allows 100", len(tt.customID))
+			}
+			got, ok := b.PruneTarget("1", strings.TrimPrefix(tt.customID, "prune:"))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("PruneTarget = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Returns a preview URL for a memo that works without the server token until expires.
+func (h *HTTPServer) SignedPreviewURL(name string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	query := url.Values{"expires": {exp}, "signature": {h.sign(name, exp)}}
+	return strings.TrimSuffix(h.publicURL, "/") + "/memos/" + url.PathEscape(name) + "/preview.ogg?" + query.Encode()
+}
+
+// Signs a memo name and expiry with the server token, so links stop working if the token is rotated.
+func (h *HTTPServer) sign(name, expires string) string {
+	mac := hmac.New(sha256.New, []byte(h.token))
+	mac.Write([]byte(name + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Reports whether the request carries a valid, unexpired signature for the memo it asks for.
+func (h *HTTPServer) validSignature(r *http.Request, name string) bool {
+	exp := r.URL.Query().Get("expires")
+	signature := r.URL.Query().Get("signature")
+	if exp == "" || signature == "" {
+		return false
+	}
+
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(h.sign(name, exp)))
+}
+
+func (b *Bot) HandleLink(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, args []string) {
+	if b.HTTP == nil || b.HTTP.publicURL == "" {
+		s.ChannelMessageSend(c.ID, "Sharing links need the HTTP server, ask the bot owner to set -http and -http-public-url.")
+		return
+	}
+	if len(args) == 0 {
+		s.ChannelMessageSend(c.ID, "Usage: !link <name>")
+		return
+	}
+
+	// Anyone with the link can listen, so only hand out links to memos that are in this guild's library.
+	vm := b.VoiceMemoManager.GuildMemo(g.ID, args[0])
+	if vm == nil {
+		s.ChannelMessageSend(c.ID, "Cannot find "+args[0])
+		return
+	}
+	name := vm.name
+
+	expires := time.Now().Add(linkTTL)
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("Listen to %s here until <t:%d:f>: %s", name, expires.Unix(), b.HTTP.SignedPreviewURL(name, expires)))
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How long !listen waits for a spoken command.
+const listenWindow = 10 * time.Second
+
+func (b *Bot) HandleListen(ctx context.Context, s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate) {
+	if !b.RequireFeature(s, c, FeatureRecording) {
+		return
+	}
+	gs, ok := b.Session(g.ID)
+	if !ok {
+		fmt.Println("Error finding guild session.")
+		s.ChannelMessageSend(c.ID, "I need to !join a voice channel before I can listen.")
+		return
+	}
+
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("Listening for %d seconds... say \"play <name>\".", int(listenWindow.Seconds())))
+	packets := gs.Capture(ctx, m.Author.ID, listenWindow)
+	if len(packets) == 0 {
+		s.ChannelMessageSend(c.ID, "I didn't hear anything.")
+		return
+	}
+
+	ctx, _, done := b.Jobs.Start(ctx, "transcription", g.ID, m.Author.ID, "Transcribing a voice command")
+	transcript, err := Transcribe(ctx, b.STT, packets)
+	done()
+	if errors.Is(err, context.Canceled) {
+		s.ChannelMessageSend(c.ID, "Stopped listening.")
+		return
+	}
+	if err != nil {
+		fmt.Println("Error transcribing voice command: ", err)
+		b.Alerts.Report(g.ID, AlertSpeech, err)
+		s.ChannelMessageSend(c.ID, "Sorry, I couldn't understand that.")
+		return
+	}
+	fmt.Println("Heard: ", transcript)
+
+	spoken, ok := ParsePlayCommand(transcript)
+	if !ok {
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("I heard \"%s\", but that isn't a play command.", transcript))
+		return
+	}
+
+	targets := b.SpokenTargets(g.ID)
+	names := make([]string, 0, len(targets))
+	for spokenName := range targets {
+		names = append(names, spokenName)
+	}
+	// The first of equally close names wins, so keep it the same from one try to the next.
+	sort.Strings(names)
+	match, ok := FuzzyMatch(spoken, names)
+	if !ok {
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("I heard \"%s\", but there's no voice memo like that.", spoken))
+		return
+	}
+	name := targets[match]
+
+	s.ChannelMessageSend(c.ID, "Playing "+name)
+	b.HandlePlay(s, g, c, m.Author.ID, name, 1, false, 0)
+}
+
+// Returns the names a voice command can ask for in a guild, the memos in its library and its aliases for them,
+// and which memo each one plays.
+func (b *Bot) SpokenTargets(guildID string) map[string]string {
+	targets := make(map[string]string)
+	for _, vm := range b.VoiceMemoManager.GuildLibrary(guildID) {
+		targets[vm.name] = vm.name
+	}
+	for alias, memo := range b.VoiceMemoManager.Metadata.Guild(guildID).Aliases {
+		// Memos win over aliases by the same name, as in !play.
+		if _, taken := targets[alias]; !taken && targets[memo] == memo {
+			targets[alias] = memo
+		}
+	}
+	return targets
+}
+
+// Collects the Opus packets a user speaks into the voice channel for the given duration.
+func (gs *GuildSession) Capture(ctx context.Context, userID string, d time.Duration) [][]byte {
+	packets := gs.CapturePackets(ctx, userID, d)
+	opus := make([][]byte, len(packets))
+	for i, p := range packets {
+		opus[i] = p.Opus
+	}
+	return opus
+}
+
+// Collects the voice packets a user sends for the given duration, with their RTP timestamps.
+// Falls back to whoever spoke if we never learn which SSRC belongs to the user.
+func (gs *GuildSession) CapturePackets(ctx context.Context, userID string, d time.Duration) []*discordgo.Packet {
+	packets, unsubscribe := gs.Receiver.Subscribe()
+	defer unsubscribe()
+	gs.Touch()
+	defer gs.Touch()
+
+	bySSRC := make(map[uint32][]*discordgo.Packet)
+	timeout := time.After(d)
+	for {
+		select {
+		case p := <-packets:
+			bySSRC[p.SSRC] = append(bySSRC[p.SSRC], p)
+			continue
+		case <-timeout:
+		case <-ctx.Done():
+		}
+		break
+	}
+
+	for ssrc, captured := range bySSRC {
+		if gs.Receiver.UserID(ssrc) == userID {
+			return captured
+		}
+	}
+	if len(bySSRC) == 1 {
+		for _, captured := range bySSRC {
+			return captured
+		}
+	}
+	return nil
+}
+
+// Wraps a stream of Opus packets in an Ogg file and transcribes it.
+func Transcribe(ctx context.Context, stt STTProvider, packets [][]byte) (string, error) {
+	dir, err := os.MkdirTemp("", "voicememo-listen-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	audioPath := filepath.Join(dir, "command.ogg")
+	f, err := os.Create(audioPath)
+	if err != nil {
+		return "", err
+	}
+
+	ow, err := NewOggOpusWriter(f, opusChannels)
+	if err != nil {
+		f.Close()
+		return "", err
+	}
+	for _, p := range packets {
+		if err := ow.WritePacket(p); err != nil {
+			f.Close()
+			return "", err
+		}
+	}
+	if err := ow.Close(); err != nil {
+		f.Close()
+		return "", err
+	}
+	f.Close()
+
+	return stt.Transcribe(ctx, audioPath)
+}
+
+// Pulls the memo name out of a transcript like "Play duck quack." Returns false if nobody said "play".
+func ParsePlayCommand(transcript string) (string, bool) {
+	words := strings.Fields(strings.ToLower(transcript))
+	for i, w := range words {
+		if strings.Trim(w, ".,!?\"'") == "play" && i+1 < len(words) {
+			return strings.Join(words[i+1:], " "), true
+		}
+	}
+	return "", false
+}
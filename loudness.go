@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Range of targets !loudness accepts, in LUFS. -14 is what streaming services aim for, -18 is a bit calmer.
+	minTargetLoudness = -30
+	maxTargetLoudness = -6
+
+	// Memos this close to the target aren't worth re-encoding.
+	minGain = 1.0
+
+	// Boosting quiet memos further than this mostly makes their noise louder.
+	maxGain = 12.0
+
+	// !volume is a percentage of how loud memos would otherwise play.
+	defaultVolume = 100
+	maxVolume     = 200
+)
+
+// Measures the integrated loudness of a memo in LUFS with ffmpeg's loudnorm filter.
+func MeasureLoudness(ctx context.Context, vm *VoiceMemo) (float64, error) {
+	var ogg bytes.Buffer
+	if err := vm.WriteOgg(&ogg); err != nil {
+		return 0, err
+	}
+
+	ffmpeg := exec.CommandContext(ctx, "ffmpeg", "-hide_banner", "-nostats", "-i", "pipe:0", "-af", "loudnorm=print_format=json", "-f", "null", "-")
+	var stderr bytes.Buffer
+	ffmpeg.Stdin = &ogg
+	ffmpeg.Stderr = &stderr
+	if err := ffmpeg.Run(); err != nil {
+		return 0, fmt.Errorf("ffmpeg: %w: %s", err, stderr.String())
+	}
+
+	// loudnorm prints its measurements as the last thing on stderr.
+	out := stderr.String()
+	start, end := strings.LastIndex(out, "{"), strings.LastIndex(out, "}")
+	if start < 0 || end < start {
+		return 0, errors.New("ffmpeg didn't report the loudness")
+	}
+	var measured struct {
+		InputI string `json:"input_i"`
+	}
+	if err := json.Unmarshal([]byte(out[start:end+1]), &measured); err != nil {
+		return 0, err
+	}
+	loudness, err := strconv.ParseFloat(measured.InputI, 64)
+	if err != nil || math.IsInf(loudness, 0) {
+		return 0, fmt.Errorf("%s has no loudness to measure (%s)", vm.name, measured.InputI)
+	}
+	return loudness, nil
+}
+
+// Returns a memo's loudness, measuring it and saving the measurement if that hasn't happened yet.
+// Memos that aren't in the library, like previews, are measured every time.
+func (m *VoiceMemoManager) Loudness(ctx context.Context, vm *VoiceMemo) (float64, error) {
+	inLibrary := m.Get(vm.name) == vm
+	if inLibrary {
+		if md := m.Metadata.Memo(vm.name); md.Hash == vm.hash && md.Loudness != 0 {
+			return md.Loudness, nil
+		}
+	}
+
+	loudness, err := MeasureLoudness(ctx, vm)
+	if err != nil || !inLibrary {
+		return loudness, err
+	}
+	err = m.Metadata.UpdateMemo(vm.name, func(md *MemoMetadata) {
+		if md.Hash == vm.hash {
+			md.Loudness = loudness
+		}
+	})
+	if err != nil {
+		fmt.Println("Error saving metadata for ", vm.name, ": ", err)
+	}
+	return loudness, nil
+}
+
+// Re-encodes a memo with its samples scaled by factor, so 2 is twice and 0.5 half as loud.
+func ApplyGain(ctx context.Context, vm *VoiceMemo, factor float64) ([][]byte, error) {
+	var ogg bytes.Buffer
+	if err := vm.WriteOgg(&ogg); err != nil {
+		return nil, err
+	}
+	filters := []string{fmt.Sprintf("volume=%.3f", factor)}
+	if factor > 1 {
+		// Keep boosted peaks from clipping.
+		filters = append(filters, "alimiter=limit=0.95")
+	}
+	return EncodeDCA(ctx, ogg.Bytes(), vm.Channels(), filters...)
+}
+
+// Returns the memo turned to the guild's target loudness and !volume, or vm itself if there's nothing to
+// change or it can't be done. Memos are stored as uploaded, so this runs every time one plays, but the
+// re-encoded audio is cached.
+func (b *Bot) Level(ctx context.Context, guildID string, vm *VoiceMemo) *VoiceMemo {
+	settings := b.VoiceMemoManager.Metadata.Guild(guildID)
+
+	// Long-form memos would have to be re-encoded into memory in full, so they play as they are.
+	if vm.streamed {
+		return vm
+	}
+
+	gain := 0.0
+	if settings.TargetLoudness != 0 {
+		loudness, err := b.VoiceMemoManager.Loudness(ctx, vm)
+		if err != nil && ctx.Err() == nil {
+			fmt.Println("Error measuring ", vm.name, ": ", err)
+		}
+		if err == nil {
+			gain = settings.TargetLoudness - loudness
+		}
+		if gain > maxGain {
+			gain = maxGain
+		}
+	}
+
+	// Both come down to the same volume filter, so the memo is only re-encoded once.
+	factor := math.Pow(10, gain/20) * float64(settings.VolumePercent()) / defaultVolume
+	if math.Abs(20*math.Log10(factor)) < minGain {
+		return vm
+	}
+
+	// The same memo tends to play at the same volume over and over, so the result is kept.
+	frames, err := b.VoiceMemoManager.Artifacts.Frames(vm.hash, fmt.Sprintf("volume%.3f.dca", factor), func() ([][]byte, error) {
+		return ApplyGain(ctx, vm, factor)
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			fmt.Println("Error adjusting the loudness of ", vm.name, ": ", err)
+		}
+		return vm
+	}
+	return &VoiceMemo{name: vm.name, hash: vm.hash, buffer: frames}
+}
+
+func (b *Bot) HandleVolume(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) > 0 && args[0] == "reactions" {
+		b.HandleVolumeReactions(s, g, c, m, args[1:])
+		return
+	}
+	if len(args) == 0 {
+		settings := b.VoiceMemoManager.Metadata.Guild(g.ID)
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Memos play at %d%% volume in %s. %s", settings.VolumePercent(), g.Name, describeVolumeReactions(settings.ReactionVolume())))
+		return
+	}
+	if !b.IsDJ(s, g.ID, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins and DJs can change the volume.")
+		return
+	}
+
+	percent, err := strconv.Atoi(strings.TrimSuffix(args[0], "%"))
+	if err != nil || percent < 0 || percent > maxVolume {
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Usage: !volume [0-%d] | !volume reactions [<min>-<max>|off]", maxVolume))
+		return
+	}
+
+	err = b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		gs.Volume = &percent
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+	if gs, ok := b.Session(g.ID); ok {
+		gs.Relevel()
+	}
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("Memos play at %d%% volume now.", percent))
+}
+
+func (b *Bot) HandleLoudness(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := fmt.Sprintf("Usage: !loudness [off|<LUFS from %d to %d>], e.g. !loudness -14", minTargetLoudness, maxTargetLoudness)
+	if len(args) == 0 {
+		target := b.VoiceMemoManager.Metadata.Guild(g.ID).TargetLoudness
+		if target == 0 {
+			s.ChannelMessageSend(c.ID, "Memos play as loud as they were uploaded. "+usage)
+			return
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Memos play at %g LUFS.", target))
+		return
+	}
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can change the playback loudness.")
+		return
+	}
+
+	target := 0.0
+	if args[0] != "off" {
+		var err error
+		target, err = strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(args[0]), "lufs"), 64)
+		if err != nil || target < minTargetLoudness || target > maxTargetLoudness {
+			s.ChannelMessageSend(c.ID, usage)
+			return
+		}
+	}
+
+	err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		gs.TargetLoudness = target
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+
+	if target == 0 {
+		s.ChannelMessageSend(c.ID, "Memos play as loud as they were uploaded again.")
+		return
+	}
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("Memos will play at %g LUFS. Uploads aren't changed, only how loud they play.", target))
+}
@@ -1,15 +1,18 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"encoding/binary"
 	"flag"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
-	"os/exec"
 	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
@@ -17,16 +20,113 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
+// Alternative builds (e.g. -tags soak) set this to run something other than the bot. Its result is the exit code.
+var harness func() int
+
 var (
-	token string
+	token          string
+	metadataPath   string
+	maxMemos       int
+	sttKind        string
+	sttURL         string
+	sttModel       string
+	whisperPath    string
+	whisperModel   string
+	ttsKind        string
+	ttsURL         string
+	ttsModel       string
+	ttsVoices      string
+	espeakPath     string
+	speechAPIKey   string
+	fpcalcPath     string
+	cmdTimeout     time.Duration
+	registerSlash  bool
+	prefixCommands bool
+	slashNudge     bool
+	httpAddr       string
+	httpToken      string
+	previewDir     string
+	artifactMB     int
+	httpPublicURL  string
+	linkTTL        time.Duration
+	mirror         bool
+	mirrorRefresh  time.Duration
+	featureList    string
+	ownerList      string
+	dryRun         bool
+	role           string
+	encodeQueue    string
+
+	transcribeMemos bool
 )
 
 func init() {
 	flag.StringVar(&token, "t", "", "Bot Token")
+	flag.StringVar(&metadataPath, "metadata", "voicememo_metadata.json", "Path to the voice memo metadata file")
+	flag.IntVar(&maxMemos, "max-memos", 0, "Default maximum number of memos per guild (0 for unlimited)")
+	flag.StringVar(&sttKind, "stt", "whisper", "Speech-to-text provider (whisper or http)")
+	flag.StringVar(&sttURL, "stt-url", "", "Transcription endpoint for -stt=http")
+	flag.StringVar(&sttModel, "stt-model", "whisper-1", "Model requested from the -stt=http endpoint")
+	flag.StringVar(&whisperPath, "whisper", "whisper", "Path to the whisper speech recognition CLI")
+	flag.StringVar(&whisperModel, "whisper-model", "base", "Whisper model used for voice commands")
+	flag.BoolVar(&transcribeMemos, "transcribe-memos", false, "Transcribe new voice memos with -stt, so servers' !warnwords can give them content warnings")
+	flag.StringVar(&ttsKind, "tts", "espeak", "Text-to-speech provider (espeak or http)")
+	flag.StringVar(&ttsURL, "tts-url", "", "Speech synthesis endpoint for -tts=http")
+	flag.StringVar(&ttsModel, "tts-model", "tts-1", "Model requested from the -tts=http endpoint")
+	flag.StringVar(&ttsVoices, "tts-voices", "alloy,echo,fable,onyx,nova,shimmer", "Comma separated voices offered by the -tts=http endpoint")
+	flag.StringVar(&espeakPath, "espeak", "espeak-ng", "Path to the espeak-ng CLI")
+	flag.StringVar(&speechAPIKey, "speech-api-key", os.Getenv("SPEECH_API_KEY"), "API key for the http speech providers")
+	flag.StringVar(&fpcalcPath, "fpcalc", "fpcalc", "Path to the chromaprint fpcalc CLI used to spot duplicate uploads")
+	flag.BoolVar(&registerSlash, "slash", true, "Register slash commands alongside the ! prefix commands")
+	flag.BoolVar(&prefixCommands, "prefix-commands", true, "Handle commands typed with the ! prefix, or a server's !prefix. Commands that mention the bot always work")
+	flag.BoolVar(&slashNudge, "slash-nudge", false, "Point people using prefix commands to the slash command instead, in servers that don't set !nudge")
+	flag.DurationVar(&cmdTimeout, "command-timeout", 2*time.Minute, "How long a command may run before the watchdog cancels it")
+	flag.StringVar(&httpAddr, "http", "", "Address to serve the dashboard API on, e.g. :8080 (disabled if empty)")
+	flag.StringVar(&httpToken, "http-token", os.Getenv("HTTP_TOKEN"), "Token HTTP clients must present")
+	flag.StringVar(&previewDir, "preview-dir", "voicememo_previews", "Directory for generated audio previews and other audio derived from memos")
+	flag.IntVar(&artifactMB, "artifact-cache-mb", defaultArtifactCacheMB, "How many megabytes of derived audio to keep in -preview-dir")
+	flag.StringVar(&httpPublicURL, "http-public-url", "", "Public base URL of the HTTP server for !link, e.g. https://memos.example.com")
+	flag.DurationVar(&linkTTL, "link-ttl", 24*time.Hour, "How long links made by !link keep working")
+	flag.BoolVar(&mirror, "mirror", false, "Only serve -http from storage shared with the bot, without connecting to Discord")
+	flag.DurationVar(&mirrorRefresh, "mirror-refresh", 30*time.Second, "How often a -mirror instance picks up changes the bot made")
+	flag.StringVar(&featureList, "features", allFeatures(), "Comma separated features that are on in every server unless !feature says otherwise")
+	flag.StringVar(&ownerList, "owners", os.Getenv("BOT_OWNERS"), "Comma separated IDs of the users running the bot, who can use !feature")
+	flag.StringVar(&role, "role", "bot", "What this process does: bot, or encoder to convert uploads the bot queues in -encode-queue")
+	flag.StringVar(&encodeQueue, "encode-queue", "", "Directory shared with -role=encoder processes that convert uploads, instead of converting them here (disabled if empty)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Only report what deleting, cleanup, purge and migration commands would change, without changing it")
+}
+
+// Reads the flags registered in init. main does this rather than init, so go test can pass its own.
+func parseFlags() {
 	flag.Parse()
+	if dryRun {
+		fmt.Println("Running with -dry-run: deleting, cleanup, purge and migration commands only report what they would change.")
+	}
+	if !prefixCommands {
+		fmt.Println("Running with -prefix-commands=false: only slash commands and commands that mention the bot are handled.")
+		if !registerSlash {
+			fmt.Println("Slash commands aren't registered either, so make sure they were registered before.")
+		}
+	}
 }
 
 func main() {
+	parseFlags()
+	if harness != nil {
+		os.Exit(harness())
+	}
+	if mirror {
+		os.Exit(RunMirror())
+	}
+	switch role {
+	case "bot":
+	case "encoder":
+		os.Exit(RunEncoder())
+	default:
+		fmt.Println("Unknown -role ", role, ", it has to be bot or encoder")
+		os.Exit(1)
+	}
+
 	// Create discord session.
 	session, err := discordgo.New("Bot " + token)
 	if err != nil {
@@ -34,25 +134,91 @@ func main() {
 		return
 	}
 
-	voiceMemoManager, err := NewVoiceMemoManager()
+	metadata, err := NewMetadataStore(metadataPath)
+	if err != nil {
+		fmt.Println("Error loading voice memo metadata: ", err)
+		return
+	}
+
+	voiceMemoManager, err := NewVoiceMemoManager(metadata)
 	if err != nil {
 		fmt.Println("Error creating Voice Memo Manager for Discord session: ", err)
 		return
 	}
+	voiceMemoManager.Artifacts, err = NewArtifactCache(previewDir, int64(artifactMB)<<20)
+	if err != nil {
+		fmt.Println("Error opening the artifact cache: ", err)
+		return
+	}
 	voiceMemoManager.LoadAll()
+	go func() {
+		for _, name := range voiceMemoManager.Verify() {
+			fmt.Println("WARNING: the audio of ", name, " is missing or corrupt")
+		}
+	}()
+
+	stt, err := NewSTTProvider(sttKind)
+	if err != nil {
+		fmt.Println("Error creating speech-to-text provider: ", err)
+		return
+	}
+
+	tts, err := NewTTSProvider(ttsKind)
+	if err != nil {
+		fmt.Println("Error creating text-to-speech provider: ", err)
+		return
+	}
 
-	bot, err := NewBot(voiceMemoManager)
+	bot, err := NewBot(voiceMemoManager, stt, tts)
 	if err != nil {
 		fmt.Println("Error creating Voice Memo Manager for Discord session: ", err)
 		return
 	}
+	bot.Features, err = ParseFeatures(featureList)
+	if err != nil {
+		fmt.Println("Error parsing -features: ", err)
+		return
+	}
+	bot.Owners = ParseOwners(ownerList)
+	bot.SigningKey = SigningKey(token)
+	if httpAddr != "" {
+		server, err := NewHTTPServer(voiceMemoManager, httpToken, httpPublicURL)
+		if err != nil {
+			fmt.Println("Error creating HTTP server: ", err)
+			return
+		}
+		server.PurgeGuild = bot.PurgeGuildData
+		server.Metrics = bot.PlaybackMetrics
+		server.Health = func() HealthReport { return bot.HealthReport(session) }
+		bot.HTTP = server
+
+		go func() {
+			fmt.Println("Serving HTTP on ", httpAddr)
+			if err := server.ListenAndServe(httpAddr); err != nil {
+				fmt.Println("Error serving HTTP: ", err)
+			}
+		}()
+	}
+
+	bot.Alerts = NewAlerts(session, metadata)
+	bot.Cooldowns.Persist(metadata)
+	go bot.ExpireGrants(session)
+	go bot.LeaveIdleSessions()
+
 	session.AddHandler(bot.CommandCenter)
+	session.AddHandler(bot.InteractionCenter)
+	session.AddHandler(bot.ReactionCenter)
+	session.AddHandler(bot.VoiceStateCenter)
+	if registerSlash {
+		session.AddHandler(bot.RegisterSlashCommands)
+	}
 
 	err = session.Open()
 	if err != nil {
 		fmt.Println("Error opening Discord session: ", err)
 		return
 	}
+	go bot.MonitorHealth(session)
 
 	// Wait here until CTRL-C or other term signal is received.
 	fmt.Println("Voice memo bot is now running.  Press CTRL-C to exit.")
@@ -60,19 +226,68 @@ func main() {
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	<-sc
 
-	// Cleanly close down the Discord session.
+	// Save the cooldowns that haven't been yet, then cleanly close down the Discord session.
+	bot.Cooldowns.Flush()
 	session.Close()
 }
 
 type Bot struct {
+	// Guild sessions by guild ID. Use Session, JoinChannel and LeaveGuild, handlers run concurrently.
 	GuildSessions    map[string]*GuildSession
+	sessionsMu       sync.RWMutex
+	joinMu           sync.Mutex
 	VoiceMemoManager *VoiceMemoManager
+	STT              STTProvider
+	TTS              TTSProvider
+	Jobs             *JobRegistry
+	Cooldowns        *Cooldowns
+	Health           *HealthMonitor
+
+	// Nil unless -http is set.
+	HTTP *HTTPServer
+
+	// Nil when there's no Discord session to post to, which Report tolerates.
+	Alerts *Alerts
+
+	// Sends error replies that people tend to trigger over and over, like a full queue.
+	Outbox *Outbox
+
+	// Features that are on unless a guild overrides them, and the users who can change that with !feature.
+	// Both are set once at startup.
+	Features map[string]bool
+	Owners   map[string]bool
+
+	// Key the custom IDs of buttons that carry their own state are signed with. See SignCustomID.
+	SigningKey []byte
+
+	// When the bot started, for !sysinfo.
+	Started time.Time
+
+	// Handle clicks on !cleanup, !trim and taken name messages one at a time. Their state is saved with the
+	// metadata so it survives restarts.
+	cleanupsMu    sync.Mutex
+	trimsMu       sync.Mutex
+	nameClashesMu sync.Mutex
+
+	// Frames of the recordings waiting for a decision about their taken name, by clash ID. They're too big
+	// to save, so they're lost if the bot restarts.
+	clashFrames map[string][][]byte
 }
 
-func NewBot(am *VoiceMemoManager) (*Bot, error) {
+func NewBot(am *VoiceMemoManager, stt STTProvider, tts TTSProvider) (*Bot, error) {
 	return &Bot{
 		GuildSessions:    make(map[string]*GuildSession, 0),
 		VoiceMemoManager: am,
+		STT:              stt,
+		TTS:              tts,
+		Jobs:             NewJobRegistry(),
+		Cooldowns:        NewCooldowns(),
+		Health:           NewHealthMonitor(),
+		Outbox:           NewOutbox(),
+		Features:         everyFeature(),
+		Owners:           make(map[string]bool),
+		clashFrames:      make(map[string][][]byte),
+		Started:          time.Now(),
 	}, nil
 }
 
@@ -83,8 +298,7 @@ func (b *Bot) CommandCenter(s *discordgo.Session, m *discordgo.MessageCreate) {
 		return
 	}
 
-	command := m.Content
-	fmt.Println("Message: ", command)
+	fmt.Println("Message: ", m.Content)
 
 	// Find the channel that the message came from.
 	c, err := s.State.Channel(m.ChannelID)
@@ -100,34 +314,197 @@ func (b *Bot) CommandCenter(s *discordgo.Session, m *discordgo.MessageCreate) {
 		return
 	}
 
-	if strings.HasPrefix(m.Content, "!") {
+	if content, ok := b.TrimCommandPrefix(s, g.ID, m.Content); ok {
 
-		args := strings.Fields(command)
-		command = strings.TrimPrefix(args[0], "!")
+		args := strings.Fields(content)
+		if len(args) == 0 {
+			return
+		}
+		if wait := b.CommandWait(s, g.ID, c.ID, m.Author.ID, args[0]); wait > 0 {
+			s.ChannelMessageSend(c.ID, "<@"+m.Author.ID+"> "+CooldownNotice("!"+args[0], wait))
+			return
+		}
+		b.Dispatch(s, g, c, m, args[0], args[1:])
+		b.NudgeToSlash(s, g, c, m.Author.ID, args[0])
+
+	} else {
+		b.TriggerBinding(s, c.ID, m.Author.ID, m.Content)
+	}
+}
 
+// Runs a command, whether it came from a prefixed message or a slash command.
+func (b *Bot) Dispatch(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, command string, args []string) {
+	b.RunWithWatchdog(s, c.ID, command, func(ctx context.Context) {
 		switch command {
 		case "join":
 			b.HandleJoin(s, g, c, m)
 		case "leave":
 			b.HandleLeave(s, g)
+		case "handoff":
+			b.HandleHandoff(s, g, c, m, args)
 		case "play":
-			b.HandlePlay(s, g, c, strings.TrimPrefix(args[1], "-"))
+			full, args := takeFlag(args, "full")
+			if len(args) == 0 {
+				s.ChannelMessageSend(c.ID, fmt.Sprintf("Usage: !play [-full] <name> [x1-x%d] [m:ss] [<name> ...]", maxPlayRepeat))
+				return
+			}
+			requests, bad := ParsePlayRequests(args)
+			if bad != "" {
+				s.ChannelMessageSend(c.ID, fmt.Sprintf("I can play a memo 1 to %d times in a row, from a point in it if you like, e.g. !play %s x3 0:15", maxPlayRepeat, args[0]))
+				return
+			}
+			if full && !b.IsDJ(s, g.ID, m.Author.ID, c.ID) {
+				s.ChannelMessageSend(c.ID, "Only admins and DJs can play a voice memo past the server's !maxplay.")
+				return
+			}
+			if len(requests) > 1 {
+				b.HandlePlayAll(s, g, c, m.Author.ID, requests, full)
+				return
+			}
+			b.HandlePlay(s, g, c, m.Author.ID, requests[0].Name, requests[0].Times, full, requests[0].Start)
+		case "playnext":
+			b.HandlePlayNext(s, g, c, m, args)
+		case "cue":
+			b.HandleCue(s, g, c, m, args)
+		case "skip":
+			b.HandleSkip(s, g, c, m)
+		case "stop":
+			b.HandleStop(s, g, c, m)
+		case "seek":
+			b.HandleSeek(s, g, c, m, args)
+		case "pause":
+			b.HandlePause(s, g, c)
+		case "resume":
+			b.HandleResume(s, g, c)
+		case "loop":
+			b.HandleLoop(s, g, c, args, false)
+		case "loopqueue":
+			b.HandleLoop(s, g, c, args, true)
+		case "shuffle":
+			b.HandleShuffle(s, g, c, m)
+		case "remove":
+			b.HandleRemove(s, g, c, m, args)
+		case "move":
+			b.HandleMove(s, g, c, m, args)
+		case "clearqueue":
+			b.HandleClearQueue(s, g, c, m)
+		case "queue":
+			b.HandleQueue(s, g, c)
 		case "list":
-			b.HandleList(s, c)
+			b.HandleList(s, c, args)
+		case "search":
+			b.HandleSearch(s, c, args)
+		case "tag":
+			b.HandleTag(s, g, c, m, args)
+		case "random":
+			b.HandleRandom(s, g, c, m, args)
+		case "history":
+			b.HandleHistory(s, c, args)
+		case "stats":
+			b.HandleStats(s, c, args)
+		case "recent":
+			b.HandleRecent(s, c, args)
+		case "intro":
+			b.HandleIntro(s, g, c, m, args)
 		case "upload":
-			b.HandleUpload(s, m)
+			b.HandleUpload(ctx, s, m, args)
+		case "delete":
+			b.HandleDelete(s, g, c, m, args)
+		case "rename":
+			b.HandleRename(s, g, c, m, args)
+		case "transfer":
+			b.HandleTransfer(s, g, c, m, args)
+		case "maxmemos":
+			b.HandleMaxMemos(s, g, c, m, args)
+		case "prefix":
+			b.HandlePrefix(s, g, c, m, args)
+		case "nudge":
+			b.HandleNudge(s, g, c, m, args)
+		case "cooldown":
+			b.HandleCooldown(s, g, c, m, args)
+		case "maxplay":
+			b.HandleMaxPlay(s, g, c, m, args)
+		case "namepolicy":
+			b.HandleNamePolicy(s, g, c, m, args)
+		case "preset":
+			b.HandlePreset(s, g, c, m, args)
+		case "loudness":
+			b.HandleLoudness(s, g, c, m, args)
+		case "volume":
+			b.HandleVolume(s, g, c, m, args)
+		case "duck":
+			b.HandleDuck(s, g, c, m, args)
+		case "greeting":
+			b.HandleGreeting(s, g, c, m, args)
+		case "jobs":
+			b.HandleJobs(s, g, c, m, args)
+		case "link":
+			b.HandleLink(s, g, c, args)
+		case "bind":
+			b.HandleBind(s, g, c, m, args)
+		case "alias":
+			b.HandleAlias(s, g, c, m, args)
+		case "soundboard":
+			b.HandleSoundboard(s, g, c, m, args)
+		case "autojoin":
+			b.HandleAutoJoin(s, g, c, m, args)
+		case "idle":
+			b.HandleIdle(s, g, c, m, args)
+		case "pack":
+			b.HandlePack(s, g, c, m, args)
+		case "playlist":
+			b.HandlePlaylist(s, g, c, m, args)
+		case "cleanup":
+			b.HandleCleanup(s, g, c, m, args)
+		case "audit":
+			b.HandleAudit(s, g, c, m, args)
+		case "grant":
+			b.HandleGrant(s, g, c, m, args)
+		case "window":
+			b.HandleWindow(s, g, c, m, args)
+		case "warnwords":
+			b.HandleWarnWords(s, g, c, m, args)
+		case "restrict":
+			b.HandleRestrict(s, g, c, m, args)
+		case "alerts":
+			b.HandleAlerts(s, g, c, m, args)
+		case "suggest":
+			b.HandleSuggest(s, g, c, m)
+		case "trim":
+			b.HandleTrim(s, g, c, m, args)
+		case "describe":
+			b.HandleDescribe(s, g, c, m, args)
+		case "info":
+			b.HandleInfo(s, c, args)
+		case "listen":
+			b.HandleListen(ctx, s, g, c, m)
+		case "say":
+			b.HandleSay(ctx, s, g, c, m, args)
+		case "voices":
+			b.HandleVoices(ctx, s, c)
+		case "voice":
+			b.HandleVoice(ctx, s, g, c, m, args)
 		case "record":
+			b.HandleRecord(ctx, s, g, c, m, args)
+		case "purge-guild-data":
+			b.HandlePurgeGuildData(s, g, c, m, args)
+		case "mydata":
+			b.HandleMyData(s, c, m, args)
+		case "sysinfo":
+			b.HandleSysinfo(s, c, m)
+		case "feature":
+			b.HandleFeature(s, g, c, m, args)
+		case "help":
+			b.HandleHelp(s, c, args)
 		default:
-			s.ChannelMessageSend(c.ID, "Unrecognizable command, dummy...")
+			s.ChannelMessageSend(c.ID, "Unrecognizable command, dummy... !help lists the ones I know.")
 		}
-
-	}
+	})
 }
 
 func (b *Bot) HandleJoin(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate) {
 	// Look for Guild Session by id, else create one.
-	_, ok := b.GuildSessions[g.ID]
-	if ok {
+	if _, ok := b.Session(g.ID); ok {
 		// Guild session already exists.
 		fmt.Println("Already joined a voice channel in ", g.Name)
 		s.ChannelMessageSend(c.ID, "I have already joined a voice channel in "+g.Name)
@@ -136,78 +513,230 @@ func (b *Bot) HandleJoin(s *discordgo.Session, g *discordgo.Guild, c *discordgo.
 
 	// Look for the message sender in that guild's current voice states.
 	fmt.Println("Attempting to join voice channel in ", g.Name)
-	for _, vs := range g.VoiceStates {
-		if vs.UserID == m.Author.ID {
+	channelID := UserVoiceChannel(g, m.Author.ID)
+	if channelID == "" {
+		// User must join a voice channel first before commanding bot to join.
+		s.ChannelMessageSend(c.ID, "You must join a voice channel first.")
+		return
+	}
 
-			// Then join the channel inside that guild.
-			vc, err := s.ChannelVoiceJoin(g.ID, vs.ChannelID, false, true)
-			if err != nil {
-				fmt.Println("Error joining voice channel:", err)
-				return
-			}
+	// Then join the channel inside that guild.
+	gs, err := b.JoinChannel(s, g, channelID)
+	if err != nil {
+		fmt.Println("Error joining voice channel:", err)
+		b.Alerts.Report(g.ID, AlertVoice, err)
+		return
+	}
+	gs.Stats.SetChannel(c.ID)
 
-			// Create Guild Session.
-			fmt.Println("Creating new Guild session for ", g.Name)
-			b.GuildSessions[g.ID] = &GuildSession{
-				ID:              g.ID,
-				GuildName:       g.Name,
-				VoiceConnection: vc,
-				PlayQueue:       make(chan *VoiceMemo, 10), // will set length of channel to 10 for now
-				IsVoicePlaying:  &atomic.Bool{},
-			}
+	// Say hello.
+	b.Greet(s, g, gs, c.ID)
+}
 
-			// Say hello.
-			s.ChannelMessageSend(c.ID, fmt.Sprintf("Hello %s!", g.Name))
-			return
+func (b *Bot) HandleLeave(s *discordgo.Session, g *discordgo.Guild) {
+	// Say goodbye first if the guild set up a farewell.
+	if gs, ok := b.Session(g.ID); ok {
+		b.Farewell(gs)
+	}
+
+	// Disconnect from channel in guild, then remove guild session.
+	if !b.LeaveGuild(g.ID) {
+		fmt.Println("Error finding guild session.")
+		return
+	}
+}
+
+// Most copies of a memo one !play can queue, which is as many as the queue holds.
+const maxPlayRepeat = 10
+
+// Parses a repeat count like "x3", "3x" or "*3".
+func ParseRepeat(arg string) (int, bool) {
+	arg = strings.Trim(strings.ToLower(arg), "x*×")
+	times, err := strconv.Atoi(arg)
+	if err != nil || times < 1 || times > maxPlayRepeat {
+		return 0, false
+	}
+	return times, true
+}
+
+// Queues a memo times times in a row on behalf of userID, each playing from start. full plays it past the
+// guild's !maxplay.
+func (b *Bot) HandlePlay(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, userID, fileName string, times int, full bool, start time.Duration) {
+	voiceMemo := b.VoiceMemoManager.GuildMemo(g.ID, fileName)
+	if voiceMemo == nil {
+		fmt.Println("Cannot find ", fileName)
+		b.Outbox.Error(s, c.ID, "Cannot find "+fileName)
+		return
+	}
+	if !b.CanPlay(s, g, c.ID, userID, voiceMemo.name) {
+		b.Outbox.Error(s, c.ID, "You don't have a role that can play "+voiceMemo.name)
+		return
+	}
+	if reason := b.OutsideWindow(g.ID, voiceMemo.name); reason != "" {
+		b.Outbox.Error(s, c.ID, reason)
+		return
+	}
+	if start >= voiceMemo.Duration() {
+		b.Outbox.Error(s, c.ID, fmt.Sprintf("%s is only %s long.", voiceMemo.name, FormatDuration(voiceMemo.Duration())))
+		return
+	}
+
+	// Join the requester's voice channel if nobody asked for a !join yet.
+	gs, ok := b.SessionFor(s, g, c.ID, userID)
+	if !ok {
+		return
+	}
+
+	// Tell people when their memo will play if something is ahead of it.
+	eta := gs.QueueETA()
+	wait := gs.IsVoicePlaying.Load()
+	played := make([]string, 0, times)
+	added := 0
+	entry := QueueEntry{Memo: voiceMemo, RequesterID: userID, ChannelID: c.ID, Full: full, Start: int(start / frameDuration)}
+	for ; added < times; added++ {
+		if !gs.EnqueueEntry(entry) {
+			break
 		}
+		played = append(played, voiceMemo.name)
+	}
+	b.VoiceMemoManager.RecordPlay(g.ID, userID, played...)
+	if added == 0 {
+		b.Outbox.Error(s, c.ID, "The queue is full. Try again later.")
+		return
+	}
+
+	if times > 1 {
+		reply := fmt.Sprintf("Queued %s %d times (%s).", voiceMemo.name, added, FormatDuration(time.Duration(added)*entry.Duration()))
+		if added < times {
+			reply += fmt.Sprintf(" The other %d didn't fit, the queue is full.", times-added)
+		}
+		if gs.Paused() {
+			reply += " Playback is paused, !resume to carry on."
+		}
+		s.ChannelMessageSend(c.ID, reply)
+	} else if gs.Paused() {
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Queued %s (%s). Playback is paused, !resume to carry on.", voiceMemo.name, FormatDuration(entry.Duration())))
+	} else if wait {
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Queued %s (%s), playing in about %s. %s of audio queued.",
+			voiceMemo.name, FormatDuration(entry.Duration()), FormatDuration(eta), FormatDuration(gs.QueueETA())))
 	}
 
-	// User must join a voice channel first before commanding bot to join.
-	s.ChannelMessageSend(c.ID, "You must join a voice channel first.")
+	// Playback outlives the command, so it doesn't count against the command timeout.
+	go gs.PlayFromQueue()
 }
 
-func (b *Bot) HandleLeave(s *discordgo.Session, g *discordgo.Guild) {
-	gs, ok := b.GuildSessions[g.ID]
+func (b *Bot) HandleSkip(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate) {
+	gs, ok := b.Session(g.ID)
 	if !ok {
 		fmt.Println("Error finding guild session.")
 		return
 	}
+	if refusal := b.QueueRefusal(s, gs, c.ID, m.Author.ID, "skip"); refusal != "" {
+		s.ChannelMessageSend(c.ID, refusal)
+		return
+	}
 
-	// Disconnect from channel in guild, then remove guild session.
-	gs.Disconnect()
-	delete(b.GuildSessions, g.ID)
+	skipped, ok := gs.Skip()
+	if !ok {
+		s.ChannelMessageSend(c.ID, "Nothing is playing.")
+		return
+	}
+	s.ChannelMessageSend(c.ID, "Skipped "+skipped.name)
 }
 
-func (b *Bot) HandlePlay(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, fileName string) {
-	gs, ok := b.GuildSessions[g.ID]
+func (b *Bot) HandleStop(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate) {
+	gs, ok := b.Session(g.ID)
 	if !ok {
 		fmt.Println("Error finding guild session.")
 		return
 	}
-
-	voiceMemo := b.VoiceMemoManager.Get(fileName)
-	if voiceMemo == nil {
-		fmt.Println("Cannot find ", fileName)
-		s.ChannelMessageSend(c.ID, "Cannot find "+fileName)
+	if refusal := b.QueueRefusal(s, gs, c.ID, m.Author.ID, "stop"); refusal != "" {
+		s.ChannelMessageSend(c.ID, refusal)
 		return
 	}
 
-	gs.Enqueue(voiceMemo)
-	gs.PlayFromQueue()
+	stopped := gs.Stop()
+	if stopped == 0 {
+		s.ChannelMessageSend(c.ID, "Nothing is playing.")
+		return
+	}
+	// The player stops speaking once it notices, but make sure in case it never started.
+	gs.VoiceConnection.Speaking(false)
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("Stopped playback and cleared %d voice memos.", stopped))
 }
 
-func (b *Bot) HandleList(s *discordgo.Session, c *discordgo.Channel) {
+func (b *Bot) HandleList(s *discordgo.Session, c *discordgo.Channel, args []string) {
+	usage := "Usage: !list [-sort=" + strings.Join(listSorts, "|") + "] [-tag=<tags>] [page]"
+	order := "name"
+	for len(args) > 0 {
+		value, ok := OptionValue(args[0], "sort")
+		if !ok {
+			break
+		}
+		if order = strings.ToLower(value); !containsString(listSorts, order) {
+			s.ChannelMessageSend(c.ID, usage)
+			return
+		}
+		args = args[1:]
+	}
+	tags, args := ParseTagFilter(args)
+	page := 1
+	if len(args) > 0 {
+		p, err := strconv.Atoi(args[0])
+		if err != nil || p < 1 {
+			s.ChannelMessageSend(c.ID, usage)
+			return
+		}
+		page = p
+	}
+
+	library := b.VoiceMemoManager.FilterTagged(b.VoiceMemoManager.GuildLibrary(c.GuildID), tags)
+	if len(library) == 0 && len(tags) > 0 {
+		s.ChannelMessageSend(c.ID, "No voice memos are tagged "+strings.Join(tags, ", "))
+		return
+	}
+	if order != "name" {
+		b.VoiceMemoManager.SortMemos(c.GuildID, library, order)
+	}
+	memos, pages := Paginate(library, page, listPageSize)
+	if page > pages {
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("There are only %d pages of voice memos.", pages))
+		return
+	}
+
 	// Create list embed.
 	embed := &discordgo.MessageEmbed{
 		Title:  "List of all voice memos",
 		Color:  65535,
 		Fields: []*discordgo.MessageEmbedField{},
+		Footer: &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Page %d of %d", page, pages)},
+	}
+	more := fmt.Sprint(page + 1)
+	if len(tags) > 0 {
+		embed.Title = "Voice memos tagged " + strings.Join(tags, ", ")
+		if len(embed.Title) > 256 {
+			embed.Title = embed.Title[:250] + "..."
+		}
+		more = "-tag=" + strings.Join(tags, ",") + " " + more
+	}
+	if order != "name" {
+		more = "-sort=" + order + " " + more
+	}
+	if page < pages {
+		embed.Footer.Text += " · !list " + more + " for more"
 	}
 
-	for _, v := range b.VoiceMemoManager.Store {
+	for _, v := range memos {
+		value := "-" + v.name
+		if v.streamed {
+			value += " (long-form)"
+		}
+		if len(b.ContentWarnings(c.GuildID, v.name)) > 0 {
+			value += " ⚠️"
+		}
 		field := discordgo.MessageEmbedField{
 			Name:   "\u200b",
-			Value:  "-" + v.name,
+			Value:  value,
 			Inline: true,
 		}
 		embed.Fields = append(embed.Fields, &field)
@@ -220,209 +749,1067 @@ func (b *Bot) HandleList(s *discordgo.Session, c *discordgo.Channel) {
 	}
 }
 
-func (b *Bot) HandleUpload(s *discordgo.Session, m *discordgo.MessageCreate) {
-	if len(m.Attachments) == 0 {
-		s.ChannelMessageSend(m.ChannelID, "Please attach an audio file.")
+func (b *Bot) HandleDelete(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		s.ChannelMessageSend(c.ID, "Usage: !delete [-dry-run] <name>")
 		return
 	}
-
-	url := m.Attachments[0].URL
-	res, err := http.Get(url)
-	if err != nil {
+	dry, args := ParseDryRun(args)
+	if len(args) == 0 {
+		s.ChannelMessageSend(c.ID, "Usage: !delete [-dry-run] <name>")
 		return
 	}
-	defer res.Body.Close()
+	name := args[0]
 
-	fileName := m.Attachments[0].Filename
-	original, err := os.Create("voicememo_files/" + fileName)
-	if err != nil {
+	// Only admins and the original uploader may delete a memo.
+	md := b.VoiceMemoManager.Metadata.Memo(name)
+	if md.OtherGuild(g.ID) {
+		s.ChannelMessageSend(c.ID, name+" belongs to another server.")
 		return
 	}
-
-	_, err = io.Copy(original, res.Body)
-	if err != nil {
+	if md.UploaderID != m.Author.ID && !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins or the uploader can delete "+name)
+		return
+	}
+	if dry {
+		if b.VoiceMemoManager.Get(name) == nil {
+			s.ChannelMessageSend(c.ID, "Cannot find "+name)
+			return
+		}
+		SendDryRun(s, c.ID, "!delete would delete "+name, b.VoiceMemoManager.PlanDeletion([]string{name}).Fields())
 		return
 	}
 
-	original.Close()
-
-	// Run ffmpeg command to convert the original file to .dca
-	name := strings.Split(fileName, ".")[0]
-	converted, err := os.Create("voicememo_files/" + name + ".dca")
+	pending, err := b.VoiceMemoManager.Delete(name)
 	if err != nil {
+		fmt.Println("Error deleting ", name, ": ", err)
+		s.ChannelMessageSend(c.ID, "Could not delete "+name)
 		return
 	}
 
-	ffmpeg := exec.Command("ffmpeg", "-i", "voicememo_files/"+fileName, "-f", "s16le", "-ar", "48000", "-ac", "2", "pipe:1")
-	dca := exec.Command("dca")
-
-	dca.Stdin, _ = ffmpeg.StdoutPipe()
-	dca.Stdout = converted
-	dca.Start()
-	ffmpeg.Run()
-	dca.Wait()
-	converted.Close()
+	if pending {
+		s.ChannelMessageSend(c.ID, "Deleted "+name+". It will finish any playback that is already queued.")
+		return
+	}
+	s.ChannelMessageSend(c.ID, "Deleted "+name)
+}
 
-	defer func() {
-		if err := os.Remove(original.Name()); err != nil {
-			fmt.Println(err)
-			return
-		}
-	}()
+func (b *Bot) HandleRename(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) < 2 {
+		s.ChannelMessageSend(c.ID, "Usage: !rename <name> <new name>")
+		return
+	}
+	oldName, newName := args[0], args[1]
+	if b.VoiceMemoManager.Get(oldName) == nil {
+		s.ChannelMessageSend(c.ID, "Cannot find "+oldName)
+		return
+	}
 
-	newVoiceMemo := &VoiceMemo{
-		name:   name,
-		buffer: make([][]byte, 0),
+	// Only admins and the original uploader may rename a memo.
+	md := b.VoiceMemoManager.Metadata.Memo(oldName)
+	if md.OtherGuild(g.ID) {
+		s.ChannelMessageSend(c.ID, oldName+" belongs to another server.")
+		return
+	}
+	if md.UploaderID != m.Author.ID && !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins or the uploader can rename "+oldName)
+		return
 	}
-	newVoiceMemo.Load()
-	b.VoiceMemoManager.Store[newVoiceMemo.name] = newVoiceMemo
 
-	s.ChannelMessageSend(m.ChannelID, "Successfully uploaded "+name)
+	if err := b.VoiceMemoManager.Rename(oldName, newName); err != nil {
+		fmt.Println("Error renaming ", oldName, ": ", err)
+		s.ChannelMessageSend(c.ID, "Could not rename "+oldName+": "+err.Error())
+		return
+	}
+	s.ChannelMessageSend(c.ID, "Renamed "+oldName+" to "+newName)
 }
 
 type GuildSession struct {
 	ID              string
 	GuildName       string
 	VoiceConnection *discordgo.VoiceConnection
-	PlayQueue       chan *VoiceMemo
+	PlayQueue       *PlayQueue
 	IsVoicePlaying  *atomic.Bool
+	Receiver        *VoiceReceiver
+
+	// Optional hooks run by the player when a memo starts, when the queue runs dry and when a memo fails.
+	// OnPlay runs once per memo taken off the queue, not again for every !loop repeat.
+	OnPlay  func(entry QueueEntry)
+	OnIdle  func()
+	OnError func(err error)
+
+	// Optional hook run by LeaveGuild once the session has disconnected.
+	OnLeave func()
+
+	// Optional hook run by the player once !handoff moved the connection to another voice channel.
+	OnMove func(channelID string)
+
+	// Optional hook run when the player gives up on a memo because the voice connection kept stalling.
+	OnDeadLetter func(entry QueueEntry, err error)
+
+	// Counts what the session played and who heard it, for the summary posted when it ends.
+	Stats SessionStats
+
+	// How long the latest frames waited for the voice connection to take them, for !sysinfo.
+	SendTimings SendTimings
+
+	// Optional hook that returns how long memos may play before they're faded out, or 0 if they may play in full.
+	MaxPlayback func() time.Duration
+
+	// Optional hook that returns what to send in place of a memo, e.g. turned to the guild's target loudness.
+	// It runs once the memo is up, with a context that ends when the memo is skipped.
+	Prepare func(ctx context.Context, vm *VoiceMemo) *VoiceMemo
+
+	// Set when the session was started by auto-join rather than !join, so auto-join may end it too.
+	AutoJoined atomic.Bool
+
+	// Set by !loop to repeat the memo that's playing, and by !loopqueue to send every memo back round
+	// to the end of the queue once it's played.
+	LoopOne   atomic.Bool
+	LoopQueue atomic.Bool
+
+	// Frames waiting in PlayQueue and frames left in the memo that's playing, for ETAs.
+	queuedFrames    atomic.Int64
+	remainingFrames atomic.Int64
+
+	// The memo that's playing, who asked for it and how to cut it short. All are empty while idle.
+	currentMu     sync.Mutex
+	current       *VoiceMemo
+	currentBy     string
+	cancelCurrent context.CancelFunc
+
+	// Closed by Resume. Non-nil while paused, and the player waits on it between frames.
+	pauseMu sync.Mutex
+	resume  chan struct{}
+
+	// Counts calls to Stop, so the player can tell a playlist was stopped while one of its memos played.
+	stops atomic.Int64
+
+	// What the player is sending after Prepare, the frame it stops at, the frame it's on, speech waiting to be
+	// mixed over it by Announce, and the frame !seek asked it to go to.
+	duckMu     sync.Mutex
+	playing    *VoiceMemo
+	playingEnd int
+	position   atomic.Int64
+	duck       *Duck
+	seekTo     int
+	seeking    bool
+
+	// A move to another voice channel asked for with !handoff, run by the player once the memo it's on is over.
+	moveMu sync.Mutex
+	move   func()
+
+	// Counts calls to Relevel, so the player can tell the memo it's on has to be prepared again.
+	relevels atomic.Int64
+
+	// The latest now playing message, which the volume reactions count on.
+	nowPlayingMu sync.Mutex
+	nowPlaying   string
+
+	// When a memo last started or finished playing, or the session last recorded, in Unix nanoseconds.
+	lastActive atomic.Int64
 }
 
-func (gs *GuildSession) Enqueue(voiceMemo *VoiceMemo) {
-	select {
-	case gs.PlayQueue <- voiceMemo:
+// Queues every memo of a playlist as one entry on behalf of requesterID. Deleted memos are left out.
+// Returns how many memos were queued, or 0 if the queue is full or none of them could be.
+func (gs *GuildSession) EnqueuePlaylist(name string, memos []*VoiceMemo, requesterID, channelID string) int {
+	acquired := make([]*VoiceMemo, 0, len(memos))
+	for _, vm := range memos {
+		if vm.Acquire() {
+			acquired = append(acquired, vm)
+			gs.queuedFrames.Add(int64(vm.Frames()))
+		}
+	}
+	if len(acquired) == 0 {
+		return 0
+	}
 
-	default:
-		fmt.Println("Queue is currently full. Try again later. Queue count: ", len(gs.PlayQueue))
-		break
+	entry := QueueEntry{Memo: acquired[0], Rest: acquired[1:], Playlist: name, RequesterID: requesterID, ChannelID: channelID, QueuedAt: time.Now()}
+	if !gs.PlayQueue.Push(entry) {
+		fmt.Println("Queue is currently full. Try again later. Queue count: ", gs.PlayQueue.Len())
+		for _, vm := range acquired {
+			gs.queuedFrames.Add(-int64(vm.Frames()))
+			vm.Release()
+		}
+		return 0
+	}
+	return len(acquired)
+}
+
+// Puts the rest of a playlist back at the front of the queue once one of its memos has played, unless
+// the session was stopped meanwhile. stops is what gs.stops was when the memo was taken off the queue.
+func (gs *GuildSession) continuePlaylist(entry QueueEntry, stops int64) {
+	if len(entry.Rest) == 0 {
+		return
 	}
+	if gs.stops.Load() != stops {
+		for _, vm := range entry.Rest {
+			gs.queuedFrames.Add(-int64(vm.Frames()))
+			vm.Release()
+		}
+		return
+	}
+	next := entry
+	next.Memo, next.Rest, next.Attempts, next.Start = entry.Rest[0], entry.Rest[1:], 0, 0
+	gs.PlayQueue.PushFront(next)
+}
+
+// Queues a memo on behalf of requesterID, who asked for it in channelID. Returns false if the queue is full
+// or the memo was deleted.
+func (gs *GuildSession) Enqueue(voiceMemo *VoiceMemo, requesterID, channelID string) bool {
+	return gs.EnqueueEntry(QueueEntry{Memo: voiceMemo, RequesterID: requesterID, ChannelID: channelID})
+}
+
+// Queues the memo of an entry that isn't a playlist. Returns false if the queue is full or the memo was deleted.
+func (gs *GuildSession) EnqueueEntry(entry QueueEntry) bool {
+	return gs.enqueue(entry, gs.PlayQueue.Push)
+}
+
+// Queues the memo of an entry that isn't a playlist ahead of everything else, as !playnext does. Returns false
+// if the queue is full or the memo was deleted.
+func (gs *GuildSession) EnqueueNext(entry QueueEntry) bool {
+	return gs.enqueue(entry, gs.PlayQueue.Insert)
+}
+
+func (gs *GuildSession) enqueue(entry QueueEntry, push func(QueueEntry) bool) bool {
+	voiceMemo := entry.Memo
+
+	// Deleted memos may still be referenced by other queues, but can't be queued again.
+	if !voiceMemo.Acquire() {
+		fmt.Println("Cannot enqueue deleted voice memo ", voiceMemo.name)
+		return false
+	}
+
+	// Count the frames before the memo is visible to the player, so it can't subtract them first.
+	gs.queuedFrames.Add(int64(entry.Frames()))
+
+	entry.QueuedAt = time.Now()
+	if !push(entry) {
+		fmt.Println("Queue is currently full. Try again later. Queue count: ", gs.PlayQueue.Len())
+		gs.queuedFrames.Add(-int64(entry.Frames()))
+		voiceMemo.Release()
+		return false
+	}
+	return true
+}
+
+// Pause between memos in the queue.
+const playbackGap = 100 * time.Millisecond
+
+// Returns how long until everything currently queued has finished playing.
+func (gs *GuildSession) QueueETA() time.Duration {
+	frames := gs.queuedFrames.Load() + gs.remainingFrames.Load()
+	return time.Duration(frames)*frameDuration + time.Duration(gs.PlayQueue.Len())*playbackGap
 }
 
 func (gs *GuildSession) PlayFromQueue() {
-	// Don't play if already playing.
-	if gs.IsVoicePlaying.Load() {
+	// Don't play if already playing. Swap atomically so two callers can't both start.
+	if !gs.IsVoicePlaying.CompareAndSwap(false, true) {
 		fmt.Println("Your voice memo is being added to the queue.")
 		return
 	}
 
-	gs.IsVoicePlaying.Store(true) // write new value atomically
 	vc := gs.VoiceConnection
 
 	// Start speaking.
 	vc.Speaking(true)
 
 	for {
-		select {
-		case dequeued := <-gs.PlayQueue:
+		gs.runMove()
+		entry, ok := gs.PlayQueue.Pop()
+		if !ok {
+			gs.IsVoicePlaying.Store(false)
 
-			// Send the buffer data.
-			for _, buff := range dequeued.buffer {
-				vc.OpusSend <- buff
+			// Someone may have queued a memo or asked for a move after we found the queue empty but before
+			// they could see we stopped playing. Pick it up instead of leaving it stranded.
+			if (gs.PlayQueue.Len() > 0 || gs.movePending()) && gs.IsVoicePlaying.CompareAndSwap(false, true) {
+				continue
 			}
 
-			// Sleep for a specificed amount of time before ending.
-			time.Sleep(100 * time.Millisecond)
+			// Nothing left to pause, so don't hold back whatever gets queued next.
+			gs.Resume()
 
-		default:
 			// Stop speaking.
-			defer vc.Speaking(false)
-			gs.IsVoicePlaying.Store(false)
+			vc.Speaking(false)
+			if gs.OnIdle != nil {
+				gs.OnIdle()
+			}
 			return
 		}
+
+		dequeued := entry.Memo
+		stops := gs.stops.Load()
+		gs.queuedFrames.Add(-int64(entry.memoFrames()))
+		if gs.OnPlay != nil && entry.Attempts == 0 {
+			gs.OnPlay(entry)
+		}
+		var failed error
+		for {
+			cut, err := gs.play(dequeued, entry.RequesterID, entry.Full, entry.Start)
+			failed = err
+			gs.runMove()
+
+			// !loop plays it again until it's turned off, skipped or the memo is deleted.
+			if cut || err != nil || !gs.LoopOne.Load() || dequeued.Tombstoned() {
+				break
+			}
+		}
+
+		if failed != nil {
+			entry.Attempts++
+			if entry.Attempts < maxPlayAttempts {
+				// Try again before anything else, keeping the reference the entry already holds.
+				fmt.Println("Error playing ", dequeued.name, ", trying again: ", failed)
+				gs.queuedFrames.Add(int64(entry.memoFrames()))
+				gs.PlayQueue.PushFront(entry)
+				continue
+			}
+			fmt.Println("Error playing ", dequeued.name, ", giving up: ", failed)
+			if gs.OnDeadLetter != nil {
+				gs.OnDeadLetter(entry, failed)
+			}
+		} else if gs.LoopQueue.Load() {
+			// !loopqueue sends it round again. Enqueue refuses memos that were deleted meanwhile.
+			gs.EnqueueEntry(QueueEntry{Memo: dequeued, RequesterID: entry.RequesterID, ChannelID: entry.ChannelID, Full: entry.Full, Start: entry.Start})
+		}
+		gs.continuePlaylist(entry, stops)
+		dequeued.Release()
 	}
 }
 
+const (
+	// How long one frame may wait to be sent before the voice connection counts as stalled.
+	opusSendTimeout = 5 * time.Second
+
+	// How many times a memo is played before giving up on it when the connection keeps stalling,
+	// and how long to give the connection to recover in between.
+	maxPlayAttempts = 3
+	playRetryDelay  = 2 * time.Second
+)
+
+// Plays one memo from frame start through to the end, or until the guild's !maxplay unless full. Returns true
+// if it was cut short by !skip or !stop, or an error if the voice connection stopped taking audio and it's
+// worth trying again.
+func (gs *GuildSession) play(vm *VoiceMemo, requesterID string, full bool, start int) (bool, error) {
+	vc := gs.VoiceConnection
+	gs.remainingFrames.Store(int64(vm.Frames()))
+	ctx := gs.setCurrent(vm, requesterID)
+	out := vm
+	if gs.Prepare != nil {
+		out = gs.Prepare(ctx, vm)
+	}
+
+	stall := time.NewTimer(opusSendTimeout)
+	defer stall.Stop()
+	var stalled error
+
+	send := func(buff []byte) bool {
+		// Time each frame on its own, so pauses and long memos don't count.
+		if !stall.Stop() {
+			select {
+			case <-stall.C:
+			default:
+			}
+		}
+		stall.Reset(opusSendTimeout)
+
+		sent := time.Now()
+		select {
+		case vc.OpusSend <- buff:
+			gs.SendTimings.Record(time.Since(sent))
+		case <-ctx.Done():
+			return false
+		case <-stall.C:
+			stalled = fmt.Errorf("playing %s: the voice connection stopped taking audio for %s", vm.name, opusSendTimeout)
+			return false
+		}
+		gs.Stats.CountFrame()
+		return true
+	}
+
+	// Memos that would play past the guild's !maxplay fade out at it.
+	end := out.Frames()
+	if start >= end {
+		start = 0
+	}
+	var fade <-chan [][]byte
+	if !full && gs.MaxPlayback != nil {
+		if limit := int(gs.MaxPlayback() / frameDuration); limit > 0 && start+limit < end {
+			end = start + limit
+			fade = FadeOut(ctx, out, end)
+		}
+	}
+	gs.remainingFrames.Store(int64(end - start))
+	fadeAt := end - int(maxPlayFade/frameDuration)
+	fading := false
+
+	// A !volume change while the memo plays has it prepared again in the background, and the result carries on
+	// from the frame the player got to.
+	relevels := gs.relevels.Load()
+	var releveled chan *VoiceMemo
+	var relevel *VoiceMemo
+
+	// Speech mixed over the memo by Announce replaces the frames it covers.
+	stops := gs.stops.Load()
+	gs.startPlaying(out, start, end)
+	var overlay [][]byte
+	var missed *Duck
+
+	// Send the buffer data until it runs out or the memo is skipped, holding still while paused. Frames before
+	// the one the player is on are read past without sending them, so !seek reads the memo over from the start.
+	var err error
+	for seeked := true; seeked && err == nil; {
+		seeked = false
+		frame := 0
+		err = out.EachFrame(func(buff []byte) bool {
+			if frame++; frame <= int(gs.position.Load()) {
+				return true
+			}
+			if !gs.waitWhilePaused(ctx) {
+				return false
+			}
+			if d := gs.takeDuck(); d != nil {
+				select {
+				case <-d.ready:
+					overlay = d.frames
+				case <-ctx.Done():
+					missed = d
+					return false
+				}
+			}
+			if fade != nil && int(gs.position.Load()) >= fadeAt {
+				select {
+				case overlay = <-fade:
+					fading = overlay != nil
+				case <-ctx.Done():
+					return false
+				}
+				fade = nil
+			}
+			// Stop once the fade has been sent, or at the limit if it couldn't be made.
+			if (fading && len(overlay) == 0) || int(gs.position.Load()) >= end {
+				return false
+			}
+			if len(overlay) > 0 {
+				buff, overlay = overlay[0], overlay[1:]
+			}
+
+			if !send(buff) {
+				return false
+			}
+			gs.position.Add(1)
+			gs.remainingFrames.Add(-1)
+
+			// Speech and fades are made for the frames they cover, so seeking waits until they're over.
+			if len(overlay) == 0 && !fading {
+				if to, ok := gs.takeSeek(); ok {
+					gs.position.Store(int64(to))
+					gs.remainingFrames.Store(int64(end - to))
+					seeked = true
+					return false
+				}
+			}
+
+			if n := gs.relevels.Load(); n != relevels && releveled == nil && gs.Prepare != nil {
+				relevels = n
+				releveled = make(chan *VoiceMemo, 1)
+				go func(done chan<- *VoiceMemo) { done <- gs.Prepare(ctx, vm) }(releveled)
+			}
+			select {
+			case relevel = <-releveled:
+				releveled = nil
+			default:
+			}
+			// Carrying on from the same frame reads the new audio over from the start, like !seek.
+			if relevel != nil && len(overlay) == 0 && !fading && gs.replacePlaying(relevel) {
+				out, relevel = relevel, nil
+				seeked = true
+				return false
+			}
+			return true
+		})
+	}
+
+	// Speech can run on past the end of the memo.
+	for err == nil && !fading && len(overlay) > 0 && gs.waitWhilePaused(ctx) && send(overlay[0]) {
+		overlay = overlay[1:]
+	}
+	if d := gs.stopPlaying(); d != nil {
+		missed = d
+	}
+	// Speech the player didn't get to plays on its own next, unless everything was stopped.
+	if missed != nil && gs.stops.Load() == stops {
+		missed.fallback(gs)
+	}
+	if err != nil {
+		fmt.Println("Error playing ", vm.name, ": ", err)
+		if gs.OnError != nil {
+			gs.OnError(fmt.Errorf("playing %s: %w", vm.name, err))
+		}
+	}
+
+	// Give a stalled connection a moment to come back. Skipping or stopping still works meanwhile.
+	if stalled != nil {
+		select {
+		case <-time.After(playRetryDelay):
+		case <-ctx.Done():
+			stalled = nil
+		}
+	}
+	cut := ctx.Err() != nil
+	gs.setCurrent(nil, "")
+	gs.remainingFrames.Store(0)
+
+	// Sleep for a specificed amount of time before ending.
+	time.Sleep(playbackGap)
+	return cut, stalled
+}
+
+// Records the memo the player is on and returns a context that's cancelled when it's skipped.
+func (gs *GuildSession) setCurrent(vm *VoiceMemo, requesterID string) context.Context {
+	gs.currentMu.Lock()
+	defer gs.currentMu.Unlock()
+
+	if gs.cancelCurrent != nil {
+		gs.cancelCurrent()
+	}
+	gs.current, gs.currentBy, gs.cancelCurrent = vm, requesterID, nil
+	gs.Touch()
+	if vm == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	gs.cancelCurrent = cancel
+	return ctx
+}
+
+// Stops the memo that's playing so the player moves on to the next one in the queue.
+// Returns the skipped memo, or false if nothing was playing.
+func (gs *GuildSession) Skip() (*VoiceMemo, bool) {
+	gs.currentMu.Lock()
+	defer gs.currentMu.Unlock()
+
+	if gs.current == nil {
+		return nil, false
+	}
+	gs.cancelCurrent()
+	return gs.current, true
+}
+
+// Stops the memo that's playing and throws away everything queued after it. The player finds the queue
+// empty and stops speaking. Returns how many memos were stopped or dropped.
+func (gs *GuildSession) Stop() int {
+	// Empty the queue first so the player has nothing to move on to once the current memo is cut,
+	// and stop looping so nothing is put back.
+	gs.LoopOne.Store(false)
+	gs.LoopQueue.Store(false)
+	gs.stops.Add(1)
+	stopped := gs.flush()
+	gs.Resume()
+	if _, ok := gs.Skip(); ok {
+		stopped++
+	}
+	// Catch anything the player dequeued or someone queued in the meantime.
+	return stopped + gs.flush()
+}
+
+// Releases every memo waiting in the queue and returns how many there were.
+func (gs *GuildSession) flush() int {
+	flushed := 0
+	for _, entry := range gs.PlayQueue.Clear() {
+		flushed += gs.release(entry)
+	}
+	return flushed
+}
+
+// Releases the memos of an entry taken off the queue and returns how many there were.
+func (gs *GuildSession) release(entry QueueEntry) int {
+	gs.queuedFrames.Add(-int64(entry.Frames()))
+	for _, vm := range entry.Memos() {
+		vm.Release()
+	}
+	return len(entry.Memos())
+}
+
 func (gs *GuildSession) Disconnect() {
+	// Stop first, or the player could block forever sending to a connection that's gone.
+	gs.Stop()
 	gs.VoiceConnection.Disconnect()
+	gs.Close()
+}
+
+// Releases everything the session holds apart from the voice connection itself.
+func (gs *GuildSession) Close() {
+	gs.Receiver.Close()
+
+	// Let go of everything that never got played.
+	gs.flush()
 }
 
 type VoiceMemoManager struct {
-	Store map[string]*VoiceMemo
+	Metadata *MetadataStore
 	// db instance?
+
+	// Guards store and tombstones. Handlers run concurrently, so never touch either without it.
+	mu    sync.RWMutex
+	store map[string]*VoiceMemo
+
+	// Deleted memos that are still queued or playing somewhere, keyed by name.
+	tombstones map[string]*VoiceMemo
+
+	// Serializes adding files to the object store with removing them, so an upload can't lose a file
+	// that a deleted memo with the same audio is about to remove.
+	objectsMu sync.Mutex
+
+	// Previews and other audio derived from objects. Nil caches nothing.
+	Artifacts *ArtifactCache
 }
 
-func NewVoiceMemoManager() (*VoiceMemoManager, error) {
-	voiceMemoMap := make(map[string]*VoiceMemo)
+func NewVoiceMemoManager(metadata *MetadataStore) (*VoiceMemoManager, error) {
+	m := &VoiceMemoManager{
+		store:      make(map[string]*VoiceMemo),
+		Metadata:   metadata,
+		tombstones: make(map[string]*VoiceMemo),
+	}
 
-	// Read file names from disk for now. Will eventually query from db to get list of voice memos.
-	files, err := os.ReadDir("voicememo_files/")
-	if err != nil {
+	if err := os.MkdirAll(objectDir, 0755); err != nil {
 		fmt.Println(err)
 		return nil, err
 	}
-
-	for _, f := range files {
-		name := strings.Split(f.Name(), ".")[0]
-		vm := &VoiceMemo{name, make([][]byte, 0)}
-		voiceMemoMap[vm.name] = vm
+	if err := m.migrateLegacyFiles(); err != nil {
+		fmt.Println(err)
+		return nil, err
 	}
 
-	m := &VoiceMemoManager{
-		Store: voiceMemoMap,
+	// The metadata knows every memo's name. Will eventually query from db to get list of voice memos.
+	for _, md := range metadata.AllMemos() {
+		if md.Hash == "" {
+			continue
+		}
+		if _, err := os.Stat(ObjectPath(md.Hash)); err != nil {
+			fmt.Println("Error finding the audio of ", md.Name, ": ", err)
+			continue
+		}
+		m.store[md.Name] = &VoiceMemo{name: md.Name, hash: md.Hash, buffer: make([][]byte, 0)}
 	}
 	return m, nil
 }
 
 func (m *VoiceMemoManager) LoadAll() (err error) {
-	for _, voiceMemo := range m.Store {
+	for _, voiceMemo := range m.List() {
+		// Long-form memos would eat too much memory, they get streamed when played instead.
+		if m.Metadata.Memo(voiceMemo.name).Type == MemoTypeLongForm {
+			voiceMemo.streamed = true
+			voiceMemo.CountFrames()
+			continue
+		}
 		voiceMemo.Load()
 	}
 	return nil
 }
 
 func (m *VoiceMemoManager) Get(fileName string) *VoiceMemo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	// Try to find voiceMemo file in memory store.
-	if file, ok := m.Store[fileName]; ok {
+	if file, ok := m.store[fileName]; ok {
 		return file
 	}
 	return nil
 }
 
+// Adds a voice memo to the store, replacing any memo with the same name.
+// Fails if a memo with that name was deleted but is still waiting to be purged.
+func (m *VoiceMemoManager) Add(vm *VoiceMemo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.tombstones[vm.name]; ok {
+		return fmt.Errorf("%s is still being deleted", vm.name)
+	}
+	m.store[vm.name] = vm
+	return nil
+}
+
+// Removes a voice memo from the store without touching disk or metadata.
+func (m *VoiceMemoManager) Remove(name string) (*VoiceMemo, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	vm, ok := m.store[name]
+	if ok {
+		delete(m.store, name)
+	}
+	return vm, ok
+}
+
+// Returns every voice memo in the store, sorted by name.
+func (m *VoiceMemoManager) List() []*VoiceMemo {
+	m.mu.RLock()
+	memos := make([]*VoiceMemo, 0, len(m.store))
+	for _, vm := range m.store {
+		memos = append(memos, vm)
+	}
+	m.mu.RUnlock()
+
+	// Alphabetical regardless of case, so the order never changes between calls.
+	sort.Slice(memos, func(i, j int) bool {
+		a, b := strings.ToLower(memos[i].name), strings.ToLower(memos[j].name)
+		if a != b {
+			return a < b
+		}
+		return memos[i].name < memos[j].name
+	})
+	return memos
+}
+
+// Number of memos on each page of !list. Embeds can hold at most 25 fields.
+const listPageSize = 24
+
+// Orders !list can sort by. Names go A to Z, the rest biggest or newest first.
+var listSorts = []string{"name", "date", "plays", "size"}
+
+// Sorts memos for !list in a guild. Memos that tie stay in the order they came in, alphabetical from List.
+func (m *VoiceMemoManager) SortMemos(guildID string, memos []*VoiceMemo, order string) {
+	keys := make(map[string]int64, len(memos))
+	for _, vm := range memos {
+		switch order {
+		case "date":
+			keys[vm.name] = m.Metadata.Memo(vm.name).UploadedAt.UnixNano()
+		case "plays":
+			plays, _ := m.Metadata.GuildPlays(guildID, vm.name)
+			keys[vm.name] = int64(plays)
+		case "size":
+			if info, err := os.Stat(ObjectPath(vm.hash)); err == nil {
+				keys[vm.name] = info.Size()
+			}
+		}
+	}
+	sort.SliceStable(memos, func(i, j int) bool {
+		return keys[memos[i].name] > keys[memos[j].name]
+	})
+}
+
+// Returns the items on a 1-based page along with the total number of pages, which is at least 1.
+func Paginate[T any](items []T, page, perPage int) ([]T, int) {
+	pages := (len(items) + perPage - 1) / perPage
+	if pages == 0 {
+		pages = 1
+	}
+
+	start := (page - 1) * perPage
+	if start >= len(items) || start < 0 {
+		return nil, pages
+	}
+	end := start + perPage
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end], pages
+}
+
+// Returns the name of every voice memo in the store, sorted.
+func (m *VoiceMemoManager) Names() []string {
+	memos := m.List()
+	names := make([]string, len(memos))
+	for i, vm := range memos {
+		names[i] = vm.name
+	}
+	return names
+}
+
+// Gives a voice memo a new name. Audio is stored by hash, so only the store and the metadata change, and
+// both change together. Queues that already hold the memo play it under its old name.
+func (m *VoiceMemoManager) Rename(oldName, newName string) error {
+	if err := ValidateMemoName(newName); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	vm, ok := m.store[oldName]
+	if !ok {
+		return fmt.Errorf("cannot find %s", oldName)
+	}
+	if _, ok := m.store[newName]; ok {
+		return fmt.Errorf("there's already a memo called %s", newName)
+	}
+	if _, ok := m.tombstones[newName]; ok {
+		return fmt.Errorf("%s is still being deleted", newName)
+	}
+	if err := m.Metadata.RenameMemo(oldName, newName); err != nil {
+		return err
+	}
+
+	// Other goroutines read names without locking, so the renamed memo is a new VoiceMemo with the same audio.
+	delete(m.store, oldName)
+	m.store[newName] = &VoiceMemo{name: newName, hash: vm.hash, buffer: vm.buffer, streamed: vm.streamed, frames: vm.frames}
+	return nil
+}
+
+// Removes a voice memo from the store and the metadata store so it can't be played again.
+// If a guild session still has the memo queued or playing, the file is only removed from disk
+// once the last of them is done with it, in which case pending is true.
+func (m *VoiceMemoManager) Delete(name string) (pending bool, err error) {
+	m.mu.Lock()
+	vm, ok := m.store[name]
+	if !ok {
+		m.mu.Unlock()
+		return false, fmt.Errorf("cannot find %s", name)
+	}
+	delete(m.store, name)
+	m.tombstones[name] = vm
+	m.mu.Unlock()
+
+	if err := m.Metadata.RemoveMemo(name); err != nil {
+		fmt.Println("Error removing metadata of ", name, ": ", err)
+	}
+
+	purged := vm.Tombstone(func() {
+		// Other memos may have the same audio.
+		m.objectsMu.Lock()
+		m.releaseObject(vm.hash)
+		m.objectsMu.Unlock()
+
+		m.mu.Lock()
+		delete(m.tombstones, name)
+		m.mu.Unlock()
+	})
+	return !purged, nil
+}
+
+// Reports whether a deleted memo is still waiting on a guild session before its file can be removed.
+func (m *VoiceMemoManager) IsPendingDeletion(name string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.tombstones[name]
+	return ok
+}
+
+// Counts a play of each of the memos, in one write, and adds them to the guild's play history. A memo that's
+// queued several times is named once per copy.
+func (m *VoiceMemoManager) RecordPlay(guildID, userID string, names ...string) {
+	if len(names) == 0 {
+		return
+	}
+	now := time.Now()
+	plays := make([]PlayRecord, 0, len(names))
+	for _, name := range names {
+		plays = append(plays, PlayRecord{Memo: name, UserID: userID, At: now})
+	}
+	if err := m.Metadata.RecordPlay(guildID, plays...); err != nil {
+		fmt.Println("Error recording plays of ", strings.Join(names, ", "), ": ", err)
+	}
+}
+
 type VoiceMemo struct {
 	name   string
+	hash   string
 	buffer [][]byte
+
+	// Long-form memos are streamed from disk rather than held in buffer,
+	// so their length is counted once up front.
+	streamed bool
+	frames   int
+
+	// Number of guild session queues currently holding the memo, including the one playing it, and whether
+	// it has been deleted and purged. One lock covers them all, so a memo can't be acquired between being
+	// deleted and purged.
+	refMu      sync.Mutex
+	refs       int
+	tombstoned bool
+	purged     bool
+	purge      func()
+}
+
+// Takes a reference to the memo for a queue. Returns false if the memo has been deleted.
+func (vm *VoiceMemo) Acquire() bool {
+	vm.refMu.Lock()
+	defer vm.refMu.Unlock()
+
+	if vm.tombstoned {
+		return false
+	}
+	vm.refs++
+	return true
+}
+
+// Drops a reference taken by Acquire, purging the memo if it was deleted and this was the last reference.
+func (vm *VoiceMemo) Release() {
+	vm.refMu.Lock()
+	vm.refs--
+	purge := vm.duePurge()
+	vm.refMu.Unlock()
+
+	if purge != nil {
+		purge()
+	}
+}
+
+// Marks the memo as deleted. purge runs as soon as nothing references the memo, which may be right away.
+// Returns true if purge already ran.
+func (vm *VoiceMemo) Tombstone(purge func()) bool {
+	vm.refMu.Lock()
+	vm.purge = purge
+	vm.tombstoned = true
+	due := vm.duePurge()
+	vm.refMu.Unlock()
+
+	if due == nil {
+		return false
+	}
+	due()
+	return true
+}
+
+// Returns the purge to run if the memo was deleted, nothing references it and it hasn't been purged yet, and
+// marks it purged. Callers must hold vm.refMu and run it once they've let go.
+func (vm *VoiceMemo) duePurge() func() {
+	if !vm.tombstoned || vm.refs > 0 || vm.purged {
+		return nil
+	}
+	vm.purged = true
+	return vm.purge
+}
+
+// Returns how many queues hold the memo.
+func (vm *VoiceMemo) Refs() int {
+	vm.refMu.Lock()
+	defer vm.refMu.Unlock()
+	return vm.refs
+}
+
+// Reports whether the memo has been deleted, even if it's still waiting for its queues to let go of it.
+func (vm *VoiceMemo) Tombstoned() bool {
+	vm.refMu.Lock()
+	defer vm.refMu.Unlock()
+	return vm.tombstoned
 }
 
 // Attempts to load an encoded voiceMemo file from disk.
 func (vm *VoiceMemo) Load() error {
-	extension := ".dca"
-	file, err := os.Open("voicememo_files/" + vm.name + extension)
+	file, err := os.Open(ObjectPath(vm.hash))
 	if err != nil {
 		fmt.Println("Error opening dca file :", err)
 		return err
 	}
+	defer file.Close()
 
-	var opuslen int16
+	frames, err := ReadDCA(file)
+	if err != nil {
+		return err
+	}
+
+	// Append encoded pcm data to the buffer.
+	vm.buffer = append(vm.buffer, frames...)
+	return nil
+}
 
+// Reads every opus frame from a dca stream.
+func ReadDCA(r io.Reader) ([][]byte, error) {
+	frames := make([][]byte, 0)
 	for {
-		// Read opus frame length from dca file.
-		err = binary.Read(file, binary.LittleEndian, &opuslen)
-
-		// If this is the end of the file, just return.
-		if err == io.EOF || err == io.ErrUnexpectedEOF {
-			err := file.Close()
-			if err != nil {
-				return err
-			}
-			return nil
+		frame, err := ReadDCAFrame(r)
+		if err == io.EOF {
+			return frames, nil
 		}
-
 		if err != nil {
-			fmt.Println("Error reading from dca file1 :", err)
-			return err
+			return nil, err
+		}
+		frames = append(frames, frame)
+	}
+}
+
+// Reads the next opus frame from a dca stream. Returns io.EOF once the stream is done.
+func ReadDCAFrame(r io.Reader) ([]byte, error) {
+	var opuslen int16
+
+	// Read opus frame length from dca file.
+	err := binary.Read(r, binary.LittleEndian, &opuslen)
+
+	// If this is the end of the file, just return.
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, io.EOF
+	}
+
+	if err != nil {
+		fmt.Println("Error reading from dca file1 :", err)
+		return nil, err
+	}
+
+	// Read encoded pcm from dca file.
+	IntBuf := make([]byte, opuslen)
+	err = binary.Read(r, binary.LittleEndian, &IntBuf)
+
+	// Should not be any end of file errors.
+	if err != nil {
+		fmt.Println("Error reading from dca file2 :", err)
+		return nil, err
+	}
+	return IntBuf, nil
+}
+
+// Every opus frame is 20ms of audio.
+const frameDuration = 20 * time.Millisecond
+
+// Number of opus frames in the memo.
+func (vm *VoiceMemo) Frames() int {
+	if vm.streamed {
+		return vm.frames
+	}
+	return len(vm.buffer)
+}
+
+// How long the memo takes to play.
+func (vm *VoiceMemo) Duration() time.Duration {
+	return time.Duration(vm.Frames()) * frameDuration
+}
+
+// Counts the frames of a streamed memo by reading through its file.
+func (vm *VoiceMemo) CountFrames() error {
+	count := 0
+	err := vm.EachFrame(func(frame []byte) bool {
+		count++
+		return true
+	})
+	vm.frames = count
+	return err
+}
+
+// Calls fn with each opus frame of the memo in order until fn returns false.
+// Long-form memos aren't kept in memory, so they are streamed from disk instead.
+func (vm *VoiceMemo) EachFrame(fn func(frame []byte) bool) error {
+	if !vm.streamed {
+		for _, frame := range vm.buffer {
+			if !fn(frame) {
+				return nil
+			}
 		}
+		return nil
+	}
 
-		// Read encoded pcm from dca file.
-		IntBuf := make([]byte, opuslen)
-		err = binary.Read(file, binary.LittleEndian, &IntBuf)
+	file, err := os.Open(ObjectPath(vm.hash))
+	if err != nil {
+		fmt.Println("Error opening dca file :", err)
+		return err
+	}
+	defer file.Close()
 
-		// Should not be any end of file errors.
+	r := bufio.NewReader(file)
+	for {
+		frame, err := ReadDCAFrame(r)
+		if err == io.EOF {
+			return nil
+		}
 		if err != nil {
-			fmt.Println("Error reading from dca file2 :", err)
 			return err
 		}
-
-		// Append encoded pcm data to the buffer.
-		vm.buffer = append(vm.buffer, IntBuf)
+		if !fn(frame) {
+			return nil
+		}
 	}
 }
@@ -0,0 +1,70 @@
+package main
+
+import "testing"
+
+func TestVoiceMemoTombstone(t *testing.T) {
+	tests := []struct {
+		name string
+		// References taken before the memo is deleted, and released after.
+		acquired, released int
+		wantPurged         bool
+	}{
+		{"nothing queued", 0, 0, true},
+		{"still queued", 1, 0, false},
+		{"finished playing", 1, 1, true},
+		{"one of two finished", 2, 1, false},
+		{"both finished", 2, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vm := &VoiceMemo{name: "bruh"}
+			for i := 0; i < tt.acquired; i++ {
+				if !vm.Acquire() {
+					t.Fatal("couldn't acquire a memo that wasn't deleted")
+				}
+			}
+			purges := 0
+			vm.Tombstone(func() { purges++ })
+			if vm.Acquire() {
+				t.Error("acquired a deleted memo")
+			}
+			for i := 0; i < tt.released; i++ {
+				vm.Release()
+			}
+			if want := map[bool]int{false: 0, true: 1}[tt.wantPurged]; purges != want {
+				t.Errorf("purged %d times, want %d", purges, want)
+			}
+		})
+	}
+}
+
+func TestParseRepeat(t *testing.T) {
+	tests := []struct {
+		arg    string
+		want   int
+		wantOK bool
+	}{
+		{"x3", 3, true},
+		{"3x", 3, true},
+		{"X3", 3, true},
+		{"*3", 3, true},
+		{"×3", 3, true},
+		{"x1", 1, true},
+		{"x10", maxPlayRepeat, true},
+		{"x11", 0, false},
+		{"x0", 0, false},
+		{"x-2", 0, false},
+		{"x", 0, false},
+		{"", 0, false},
+		{"bruh", 0, false},
+		{"x3.5", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, ok := ParseRepeat(tt.arg)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseRepeat(%q) = %d, %v, want %d, %v", tt.arg, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How long memos take to fade out when they reach the guild's !maxplay.
+const maxPlayFade = time.Second
+
+// Returns how long memos may play in the guild before they're faded out, or 0 if they may play in full.
+func (gs GuildSettings) MaxPlayback() time.Duration {
+	return time.Duration(gs.MaxPlaySeconds) * time.Second
+}
+
+// Takes a -flag or --flag option off a command's arguments, wherever it is, and reports whether it was there.
+func takeFlag(args []string, name string) (bool, []string) {
+	found, rest := false, make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "-"+name || arg == "--"+name {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return found, rest
+}
+
+// Re-encodes the second before frame end of vm fading out, for the player to send in place of it. Sends nil
+// if that can't be done, and the memo is cut off without a fade.
+func FadeOut(ctx context.Context, vm *VoiceMemo, end int) <-chan [][]byte {
+	faded := make(chan [][]byte, 1)
+	go func() {
+		start := end - int(maxPlayFade/frameDuration)
+		if start < 0 {
+			start = 0
+		}
+		tail := make([][]byte, 0, end-start)
+		i := 0
+		err := vm.EachFrame(func(frame []byte) bool {
+			if i >= start {
+				tail = append(tail, frame)
+			}
+			i++
+			return i < end
+		})
+
+		var ogg bytes.Buffer
+		if err == nil {
+			err = (&VoiceMemo{name: vm.name, buffer: tail}).WriteOgg(&ogg)
+		}
+		var frames [][]byte
+		if err == nil {
+			seconds := float64(len(tail)) * frameDuration.Seconds()
+			frames, err = EncodeDCA(ctx, ogg.Bytes(), vm.Channels(), fmt.Sprintf("afade=t=out:st=0:d=%.2f", seconds))
+		}
+		if err != nil && ctx.Err() == nil {
+			fmt.Println("Error fading out ", vm.name, ": ", err)
+		}
+		faded <- frames
+	}()
+	return faded
+}
+
+func (b *Bot) HandleMaxPlay(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		limit := b.VoiceMemoManager.Metadata.Guild(g.ID).MaxPlayback()
+		if limit == 0 {
+			s.ChannelMessageSend(c.ID, "Voice memos play in full in "+g.Name)
+			return
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Voice memos fade out after %s in %s.", FormatDuration(limit), g.Name))
+		return
+	}
+
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can change how long voice memos play.")
+		return
+	}
+
+	seconds, err := strconv.Atoi(strings.TrimSuffix(args[0], "s"))
+	if args[0] == "off" {
+		seconds, err = 0, nil
+	}
+	if err != nil || seconds < 0 {
+		s.ChannelMessageSend(c.ID, "Usage: !maxplay [<seconds>|off]")
+		return
+	}
+
+	err = b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		gs.MaxPlaySeconds = seconds
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+
+	if seconds == 0 {
+		s.ChannelMessageSend(c.ID, "Voice memos play in full again.")
+		b.Audit(s, g.ID, fmt.Sprintf("<@%s> let voice memos play in full.", m.Author.ID))
+		return
+	}
+	limit := FormatDuration(time.Duration(seconds) * time.Second)
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("Voice memos fade out after %s from the next one on. Admins and DJs can !play -full <name> to hear one in full.", limit))
+	b.Audit(s, g.ID, fmt.Sprintf("<@%s> made voice memos fade out after %s.", m.Author.ID, limit))
+}
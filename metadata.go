@@ -0,0 +1,837 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// What a memo is for. Soundboard memos are short clips kept in memory; long-form memos
+// (recordings, podcasts, ...) are streamed from disk and left out of random selection.
+type MemoType string
+
+const (
+	MemoTypeSoundboard MemoType = ""
+	MemoTypeLongForm   MemoType = "longform"
+)
+
+// MemoMetadata holds everything we know about a voice memo that isn't part of the encoded audio itself.
+type MemoMetadata struct {
+	Name       string    `json:"name"`
+	GuildID    string    `json:"guild_id,omitempty"`
+	UploaderID string    `json:"uploader_id,omitempty"`
+	UploadedAt time.Time `json:"uploaded_at"`
+	PlayCount  int       `json:"play_count"`
+	Type       MemoType  `json:"type,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+
+	// SHA-256 of the encoded audio, which is also where it's stored. See ObjectPath.
+	Hash string `json:"hash,omitempty"`
+
+	// Chromaprint fingerprint of the uploaded audio, used to spot near-duplicate uploads.
+	Fingerprint []uint32 `json:"fingerprint,omitempty"`
+
+	// Context or credit for the memo, set with !describe.
+	Description string `json:"description,omitempty"`
+
+	// Jump link to the message the memo was uploaded from.
+	MessageLink string `json:"message_link,omitempty"`
+
+	// Integrated loudness of the encoded audio in LUFS. Zero if it hasn't been measured yet.
+	Loudness float64 `json:"loudness,omitempty"`
+
+	// What's said in the memo, if it was transcribed with -transcribe-memos. !warnwords checks it.
+	Transcript string `json:"transcript,omitempty"`
+}
+
+// Reports whether the memo can be picked by automatic selection (random, triggers, chaos mode)
+// without being asked for by name.
+func (md MemoMetadata) AutoSelectable() bool {
+	return md.Type != MemoTypeLongForm
+}
+
+// Reports whether the memo belongs to a guild other than guildID, so that guild's admins can't edit or delete
+// it. Memos from before memos belonged to a guild belong to none.
+func (md MemoMetadata) OtherGuild(guildID string) bool {
+	return md.GuildID != "" && md.GuildID != guildID
+}
+
+// GuildSettings holds the per-guild configuration that admins can change at runtime.
+type GuildSettings struct {
+	// Maximum number of memos the guild may upload. Zero falls back to the bot-wide default.
+	MaxMemos int `json:"max_memos,omitempty"`
+
+	// What commands start with, set with !prefix. Empty uses defaultPrefix.
+	Prefix string `json:"prefix,omitempty"`
+
+	// How long memos play before they fade out, set with !maxplay. Zero plays them in full.
+	MaxPlaySeconds int `json:"max_play_seconds,omitempty"`
+
+	// Voice !say uses when none is given. Empty uses the TTS provider's default.
+	Voice string `json:"voice,omitempty"`
+
+	// How new uploads are encoded.
+	Preset ConversionPreset `json:"preset"`
+
+	// Join the busiest voice channel once it has this many people in it. Zero turns auto-join off.
+	AutoJoin int `json:"auto_join,omitempty"`
+
+	// How long the bot stays in a voice channel with nothing playing, set with !idle. Nil uses
+	// defaultIdleTimeout, zero stays until !leave.
+	IdleSeconds *int `json:"idle_seconds,omitempty"`
+
+	// Channel that repeated failures affecting the guild are posted to. Empty turns alerts off.
+	ErrorChannel string `json:"error_channel,omitempty"`
+
+	// Channel that admin actions like !cleanup are logged to. Empty turns logging off.
+	AuditChannel string `json:"audit_channel,omitempty"`
+
+	// Names of the packs from other guilds whose memos show up in this guild's library.
+	Subscriptions []string `json:"subscriptions,omitempty"`
+
+	// Memos played by posting or reacting with an emoji, keyed by EmojiKey.
+	EmojiBindings map[string]EmojiBinding `json:"emoji_bindings,omitempty"`
+
+	// IDs of the roles allowed to play a memo, by memo name. Memos that aren't listed are open to everyone.
+	Restrictions map[string][]string `json:"restrictions,omitempty"`
+
+	// When memos may be played, set with !window, by memo name. Memos that aren't listed can be played any time.
+	Windows map[string]Window `json:"windows,omitempty"`
+
+	// Words and phrases that give memos saying them a content warning, set with !warnwords. BlockWarned keeps
+	// those memos out of !random and emoji bindings.
+	WarnWords   []string `json:"warn_words,omitempty"`
+	BlockWarned bool     `json:"block_warned,omitempty"`
+
+	// Temporary roles handed out with !grant, by user ID.
+	Grants map[string]Grant `json:"grants,omitempty"`
+
+	// Loudness in LUFS that memos are turned up or down to when they play. Zero plays them as uploaded.
+	TargetLoudness float64 `json:"target_loudness,omitempty"`
+
+	// What the bot says and plays when it joins and leaves.
+	Greeting Greeting `json:"greeting"`
+
+	// Who auto-join ignores, set with !intro exempt.
+	IntroExempt Exemptions `json:"intro_exempt"`
+
+	// Percentage memos are scaled by when they play, set with !volume. Nil plays them at 100%.
+	Volume *int `json:"volume,omitempty"`
+
+	// Range the 🔉 and 🔊 reactions on the now playing message turn Volume within. Nil uses defaultVolumeBounds.
+	VolumeReactions *VolumeBounds `json:"volume_reactions,omitempty"`
+
+	// Percentage memos are turned down to while !say talks over them, set with !duck. Nil uses defaultDuck.
+	Duck *int `json:"duck,omitempty"`
+
+	// How many of the latest plays !random stays away from, set with !random avoid. Nil uses defaultRandomAvoid.
+	RandomAvoid *int `json:"random_avoid,omitempty"`
+
+	// What happens when a new memo is given a name that's taken, set with !namepolicy. Empty rejects it.
+	NamePolicy NamePolicy `json:"name_policy,omitempty"`
+
+	// Short names for memos, set with !alias, mapping each alias to the memo it plays.
+	Aliases map[string]string `json:"aliases,omitempty"`
+
+	// Whether prefix commands get a tip pointing to their slash command, set with !nudge. Nil follows
+	// -slash-nudge. NudgeMessage replaces the tip, with {slash} standing for the slash command.
+	SlashNudge   *bool  `json:"slash_nudge,omitempty"`
+	NudgeMessage string `json:"nudge_message,omitempty"`
+
+	// Memos on the guild's !soundboard, in the order their buttons are shown.
+	Soundboard []string `json:"soundboard,omitempty"`
+
+	// How long each person has to wait between uses of a command, in seconds by command name, set with !cooldown.
+	CommandCooldowns map[string]int `json:"command_cooldowns,omitempty"`
+
+	// Features the bot's owners turned on or off for the guild with !feature. Ones that aren't listed follow -features.
+	Features map[string]bool `json:"features,omitempty"`
+}
+
+// Returns the guild's !volume as a percentage.
+func (gs GuildSettings) VolumePercent() int {
+	if gs.Volume == nil {
+		return defaultVolume
+	}
+	return *gs.Volume
+}
+
+// Returns how many of the latest plays !random stays away from.
+func (gs GuildSettings) RandomAvoidCount() int {
+	if gs.RandomAvoid == nil {
+		return defaultRandomAvoid
+	}
+	return *gs.RandomAvoid
+}
+
+// Returns a copy that shares nothing with gs.
+func (gs GuildSettings) clone() GuildSettings {
+	if gs.EmojiBindings != nil {
+		bindings := make(map[string]EmojiBinding, len(gs.EmojiBindings))
+		for k, v := range gs.EmojiBindings {
+			v.Pool = append([]PoolMemo(nil), v.Pool...)
+			bindings[k] = v
+		}
+		gs.EmojiBindings = bindings
+	}
+	if gs.Aliases != nil {
+		aliases := make(map[string]string, len(gs.Aliases))
+		for k, v := range gs.Aliases {
+			aliases[k] = v
+		}
+		gs.Aliases = aliases
+	}
+	gs.Subscriptions = append([]string(nil), gs.Subscriptions...)
+	if gs.WarnWords != nil {
+		gs.WarnWords = append([]string(nil), gs.WarnWords...)
+	}
+	if gs.Soundboard != nil {
+		gs.Soundboard = append([]string(nil), gs.Soundboard...)
+	}
+	gs.IntroExempt = gs.IntroExempt.clone()
+	if gs.Restrictions != nil {
+		restrictions := make(map[string][]string, len(gs.Restrictions))
+		for k, v := range gs.Restrictions {
+			restrictions[k] = append([]string(nil), v...)
+		}
+		gs.Restrictions = restrictions
+	}
+	if gs.Windows != nil {
+		windows := make(map[string]Window, len(gs.Windows))
+		for k, v := range gs.Windows {
+			v.Months = append([]time.Month(nil), v.Months...)
+			v.Weekdays = append([]time.Weekday(nil), v.Weekdays...)
+			windows[k] = v
+		}
+		gs.Windows = windows
+	}
+	if gs.Grants != nil {
+		grants := make(map[string]Grant, len(gs.Grants))
+		for k, v := range gs.Grants {
+			grants[k] = v
+		}
+		gs.Grants = grants
+	}
+	if gs.CommandCooldowns != nil {
+		cooldowns := make(map[string]int, len(gs.CommandCooldowns))
+		for k, v := range gs.CommandCooldowns {
+			cooldowns[k] = v
+		}
+		gs.CommandCooldowns = cooldowns
+	}
+	if gs.Features != nil {
+		features := make(map[string]bool, len(gs.Features))
+		for k, v := range gs.Features {
+			features[k] = v
+		}
+		gs.Features = features
+	}
+	return gs
+}
+
+// How many plays each guild's history keeps.
+const maxHistory = 500
+
+// One play of a memo in a guild.
+type PlayRecord struct {
+	Memo   string    `json:"memo"`
+	UserID string    `json:"user_id"`
+	At     time.Time `json:"at"`
+}
+
+// A guild's voice sessions added up.
+type VoiceStats struct {
+	Sessions    int           `json:"sessions"`
+	Connected   time.Duration `json:"connected"`
+	FramesSent  int64         `json:"frames_sent"`
+	MemosPlayed int           `json:"memos_played"`
+
+	// How many sessions each user was around to hear a memo in, by user ID.
+	Listeners map[string]int `json:"listeners,omitempty"`
+
+	// How many times each memo was played in the guild, by memo name. Unlike the history this never forgets.
+	Plays map[string]int `json:"plays,omitempty"`
+}
+
+func (vs VoiceStats) clone() VoiceStats {
+	listeners := make(map[string]int, len(vs.Listeners))
+	for k, v := range vs.Listeners {
+		listeners[k] = v
+	}
+	vs.Listeners = listeners
+	plays := make(map[string]int, len(vs.Plays))
+	for k, v := range vs.Plays {
+		plays[k] = v
+	}
+	vs.Plays = plays
+	return vs
+}
+
+// MetadataStore persists memo metadata and guild settings as a single JSON document on disk.
+// Will eventually be replaced by a db.
+type MetadataStore struct {
+	mu     sync.Mutex
+	path   string
+	Memos  map[string]*MemoMetadata  `json:"memos"`
+	Guilds map[string]*GuildSettings `json:"guilds"`
+
+	// Each guild's most recent plays, oldest first.
+	History map[string]*PlayHistory `json:"history"`
+
+	// Sound packs by name.
+	Packs map[string]*SoundPack `json:"packs"`
+
+	// Voice session stats by guild ID.
+	Stats map[string]*VoiceStats `json:"stats"`
+
+	// Each guild's playlists by name, by guild ID.
+	Playlists map[string]map[string]*Playlist `json:"playlists"`
+
+	// State of the buttons and menus that need more than their custom ID to keep working, by "<kind>:<id>".
+	Components map[string]*ComponentState `json:"components,omitempty"`
+
+	// When the cooldowns that were running at the last save run out, by key, so a restart doesn't reset them.
+	Cooldowns map[string]time.Time `json:"cooldowns,omitempty"`
+
+	// Why the last save failed, or nil if it worked.
+	saveErr error
+}
+
+func NewMetadataStore(path string) (*MetadataStore, error) {
+	ms := &MetadataStore{
+		path:    path,
+		Memos:   make(map[string]*MemoMetadata),
+		Guilds:  make(map[string]*GuildSettings),
+		History: make(map[string]*PlayHistory),
+		Packs:   make(map[string]*SoundPack),
+		Stats:   make(map[string]*VoiceStats),
+
+		Playlists:  make(map[string]map[string]*Playlist),
+		Components: make(map[string]*ComponentState),
+		Cooldowns:  make(map[string]time.Time),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Nothing has been saved yet.
+		return ms, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, ms); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if ms.Memos == nil {
+		ms.Memos = make(map[string]*MemoMetadata)
+	}
+	if ms.Guilds == nil {
+		ms.Guilds = make(map[string]*GuildSettings)
+	}
+	if ms.History == nil {
+		ms.History = make(map[string]*PlayHistory)
+	}
+	if ms.Packs == nil {
+		ms.Packs = make(map[string]*SoundPack)
+	}
+	if ms.Stats == nil {
+		ms.Stats = make(map[string]*VoiceStats)
+	}
+	if ms.Playlists == nil {
+		ms.Playlists = make(map[string]map[string]*Playlist)
+	}
+	if ms.Components == nil {
+		ms.Components = make(map[string]*ComponentState)
+	}
+	if ms.Cooldowns == nil {
+		ms.Cooldowns = make(map[string]time.Time)
+	}
+	return ms, nil
+}
+
+// Reload replaces the store's contents with what's on disk, for instances that share the file with the bot.
+func (ms *MetadataStore) Reload() error {
+	fresh, err := NewMetadataStore(ms.path)
+	if err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.Memos, ms.Guilds, ms.History, ms.Packs, ms.Stats = fresh.Memos, fresh.Guilds, fresh.History, fresh.Packs, fresh.Stats
+	ms.Playlists, ms.Components, ms.Cooldowns = fresh.Playlists, fresh.Components, fresh.Cooldowns
+	return nil
+}
+
+// Saves the store to disk. Writes to a temp file first so a crash can't leave a half written document behind.
+// Callers must hold ms.mu.
+func (ms *MetadataStore) save() error {
+	ms.saveErr = ms.write()
+	return ms.saveErr
+}
+
+// Returns why the last save failed, or nil if it worked.
+func (ms *MetadataStore) SaveError() error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.saveErr
+}
+
+// Saves the store again, e.g. after a save failed, so changes that only made it into memory reach the disk.
+func (ms *MetadataStore) Resave() error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return ms.save()
+}
+
+func (ms *MetadataStore) write() error {
+	data, err := json.MarshalIndent(ms, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := ms.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ms.path)
+}
+
+// Memo returns a copy of the metadata for a memo, creating an empty record if none exists yet.
+func (ms *MetadataStore) Memo(name string) MemoMetadata {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if md, ok := ms.Memos[name]; ok {
+		return *md
+	}
+	return MemoMetadata{Name: name}
+}
+
+// UpdateMemo applies fn to the memo's metadata record and persists the result.
+func (ms *MetadataStore) UpdateMemo(name string, fn func(md *MemoMetadata)) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	md, ok := ms.Memos[name]
+	if !ok {
+		md = &MemoMetadata{Name: name}
+		ms.Memos[name] = md
+	}
+	fn(md)
+	return ms.save()
+}
+
+// RemoveMemo forgets everything about a memo, including which packs it was in, who may play it, which emoji
+// play it and whether it greets, so a new memo by the same name starts out clean.
+func (ms *MetadataStore) RemoveMemo(name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.Memos, name)
+	for _, pack := range ms.Packs {
+		pack.Remove(name)
+	}
+	for _, gs := range ms.Guilds {
+		delete(gs.Restrictions, name)
+		delete(gs.Windows, name)
+		for alias, memo := range gs.Aliases {
+			if memo == name {
+				delete(gs.Aliases, alias)
+			}
+		}
+		kept := gs.Soundboard[:0]
+		for _, memo := range gs.Soundboard {
+			if memo != name {
+				kept = append(kept, memo)
+			}
+		}
+		gs.Soundboard = kept
+		for key, binding := range gs.EmojiBindings {
+			if binding.dropMemo(name) {
+				delete(gs.EmojiBindings, key)
+			} else {
+				gs.EmojiBindings[key] = binding
+			}
+		}
+		if gs.Greeting.JoinMemo == name {
+			gs.Greeting.JoinMemo = ""
+		}
+		if gs.Greeting.LeaveMemo == name {
+			gs.Greeting.LeaveMemo = ""
+		}
+	}
+	for _, playlists := range ms.Playlists {
+		for _, pl := range playlists {
+			pl.Remove(name)
+		}
+	}
+	for _, stats := range ms.Stats {
+		delete(stats.Plays, name)
+	}
+	return ms.save()
+}
+
+// RenameMemo moves a memo's metadata to a new name, along with every pack, playlist, restriction, binding,
+// alias, soundboard and greeting that refers to it and its play history. Fails if newName is taken.
+func (ms *MetadataStore) RenameMemo(oldName, newName string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.Memos[newName]; ok {
+		return fmt.Errorf("%s is already taken", newName)
+	}
+	rename := func(names []string) {
+		for i, name := range names {
+			if name == oldName {
+				names[i] = newName
+			}
+		}
+	}
+
+	if md, ok := ms.Memos[oldName]; ok {
+		delete(ms.Memos, oldName)
+		md.Name = newName
+		ms.Memos[newName] = md
+	}
+	for _, pack := range ms.Packs {
+		rename(pack.Memos)
+	}
+	for _, playlists := range ms.Playlists {
+		for _, pl := range playlists {
+			rename(pl.Memos)
+		}
+	}
+	for _, gs := range ms.Guilds {
+		if roles, ok := gs.Restrictions[oldName]; ok {
+			delete(gs.Restrictions, oldName)
+			gs.Restrictions[newName] = roles
+		}
+		if w, ok := gs.Windows[oldName]; ok {
+			delete(gs.Windows, oldName)
+			gs.Windows[newName] = w
+		}
+		for key, binding := range gs.EmojiBindings {
+			if binding.Memo == oldName {
+				binding.Memo = newName
+			}
+			for i, pm := range binding.Pool {
+				if pm.Memo == oldName {
+					binding.Pool[i].Memo = newName
+				}
+			}
+			gs.EmojiBindings[key] = binding
+		}
+		for alias, memo := range gs.Aliases {
+			if memo == oldName {
+				gs.Aliases[alias] = newName
+			}
+		}
+		rename(gs.Soundboard)
+		if gs.Greeting.JoinMemo == oldName {
+			gs.Greeting.JoinMemo = newName
+		}
+		if gs.Greeting.LeaveMemo == oldName {
+			gs.Greeting.LeaveMemo = newName
+		}
+	}
+	for _, stats := range ms.Stats {
+		if plays, ok := stats.Plays[oldName]; ok {
+			delete(stats.Plays, oldName)
+			stats.Plays[newName] = plays
+		}
+	}
+	for _, history := range ms.History {
+		history.Update(func(play *PlayRecord) {
+			if play.Memo == oldName {
+				play.Memo = newName
+			}
+		})
+	}
+	return ms.save()
+}
+
+// GuildMemos returns copies of the metadata for every memo uploaded in a guild.
+func (ms *MetadataStore) GuildMemos(guildID string) []MemoMetadata {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	memos := make([]MemoMetadata, 0)
+	for _, md := range ms.Memos {
+		if md.GuildID == guildID {
+			memos = append(memos, *md)
+		}
+	}
+	return memos
+}
+
+// AllMemos returns copies of the metadata for every memo.
+func (ms *MetadataStore) AllMemos() []MemoMetadata {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	memos := make([]MemoMetadata, 0, len(ms.Memos))
+	for _, md := range ms.Memos {
+		memos = append(memos, *md)
+	}
+	return memos
+}
+
+// HashInUse reports whether any memo's audio is stored under hash.
+func (ms *MetadataStore) HashInUse(hash string) bool {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, md := range ms.Memos {
+		if md.Hash == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordPlay counts plays of memos, both overall and in the guild, and adds them to the guild's history.
+// However many there are, the store is written once.
+func (ms *MetadataStore) RecordPlay(guildID string, plays ...PlayRecord) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	stats, ok := ms.Stats[guildID]
+	if !ok {
+		stats = &VoiceStats{}
+		ms.Stats[guildID] = stats
+	}
+	if stats.Plays == nil {
+		stats.Plays = make(map[string]int)
+	}
+	if ms.History[guildID] == nil {
+		ms.History[guildID] = &PlayHistory{}
+	}
+
+	for _, play := range plays {
+		md, ok := ms.Memos[play.Memo]
+		if !ok {
+			md = &MemoMetadata{Name: play.Memo}
+			ms.Memos[play.Memo] = md
+		}
+		md.PlayCount++
+		stats.Plays[play.Memo]++
+		ms.History[guildID].Add(play)
+	}
+	return ms.save()
+}
+
+// AddSession adds a finished voice session to the guild's stats.
+func (ms *MetadataStore) AddSession(guildID string, summary SessionSummary) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	stats, ok := ms.Stats[guildID]
+	if !ok {
+		stats = &VoiceStats{}
+		ms.Stats[guildID] = stats
+	}
+	if stats.Listeners == nil {
+		stats.Listeners = make(map[string]int)
+	}
+	stats.Sessions++
+	stats.Connected += summary.Connected
+	stats.FramesSent += summary.Frames
+	stats.MemosPlayed += summary.Memos
+	for _, id := range summary.Listeners {
+		stats.Listeners[id]++
+	}
+	return ms.save()
+}
+
+// GuildStats returns a copy of a guild's voice session stats.
+func (ms *MetadataStore) GuildStats(guildID string) VoiceStats {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if stats, ok := ms.Stats[guildID]; ok {
+		return stats.clone()
+	}
+	return VoiceStats{}.clone()
+}
+
+// Playlist returns a copy of one of a guild's playlists, or false if it has none by that name.
+func (ms *MetadataStore) Playlist(guildID, name string) (Playlist, bool) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if pl, ok := ms.Playlists[guildID][name]; ok {
+		return pl.clone(), true
+	}
+	return Playlist{}, false
+}
+
+// GuildPlaylists returns copies of a guild's playlists.
+func (ms *MetadataStore) GuildPlaylists(guildID string) []Playlist {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	playlists := make([]Playlist, 0, len(ms.Playlists[guildID]))
+	for _, pl := range ms.Playlists[guildID] {
+		playlists = append(playlists, pl.clone())
+	}
+	return playlists
+}
+
+// UpdatePlaylist applies fn to one of a guild's playlists, creating it first if it doesn't exist, and saves.
+func (ms *MetadataStore) UpdatePlaylist(guildID, name string, fn func(pl *Playlist)) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	playlists, ok := ms.Playlists[guildID]
+	if !ok {
+		playlists = make(map[string]*Playlist)
+		ms.Playlists[guildID] = playlists
+	}
+	pl, ok := playlists[name]
+	if !ok {
+		pl = &Playlist{Name: name}
+		playlists[name] = pl
+	}
+	fn(pl)
+	pl.UpdatedAt = time.Now()
+	return ms.save()
+}
+
+// RemovePlaylist deletes one of a guild's playlists and saves.
+func (ms *MetadataStore) RemovePlaylist(guildID, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.Playlists[guildID], name)
+	return ms.save()
+}
+
+// Pack returns a copy of a sound pack, or false if there's no pack by that name.
+func (ms *MetadataStore) Pack(name string) (SoundPack, bool) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if pack, ok := ms.Packs[name]; ok {
+		return pack.clone(), true
+	}
+	return SoundPack{}, false
+}
+
+// AllPacks returns copies of every sound pack.
+func (ms *MetadataStore) AllPacks() []SoundPack {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	packs := make([]SoundPack, 0, len(ms.Packs))
+	for _, pack := range ms.Packs {
+		packs = append(packs, pack.clone())
+	}
+	return packs
+}
+
+// UpdatePack applies fn to a sound pack, creating it if it doesn't exist yet, and persists the result.
+func (ms *MetadataStore) UpdatePack(name string, fn func(pack *SoundPack)) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	pack, ok := ms.Packs[name]
+	if !ok {
+		pack = &SoundPack{Name: name, Memos: make([]string, 0)}
+		ms.Packs[name] = pack
+	}
+	fn(pack)
+	pack.UpdatedAt = time.Now()
+	return ms.save()
+}
+
+// RemovePack deletes a sound pack and every guild's subscription to it.
+func (ms *MetadataStore) RemovePack(name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.Packs, name)
+	ms.unsubscribeAll(name)
+	return ms.save()
+}
+
+// Drops every guild's subscription to a pack. Callers must hold ms.mu.
+func (ms *MetadataStore) unsubscribeAll(pack string) {
+	for _, gs := range ms.Guilds {
+		kept := gs.Subscriptions[:0]
+		for _, sub := range gs.Subscriptions {
+			if sub != pack {
+				kept = append(kept, sub)
+			}
+		}
+		gs.Subscriptions = kept
+	}
+}
+
+// UnpublishPack hides a sound pack from other guilds and drops their subscriptions to it.
+func (ms *MetadataStore) UnpublishPack(name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	pack, ok := ms.Packs[name]
+	if !ok {
+		return fmt.Errorf("there's no pack called %s", name)
+	}
+	pack.Published = false
+	pack.UpdatedAt = time.Now()
+	ms.unsubscribeAll(name)
+	return ms.save()
+}
+
+// ExpireGrants removes every grant that ran out before now and returns whose they were, by guild.
+func (ms *MetadataStore) ExpireGrants(now time.Time) map[string][]string {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	expired := make(map[string][]string)
+	for guildID, gs := range ms.Guilds {
+		for userID, grant := range gs.Grants {
+			if !now.Before(grant.Until) {
+				delete(gs.Grants, userID)
+				expired[guildID] = append(expired[guildID], userID)
+			}
+		}
+	}
+	if len(expired) == 0 {
+		return expired
+	}
+	if err := ms.save(); err != nil {
+		fmt.Println("Error saving expired grants: ", err)
+	}
+	return expired
+}
+
+// Guild returns a copy of a guild's settings. Guilds that haven't changed anything get the zero value.
+func (ms *MetadataStore) Guild(guildID string) GuildSettings {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if gs, ok := ms.Guilds[guildID]; ok {
+		return gs.clone()
+	}
+	return GuildSettings{}
+}
+
+// UpdateGuild applies fn to the guild's settings and persists the result.
+func (ms *MetadataStore) UpdateGuild(guildID string, fn func(gs *GuildSettings)) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	gs, ok := ms.Guilds[guildID]
+	if !ok {
+		gs = &GuildSettings{}
+		ms.Guilds[guildID] = gs
+	}
+	fn(gs)
+	return ms.save()
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Returns an empty store that saves to a temporary file.
+func testMetadataStore(t *testing.T) *MetadataStore {
+	t.Helper()
+	ms, err := NewMetadataStore(filepath.Join(t.TempDir(), "metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ms
+}
+
+// Adds a memo uploaded in guildID to the store.
+func addTestMemo(t *testing.T, ms *MetadataStore, guildID, name string) {
+	t.Helper()
+	err := ms.UpdateMemo(name, func(md *MemoMetadata) {
+		md.GuildID = guildID
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGuildMemos(t *testing.T) {
+	ms := testMetadataStore(t)
+	addTestMemo(t, ms, "1", "bruh")
+	addTestMemo(t, ms, "1", "oof")
+	addTestMemo(t, ms, "2", "honk")
+
+	tests := []struct {
+		guildID string
+		want    []string
+	}{
+		{"1", []string{"bruh", "oof"}},
+		{"2", []string{"honk"}},
+		{"3", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.guildID, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, md := range ms.GuildMemos(tt.guildID) {
+				got = append(got, md.Name)
+			}
+			sort.Strings(got)
+			if !equalStrings(got, tt.want) {
+				t.Errorf("GuildMemos(%s) = %v, want %v", tt.guildID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetadataStoreReload(t *testing.T) {
+	ms := testMetadataStore(t)
+	addTestMemo(t, ms, "1", "bruh")
+	if err := ms.UpdateGuild("1", func(gs *GuildSettings) { gs.MaxMemos = 3 }); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := NewMetadataStore(ms.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md := loaded.Memo("bruh"); md.GuildID != "1" {
+		t.Errorf("reloaded bruh belongs to guild %q, want 1", md.GuildID)
+	}
+	if limit := loaded.Guild("1").MaxMemos; limit != 3 {
+		t.Errorf("reloaded memo limit is %d, want 3", limit)
+	}
+}
+
+func TestRemoveMemo(t *testing.T) {
+	ms := testMetadataStore(t)
+	addTestMemo(t, ms, "1", "bruh")
+	addTestMemo(t, ms, "1", "oof")
+	if err := ms.RemoveMemo("bruh"); err != nil {
+		t.Fatal(err)
+	}
+
+	if memos := ms.GuildMemos("1"); len(memos) != 1 || memos[0].Name != "oof" {
+		t.Errorf("after removing bruh the guild has %v, want only oof", memos)
+	}
+	if md := ms.Memo("bruh"); md.GuildID != "" {
+		t.Errorf("bruh still belongs to guild %q", md.GuildID)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns a store where "bruh" is referred to from everywhere a memo name can be kept.
+func testReferencedMemo(t *testing.T) *MetadataStore {
+	t.Helper()
+	ms := testMetadataStore(t)
+	addTestMemo(t, ms, "1", "bruh")
+	addTestMemo(t, ms, "1", "oof")
+
+	steps := []error{
+		ms.UpdatePack("sounds", func(pack *SoundPack) {
+			pack.GuildID = "1"
+			pack.Memos = []string{"oof", "bruh"}
+		}),
+		ms.UpdatePlaylist("1", "mix", func(pl *Playlist) {
+			pl.Memos = []string{"bruh", "oof", "bruh"}
+		}),
+		ms.UpdateGuild("1", func(gs *GuildSettings) {
+			gs.Restrictions = map[string][]string{"bruh": {"role"}}
+			gs.EmojiBindings = map[string]EmojiBinding{"👍": {Memo: "bruh"}, "👎": {Memo: "oof"}}
+			gs.Greeting.JoinMemo = "bruh"
+			gs.Greeting.LeaveMemo = "bruh"
+		}),
+		ms.RecordPlay("1", PlayRecord{Memo: "bruh", UserID: "u"}),
+	}
+	for _, err := range steps {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ms
+}
+
+func TestRenameMemo(t *testing.T) {
+	ms := testReferencedMemo(t)
+	if err := ms.RenameMemo("bruh", "oof"); err == nil {
+		t.Error("renamed a memo to a name that's taken")
+	}
+	if err := ms.RenameMemo("bruh", "honk"); err != nil {
+		t.Fatal(err)
+	}
+
+	pack, _ := ms.Pack("sounds")
+	playlist, _ := ms.Playlist("1", "mix")
+	gs := ms.Guild("1")
+	history := ms.GuildHistory("1")
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"metadata", []string{ms.Memo("honk").GuildID, ms.Memo("bruh").GuildID}, []string{"1", ""}},
+		{"pack", pack.Memos, []string{"oof", "honk"}},
+		{"playlist", playlist.Memos, []string{"honk", "oof", "honk"}},
+		{"restriction", []string{strings.Join(gs.Restrictions["honk"], ","), strings.Join(gs.Restrictions["bruh"], ",")}, []string{"role", ""}},
+		{"bindings", []string{gs.EmojiBindings["👍"].Memo, gs.EmojiBindings["👎"].Memo}, []string{"honk", "oof"}},
+		{"greeting", []string{gs.Greeting.JoinMemo, gs.Greeting.LeaveMemo}, []string{"honk", "honk"}},
+		{"history", []string{history[0].Memo}, []string{"honk"}},
+		{"play counts", []string{strconv.Itoa(ms.GuildStats("1").Plays["honk"]), strconv.Itoa(ms.GuildStats("1").Plays["bruh"])}, []string{"1", "0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !equalStrings(tt.got, tt.want) {
+				t.Errorf("after renaming, %s holds %v, want %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveMemoReferences(t *testing.T) {
+	ms := testReferencedMemo(t)
+	if err := ms.RemoveMemo("bruh"); err != nil {
+		t.Fatal(err)
+	}
+
+	pack, _ := ms.Pack("sounds")
+	playlist, _ := ms.Playlist("1", "mix")
+	gs := ms.Guild("1")
+	_, bound := gs.EmojiBindings["👍"]
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"pack", pack.Memos, []string{"oof"}},
+		{"playlist", playlist.Memos, []string{"oof"}},
+		{"restriction", gs.Restrictions["bruh"], []string{}},
+		{"bindings", []string{strconv.FormatBool(bound), gs.EmojiBindings["👎"].Memo}, []string{"false", "oof"}},
+		{"greeting", []string{gs.Greeting.JoinMemo, gs.Greeting.LeaveMemo}, []string{"", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !equalStrings(tt.got, tt.want) {
+				t.Errorf("after removing bruh, %s holds %v, want %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+}
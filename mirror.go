@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Serves the HTTP API from the metadata file and objects the bot writes, so it can run on other machines or
+// in several copies. It never connects to Discord and never writes to the shared storage. Returns the exit code.
+func RunMirror() int {
+	if httpAddr == "" {
+		fmt.Println("Mirror mode needs an -http address to serve on")
+		return 1
+	}
+
+	metadata, err := NewMetadataStore(metadataPath)
+	if err != nil {
+		fmt.Println("Error loading voice memo metadata: ", err)
+		return 1
+	}
+
+	// Unlike NewVoiceMemoManager, this doesn't migrate anything. That's up to the bot.
+	voiceMemoManager := &VoiceMemoManager{
+		store:      make(map[string]*VoiceMemo),
+		Metadata:   metadata,
+		tombstones: make(map[string]*VoiceMemo),
+	}
+	if err := voiceMemoManager.Refresh(); err != nil {
+		fmt.Println("Error loading voice memos: ", err)
+		return 1
+	}
+	voiceMemoManager.Artifacts, err = NewArtifactCache(previewDir, int64(artifactMB)<<20)
+	if err != nil {
+		fmt.Println("Error opening the artifact cache: ", err)
+		return 1
+	}
+
+	server, err := NewHTTPServer(voiceMemoManager, httpToken, httpPublicURL)
+	if err != nil {
+		fmt.Println("Error creating HTTP server: ", err)
+		return 1
+	}
+
+	go func() {
+		for range time.Tick(mirrorRefresh) {
+			if err := voiceMemoManager.Refresh(); err != nil {
+				fmt.Println("Error refreshing voice memos: ", err)
+			}
+		}
+	}()
+
+	go func() {
+		fmt.Println("Serving HTTP on ", httpAddr)
+		if err := server.ListenAndServe(httpAddr); err != nil {
+			fmt.Println("Error serving HTTP: ", err)
+		}
+	}()
+
+	fmt.Println("Voice memo mirror is now running.  Press CTRL-C to exit.")
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+	<-sc
+	return 0
+}
+
+// Rereads the metadata and brings the store in line with it, picking up memos the bot added, deleted or
+// replaced since the last refresh. Memos are streamed from disk since a mirror never plays them.
+func (m *VoiceMemoManager) Refresh() error {
+	if err := m.Metadata.Reload(); err != nil {
+		return err
+	}
+
+	wanted := make(map[string]string)
+	for _, md := range m.Metadata.AllMemos() {
+		if md.Hash != "" {
+			wanted[md.Name] = md.Hash
+		}
+	}
+
+	// Count the frames of new memos before anyone can see them.
+	m.mu.RLock()
+	added := make([]*VoiceMemo, 0)
+	for name, hash := range wanted {
+		if vm, ok := m.store[name]; !ok || vm.hash != hash {
+			added = append(added, &VoiceMemo{name: name, hash: hash, streamed: true})
+		}
+	}
+	m.mu.RUnlock()
+
+	ready := make([]*VoiceMemo, 0, len(added))
+	for _, vm := range added {
+		if err := vm.CountFrames(); err != nil {
+			// The bot may not have finished writing it yet, try again next time.
+			fmt.Println("Error reading the audio of ", vm.name, ": ", err)
+			continue
+		}
+		ready = append(ready, vm)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, vm := range m.store {
+		if hash, ok := wanted[name]; !ok || vm.hash != hash {
+			delete(m.store, name)
+		}
+	}
+	for _, vm := range ready {
+		m.store[vm.name] = vm
+	}
+	return nil
+}
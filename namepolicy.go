@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// What happens when a new upload or recording is given the name of a memo that's already in the library,
+// set per guild with !namepolicy.
+type NamePolicy string
+
+const (
+	// Refuse the new memo. This is the default.
+	NamePolicyReject NamePolicy = "reject"
+	// Give the new memo the first free name out of <name>-2, <name>-3, ...
+	NamePolicySuffix NamePolicy = "suffix"
+	// Let the new memo take the name, keeping the old one as <name>-v1, <name>-v2, ...
+	NamePolicyVersion NamePolicy = "version"
+	// Ask the uploader which of the above they want.
+	NamePolicyPrompt NamePolicy = "prompt"
+)
+
+// How long the buttons asking what to do about a taken name keep working.
+const nameClashTTL = 15 * time.Minute
+
+var namePolicies = []NamePolicy{NamePolicyReject, NamePolicySuffix, NamePolicyVersion, NamePolicyPrompt}
+
+func ParseNamePolicy(s string) (NamePolicy, bool) {
+	for _, policy := range namePolicies {
+		if string(policy) == strings.ToLower(s) {
+			return policy, true
+		}
+	}
+	return "", false
+}
+
+// Describes the policy the way !namepolicy shows it.
+func (p NamePolicy) Description() string {
+	switch p {
+	case NamePolicySuffix:
+		return "new memos get the next free name, like name-2"
+	case NamePolicyVersion:
+		return "new memos replace the old one, which is kept as name-v1"
+	case NamePolicyPrompt:
+		return "the uploader is asked what to do"
+	}
+	return "new memos are refused"
+}
+
+// Returned when a name is taken and the guild's policy doesn't pick another one.
+type NameTakenError struct {
+	Name string
+
+	// Set when the uploader should be asked what to do instead.
+	Prompt bool
+}
+
+func (e *NameTakenError) Error() string {
+	return "there's already a voice memo called " + e.Name + ". Pick another name"
+}
+
+// Returns the first free name out of prefix followed by n, n+1, ...
+func (m *VoiceMemoManager) FreeName(prefix string, n int) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.freeName(prefix, n)
+}
+
+// Like FreeName. Callers must hold m.mu.
+func (m *VoiceMemoManager) freeName(prefix string, n int) string {
+	for ; ; n++ {
+		name := prefix + strconv.Itoa(n)
+		_, taken := m.store[name]
+		_, deleting := m.tombstones[name]
+		if !taken && !deleting {
+			return name
+		}
+	}
+}
+
+// Keeps a copy of a memo under the first free <name>-v<n>, sharing its audio, so a new memo can take its
+// name without losing it. The copy has the same metadata, but bindings, packs and playlists stay with the
+// name. Returns the copy's name.
+func (m *VoiceMemoManager) KeepVersion(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	vm, ok := m.store[name]
+	if !ok {
+		return "", fmt.Errorf("cannot find %s", name)
+	}
+	md := m.Metadata.Memo(name)
+	version := m.freeName(name+"-v", 1)
+	err := m.Metadata.UpdateMemo(version, func(v *MemoMetadata) {
+		*v = md
+		v.Name = version
+		v.Tags = append([]string(nil), md.Tags...)
+		v.Fingerprint = append([]uint32(nil), md.Fingerprint...)
+	})
+	if err != nil {
+		return "", err
+	}
+	m.store[version] = &VoiceMemo{name: version, hash: vm.hash, buffer: vm.buffer, streamed: vm.streamed, frames: vm.frames}
+	return version, nil
+}
+
+// Returns the policy an upload to the guild follows.
+func (gs GuildSettings) UploadNamePolicy() NamePolicy {
+	if gs.NamePolicy == "" {
+		return NamePolicyReject
+	}
+	return gs.NamePolicy
+}
+
+// Picks the name a memo the guild wants to call name gets under policy. replace is set when the memo
+// that's called that now should be kept as a version first. Memos of other guilds are never replaced,
+// so the version policy gives the new memo another name instead.
+func (b *Bot) ResolveMemoName(guildID, name string, policy NamePolicy) (resolved string, replace bool, err error) {
+	if b.VoiceMemoManager.Get(name) == nil {
+		return name, false, nil
+	}
+	switch policy {
+	case NamePolicySuffix:
+		return b.VoiceMemoManager.FreeName(name+"-", 2), false, nil
+	case NamePolicyVersion:
+		if b.VoiceMemoManager.Metadata.Memo(name).GuildID == guildID {
+			return name, true, nil
+		}
+		return b.VoiceMemoManager.FreeName(name+"-", 2), false, nil
+	case NamePolicyPrompt:
+		return "", false, &NameTakenError{Name: name, Prompt: true}
+	}
+	return "", false, &NameTakenError{Name: name}
+}
+
+// Resolves name like ResolveMemoName and keeps the memo it replaces, if any, as a version. Returns the
+// name the new memo gets and the name of the kept version.
+func (b *Bot) ClaimMemoName(guildID, name string, policy NamePolicy) (resolved, version string, err error) {
+	resolved, replace, err := b.ResolveMemoName(guildID, name, policy)
+	if err != nil || !replace {
+		return resolved, "", err
+	}
+	version, err = b.VoiceMemoManager.KeepVersion(resolved)
+	if err != nil {
+		fmt.Println("Error keeping a version of ", resolved, ": ", err)
+		return "", "", errors.New("the memo called " + resolved + " could not be kept as a version")
+	}
+	return resolved, version, nil
+}
+
+// Reports whether a user may replace a memo, keeping the old one as a version: it has to be from the
+// guild, and they have to be its uploader or an admin.
+func (b *Bot) CanReplaceMemo(s *discordgo.Session, guildID, channelID, userID, name string) bool {
+	md := b.VoiceMemoManager.Metadata.Memo(name)
+	if md.GuildID != guildID {
+		return false
+	}
+	return md.UploaderID == userID || IsAdmin(s, userID, channelID)
+}
+
+// An upload or recording waiting for its uploader to decide what to do about a taken name. It's saved as a
+// ComponentState so the buttons keep working if the bot restarts.
+type NameClash struct {
+	ID        string    `json:"id"`
+	GuildID   string    `json:"guild_id"`
+	ChannelID string    `json:"channel_id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	Started   time.Time `json:"started"`
+
+	// Whether the uploader may replace the memo that has the name.
+	Replaceable bool `json:"replaceable"`
+
+	// Exactly one of these is set: the upload to run once they decide, or the frames they recorded. The
+	// frames are kept in b.clashFrames instead of being saved.
+	Upload *UploadRequest `json:"upload,omitempty"`
+	Frames [][]byte       `json:"-"`
+}
+
+// Remembers a clash until its uploader decides, and returns the message asking them.
+func (b *Bot) StartNameClash(s *discordgo.Session, nc *NameClash) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	nc.Started = time.Now()
+	nc.Replaceable = b.CanReplaceMemo(s, nc.GuildID, nc.ChannelID, nc.UserID, nc.Name)
+
+	b.nameClashesMu.Lock()
+	for id := range b.clashFrames {
+		if !b.VoiceMemoManager.Metadata.LoadComponent("name", id, &NameClash{}) {
+			delete(b.clashFrames, id)
+		}
+	}
+	if nc.Frames != nil {
+		b.clashFrames[nc.ID] = nc.Frames
+	}
+	if err := b.VoiceMemoManager.Metadata.SaveComponent("name", nc.ID, nc.GuildID, nc.Started.Add(nameClashTTL), nc); err != nil {
+		fmt.Println("Error saving name clash: ", err)
+	}
+	b.nameClashesMu.Unlock()
+
+	suffixed := b.VoiceMemoManager.FreeName(nc.Name+"-", 2)
+	description := "There's already a voice memo called " + nc.Name + ". Yours can be called " + suffixed + " instead"
+	buttons := []discordgo.MessageComponent{
+		discordgo.Button{Label: "Keep both", Style: discordgo.PrimaryButton, CustomID: nc.customID(NamePolicySuffix)},
+	}
+	if nc.Replaceable {
+		description += ", or replace it, keeping the old one as " + b.VoiceMemoManager.FreeName(nc.Name+"-v", 1)
+		buttons = append(buttons, discordgo.Button{Label: "Replace it", Style: discordgo.DangerButton, CustomID: nc.customID(NamePolicyVersion)})
+	}
+	buttons = append(buttons, discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: nc.customID("cancel")})
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "That name is taken",
+		Description: description + ".",
+		Color:       16711680,
+	}
+	if len(embed.Description) > 4096 {
+		embed.Description = embed.Description[:4000] + "..."
+	}
+	return embed, []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+}
+
+// Sends the message asking what to do about a taken name to a channel.
+func (b *Bot) SendNameClash(s *discordgo.Session, nc *NameClash) {
+	embed, components := b.StartNameClash(s, nc)
+	msg := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}, Components: components}
+	if _, err := s.ChannelMessageSendComplex(nc.ChannelID, msg); err != nil {
+		fmt.Println(err)
+		return
+	}
+}
+
+func (nc *NameClash) customID(choice NamePolicy) string {
+	return "name:" + nc.ID + ":" + string(choice)
+}
+
+// Handles the buttons asking what to do about a taken name. arg is "<clash id>:<suffix|version|cancel>".
+func (b *Bot) HandleNameClashButton(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	id, choice, _ := strings.Cut(arg, ":")
+
+	b.nameClashesMu.Lock()
+	nc := &NameClash{}
+	ok := b.VoiceMemoManager.Metadata.LoadComponent("name", id, nc)
+	if ok && nc.UserID != InteractionUserID(i) {
+		b.nameClashesMu.Unlock()
+		RespondEphemeral(s, i, "Only whoever added this memo can decide.")
+		return
+	}
+	nc.Frames = b.clashFrames[id]
+	delete(b.clashFrames, id)
+	if err := b.VoiceMemoManager.Metadata.DropComponent("name", id); err != nil {
+		fmt.Println("Error saving name clash: ", err)
+	}
+	b.nameClashesMu.Unlock()
+	if !ok || nc.GuildID != i.GuildID {
+		b.replaceComponentMessage(s, i, "This has expired, nothing was saved.")
+		return
+	}
+	if nc.Upload == nil && nc.Frames == nil {
+		b.replaceComponentMessage(s, i, "The recording was lost when the bot restarted, sorry. Please !record it again.")
+		return
+	}
+
+	policy := NamePolicy(choice)
+	if policy == NamePolicyVersion && !nc.Replaceable {
+		policy = ""
+	}
+	if policy != NamePolicySuffix && policy != NamePolicyVersion {
+		b.replaceComponentMessage(s, i, "Cancelled, nothing was saved.")
+		return
+	}
+
+	if nc.Frames != nil {
+		b.replaceComponentMessage(s, i, "Saving the recording...")
+		content := ""
+		vm, version, err := b.SaveRecording(nc.GuildID, nc.UserID, nc.Name, policy, nc.Frames)
+		if err != nil {
+			fmt.Println("Error saving recording ", nc.Name, ": ", err)
+			content = "Could not save the recording: " + err.Error()
+		} else {
+			content = RecordedMessage(vm, version)
+		}
+		if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+			fmt.Println("Error editing interaction response: ", err)
+		}
+		return
+	}
+
+	// Converting can take longer than Discord waits for a response, so answer first.
+	b.replaceComponentMessage(s, i, "Uploading "+nc.Name+"...")
+	req := *nc.Upload
+	req.NamePolicy = policy
+	b.RunWithWatchdog(s, i.ChannelID, "upload", func(ctx context.Context) {
+		content := ""
+		result, err := b.Upload(ctx, req)
+		if err != nil {
+			content = "Could not upload: " + err.Error()
+		} else {
+			content = result.Message()
+		}
+		if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+			fmt.Println("Error editing interaction response: ", err)
+		}
+	})
+}
+
+// Shows or changes what happens when a new memo is given a name that's taken.
+func (b *Bot) HandleNamePolicy(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		policy := b.VoiceMemoManager.Metadata.Guild(g.ID).UploadNamePolicy()
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("When a name is taken in %s, %s (%s).", g.Name, policy.Description(), policy))
+		return
+	}
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can change what happens when a name is taken.")
+		return
+	}
+
+	policy, ok := ParseNamePolicy(args[0])
+	if !ok {
+		s.ChannelMessageSend(c.ID, "Usage: !namepolicy [reject|suffix|version|prompt]")
+		return
+	}
+	err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		gs.NamePolicy = policy
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+	s.ChannelMessageSend(c.ID, "From now on, when a name is taken "+policy.Description()+".")
+	b.Audit(s, g.ID, fmt.Sprintf("<@%s> set the name policy to %s.", m.Author.ID, policy))
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// How often the same person is pointed to slash commands in a guild.
+	nudgeInterval = 24 * time.Hour
+
+	// The tip when a guild doesn't write its own with !nudge message.
+	defaultNudgeMessage = "Tip: this command also works as {slash}"
+
+	// Longest tip !nudge message accepts.
+	maxNudgeMessage = 300
+)
+
+// Whether prefix commands in the guild get a tip pointing to their slash command.
+func (gs GuildSettings) NudgesToSlash() bool {
+	if gs.SlashNudge == nil {
+		return slashNudge
+	}
+	return *gs.SlashNudge
+}
+
+// Returns the tip for a command, with {slash} filled in.
+func (gs GuildSettings) Nudge(def *discordgo.ApplicationCommand) string {
+	message := gs.NudgeMessage
+	if message == "" {
+		message = defaultNudgeMessage
+	}
+	return strings.ReplaceAll(message, "{slash}", SlashSyntax(def))
+}
+
+// Points someone who typed a prefix command to its slash command, if the guild wants that and they
+// haven't been told in the last nudgeInterval.
+func (b *Bot) NudgeToSlash(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, userID, command string) {
+	sc, ok := slashCommands[command]
+	if !ok {
+		return
+	}
+	settings := b.VoiceMemoManager.Metadata.Guild(g.ID)
+	if !settings.NudgesToSlash() {
+		return
+	}
+	if !b.Cooldowns.Try("nudge:"+g.ID+":"+userID, nudgeInterval) {
+		return
+	}
+	s.ChannelMessageSend(c.ID, settings.Nudge(sc.Definition))
+}
+
+func (b *Bot) HandleNudge(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := "Usage: !nudge [on|off|default] | !nudge message <text>|default"
+	metadata := b.VoiceMemoManager.Metadata
+	if len(args) == 0 {
+		settings := metadata.Guild(g.ID)
+		if !settings.NudgesToSlash() {
+			s.ChannelMessageSend(c.ID, "Prefix commands aren't pointed to slash commands in "+g.Name+".")
+			return
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Prefix commands are pointed to slash commands in %s, once a day per person, e.g.\n%s", g.Name, settings.Nudge(slashCommands["play"].Definition)))
+		return
+	}
+
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can change slash command tips.")
+		return
+	}
+
+	var update func(gs *GuildSettings)
+	reply, audit := "", ""
+	switch {
+	case args[0] == "message" && len(args) >= 2:
+		message := strings.Join(args[1:], " ")
+		if message == "default" {
+			message = ""
+		}
+		if len(message) > maxNudgeMessage {
+			s.ChannelMessageSend(c.ID, fmt.Sprintf("The tip can be up to %d characters long.", maxNudgeMessage))
+			return
+		}
+		update = func(gs *GuildSettings) { gs.NudgeMessage = message }
+		reply = "Slash command tips now read:\n" + GuildSettings{NudgeMessage: message}.Nudge(slashCommands["play"].Definition)
+		audit = fmt.Sprintf("<@%s> changed the slash command tip.", m.Author.ID)
+	case args[0] == "default":
+		update = func(gs *GuildSettings) { gs.SlashNudge = nil }
+		reply = "Slash command tips follow the bot's default again."
+		audit = fmt.Sprintf("<@%s> made slash command tips follow the bot's default.", m.Author.ID)
+	default:
+		on, err := parseOnOff(args[0])
+		if err != nil {
+			s.ChannelMessageSend(c.ID, usage)
+			return
+		}
+		update = func(gs *GuildSettings) { gs.SlashNudge = &on }
+		if on {
+			reply = "Prefix commands that have a slash command will get a tip pointing to it, once a day per person."
+			audit = fmt.Sprintf("<@%s> turned on slash command tips.", m.Author.ID)
+		} else {
+			reply = "Prefix commands won't get slash command tips."
+			audit = fmt.Sprintf("<@%s> turned off slash command tips.", m.Author.ID)
+		}
+	}
+
+	if err := metadata.UpdateGuild(g.ID, update); err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+	s.ChannelMessageSend(c.ID, reply)
+	b.Audit(s, g.ID, audit)
+}
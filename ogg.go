@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Discord always sends 20ms stereo Opus frames at 48kHz.
+const (
+	opusSampleRate      = 48000
+	opusFrameSamples    = 960
+	opusChannels        = 2
+	oggOpusPreSkip      = 312
+	oggOpusStreamSerial = 0x766d656d // "vmem"
+)
+
+var oggCRCTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = (r << 1) ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		table[i] = r
+	}
+	return table
+}()
+
+func oggCRC(data []byte) uint32 {
+	var crc uint32
+	for _, b := range data {
+		crc = (crc << 8) ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+// OggOpusWriter wraps raw Opus packets in an Ogg container so tools like ffmpeg and whisper can read them.
+// Each packet gets a page of its own, which wastes a few bytes but keeps the writer trivial.
+type OggOpusWriter struct {
+	w        io.Writer
+	sequence uint32
+	granule  int64
+}
+
+// Writes the Opus identification and comment headers.
+func NewOggOpusWriter(w io.Writer, channels int) (*OggOpusWriter, error) {
+	ow := &OggOpusWriter{w: w}
+
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8] = 1 // version
+	head[9] = byte(channels)
+	binary.LittleEndian.PutUint16(head[10:], oggOpusPreSkip)
+	binary.LittleEndian.PutUint32(head[12:], opusSampleRate)
+	if err := ow.writePage(head, 0x02); err != nil {
+		return nil, err
+	}
+
+	vendor := "voice-memo-discord-bot"
+	tags := make([]byte, 8+4+len(vendor)+4)
+	copy(tags, "OpusTags")
+	binary.LittleEndian.PutUint32(tags[8:], uint32(len(vendor)))
+	copy(tags[12:], vendor)
+	if err := ow.writePage(tags, 0); err != nil {
+		return nil, err
+	}
+	return ow, nil
+}
+
+// Writes one 20ms Opus packet.
+func (ow *OggOpusWriter) WritePacket(packet []byte) error {
+	ow.granule += opusFrameSamples
+	return ow.writePage(packet, 0)
+}
+
+// Writes an empty end-of-stream page. The writer must not be used afterwards.
+func (ow *OggOpusWriter) Close() error {
+	return ow.writePage(nil, 0x04)
+}
+
+// Writes the memo as an Ogg Opus file. The frames are already encoded, so this only rewraps them.
+func (vm *VoiceMemo) WriteOgg(w io.Writer) error {
+	ow, err := NewOggOpusWriter(w, vm.Channels())
+	if err != nil {
+		return err
+	}
+
+	var writeErr error
+	err = vm.EachFrame(func(frame []byte) bool {
+		writeErr = ow.WritePacket(frame)
+		return writeErr == nil
+	})
+	if err == nil {
+		err = writeErr
+	}
+	if err == nil {
+		err = ow.Close()
+	}
+	return err
+}
+
+// Returns how many channels an Opus packet was encoded with, which its TOC byte records. Decoders play
+// mono packets on stereo output as they are, so memos can mix either kind.
+func OpusPacketChannels(packet []byte) int {
+	if len(packet) > 0 && packet[0]&0x04 == 0 {
+		return 1
+	}
+	return 2
+}
+
+// Returns 1 for a memo encoded in mono, 2 for stereo.
+func (vm *VoiceMemo) Channels() int {
+	channels := opusChannels
+	err := vm.EachFrame(func(frame []byte) bool {
+		channels = OpusPacketChannels(frame)
+		return false
+	})
+	if err != nil {
+		fmt.Println("Error reading ", vm.name, ": ", err)
+	}
+	return channels
+}
+
+func (ow *OggOpusWriter) writePage(data []byte, headerType byte) error {
+	// Lacing values: as many 255s as fit, then the remainder (which may be 0).
+	segments := make([]byte, 0, len(data)/255+1)
+	for n := len(data); ; n -= 255 {
+		if n < 255 {
+			segments = append(segments, byte(n))
+			break
+		}
+		segments = append(segments, 255)
+	}
+
+	page := make([]byte, 27, 27+len(segments)+len(data))
+	copy(page, "OggS")
+	page[5] = headerType
+	binary.LittleEndian.PutUint64(page[6:], uint64(ow.granule))
+	binary.LittleEndian.PutUint32(page[14:], oggOpusStreamSerial)
+	binary.LittleEndian.PutUint32(page[18:], ow.sequence)
+	page[26] = byte(len(segments))
+	page = append(page, segments...)
+	page = append(page, data...)
+	binary.LittleEndian.PutUint32(page[22:], oggCRC(page))
+
+	ow.sequence++
+	_, err := ow.w.Write(page)
+	return err
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How long after the last identical error reply in a channel another one is folded into it.
+const replyDebounceWindow = 30 * time.Second
+
+// Sends replies on the bot's behalf. Error replies that are the same as one sent to the channel shortly
+// before aren't sent again; the earlier message is edited to count them instead, so spamming a command
+// that keeps failing doesn't flood the channel.
+type Outbox struct {
+	mu     sync.Mutex
+	recent map[outboxKey]*sentReply
+}
+
+type outboxKey struct {
+	channelID string
+	content   string
+}
+
+// An error reply that later identical ones are folded into.
+type sentReply struct {
+	messageID string
+	count     int
+	last      time.Time
+}
+
+func NewOutbox() *Outbox {
+	return &Outbox{recent: make(map[outboxKey]*sentReply)}
+}
+
+// Sends an error reply to a channel, or counts it on the same reply sent there within replyDebounceWindow.
+func (o *Outbox) Error(s *discordgo.Session, channelID, content string) {
+	key := outboxKey{channelID, content}
+	now := time.Now()
+
+	o.mu.Lock()
+	for k, sent := range o.recent {
+		if now.Sub(sent.last) > replyDebounceWindow {
+			delete(o.recent, k)
+		}
+	}
+	if sent, ok := o.recent[key]; ok {
+		sent.count++
+		sent.last = now
+		messageID, count := sent.messageID, sent.count
+		o.mu.Unlock()
+
+		// The first reply may still be on its way. It picks the count up once it's there.
+		if messageID != "" {
+			o.edit(s, channelID, messageID, content, count)
+		}
+		return
+	}
+	sent := &sentReply{count: 1, last: now}
+	o.recent[key] = sent
+	o.mu.Unlock()
+
+	msg, err := s.ChannelMessageSend(channelID, content)
+	if err != nil {
+		fmt.Println("Error sending reply: ", err)
+		o.mu.Lock()
+		delete(o.recent, key)
+		o.mu.Unlock()
+		return
+	}
+
+	o.mu.Lock()
+	sent.messageID = msg.ID
+	count := sent.count
+	o.mu.Unlock()
+	if count > 1 {
+		o.edit(s, channelID, msg.ID, content, count)
+	}
+}
+
+func (o *Outbox) edit(s *discordgo.Session, channelID, messageID, content string, count int) {
+	if _, err := s.ChannelMessageEdit(channelID, messageID, fmt.Sprintf("%s (×%d)", content, count)); err != nil {
+		fmt.Println("Error editing reply: ", err)
+	}
+}
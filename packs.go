@@ -0,0 +1,396 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A named group of memos that a guild can publish for other guilds to subscribe to.
+type SoundPack struct {
+	Name      string    `json:"name"`
+	GuildID   string    `json:"guild_id"`
+	Memos     []string  `json:"memos"`
+	Published bool      `json:"published"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (p SoundPack) clone() SoundPack {
+	p.Memos = append([]string(nil), p.Memos...)
+	return p
+}
+
+// Has reports whether the pack contains a memo.
+func (p *SoundPack) Has(name string) bool {
+	for _, memo := range p.Memos {
+		if memo == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Add puts a memo in the pack unless it's already there.
+func (p *SoundPack) Add(name string) {
+	if !p.Has(name) {
+		p.Memos = append(p.Memos, name)
+	}
+}
+
+// Remove takes a memo out of the pack.
+func (p *SoundPack) Remove(name string) {
+	kept := p.Memos[:0]
+	for _, memo := range p.Memos {
+		if memo != name {
+			kept = append(kept, memo)
+		}
+	}
+	p.Memos = kept
+}
+
+// Returns the memos a guild sees in !list: its own uploads, memos from before uploads were tracked per
+// guild, and the memos of every pack it subscribes to. Packs are read on every call, so changes to a
+// pack show up in its subscribers right away.
+func (m *VoiceMemoManager) GuildLibrary(guildID string) []*VoiceMemo {
+	subscribed := m.subscribedMemos(guildID)
+	memos := make([]*VoiceMemo, 0)
+	for _, vm := range m.List() {
+		if m.inLibrary(guildID, vm.name, subscribed) {
+			memos = append(memos, vm)
+		}
+	}
+	return memos
+}
+
+// Whether a memo is in a guild's library, see GuildLibrary.
+func (m *VoiceMemoManager) InLibrary(guildID, name string) bool {
+	return m.inLibrary(guildID, name, m.subscribedMemos(guildID))
+}
+
+func (m *VoiceMemoManager) inLibrary(guildID, name string, subscribed map[string]bool) bool {
+	return !m.Metadata.Memo(name).OtherGuild(guildID) || subscribed[name]
+}
+
+// Names of the memos in the published packs a guild subscribes to.
+func (m *VoiceMemoManager) subscribedMemos(guildID string) map[string]bool {
+	subscribed := make(map[string]bool)
+	for _, sub := range m.Metadata.Guild(guildID).Subscriptions {
+		if pack, ok := m.Metadata.Pack(sub); ok && pack.Published {
+			for _, name := range pack.Memos {
+				subscribed[name] = true
+			}
+		}
+	}
+	return subscribed
+}
+
+// Returns the memo that name, or the alias name, stands for in a guild's library. Memos outside it are
+// treated as if they didn't exist, so a guild can't play or look at another guild's memos by name.
+func (m *VoiceMemoManager) GuildMemo(guildID, name string) *VoiceMemo {
+	vm := m.Get(m.ResolveAlias(guildID, name))
+	if vm == nil || !m.InLibrary(guildID, vm.name) {
+		return nil
+	}
+	return vm
+}
+
+// Whether a guild may put a memo in one of its packs. Only its own memos and untracked ones qualify.
+func (b *Bot) canPack(guildID, name string) error {
+	if b.VoiceMemoManager.Get(name) == nil {
+		return fmt.Errorf("cannot find %s", name)
+	}
+	if owner := b.VoiceMemoManager.Metadata.Memo(name).GuildID; owner != "" && owner != guildID {
+		return fmt.Errorf("%s belongs to another server", name)
+	}
+	return nil
+}
+
+func (b *Bot) HandlePack(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := "Usage: !pack [list] | !pack show <pack> | !pack create|add|remove <pack> <memos...> | !pack publish|unpublish|delete|subscribe|unsubscribe <pack>"
+	if len(args) == 0 || args[0] == "list" {
+		b.SendPacks(s, g, c)
+		return
+	}
+	if len(args) < 2 {
+		s.ChannelMessageSend(c.ID, usage)
+		return
+	}
+
+	action, name, memos := args[0], args[1], args[2:]
+	if action == "show" {
+		b.SendPack(s, g, c, name)
+		return
+	}
+
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can manage sound packs.")
+		return
+	}
+
+	metadata := b.VoiceMemoManager.Metadata
+	pack, exists := metadata.Pack(name)
+	owned := exists && pack.GuildID == g.ID
+
+	switch action {
+	case "create":
+		if err := ValidateMemoName(name); err != nil {
+			s.ChannelMessageSend(c.ID, "Could not create pack: "+err.Error())
+			return
+		}
+		if exists {
+			s.ChannelMessageSend(c.ID, "There's already a pack called "+name)
+			return
+		}
+		for _, memo := range memos {
+			if err := b.canPack(g.ID, memo); err != nil {
+				s.ChannelMessageSend(c.ID, "Could not create pack: "+err.Error())
+				return
+			}
+		}
+		err := metadata.UpdatePack(name, func(pack *SoundPack) {
+			pack.GuildID = g.ID
+			for _, memo := range memos {
+				pack.Add(memo)
+			}
+		})
+		if err != nil {
+			fmt.Println("Error saving pack ", name, ": ", err)
+			return
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Created pack %s with %d memos. !pack publish %s to share it with other servers.", name, len(memos), name))
+
+	case "add", "remove":
+		if !owned {
+			s.ChannelMessageSend(c.ID, "This server doesn't have a pack called "+name)
+			return
+		}
+		if len(memos) == 0 {
+			s.ChannelMessageSend(c.ID, usage)
+			return
+		}
+		if action == "add" {
+			for _, memo := range memos {
+				if err := b.canPack(g.ID, memo); err != nil {
+					s.ChannelMessageSend(c.ID, "Could not add to pack: "+err.Error())
+					return
+				}
+			}
+		}
+		err := metadata.UpdatePack(name, func(pack *SoundPack) {
+			for _, memo := range memos {
+				if action == "add" {
+					pack.Add(memo)
+				} else {
+					pack.Remove(memo)
+				}
+			}
+		})
+		if err != nil {
+			fmt.Println("Error saving pack ", name, ": ", err)
+			return
+		}
+		if action == "add" {
+			s.ChannelMessageSend(c.ID, fmt.Sprintf("Added %s to %s", strings.Join(memos, ", "), name))
+		} else {
+			s.ChannelMessageSend(c.ID, fmt.Sprintf("Removed %s from %s", strings.Join(memos, ", "), name))
+		}
+
+	case "publish":
+		if !owned {
+			s.ChannelMessageSend(c.ID, "This server doesn't have a pack called "+name)
+			return
+		}
+		err := metadata.UpdatePack(name, func(pack *SoundPack) {
+			pack.Published = true
+		})
+		if err != nil {
+			fmt.Println("Error saving pack ", name, ": ", err)
+			return
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Published %s. Other servers can now !pack subscribe %s", name, name))
+
+	case "unpublish":
+		if !owned {
+			s.ChannelMessageSend(c.ID, "This server doesn't have a pack called "+name)
+			return
+		}
+		if err := metadata.UnpublishPack(name); err != nil {
+			fmt.Println("Error saving pack ", name, ": ", err)
+			return
+		}
+		s.ChannelMessageSend(c.ID, "Unpublished "+name+". Servers that subscribed to it no longer see its memos.")
+
+	case "delete":
+		if !owned {
+			s.ChannelMessageSend(c.ID, "This server doesn't have a pack called "+name)
+			return
+		}
+		if err := metadata.RemovePack(name); err != nil {
+			fmt.Println("Error deleting pack ", name, ": ", err)
+			return
+		}
+		s.ChannelMessageSend(c.ID, "Deleted pack "+name+". Its memos are still in this server's library.")
+
+	case "subscribe":
+		if !exists || !pack.Published {
+			s.ChannelMessageSend(c.ID, "There's no published pack called "+name)
+			return
+		}
+		if owned {
+			s.ChannelMessageSend(c.ID, name+" is this server's own pack.")
+			return
+		}
+		settings := metadata.Guild(g.ID)
+		for _, sub := range settings.Subscriptions {
+			if sub == name {
+				s.ChannelMessageSend(c.ID, "Already subscribed to "+name)
+				return
+			}
+		}
+
+		// Memos this server can already see aren't added twice.
+		before := b.VoiceMemoManager.SnapshotLibrary(g.ID)
+		overlap := make([]string, 0)
+		for _, memo := range pack.Memos {
+			if _, ok := before[memo]; ok {
+				overlap = append(overlap, memo)
+			}
+		}
+
+		err := metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+			gs.Subscriptions = append(gs.Subscriptions, name)
+		})
+		if err != nil {
+			fmt.Println("Error saving guild settings: ", err)
+			return
+		}
+		diff := DiffLibrary(before, b.VoiceMemoManager.SnapshotLibrary(g.ID))
+		reply := fmt.Sprintf("Subscribed to %s. Its %d memos are in !list now and will stay up to date.\n%s", name, len(pack.Memos), diff.Summary())
+		if len(overlap) > 0 {
+			reply += "\nAlready in your library, so not added again: " + strings.Join(overlap, ", ")
+		}
+		s.ChannelMessageSend(c.ID, reply)
+
+	case "unsubscribe":
+		before := b.VoiceMemoManager.SnapshotLibrary(g.ID)
+		subscribed := false
+		err := metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+			kept := gs.Subscriptions[:0]
+			for _, sub := range gs.Subscriptions {
+				if sub == name {
+					subscribed = true
+					continue
+				}
+				kept = append(kept, sub)
+			}
+			gs.Subscriptions = kept
+		})
+		if err != nil {
+			fmt.Println("Error saving guild settings: ", err)
+			return
+		}
+		if !subscribed {
+			s.ChannelMessageSend(c.ID, "Not subscribed to "+name)
+			return
+		}
+		diff := DiffLibrary(before, b.VoiceMemoManager.SnapshotLibrary(g.ID))
+		s.ChannelMessageSend(c.ID, "Unsubscribed from "+name+"\n"+diff.Summary())
+
+	default:
+		s.ChannelMessageSend(c.ID, usage)
+	}
+}
+
+// Lists this server's packs, its subscriptions, and the packs other servers have published.
+func (b *Bot) SendPacks(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel) {
+	packs := b.VoiceMemoManager.Metadata.AllPacks()
+	sort.Slice(packs, func(i, j int) bool {
+		return packs[i].Name < packs[j].Name
+	})
+
+	subscribed := make(map[string]bool)
+	for _, sub := range b.VoiceMemoManager.Metadata.Guild(g.ID).Subscriptions {
+		subscribed[sub] = true
+	}
+
+	own, subs, others := make([]string, 0), make([]string, 0), make([]string, 0)
+	for _, pack := range packs {
+		line := fmt.Sprintf("%s (%d memos)", pack.Name, len(pack.Memos))
+		switch {
+		case pack.GuildID == g.ID:
+			if !pack.Published {
+				line += " · unpublished"
+			}
+			own = append(own, line)
+		case subscribed[pack.Name]:
+			subs = append(subs, line)
+		case pack.Published:
+			others = append(others, line)
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:  "Sound packs",
+		Color:  65535,
+		Fields: []*discordgo.MessageEmbedField{},
+		Footer: &discordgo.MessageEmbedFooter{Text: "!pack show <pack> to see what's in one"},
+	}
+	for _, section := range []struct {
+		name  string
+		packs []string
+	}{{"This server's packs", own}, {"Subscribed", subs}, {"Available", others}} {
+		if len(section.packs) == 0 {
+			continue
+		}
+		// Field values are capped at 1024 characters.
+		value := strings.Join(section.packs, "\n")
+		if len(value) > 1024 {
+			value = value[:1000] + "..."
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  section.name,
+			Value: value,
+		})
+	}
+	if len(embed.Fields) == 0 {
+		embed.Description = "There are no sound packs yet. !pack create <pack> <memos...> to make one."
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
+
+func (b *Bot) SendPack(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, name string) {
+	pack, ok := b.VoiceMemoManager.Metadata.Pack(name)
+	if !ok || !pack.Published && pack.GuildID != g.ID {
+		s.ChannelMessageSend(c.ID, "There's no pack called "+name)
+		return
+	}
+
+	memos := "This pack is empty."
+	if len(pack.Memos) > 0 {
+		memos = strings.Join(pack.Memos, ", ")
+	}
+	if len(memos) > 4096 {
+		memos = memos[:4000] + "..."
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       pack.Name,
+		Description: memos,
+		Color:       65535,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d memos", len(pack.Memos))},
+		Timestamp:   pack.UpdatedAt.Format(time.RFC3339),
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
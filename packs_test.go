@@ -0,0 +1,155 @@
+package main
+
+import "testing"
+
+// Returns a manager holding memos uploaded in guilds 1 and 2, plus one from before uploads were tracked.
+// Guild 2 publishes the "sounds" pack and keeps the "secret" pack to itself.
+func testLibrary(t *testing.T) *VoiceMemoManager {
+	t.Helper()
+	ms := testMetadataStore(t)
+	m := &VoiceMemoManager{store: make(map[string]*VoiceMemo), Metadata: ms}
+	memos := []struct{ guildID, name string }{
+		{"1", "bruh"},
+		{"", "legacy"},
+		{"2", "honk"},
+		{"2", "quack"},
+		{"2", "whisper"},
+	}
+	for _, memo := range memos {
+		addTestMemo(t, ms, memo.guildID, memo.name)
+		m.store[memo.name] = &VoiceMemo{name: memo.name}
+	}
+
+	packs := []struct {
+		name      string
+		memos     []string
+		published bool
+	}{
+		{"sounds", []string{"honk", "quack"}, true},
+		{"secret", []string{"whisper"}, false},
+	}
+	for _, p := range packs {
+		p := p
+		err := ms.UpdatePack(p.name, func(pack *SoundPack) {
+			pack.GuildID = "2"
+			pack.Memos = p.memos
+			pack.Published = p.published
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func TestGuildLibrary(t *testing.T) {
+	tests := []struct {
+		name          string
+		guildID       string
+		subscriptions []string
+		want          []string
+	}{
+		{"own uploads", "1", nil, []string{"bruh", "legacy"}},
+		{"subscribed", "1", []string{"sounds"}, []string{"bruh", "honk", "legacy", "quack"}},
+		{"unpublished pack", "1", []string{"secret"}, []string{"bruh", "legacy"}},
+		{"missing pack", "1", []string{"gone"}, []string{"bruh", "legacy"}},
+		{"publisher", "2", nil, []string{"honk", "legacy", "quack", "whisper"}},
+		{"new guild", "3", nil, []string{"legacy"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testLibrary(t)
+			err := m.Metadata.UpdateGuild(tt.guildID, func(gs *GuildSettings) {
+				gs.Subscriptions = tt.subscriptions
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, 0)
+			for _, vm := range m.GuildLibrary(tt.guildID) {
+				got = append(got, vm.name)
+			}
+			if !equalStrings(got, tt.want) {
+				t.Errorf("GuildLibrary(%s) = %v, want %v", tt.guildID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuildMemo(t *testing.T) {
+	tests := []struct {
+		name          string
+		subscriptions []string
+		arg           string
+		want          string
+	}{
+		{"own upload", nil, "bruh", "bruh"},
+		{"untracked", nil, "legacy", "legacy"},
+		{"unsubscribed pack", nil, "honk", ""},
+		{"subscribed pack", []string{"sounds"}, "honk", "honk"},
+		{"unpublished pack", []string{"secret"}, "whisper", ""},
+		{"outside every pack", []string{"sounds"}, "whisper", ""},
+		{"alias", nil, "b", "bruh"},
+		{"alias to an unsubscribed pack", nil, "h", ""},
+		{"missing", nil, "zzz", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testLibrary(t)
+			err := m.Metadata.UpdateGuild("1", func(gs *GuildSettings) {
+				gs.Subscriptions = tt.subscriptions
+				gs.Aliases = map[string]string{"b": "bruh", "h": "honk"}
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := ""
+			if vm := m.GuildMemo("1", tt.arg); vm != nil {
+				got = vm.name
+			}
+			if got != tt.want {
+				t.Errorf("GuildMemo(1, %q) = %q, want %q", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveMemoLeavesPacks(t *testing.T) {
+	m := testLibrary(t)
+	if err := m.Metadata.RemoveMemo("honk"); err != nil {
+		t.Fatal(err)
+	}
+	pack, ok := m.Metadata.Pack("sounds")
+	if !ok {
+		t.Fatal("removing a memo removed its pack")
+	}
+	if !equalStrings(pack.Memos, []string{"quack"}) {
+		t.Errorf("pack holds %v, want [quack]", pack.Memos)
+	}
+}
+
+func TestRemovePackUnsubscribes(t *testing.T) {
+	m := testLibrary(t)
+	for _, guildID := range []string{"1", "3"} {
+		err := m.Metadata.UpdateGuild(guildID, func(gs *GuildSettings) {
+			gs.Subscriptions = []string{"sounds", "secret"}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := m.Metadata.UnpublishPack("secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Metadata.RemovePack("sounds"); err != nil {
+		t.Fatal(err)
+	}
+	for _, guildID := range []string{"1", "3"} {
+		if subs := m.Metadata.Guild(guildID).Subscriptions; len(subs) != 0 {
+			t.Errorf("guild %s still subscribes to %v", guildID, subs)
+		}
+	}
+}
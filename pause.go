@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Holds the player where it is until the session is resumed. Returns false if nothing is playing.
+func (gs *GuildSession) Pause() bool {
+	gs.currentMu.Lock()
+	playing := gs.current != nil
+	gs.currentMu.Unlock()
+	if !playing {
+		return false
+	}
+
+	gs.pauseMu.Lock()
+	defer gs.pauseMu.Unlock()
+
+	if gs.resume == nil {
+		gs.resume = make(chan struct{})
+	}
+	return true
+}
+
+// Lets a paused player carry on from where it stopped. Returns false if it wasn't paused.
+func (gs *GuildSession) Resume() bool {
+	gs.pauseMu.Lock()
+	defer gs.pauseMu.Unlock()
+
+	if gs.resume == nil {
+		return false
+	}
+	close(gs.resume)
+	gs.resume = nil
+	return true
+}
+
+// Reports whether the session is paused.
+func (gs *GuildSession) Paused() bool {
+	gs.pauseMu.Lock()
+	defer gs.pauseMu.Unlock()
+
+	return gs.resume != nil
+}
+
+// Blocks the player while the session is paused, staying quiet in the meantime. Returns false if the
+// memo was skipped or stopped instead of resumed.
+func (gs *GuildSession) waitWhilePaused(ctx context.Context) bool {
+	gs.pauseMu.Lock()
+	resume := gs.resume
+	gs.pauseMu.Unlock()
+	if resume == nil {
+		return true
+	}
+
+	gs.VoiceConnection.Speaking(false)
+	defer gs.VoiceConnection.Speaking(true)
+
+	select {
+	case <-resume:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (b *Bot) HandlePause(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel) {
+	gs, ok := b.Session(g.ID)
+	if !ok {
+		fmt.Println("Error finding guild session.")
+		return
+	}
+
+	if gs.Paused() {
+		s.ChannelMessageSend(c.ID, "Already paused. !resume to carry on.")
+		return
+	}
+	if !gs.Pause() {
+		s.ChannelMessageSend(c.ID, "Nothing is playing.")
+		return
+	}
+	s.ChannelMessageSend(c.ID, "Paused. !resume to carry on.")
+}
+
+func (b *Bot) HandleResume(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel) {
+	gs, ok := b.Session(g.ID)
+	if !ok {
+		fmt.Println("Error finding guild session.")
+		return
+	}
+
+	if !gs.Resume() {
+		s.ChannelMessageSend(c.ID, "Nothing is paused.")
+		return
+	}
+	s.ChannelMessageSend(c.ID, "Resumed.")
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// One memo of a !play command, with how many times in a row to play it and where to start.
+type PlayRequest struct {
+	Name  string
+	Times int
+	Start time.Duration
+}
+
+// Reads the arguments of !play: memo names in the order to play them, each optionally followed by how many
+// times to play it and where to start, e.g. "hello x3 bruh 0:15 airhorn". Returns the repeat that's out of
+// range if there is one, rather than taking it for a memo name.
+func ParsePlayRequests(args []string) ([]PlayRequest, string) {
+	requests := make([]PlayRequest, 0, len(args))
+	for _, arg := range args {
+		if len(requests) > 0 {
+			last := &requests[len(requests)-1]
+			if n, ok := ParseRepeat(arg); ok {
+				last.Times = n
+				continue
+			}
+			if d, ok := ParseTimestamp(arg); ok {
+				last.Start = d
+				continue
+			}
+			if _, err := strconv.Atoi(strings.Trim(strings.ToLower(arg), "x*×")); err == nil {
+				return nil, arg
+			}
+		}
+		requests = append(requests, PlayRequest{Name: strings.TrimPrefix(arg, "-"), Times: 1})
+	}
+	return requests, ""
+}
+
+// Queues several memos in order on behalf of userID, as !play a b c asks for. Nothing is queued if one of
+// them can't be found or played. full plays them past the guild's !maxplay.
+func (b *Bot) HandlePlayAll(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, userID string, requests []PlayRequest, full bool) {
+	memos := make([]*VoiceMemo, len(requests))
+	for i, r := range requests {
+		voiceMemo := b.VoiceMemoManager.GuildMemo(g.ID, r.Name)
+		if voiceMemo == nil {
+			b.Outbox.Error(s, c.ID, "Cannot find "+r.Name)
+			return
+		}
+		if !b.CanPlay(s, g, c.ID, userID, voiceMemo.name) {
+			b.Outbox.Error(s, c.ID, "You don't have a role that can play "+voiceMemo.name)
+			return
+		}
+		if reason := b.OutsideWindow(g.ID, voiceMemo.name); reason != "" {
+			b.Outbox.Error(s, c.ID, reason)
+			return
+		}
+		if r.Start >= voiceMemo.Duration() {
+			b.Outbox.Error(s, c.ID, fmt.Sprintf("%s is only %s long.", voiceMemo.name, FormatDuration(voiceMemo.Duration())))
+			return
+		}
+		memos[i] = voiceMemo
+	}
+
+	// Join the requester's voice channel if nobody asked for a !join yet.
+	gs, ok := b.SessionFor(s, g, c.ID, userID)
+	if !ok {
+		return
+	}
+
+	eta := gs.QueueETA()
+	wait := gs.IsVoicePlaying.Load()
+	names := make([]string, 0, len(requests))
+	played := make([]string, 0, len(requests))
+	var length time.Duration
+	left := 0
+	for i, r := range requests {
+		added := 0
+		for ; left == 0 && added < r.Times; added++ {
+			entry := QueueEntry{Memo: memos[i], RequesterID: userID, ChannelID: c.ID, Full: full, Start: int(r.Start / frameDuration)}
+			if !gs.EnqueueEntry(entry) {
+				break
+			}
+			played = append(played, memos[i].name)
+			length += memos[i].Duration() - r.Start
+		}
+		left += r.Times - added
+		switch {
+		case added == 1:
+			names = append(names, memos[i].name)
+		case added > 1:
+			names = append(names, fmt.Sprintf("%s x%d", memos[i].name, added))
+		}
+	}
+	b.VoiceMemoManager.RecordPlay(g.ID, userID, played...)
+	if len(names) == 0 {
+		b.Outbox.Error(s, c.ID, "The queue is full. Try again later.")
+		return
+	}
+
+	reply := fmt.Sprintf("Queued %s (%s).", strings.Join(names, ", "), FormatDuration(length))
+	if left > 0 {
+		reply += fmt.Sprintf(" The other %d didn't fit, the queue is full.", left)
+	}
+	if gs.Paused() {
+		reply += " Playback is paused, !resume to carry on."
+	} else if wait {
+		reply += fmt.Sprintf(" Playing in about %s.", FormatDuration(eta))
+	}
+	s.ChannelMessageSend(c.ID, reply)
+
+	// Playback outlives the command, so it doesn't count against the command timeout.
+	go gs.PlayFromQueue()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePlayRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		want     []PlayRequest
+		wantOver string
+	}{
+		{"one memo", []string{"bruh"}, []PlayRequest{{Name: "bruh", Times: 1}}, ""},
+		{"repeat", []string{"bruh", "x3"}, []PlayRequest{{Name: "bruh", Times: 3}}, ""},
+		{"start", []string{"bruh", "0:15"}, []PlayRequest{{Name: "bruh", Times: 1, Start: 15 * time.Second}}, ""},
+		{"repeat and start", []string{"bruh", "3x", "1:02"}, []PlayRequest{{Name: "bruh", Times: 3, Start: 62 * time.Second}}, ""},
+		{
+			"chain",
+			[]string{"hello", "x3", "bruh", "0:15", "airhorn"},
+			[]PlayRequest{{Name: "hello", Times: 3}, {Name: "bruh", Times: 1, Start: 15 * time.Second}, {Name: "airhorn", Times: 1}},
+			"",
+		},
+		{"repeat before any memo is a name", []string{"x3", "bruh"}, []PlayRequest{{Name: "x3", Times: 1}, {Name: "bruh", Times: 1}}, ""},
+		{"leading dash", []string{"-bruh"}, []PlayRequest{{Name: "bruh", Times: 1}}, ""},
+		{"repeat out of range", []string{"bruh", "x20"}, nil, "x20"},
+		{"zero repeat", []string{"bruh", "x0"}, nil, "x0"},
+		{"nothing", nil, []PlayRequest{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, over := ParsePlayRequests(tt.args)
+			if !reflect.DeepEqual(got, tt.want) || over != tt.wantOver {
+				t.Errorf("ParsePlayRequests(%q) = %+v, %q, want %+v, %q", tt.args, got, over, tt.want, tt.wantOver)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Most memos a playlist can hold, and most playlists a guild can have.
+	maxPlaylistLength = 50
+	maxPlaylists      = 25
+)
+
+// A guild member's named list of memos that can be queued with one command. Memos may repeat.
+type Playlist struct {
+	Name      string    `json:"name"`
+	OwnerID   string    `json:"owner_id"`
+	Memos     []string  `json:"memos"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (pl Playlist) clone() Playlist {
+	pl.Memos = append([]string(nil), pl.Memos...)
+	return pl
+}
+
+// Remove takes every copy of a memo out of the playlist. Returns how many there were.
+func (pl *Playlist) Remove(name string) int {
+	kept := pl.Memos[:0]
+	for _, memo := range pl.Memos {
+		if memo != name {
+			kept = append(kept, memo)
+		}
+	}
+	removed := len(pl.Memos) - len(kept)
+	pl.Memos = kept
+	return removed
+}
+
+func (b *Bot) HandlePlaylist(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := "Usage: !playlist [list] | !playlist show|play|delete <playlist> | !playlist create|add|remove <playlist> <memos...>"
+	if len(args) == 0 || args[0] == "list" {
+		b.SendPlaylists(s, g, c)
+		return
+	}
+	if len(args) < 2 {
+		s.ChannelMessageSend(c.ID, usage)
+		return
+	}
+
+	action, name, memos := args[0], args[1], args[2:]
+	metadata := b.VoiceMemoManager.Metadata
+	pl, exists := metadata.Playlist(g.ID, name)
+	if !exists && action != "create" {
+		s.ChannelMessageSend(c.ID, "There's no playlist called "+name)
+		return
+	}
+
+	// Anyone can make and play playlists, but only their owners and admins can change them.
+	if exists && action != "show" && action != "play" && pl.OwnerID != m.Author.ID && !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins or whoever made "+name+" can change it.")
+		return
+	}
+
+	switch action {
+	case "show":
+		b.SendPlaylist(s, c, pl)
+
+	case "play":
+		b.PlayPlaylist(s, g, c, m.Author.ID, pl)
+
+	case "create":
+		if err := ValidateMemoName(name); err != nil {
+			s.ChannelMessageSend(c.ID, "Could not create playlist: "+err.Error())
+			return
+		}
+		if exists {
+			s.ChannelMessageSend(c.ID, "There's already a playlist called "+name)
+			return
+		}
+		if len(metadata.GuildPlaylists(g.ID)) >= maxPlaylists {
+			s.ChannelMessageSend(c.ID, fmt.Sprintf("%s already has %d playlists, delete one first.", g.Name, maxPlaylists))
+			return
+		}
+		if err := b.checkPlaylistMemos(g.ID, memos, 0); err != nil {
+			s.ChannelMessageSend(c.ID, "Could not create playlist: "+err.Error())
+			return
+		}
+		err := metadata.UpdatePlaylist(g.ID, name, func(pl *Playlist) {
+			pl.OwnerID = m.Author.ID
+			pl.Memos = append(pl.Memos, memos...)
+		})
+		if err != nil {
+			fmt.Println("Error saving playlist ", name, ": ", err)
+			return
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Created playlist %s with %d memos. !playlist play %s to queue it.", name, len(memos), name))
+
+	case "add":
+		if len(memos) == 0 {
+			s.ChannelMessageSend(c.ID, usage)
+			return
+		}
+		if err := b.checkPlaylistMemos(g.ID, memos, len(pl.Memos)); err != nil {
+			s.ChannelMessageSend(c.ID, "Could not add to playlist: "+err.Error())
+			return
+		}
+		err := metadata.UpdatePlaylist(g.ID, name, func(pl *Playlist) {
+			pl.Memos = append(pl.Memos, memos...)
+		})
+		if err != nil {
+			fmt.Println("Error saving playlist ", name, ": ", err)
+			return
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Added %s to %s", strings.Join(memos, ", "), name))
+
+	case "remove":
+		if len(memos) == 0 {
+			s.ChannelMessageSend(c.ID, usage)
+			return
+		}
+		removed := 0
+		err := metadata.UpdatePlaylist(g.ID, name, func(pl *Playlist) {
+			for _, memo := range memos {
+				removed += pl.Remove(memo)
+			}
+		})
+		if err != nil {
+			fmt.Println("Error saving playlist ", name, ": ", err)
+			return
+		}
+		if removed == 0 {
+			s.ChannelMessageSend(c.ID, name+" doesn't have "+strings.Join(memos, ", "))
+			return
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Removed %s from %s", strings.Join(memos, ", "), name))
+
+	case "delete":
+		if err := metadata.RemovePlaylist(g.ID, name); err != nil {
+			fmt.Println("Error deleting playlist ", name, ": ", err)
+			return
+		}
+		s.ChannelMessageSend(c.ID, "Deleted playlist "+name)
+
+	default:
+		s.ChannelMessageSend(c.ID, usage)
+	}
+}
+
+// Checks that memos are in the guild's library and fit in a playlist that already holds length memos.
+func (b *Bot) checkPlaylistMemos(guildID string, memos []string, length int) error {
+	if length+len(memos) > maxPlaylistLength {
+		return fmt.Errorf("playlists can hold up to %d memos", maxPlaylistLength)
+	}
+	for _, memo := range memos {
+		if b.VoiceMemoManager.Get(memo) == nil || !b.VoiceMemoManager.InLibrary(guildID, memo) {
+			return fmt.Errorf("cannot find %s", memo)
+		}
+	}
+	return nil
+}
+
+// Queues a whole playlist as one entry. Memos the user may not play are left out.
+func (b *Bot) PlayPlaylist(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, userID string, pl Playlist) {
+	gs, ok := b.Session(g.ID)
+	if !ok {
+		fmt.Println("Error finding guild session.")
+		s.ChannelMessageSend(c.ID, "I need to !join a voice channel to play a playlist.")
+		return
+	}
+
+	memos := make([]*VoiceMemo, 0, len(pl.Memos))
+	skipped := make([]string, 0)
+	for _, name := range pl.Memos {
+		vm := b.VoiceMemoManager.Get(name)
+		if vm == nil || !b.VoiceMemoManager.InLibrary(g.ID, name) || !b.CanPlay(s, g, c.ID, userID, name) || b.OutsideWindow(g.ID, name) != "" {
+			skipped = append(skipped, name)
+			continue
+		}
+		memos = append(memos, vm)
+	}
+	if len(memos) == 0 {
+		s.ChannelMessageSend(c.ID, "There's nothing in "+pl.Name+" you can play.")
+		return
+	}
+
+	eta := gs.QueueETA()
+	wait := gs.IsVoicePlaying.Load()
+	queued := gs.EnqueuePlaylist(pl.Name, memos, userID, c.ID)
+	if queued == 0 {
+		b.Outbox.Error(s, c.ID, "The queue is full. Try again later.")
+		return
+	}
+	names := make([]string, 0, len(memos))
+	for _, vm := range memos {
+		names = append(names, vm.name)
+	}
+	b.VoiceMemoManager.RecordPlay(g.ID, userID, names...)
+
+	reply := fmt.Sprintf("Queued playlist %s, %d memos.", pl.Name, queued)
+	if wait {
+		reply += " It starts in about " + FormatDuration(eta) + "."
+	}
+	if len(skipped) > 0 {
+		reply += " Left out because they're gone or you can't play them: " + strings.Join(skipped, ", ")
+	}
+	if gs.Paused() {
+		reply += " Playback is paused, !resume to carry on."
+	}
+	if len(reply) > 2000 {
+		reply = reply[:1990] + "..."
+	}
+	s.ChannelMessageSend(c.ID, reply)
+
+	// Playback outlives the command, so it doesn't count against the command timeout.
+	go gs.PlayFromQueue()
+}
+
+func (b *Bot) SendPlaylists(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel) {
+	playlists := b.VoiceMemoManager.Metadata.GuildPlaylists(g.ID)
+	if len(playlists) == 0 {
+		s.ChannelMessageSend(c.ID, "There are no playlists yet. !playlist create <playlist> <memos...> to make one.")
+		return
+	}
+	sort.Slice(playlists, func(i, j int) bool {
+		return playlists[i].Name < playlists[j].Name
+	})
+
+	lines := make([]string, 0, len(playlists))
+	for _, pl := range playlists {
+		lines = append(lines, fmt.Sprintf("%s (%d memos) · by <@%s>", pl.Name, len(pl.Memos), pl.OwnerID))
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "Playlists",
+		Description: strings.Join(lines, "\n"),
+		Color:       65535,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "!playlist show <playlist> to see what's in one"},
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
+
+func (b *Bot) SendPlaylist(s *discordgo.Session, c *discordgo.Channel, pl Playlist) {
+	var total time.Duration
+	lines := make([]string, 0, len(pl.Memos))
+	for i, name := range pl.Memos {
+		line := fmt.Sprintf("%d. %s", i+1, name)
+		if vm := b.VoiceMemoManager.Get(name); vm != nil {
+			line += " (" + FormatDuration(vm.Duration()) + ")"
+			total += vm.Duration()
+		}
+		lines = append(lines, line)
+	}
+
+	description := "This playlist is empty."
+	if len(lines) > 0 {
+		description = strings.Join(lines, "\n")
+	}
+	if len(description) > 4096 {
+		description = description[:4000] + "..."
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       pl.Name,
+		Description: description,
+		Color:       65535,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Made by", Value: "<@" + pl.OwnerID + ">", Inline: true},
+			{Name: "Length", Value: FormatDuration(total), Inline: true},
+		},
+		Timestamp: pl.UpdatedAt.Format(time.RFC3339),
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Queues a memo ahead of everything else waiting, for when it has to play right after the one that's on.
+// Admins and DJs only, since it jumps in front of everyone else's memos.
+func (b *Bot) HandlePlayNext(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	full, args := takeFlag(args, "full")
+	requests, bad := ParsePlayRequests(args)
+	if len(requests) != 1 || bad != "" || requests[0].Times != 1 {
+		s.ChannelMessageSend(c.ID, "Usage: !playnext [-full] <name> [m:ss]")
+		return
+	}
+	if !b.IsDJ(s, g.ID, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins and DJs can put a voice memo in front of the queue.")
+		return
+	}
+	r := requests[0]
+	voiceMemo := b.VoiceMemoManager.GuildMemo(g.ID, r.Name)
+	if voiceMemo == nil {
+		b.Outbox.Error(s, c.ID, "Cannot find "+r.Name)
+		return
+	}
+	if !b.CanPlay(s, g, c.ID, m.Author.ID, voiceMemo.name) {
+		b.Outbox.Error(s, c.ID, "You don't have a role that can play "+voiceMemo.name)
+		return
+	}
+	if reason := b.OutsideWindow(g.ID, voiceMemo.name); reason != "" {
+		b.Outbox.Error(s, c.ID, reason)
+		return
+	}
+	if r.Start >= voiceMemo.Duration() {
+		b.Outbox.Error(s, c.ID, fmt.Sprintf("%s is only %s long.", voiceMemo.name, FormatDuration(voiceMemo.Duration())))
+		return
+	}
+
+	// Join the requester's voice channel if nobody asked for a !join yet.
+	gs, ok := b.SessionFor(s, g, c.ID, m.Author.ID)
+	if !ok {
+		return
+	}
+
+	// Only the memo that's on is ahead of it.
+	eta := time.Duration(gs.remainingFrames.Load()) * frameDuration
+	wait := gs.IsVoicePlaying.Load()
+	entry := QueueEntry{Memo: voiceMemo, RequesterID: m.Author.ID, ChannelID: c.ID, Full: full, Start: int(r.Start / frameDuration)}
+	if !gs.EnqueueNext(entry) {
+		b.Outbox.Error(s, c.ID, "The queue is full. Try again later.")
+		return
+	}
+	b.VoiceMemoManager.RecordPlay(g.ID, m.Author.ID, voiceMemo.name)
+
+	switch {
+	case gs.Paused():
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("%s is up next. Playback is paused, !resume to carry on.", voiceMemo.name))
+	case wait:
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("%s is up next, playing in about %s.", voiceMemo.name, FormatDuration(eta)))
+	}
+
+	// Playback outlives the command, so it doesn't count against the command timeout.
+	go gs.PlayFromQueue()
+}
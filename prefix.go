@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// What commands start with unless a guild picks something else with !prefix.
+	defaultPrefix = "!"
+
+	// Longest prefix !prefix accepts.
+	maxPrefixLength = 5
+)
+
+// Returns what commands start with in the guild.
+func (gs GuildSettings) CommandPrefix() string {
+	if gs.Prefix == "" {
+		return defaultPrefix
+	}
+	return gs.Prefix
+}
+
+// Takes the guild's prefix, or a mention of the bot, off the start of a message. Returns false if the
+// message isn't a command. Mentioning the bot always works, so a forgotten prefix can be looked up and
+// commands without a slash command can still be used with -prefix-commands=false.
+func (b *Bot) TrimCommandPrefix(s *discordgo.Session, guildID, content string) (string, bool) {
+	if s.State.User != nil {
+		for _, mention := range []string{"<@" + s.State.User.ID + ">", "<@!" + s.State.User.ID + ">"} {
+			if strings.HasPrefix(content, mention) {
+				return strings.TrimSpace(strings.TrimPrefix(content, mention)), true
+			}
+		}
+	}
+	if !prefixCommands {
+		return "", false
+	}
+	prefix := b.VoiceMemoManager.Metadata.Guild(guildID).CommandPrefix()
+	if !strings.HasPrefix(content, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(content, prefix), true
+}
+
+// Reports why prefix can't be used, or nil if it can.
+func ValidatePrefix(prefix string) error {
+	if len(prefix) > maxPrefixLength {
+		return fmt.Errorf("it can be up to %d characters long", maxPrefixLength)
+	}
+	for _, r := range prefix {
+		if unicode.IsSpace(r) || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return fmt.Errorf("it can't contain spaces, letters or digits")
+		}
+	}
+	// Discord treats messages starting with these as slash commands, mentions and the like.
+	if strings.HasPrefix(prefix, "/") || strings.HasPrefix(prefix, "<") {
+		return fmt.Errorf("it can't start with / or <")
+	}
+	return nil
+}
+
+// Returns what a command is typed after in the guild: its prefix, or / if prefix commands are turned off.
+// Commands without a slash command are typed after a mention of the bot then.
+func (b *Bot) CommandStart(s *discordgo.Session, guildID, name string) string {
+	if prefixCommands {
+		return b.VoiceMemoManager.Metadata.Guild(guildID).CommandPrefix()
+	}
+	if _, ok := slashCommands[name]; ok || s.State.User == nil {
+		return "/"
+	}
+	return "@" + s.State.User.Username + " "
+}
+
+func (b *Bot) HandlePrefix(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if !prefixCommands {
+		s.ChannelMessageSend(c.ID, "Prefix commands are turned off on this bot, so the prefix isn't used. Slash commands, or commands that mention me, work instead.")
+		return
+	}
+	if len(args) == 0 {
+		prefix := b.VoiceMemoManager.Metadata.Guild(g.ID).CommandPrefix()
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Commands start with %s in %s, e.g. %splay <name>", prefix, g.Name, prefix))
+		return
+	}
+
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can change the command prefix.")
+		return
+	}
+
+	prefix := args[0]
+	if prefix == "default" {
+		prefix = ""
+	} else if err := ValidatePrefix(prefix); err != nil {
+		s.ChannelMessageSend(c.ID, "That prefix won't work, "+err.Error()+". Usage: !prefix [<prefix>|default]")
+		return
+	}
+
+	err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		gs.Prefix = prefix
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+
+	if prefix == "" {
+		prefix = defaultPrefix
+	}
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("Commands now start with %s, e.g. %splay <name>. Mentioning me works too, e.g. <@%s> prefix.", prefix, prefix, s.State.User.ID))
+	b.Audit(s, g.ID, fmt.Sprintf("<@%s> changed the command prefix to %s", m.Author.ID, prefix))
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Bitrate dca encodes at when a guild hasn't picked one, in kb/s.
+	defaultBitrate = 64
+	minBitrate     = 8
+	maxBitrate     = 128
+
+	// Anything quieter than this at the start or end of an upload counts as silence when trimming.
+	silenceThreshold = "-50dB"
+)
+
+// How the upload pipeline encodes a guild's memos. The zero value is the encoding every memo got before
+// presets existed: 64 kb/s stereo, untouched.
+type ConversionPreset struct {
+	// Opus bitrate in kb/s. Zero uses defaultBitrate.
+	Bitrate     int  `json:"bitrate,omitempty"`
+	Mono        bool `json:"mono,omitempty"`
+	Normalize   bool `json:"normalize,omitempty"`
+	TrimSilence bool `json:"trim_silence,omitempty"`
+}
+
+// Ready made presets admins can switch to by name.
+var namedPresets = map[string]ConversionPreset{
+	"default": {},
+	"meme":    {Bitrate: 48, Mono: true, Normalize: true, TrimSilence: true},
+	"music":   {Bitrate: maxBitrate},
+}
+
+func (p ConversionPreset) bitrate() int {
+	if p.Bitrate == 0 {
+		return defaultBitrate
+	}
+	return p.Bitrate
+}
+
+func (p ConversionPreset) channels() int {
+	if p.Mono {
+		return 1
+	}
+	return 2
+}
+
+// Arguments for decoding the input file into the PCM dca expects.
+func (p ConversionPreset) FFmpegArgs(input string) []string {
+	args := []string{"-i", input}
+
+	filters := []string{}
+	if p.TrimSilence {
+		// silenceremove only trims the start reliably, so trim, reverse, trim again and reverse back.
+		trim := "silenceremove=start_periods=1:start_threshold=" + silenceThreshold
+		filters = append(filters, trim, "areverse", trim, "areverse")
+	}
+	if p.Normalize {
+		filters = append(filters, "loudnorm")
+	}
+	if len(filters) > 0 {
+		args = append(args, "-af", strings.Join(filters, ","))
+	}
+
+	return append(args, "-f", "s16le", "-ar", "48000", "-ac", strconv.Itoa(p.channels()), "pipe:1")
+}
+
+// Arguments for encoding that PCM into dca.
+func (p ConversionPreset) DCAArgs() []string {
+	return []string{"-ac", strconv.Itoa(p.channels()), "-ab", strconv.Itoa(p.bitrate())}
+}
+
+func (p ConversionPreset) String() string {
+	channels := "stereo"
+	if p.Mono {
+		channels = "mono"
+	}
+	return fmt.Sprintf("%d kb/s %s, normalization %s, silence trimming %s", p.bitrate(), channels, onOff(p.Normalize), onOff(p.TrimSilence))
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// Parses "on"/"off" style values.
+func parseOnOff(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "on", "yes", "true", "1":
+		return true, nil
+	case "off", "no", "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("%q isn't on or off", s)
+}
+
+func (b *Bot) HandlePreset(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := "Usage: !preset show | !preset <default|meme|music> | !preset <bitrate|mono|normalize|trim> <value>"
+	if len(args) == 0 || args[0] == "show" {
+		preset := b.VoiceMemoManager.Metadata.Guild(g.ID).Preset
+		s.ChannelMessageSend(c.ID, "New uploads in "+g.Name+" are encoded at "+preset.String()+".")
+		return
+	}
+
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can change the conversion preset.")
+		return
+	}
+
+	preset := b.VoiceMemoManager.Metadata.Guild(g.ID).Preset
+	if named, ok := namedPresets[args[0]]; ok {
+		preset = named
+	} else if len(args) < 2 {
+		s.ChannelMessageSend(c.ID, usage)
+		return
+	} else {
+		var err error
+		switch args[0] {
+		case "bitrate":
+			var bitrate int
+			bitrate, err = strconv.Atoi(strings.TrimSuffix(args[1], "k"))
+			if err == nil && (bitrate < minBitrate || bitrate > maxBitrate) {
+				err = fmt.Errorf("the bitrate has to be between %d and %d kb/s", minBitrate, maxBitrate)
+			}
+			preset.Bitrate = bitrate
+		case "mono":
+			preset.Mono, err = parseOnOff(args[1])
+		case "normalize":
+			preset.Normalize, err = parseOnOff(args[1])
+		case "trim":
+			preset.TrimSilence, err = parseOnOff(args[1])
+		default:
+			s.ChannelMessageSend(c.ID, usage)
+			return
+		}
+		if err != nil {
+			s.ChannelMessageSend(c.ID, "Could not change the preset: "+err.Error())
+			return
+		}
+	}
+
+	err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		gs.Preset = preset
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+
+	s.ChannelMessageSend(c.ID, "New uploads in "+g.Name+" will be encoded at "+preset.String()+". Existing memos keep their encoding.")
+}
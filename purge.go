@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How long the button !purge-guild-data asks for confirmation with keeps working.
+const purgeConfirmTTL = 5 * time.Minute
+
+// Everything a guild has stored with the bot, or everything that was deleted when it was purged.
+type PurgeReport struct {
+	Memos     int      `json:"memos"`
+	Failed    []string `json:"failed,omitempty"`
+	Playlists int      `json:"playlists"`
+	Packs     int      `json:"packs"`
+	Plays     int      `json:"plays"`
+	Settings  bool     `json:"settings"`
+	Stats     bool     `json:"stats"`
+
+	// Set when nothing was deleted because the bot runs with -dry-run.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// Lists the report as embed fields.
+func (r PurgeReport) Fields() []*discordgo.MessageEmbedField {
+	fields := []*discordgo.MessageEmbedField{
+		{Name: "Voice memos", Value: fmt.Sprint(r.Memos), Inline: true},
+		{Name: "Playlists", Value: fmt.Sprint(r.Playlists), Inline: true},
+		{Name: "Sound packs", Value: fmt.Sprint(r.Packs), Inline: true},
+		{Name: "Plays in the history", Value: fmt.Sprint(r.Plays), Inline: true},
+		{Name: "Settings", Value: yesNo(r.Settings), Inline: true},
+		{Name: "Voice stats", Value: yesNo(r.Stats), Inline: true},
+	}
+	if len(r.Failed) > 0 {
+		failed := strings.Join(r.Failed, ", ")
+		if len(failed) > 1024 {
+			failed = failed[:1000] + "..."
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Could not delete", Value: failed})
+	}
+	return fields
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
+
+// Counts what a guild has stored, without touching it.
+func (ms *MetadataStore) GuildData(guildID string) PurgeReport {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	report := PurgeReport{Playlists: len(ms.Playlists[guildID]), Plays: ms.History[guildID].Len()}
+	for _, md := range ms.Memos {
+		if md.GuildID == guildID {
+			report.Memos++
+		}
+	}
+	for _, pack := range ms.Packs {
+		if pack.GuildID == guildID {
+			report.Packs++
+		}
+	}
+	_, report.Settings = ms.Guilds[guildID]
+	_, report.Stats = ms.Stats[guildID]
+	return report
+}
+
+// PurgeGuild forgets a guild's settings, playlists, packs, history and stats, and saves. Its memos have
+// to be deleted first, see VoiceMemoManager.PurgeGuild.
+func (ms *MetadataStore) PurgeGuild(guildID string) (PurgeReport, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	report := PurgeReport{Playlists: len(ms.Playlists[guildID]), Plays: ms.History[guildID].Len()}
+	for name, pack := range ms.Packs {
+		if pack.GuildID == guildID {
+			delete(ms.Packs, name)
+			ms.unsubscribeAll(name)
+			report.Packs++
+		}
+	}
+	_, report.Settings = ms.Guilds[guildID]
+	_, report.Stats = ms.Stats[guildID]
+
+	delete(ms.Guilds, guildID)
+	delete(ms.Playlists, guildID)
+	delete(ms.History, guildID)
+	delete(ms.Stats, guildID)
+	ms.dropGuildComponents(guildID)
+	return report, ms.save()
+}
+
+// Deletes every memo a guild uploaded or recorded, then everything else it stored. Memos that are still
+// queued somewhere finish playing first, like with !delete.
+func (m *VoiceMemoManager) PurgeGuild(guildID string) (PurgeReport, error) {
+	deleted, failed := 0, make([]string, 0)
+	for _, md := range m.Metadata.GuildMemos(guildID) {
+		if m.Get(md.Name) == nil {
+			// Only the metadata is left of it.
+			if err := m.Metadata.RemoveMemo(md.Name); err != nil {
+				failed = append(failed, md.Name)
+				continue
+			}
+			m.objectsMu.Lock()
+			m.releaseObject(md.Hash)
+			m.objectsMu.Unlock()
+			deleted++
+			continue
+		}
+		if _, err := m.Delete(md.Name); err != nil {
+			fmt.Println("Error deleting ", md.Name, ": ", err)
+			failed = append(failed, md.Name)
+			continue
+		}
+		deleted++
+	}
+
+	report, err := m.Metadata.PurgeGuild(guildID)
+	report.Memos, report.Failed = deleted, failed
+	return report, err
+}
+
+// Leaves the guild's voice channel and deletes everything the bot stored for it. With -dry-run it only
+// reports what it would delete.
+func (b *Bot) PurgeGuildData(guildID string) (PurgeReport, error) {
+	if dryRun {
+		report := b.VoiceMemoManager.Metadata.GuildData(guildID)
+		report.DryRun = true
+		return report, nil
+	}
+	// Leaving saves the session's stats, so it has to happen before they're purged.
+	b.LeaveGuild(guildID)
+	report, err := b.VoiceMemoManager.PurgeGuild(guildID)
+	b.Jobs.ClearDeadLetters(guildID)
+	return report, err
+}
+
+func (b *Bot) HandlePurgeGuildData(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can delete the server's data.")
+		return
+	}
+	if dry, _ := ParseDryRun(args); dry {
+		names := make([]string, 0)
+		for _, md := range b.VoiceMemoManager.Metadata.GuildMemos(g.ID) {
+			names = append(names, md.Name)
+		}
+		plan := b.VoiceMemoManager.PlanDeletion(names)
+		fields := append(b.VoiceMemoManager.Metadata.GuildData(g.ID).Fields(),
+			&discordgo.MessageEmbedField{Name: "Files removed", Value: fmt.Sprintf("%d (%s)", plan.Files, FormatSize(plan.Bytes)), Inline: true})
+		SendDryRun(s, c.ID, "!purge-guild-data would delete all of "+g.Name+"'s data", fields)
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Delete all of " + g.Name + "'s data?",
+		Description: "This deletes every voice memo uploaded or recorded here, and the server's playlists, sound packs, settings, play history and stats.",
+		Color:       16711680,
+		Fields:      b.VoiceMemoManager.Metadata.GuildData(g.ID).Fields(),
+		Footer:      &discordgo.MessageEmbedFooter{Text: "This can't be undone."},
+	}
+	msg := &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Delete everything", Style: discordgo.DangerButton, CustomID: "purge:" + b.SignCustomID("confirm:"+g.ID, purgeConfirmTTL)},
+			discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: "purge:cancel:" + g.ID},
+		}}},
+	}
+	if _, err := s.ChannelMessageSendComplex(c.ID, msg); err != nil {
+		fmt.Println(err)
+		return
+	}
+}
+
+// Handles the buttons of a !purge-guild-data message. arg is "confirm:<guild id>", signed so it stops working
+// after purgeConfirmTTL, or "cancel:<guild id>".
+func (b *Bot) HandlePurgeButton(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	if i.Member == nil || !HasAdminPermissions(i.Member.Permissions) {
+		RespondEphemeral(s, i, "Only admins can delete the server's data.")
+		return
+	}
+
+	if strings.HasPrefix(arg, "cancel:") {
+		b.replaceComponentMessage(s, i, "Cancelled, nothing was deleted.")
+		return
+	}
+	arg, ok := b.VerifyCustomID(arg)
+	if !ok || arg != "confirm:"+i.GuildID {
+		b.replaceComponentMessage(s, i, "This has expired, nothing was deleted. Run !purge-guild-data again.")
+		return
+	}
+	// The button may be from before the bot was restarted with -dry-run.
+	if dryRun {
+		b.replaceComponentMessageEmbed(s, i, DryRunEmbed("!purge-guild-data would delete all of the server's data", b.VoiceMemoManager.Metadata.GuildData(i.GuildID).Fields()))
+		return
+	}
+
+	// Deleting can take longer than Discord waits for a response, so answer first.
+	b.replaceComponentMessage(s, i, "Deleting the server's data...")
+	report, err := b.PurgeGuildData(i.GuildID)
+	if err != nil {
+		fmt.Println("Error purging guild data: ", err)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Deleted the server's data",
+		Description: fmt.Sprintf("<@%s> deleted everything the bot stored for this server.", i.Member.User.ID),
+		Color:       65535,
+		Fields:      report.Fields(),
+	}
+	if err != nil {
+		embed.Description += " Saving that failed though, so some of it may come back when the bot restarts."
+		embed.Color = 16711680
+	}
+	content := ""
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content, Embeds: &[]*discordgo.MessageEmbed{embed}})
+	if err != nil {
+		fmt.Println("Error editing interaction response: ", err)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import "testing"
+
+// Returns a store where guild 1 has a bit of everything and guild 2 subscribes to its pack.
+func testGuildData(t *testing.T) *MetadataStore {
+	t.Helper()
+	ms := testMetadataStore(t)
+	addTestMemo(t, ms, "1", "bruh")
+	addTestMemo(t, ms, "1", "oof")
+	addTestMemo(t, ms, "2", "honk")
+
+	steps := []error{
+		ms.UpdatePack("sounds", func(pack *SoundPack) {
+			pack.GuildID = "1"
+			pack.Memos = []string{"bruh"}
+			pack.Published = true
+		}),
+		ms.UpdatePlaylist("1", "mix", func(pl *Playlist) { pl.Memos = []string{"bruh", "oof"} }),
+		ms.UpdatePlaylist("2", "mix", func(pl *Playlist) { pl.Memos = []string{"honk"} }),
+		ms.UpdateGuild("1", func(gs *GuildSettings) { gs.AutoJoin = 3 }),
+		ms.UpdateGuild("2", func(gs *GuildSettings) { gs.Subscriptions = []string{"sounds"} }),
+		ms.RecordPlay("1", PlayRecord{Memo: "bruh"}),
+		ms.RecordPlay("1", PlayRecord{Memo: "oof"}),
+	}
+	for _, err := range steps {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ms
+}
+
+func TestGuildData(t *testing.T) {
+	tests := []struct {
+		guildID string
+		want    PurgeReport
+	}{
+		{"1", PurgeReport{Memos: 2, Playlists: 1, Packs: 1, Plays: 2, Settings: true, Stats: true}},
+		{"2", PurgeReport{Memos: 1, Playlists: 1, Settings: true}},
+		{"3", PurgeReport{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.guildID, func(t *testing.T) {
+			ms := testGuildData(t)
+			if got := ms.GuildData(tt.guildID); got.Memos != tt.want.Memos || got.Playlists != tt.want.Playlists ||
+				got.Packs != tt.want.Packs || got.Plays != tt.want.Plays || got.Settings != tt.want.Settings || got.Stats != tt.want.Stats {
+				t.Errorf("GuildData(%s) = %+v, want %+v", tt.guildID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetadataPurgeGuild(t *testing.T) {
+	ms := testGuildData(t)
+	report, err := ms.PurgeGuild("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Playlists != 1 || report.Packs != 1 || report.Plays != 2 || !report.Settings || !report.Stats {
+		t.Errorf("purging guild 1 reported %+v", report)
+	}
+
+	tests := []struct {
+		name string
+		got  int
+		want int
+	}{
+		{"purged playlists", ms.GuildData("1").Playlists, 0},
+		{"purged packs", ms.GuildData("1").Packs, 0},
+		{"purged history", len(ms.GuildHistory("1")), 0},
+		{"subscriptions to purged packs", len(ms.Guild("2").Subscriptions), 0},
+		{"other guild's playlists", ms.GuildData("2").Playlists, 1},
+		{"other guild's memos", ms.GuildData("2").Memos, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("%s: got %d, want %d", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+	if _, ok := ms.Pack("sounds"); ok {
+		t.Error("purged guild's pack is still there")
+	}
+}
@@ -0,0 +1,649 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A memo waiting in a guild's queue, who asked for it and where.
+type QueueEntry struct {
+	Memo        *VoiceMemo
+	RequesterID string
+	ChannelID   string
+	QueuedAt    time.Time
+
+	// Numbered by the queue when the entry is added, so entries queued at the same moment still tell apart.
+	Seq uint64
+
+	// Times playing the memo failed because the voice connection stopped taking audio.
+	Attempts int
+
+	// Set to play the memo past the guild's !maxplay, as !play -full does.
+	Full bool
+
+	// Frame the memo starts at, as !play <name> 0:15 asks for. Loops and retries start there too.
+	Start int
+
+	// Set when the entry is a playlist: its name and the memos that play after Memo. A playlist takes a
+	// single place in the queue however long it is, and every memo in it is already acquired.
+	Playlist string
+	Rest     []*VoiceMemo
+}
+
+// Returns every memo the entry will play, in order.
+func (e QueueEntry) Memos() []*VoiceMemo {
+	return append([]*VoiceMemo{e.Memo}, e.Rest...)
+}
+
+// Returns how many frames the entry plays, counting its memo from Start.
+func (e QueueEntry) Frames() int {
+	frames := e.memoFrames()
+	for _, vm := range e.Rest {
+		frames += vm.Frames()
+	}
+	return frames
+}
+
+// Frames of Memo left to play from Start. Starts past the end play the whole memo, just as the player does.
+func (e QueueEntry) memoFrames() int {
+	frames := e.Memo.Frames()
+	if e.Start < frames {
+		frames -= e.Start
+	}
+	return frames
+}
+
+// Returns how long the entry takes to play, not counting the gaps between a playlist's memos.
+func (e QueueEntry) Duration() time.Duration {
+	return time.Duration(e.Frames()) * frameDuration
+}
+
+// A guild's memos waiting to play, in order. Unlike a channel it can be looked into and rearranged.
+type PlayQueue struct {
+	mu       sync.Mutex
+	entries  []QueueEntry
+	capacity int
+
+	// The last Seq handed out.
+	seq uint64
+}
+
+func NewPlayQueue(capacity int) *PlayQueue {
+	return &PlayQueue{entries: make([]QueueEntry, 0, capacity), capacity: capacity}
+}
+
+// Adds an entry to the back of the queue. Returns false if the queue is full.
+func (q *PlayQueue) Push(entry QueueEntry) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.entries) >= q.capacity {
+		return false
+	}
+	q.entries = append(q.entries, q.number(entry))
+	return true
+}
+
+// Adds an entry to the front of the queue, to play next. Returns false if the queue is full.
+func (q *PlayQueue) Insert(entry QueueEntry) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.entries) >= q.capacity {
+		return false
+	}
+	q.entries = append([]QueueEntry{q.number(entry)}, q.entries...)
+	return true
+}
+
+// Puts an entry back at the front of the queue, even if it's full, because it was only just taken off. It keeps
+// its Seq.
+func (q *PlayQueue) PushFront(entry QueueEntry) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if entry.Seq == 0 {
+		entry = q.number(entry)
+	}
+	q.entries = append([]QueueEntry{entry}, q.entries...)
+}
+
+// Gives an entry that's being added the next Seq. q.mu must be held.
+func (q *PlayQueue) number(entry QueueEntry) QueueEntry {
+	q.seq++
+	entry.Seq = q.seq
+	return entry
+}
+
+// Takes the entry at the front of the queue. Returns false if the queue is empty.
+func (q *PlayQueue) Pop() (QueueEntry, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.entries) == 0 {
+		return QueueEntry{}, false
+	}
+	entry := q.entries[0]
+	q.entries = append(q.entries[:0], q.entries[1:]...)
+	return entry, true
+}
+
+// Number of entries waiting.
+func (q *PlayQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.entries)
+}
+
+// Returns a copy of what's waiting to play, next first.
+func (q *PlayQueue) Entries() []QueueEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return append([]QueueEntry(nil), q.entries...)
+}
+
+// Empties the queue and returns what was in it.
+func (q *PlayQueue) Clear() []QueueEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	cleared := q.entries
+	q.entries = make([]QueueEntry, 0, q.capacity)
+	return cleared
+}
+
+// Takes every waiting entry that match reports true for off the queue and returns them.
+func (q *PlayQueue) RemoveFunc(match func(QueueEntry) bool) []QueueEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	removed := make([]QueueEntry, 0)
+	kept := q.entries[:0]
+	for _, entry := range q.entries {
+		if match(entry) {
+			removed = append(removed, entry)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	q.entries = kept
+	return removed
+}
+
+// Moves the first waiting entry that match reports true for to index to, or as close to it as the queue is
+// long. Returns false if no entry matched.
+func (q *PlayQueue) Move(match func(QueueEntry) bool, to int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, entry := range q.entries {
+		if !match(entry) {
+			continue
+		}
+		q.entries = append(q.entries[:i], q.entries[i+1:]...)
+		if to > len(q.entries) {
+			to = len(q.entries)
+		}
+		if to < 0 {
+			to = 0
+		}
+		q.entries = append(q.entries[:to], append([]QueueEntry{entry}, q.entries[to:]...)...)
+		return true
+	}
+	return false
+}
+
+// Puts the waiting entries in a random order.
+func (q *PlayQueue) Shuffle() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	rng.Shuffle(len(q.entries), func(i, j int) {
+		q.entries[i], q.entries[j] = q.entries[j], q.entries[i]
+	})
+}
+
+// Returns the memo that's playing and who asked for it, or nil while idle.
+func (gs *GuildSession) Current() (*VoiceMemo, string) {
+	gs.currentMu.Lock()
+	defer gs.currentMu.Unlock()
+
+	return gs.current, gs.currentBy
+}
+
+func (b *Bot) HandleQueue(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel) {
+	gs, ok := b.Session(g.ID)
+	if !ok {
+		fmt.Println("Error finding guild session.")
+		s.ChannelMessageSend(c.ID, "I'm not in a voice channel in "+g.Name)
+		return
+	}
+
+	embed, components := gs.QueueMessage()
+	if embed == nil {
+		s.ChannelMessageSend(c.ID, "The queue is empty.")
+		return
+	}
+
+	msg := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}, Components: components}
+	if _, err := s.ChannelMessageSendComplex(c.ID, msg); err != nil {
+		fmt.Println(err)
+		return
+	}
+}
+
+// Identifies a queue entry for the !queue menu, !remove and !move. Entries keep it while they move up the queue.
+func queueEntryID(entry QueueEntry) string {
+	return strconv.FormatUint(entry.Seq, 36)
+}
+
+// Builds the !queue embed and the buttons and menu under it from the queue as it is now. The embed is nil if
+// nothing is playing or queued.
+func (gs *GuildSession) QueueMessage() (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	current, requester := gs.Current()
+	pending := gs.PlayQueue.Entries()
+	if current == nil && len(pending) == 0 {
+		return nil, nil
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:  "Queue",
+		Color:  65535,
+		Fields: []*discordgo.MessageEmbedField{},
+		Footer: &discordgo.MessageEmbedFooter{Text: FormatDuration(gs.QueueETA()) + " remaining in total"},
+	}
+	if gs.Paused() {
+		embed.Title += " (paused)"
+	}
+	if gs.LoopOne.Load() {
+		embed.Title += " · looping the current memo"
+	} else if gs.LoopQueue.Load() {
+		embed.Title += " · looping the queue"
+	}
+
+	if current != nil {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Now playing",
+			Value: fmt.Sprintf("%s · %s left%s", current.name, FormatDuration(time.Duration(gs.remainingFrames.Load())*frameDuration), requestedBy(requester)),
+		})
+	}
+
+	// Each memo's wait is everything ahead of it plus the gaps between them.
+	wait := time.Duration(gs.remainingFrames.Load()) * frameDuration
+	lines := make([]string, 0, len(pending))
+	options := make([]discordgo.SelectMenuOption, 0)
+	for i, entry := range pending {
+		wait += playbackGap
+		name := entry.Memo.name
+		if entry.Playlist != "" {
+			name = fmt.Sprintf("Playlist %s: %s and %d more", entry.Playlist, entry.Memo.name, len(entry.Rest))
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s (%s) · in %s%s", i+1, name, FormatDuration(entry.Duration()), FormatDuration(wait), requestedBy(entry.RequesterID)))
+		wait += entry.Duration() + time.Duration(len(entry.Rest))*playbackGap
+
+		// Select menus are limited to 25 options of up to 100 characters.
+		if len(options) < 25 {
+			label := fmt.Sprintf("%d. %s", i+1, name)
+			if len(label) > 100 {
+				label = label[:97] + "..."
+			}
+			options = append(options, discordgo.SelectMenuOption{Label: label, Value: queueEntryID(entry)})
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "Nothing else is queued.")
+	}
+	upNext := strings.Join(lines, "\n")
+	if len(upNext) > 1024 {
+		upNext = upNext[:1000] + "..."
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:  "Up next",
+		Value: upNext,
+	})
+
+	components := make([]discordgo.MessageComponent, 0, 2)
+	if len(options) > 0 {
+		components = append(components, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{
+				CustomID:    "queue:remove",
+				Placeholder: "Remove from the queue",
+				MaxValues:   len(options),
+				Options:     options,
+			},
+		}})
+	}
+	components = append(components, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: "Skip", Style: discordgo.PrimaryButton, CustomID: "queue:skip", Disabled: current == nil},
+		discordgo.Button{Label: "Clear mine", Style: discordgo.SecondaryButton, CustomID: "queue:clearmine", Disabled: len(pending) == 0},
+		discordgo.Button{Label: "Shuffle", Style: discordgo.SecondaryButton, CustomID: "queue:shuffle", Disabled: len(pending) < 2},
+		discordgo.Button{Label: "Refresh", Style: discordgo.SecondaryButton, CustomID: "queue:refresh"},
+	}})
+	return embed, components
+}
+
+// Handles the buttons and menu of a !queue message. They act on the queue as it is when they're used, so
+// whoever uses them can skip or remove what they asked for themselves, and admins and DJs anything.
+func (b *Bot) HandleQueueInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, action string) {
+	gs, ok := b.Session(i.GuildID)
+	if !ok {
+		b.replaceComponentMessage(s, i, "I'm not in a voice channel anymore.")
+		return
+	}
+	userID := InteractionUserID(i)
+	dj := b.IsDJ(s, i.GuildID, userID, i.ChannelID)
+
+	notice := ""
+	switch action {
+	case "skip":
+		if current, _ := gs.Current(); current == nil {
+			notice = "Nothing is playing."
+			break
+		}
+		if refusal := b.QueueRefusal(s, gs, i.ChannelID, userID, "skip"); refusal != "" {
+			RespondEphemeral(s, i, refusal)
+			return
+		}
+		if skipped, ok := gs.Skip(); ok {
+			notice = fmt.Sprintf("<@%s> skipped %s", userID, skipped.name)
+		}
+	case "remove":
+		selected := make(map[string]bool)
+		for _, id := range i.MessageComponentData().Values {
+			selected[id] = true
+		}
+		for _, entry := range gs.PlayQueue.Entries() {
+			if selected[queueEntryID(entry)] && entry.RequesterID != userID && !dj {
+				RespondEphemeral(s, i, "Only admins and DJs can remove voice memos someone else queued.")
+				return
+			}
+		}
+		removed := gs.PlayQueue.RemoveFunc(func(entry QueueEntry) bool {
+			return selected[queueEntryID(entry)]
+		})
+		notice = "That already left the queue."
+		if len(removed) > 0 {
+			notice = fmt.Sprintf("<@%s> removed %s from the queue.", userID, gs.releaseAll(removed))
+		}
+	case "clearmine":
+		removed := gs.PlayQueue.RemoveFunc(func(entry QueueEntry) bool {
+			return entry.RequesterID == userID
+		})
+		if len(removed) == 0 {
+			RespondEphemeral(s, i, "You don't have anything queued.")
+			return
+		}
+		notice = fmt.Sprintf("<@%s> removed %s from the queue.", userID, gs.releaseAll(removed))
+	case "shuffle":
+		if refusal := b.QueueRefusal(s, gs, i.ChannelID, userID, "shuffle"); refusal != "" {
+			RespondEphemeral(s, i, refusal)
+			return
+		}
+		gs.PlayQueue.Shuffle()
+		notice = fmt.Sprintf("<@%s> shuffled the queue.", userID)
+	}
+
+	embed, components := gs.QueueMessage()
+	if embed == nil {
+		b.replaceComponentMessage(s, i, strings.TrimSpace(notice+"\nThe queue is empty."))
+		return
+	}
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    notice,
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+	if err != nil {
+		fmt.Println("Error responding to interaction: ", err)
+	}
+}
+
+// Returns why userID may not "skip", "stop", "clear" or "shuffle" the queue of gs, or "" if they may. The
+// commands and the buttons both ask here, so they agree. Whoever asked for the memo that's playing can skip
+// it, but stopping, clearing and shuffling touch everyone's memos, so only admins and DJs can.
+func (b *Bot) QueueRefusal(s *discordgo.Session, gs *GuildSession, channelID, userID, action string) string {
+	if b.IsDJ(s, gs.ID, userID, channelID) {
+		return ""
+	}
+	switch action {
+	case "skip":
+		current, requester := gs.Current()
+		if current == nil || requester == userID {
+			return ""
+		}
+		return "Only admins, DJs and whoever asked for " + current.name + " can skip it."
+	case "stop":
+		return "Only admins and DJs can stop playback, it clears everyone's queue."
+	case "clear":
+		return "Only admins and DJs can clear the queue."
+	default:
+		return "Only admins and DJs can shuffle the queue."
+	}
+}
+
+// Releases entries taken off the queue and describes them, e.g. "bruh" or "3 voice memos".
+func (gs *GuildSession) releaseAll(entries []QueueEntry) string {
+	released := 0
+	for _, entry := range entries {
+		released += gs.release(entry)
+	}
+	if released == 1 {
+		return entries[0].Memo.name
+	}
+	return fmt.Sprintf("%d voice memos", released)
+}
+
+func (b *Bot) HandleClearQueue(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate) {
+	gs, ok := b.Session(g.ID)
+	if !ok {
+		fmt.Println("Error finding guild session.")
+		s.ChannelMessageSend(c.ID, "I'm not in a voice channel in "+g.Name)
+		return
+	}
+	if refusal := b.QueueRefusal(s, gs, c.ID, m.Author.ID, "clear"); refusal != "" {
+		s.ChannelMessageSend(c.ID, refusal)
+		return
+	}
+
+	// Whatever is playing right now finishes, !stop cuts it off too.
+	cleared := gs.flush()
+	if cleared == 0 {
+		s.ChannelMessageSend(c.ID, "The queue is empty.")
+		return
+	}
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("Cleared %d voice memos from the queue.", cleared))
+}
+
+// Takes one entry off the queue by its number in !queue. Anyone can remove what they asked for, admins and DJs
+// anything.
+func (b *Bot) HandleRemove(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	gs, ok := b.Session(g.ID)
+	if !ok {
+		fmt.Println("Error finding guild session.")
+		s.ChannelMessageSend(c.ID, "I'm not in a voice channel in "+g.Name)
+		return
+	}
+
+	pending := gs.PlayQueue.Entries()
+	if len(pending) == 0 {
+		s.ChannelMessageSend(c.ID, "The queue is empty.")
+		return
+	}
+	n := 0
+	if len(args) == 1 {
+		n, _ = strconv.Atoi(strings.TrimSuffix(args[0], "."))
+	}
+	if n < 1 || n > len(pending) {
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Usage: !remove <1-%d>, the number the memo has in !queue", len(pending)))
+		return
+	}
+
+	entry := pending[n-1]
+	if entry.RequesterID != m.Author.ID && !b.IsDJ(s, g.ID, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins and DJs can remove voice memos someone else queued.")
+		return
+	}
+	// The queue may have moved on since it was looked at, so the entry is found again by its ID.
+	id := queueEntryID(entry)
+	removed := gs.PlayQueue.RemoveFunc(func(e QueueEntry) bool {
+		return queueEntryID(e) == id
+	})
+	if len(removed) == 0 {
+		s.ChannelMessageSend(c.ID, "That already left the queue.")
+		return
+	}
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("Removed %s from the queue.", gs.releaseAll(removed)))
+}
+
+// Moves one entry to another place in the queue, both by their numbers in !queue. Admins and DJs only, since
+// it changes when everyone else's memos play.
+func (b *Bot) HandleMove(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	gs, ok := b.Session(g.ID)
+	if !ok {
+		fmt.Println("Error finding guild session.")
+		s.ChannelMessageSend(c.ID, "I'm not in a voice channel in "+g.Name)
+		return
+	}
+	if !b.IsDJ(s, g.ID, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins and DJs can rearrange the queue.")
+		return
+	}
+
+	pending := gs.PlayQueue.Entries()
+	if len(pending) < 2 {
+		s.ChannelMessageSend(c.ID, "There's nothing to rearrange.")
+		return
+	}
+	from, to := 0, 0
+	if len(args) == 2 {
+		from, _ = strconv.Atoi(strings.TrimSuffix(args[0], "."))
+		to, _ = strconv.Atoi(strings.TrimSuffix(args[1], "."))
+	}
+	if from < 1 || from > len(pending) || to < 1 || to > len(pending) {
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Usage: !move <from> <to>, the numbers in !queue from 1 to %d, e.g. !move %d 1", len(pending), len(pending)))
+		return
+	}
+	if from == to {
+		s.ChannelMessageSend(c.ID, "That's where it is already.")
+		return
+	}
+
+	// The queue may have moved on since it was looked at, so the entry is found again by its ID.
+	entry := pending[from-1]
+	id := queueEntryID(entry)
+	if !gs.PlayQueue.Move(func(e QueueEntry) bool { return queueEntryID(e) == id }, to-1) {
+		s.ChannelMessageSend(c.ID, "That already left the queue.")
+		return
+	}
+	name := entry.Memo.name
+	if entry.Playlist != "" {
+		name = "Playlist " + entry.Playlist
+	}
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("Moved %s to number %d in the queue.", name, to))
+}
+
+func (b *Bot) HandleShuffle(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate) {
+	gs, ok := b.Session(g.ID)
+	if !ok {
+		fmt.Println("Error finding guild session.")
+		s.ChannelMessageSend(c.ID, "I'm not in a voice channel in "+g.Name)
+		return
+	}
+	if refusal := b.QueueRefusal(s, gs, c.ID, m.Author.ID, "shuffle"); refusal != "" {
+		s.ChannelMessageSend(c.ID, refusal)
+		return
+	}
+
+	queued := gs.PlayQueue.Len()
+	if queued < 2 {
+		s.ChannelMessageSend(c.ID, "There's nothing to shuffle.")
+		return
+	}
+	gs.PlayQueue.Shuffle()
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("Shuffled %d voice memos. !queue to see the new order.", queued))
+}
+
+// Toggles looping the memo that's playing, or the whole queue if queue is set. "on" and "off" set it instead.
+func (b *Bot) HandleLoop(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, args []string, queue bool) {
+	gs, ok := b.Session(g.ID)
+	if !ok {
+		fmt.Println("Error finding guild session.")
+		s.ChannelMessageSend(c.ID, "I'm not in a voice channel in "+g.Name)
+		return
+	}
+
+	flag, what := &gs.LoopOne, "the current memo"
+	if queue {
+		flag, what = &gs.LoopQueue, "the queue"
+	}
+	on := !flag.Load()
+	if len(args) > 0 {
+		var err error
+		if on, err = parseOnOff(args[0]); err != nil {
+			s.ChannelMessageSend(c.ID, "Usage: !loop [on|off] | !loopqueue [on|off]")
+			return
+		}
+	}
+	flag.Store(on)
+
+	if !on {
+		s.ChannelMessageSend(c.ID, "Stopped looping "+what+".")
+		return
+	}
+	s.ChannelMessageSend(c.ID, "Looping "+what+" until you turn it off.")
+}
+
+// Posts what just started playing to the channel it was asked for in, with buttons to control it.
+func (b *Bot) SendNowPlaying(s *discordgo.Session, gs *GuildSession, entry QueueEntry) {
+	if entry.ChannelID == "" {
+		return
+	}
+
+	vm := entry.Memo
+	embed := &discordgo.MessageEmbed{
+		Title:       "Now playing",
+		Description: vm.name,
+		Color:       65535,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Length", Value: FormatDuration(vm.Duration()), Inline: true},
+		},
+	}
+	if uploaderID := b.VoiceMemoManager.Metadata.Memo(vm.name).UploaderID; uploaderID != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Uploaded by", Value: "<@" + uploaderID + ">", Inline: true})
+	}
+	if entry.RequesterID != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Requested by", Value: "<@" + entry.RequesterID + ">", Inline: true})
+	}
+
+	msg := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}, Components: gs.PlaybackControls(vm)}
+	sent, err := s.ChannelMessageSendComplex(entry.ChannelID, msg)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	gs.setNowPlaying(sent.ID)
+	b.AddVolumeReactions(s, gs.ID, sent)
+}
+
+func requestedBy(userID string) string {
+	if userID == "" {
+		return ""
+	}
+	return " · requested by <@" + userID + ">"
+}
+
+// Formats a duration as m:ss, rounding up so "0:00" only ever means nothing left.
+func FormatDuration(d time.Duration) string {
+	seconds := int((d + time.Second - 1) / time.Second)
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
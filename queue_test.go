@@ -0,0 +1,232 @@
+package main
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+// Returns a queue holding a memo for each name, in order.
+func testQueue(names ...string) *PlayQueue {
+	q := NewPlayQueue(len(names) + 1)
+	for _, name := range names {
+		q.Push(QueueEntry{Memo: &VoiceMemo{name: name}})
+	}
+	return q
+}
+
+// Returns the names of the memos waiting in a queue, next first.
+func queuedNames(q *PlayQueue) []string {
+	names := make([]string, 0, q.Len())
+	for _, entry := range q.Entries() {
+		names = append(names, entry.Memo.name)
+	}
+	return names
+}
+
+func TestPlayQueuePushPop(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		push     []string
+		wantKept []string
+	}{
+		{"empty", 2, nil, []string{}},
+		{"in order", 3, []string{"bruh", "oof", "honk"}, []string{"bruh", "oof", "honk"}},
+		{"full", 2, []string{"bruh", "oof", "honk"}, []string{"bruh", "oof"}},
+		{"no room", 0, []string{"bruh"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewPlayQueue(tt.capacity)
+			for i, name := range tt.push {
+				if ok := q.Push(QueueEntry{Memo: &VoiceMemo{name: name}}); ok != (i < tt.capacity) {
+					t.Errorf("pushing %s into a queue of %d returned %v", name, i, ok)
+				}
+			}
+
+			got := make([]string, 0)
+			for {
+				entry, ok := q.Pop()
+				if !ok {
+					break
+				}
+				got = append(got, entry.Memo.name)
+			}
+			if !equalStrings(got, tt.wantKept) {
+				t.Errorf("popped %v, want %v", got, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestPlayQueueShuffle(t *testing.T) {
+	tests := []struct {
+		name  string
+		queue []string
+	}{
+		{"empty", nil},
+		{"one", []string{"bruh"}},
+		{"two", []string{"bruh", "oof"}},
+		{"duplicates", []string{"bruh", "bruh", "oof", "bruh"}},
+		{"many", []string{"a", "b", "c", "d", "e", "f", "g", "h"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := testQueue(tt.queue...)
+			before := q.Entries()
+			q.Shuffle()
+
+			got := queuedNames(q)
+			want := append([]string(nil), tt.queue...)
+			sort.Strings(got)
+			sort.Strings(want)
+			if !equalStrings(got, want) {
+				t.Errorf("shuffled queue holds %v, want %v", got, want)
+			}
+
+			// Entries keep what they were queued with, including the ID the !queue menu knows them by.
+			seqs := make(map[uint64]string)
+			for _, entry := range before {
+				seqs[entry.Seq] = entry.Memo.name
+			}
+			for _, entry := range q.Entries() {
+				if name, ok := seqs[entry.Seq]; !ok || name != entry.Memo.name {
+					t.Errorf("entry %d (%s) wasn't in the queue before shuffling", entry.Seq, entry.Memo.name)
+				}
+				delete(seqs, entry.Seq)
+			}
+		})
+	}
+}
+
+func TestPlayQueueRemoveFunc(t *testing.T) {
+	tests := []struct {
+		name        string
+		queue       []string
+		remove      string
+		wantRemoved []string
+		wantKept    []string
+	}{
+		{"empty", nil, "bruh", []string{}, []string{}},
+		{"no match", []string{"bruh", "oof"}, "honk", []string{}, []string{"bruh", "oof"}},
+		{"first", []string{"bruh", "oof", "honk"}, "bruh", []string{"bruh"}, []string{"oof", "honk"}},
+		{"last", []string{"bruh", "oof", "honk"}, "honk", []string{"honk"}, []string{"bruh", "oof"}},
+		{"every copy", []string{"bruh", "oof", "bruh", "honk", "bruh"}, "bruh", []string{"bruh", "bruh", "bruh"}, []string{"oof", "honk"}},
+		{"everything", []string{"bruh", "bruh"}, "bruh", []string{"bruh", "bruh"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := testQueue(tt.queue...)
+			removed := q.RemoveFunc(func(entry QueueEntry) bool { return entry.Memo.name == tt.remove })
+
+			got := make([]string, 0, len(removed))
+			for _, entry := range removed {
+				got = append(got, entry.Memo.name)
+			}
+			if !equalStrings(got, tt.wantRemoved) {
+				t.Errorf("removed %v, want %v", got, tt.wantRemoved)
+			}
+			if kept := queuedNames(q); !equalStrings(kept, tt.wantKept) {
+				t.Errorf("queue holds %v, want %v", kept, tt.wantKept)
+			}
+		})
+	}
+}
+
+// !remove and the !queue menu pick entries by queueEntryID, so copies of the same memo queued at the same
+// moment have to come out one at a time.
+func TestPlayQueueRemoveByID(t *testing.T) {
+	q := testQueue("bruh", "bruh", "bruh")
+	entries := q.Entries()
+	ids := make(map[string]bool)
+	for _, entry := range entries {
+		ids[queueEntryID(entry)] = true
+	}
+	if len(ids) != len(entries) {
+		t.Fatalf("%d entries share %d IDs", len(entries), len(ids))
+	}
+
+	id := queueEntryID(entries[1])
+	removed := q.RemoveFunc(func(entry QueueEntry) bool { return queueEntryID(entry) == id })
+	if len(removed) != 1 || removed[0].Seq != entries[1].Seq {
+		t.Fatalf("removed %v, want only the second entry", removed)
+	}
+	if q.Len() != 2 {
+		t.Errorf("queue holds %d entries, want 2", q.Len())
+	}
+}
+
+func TestPlayQueueMove(t *testing.T) {
+	tests := []struct {
+		name   string
+		queue  []string
+		move   string
+		to     int
+		wantOK bool
+		want   []string
+	}{
+		{"to the front", []string{"a", "b", "c", "d"}, "c", 0, true, []string{"c", "a", "b", "d"}},
+		{"to the back", []string{"a", "b", "c", "d"}, "a", 3, true, []string{"b", "c", "d", "a"}},
+		{"down one", []string{"a", "b", "c", "d"}, "b", 2, true, []string{"a", "c", "b", "d"}},
+		{"where it is", []string{"a", "b", "c"}, "b", 1, true, []string{"a", "b", "c"}},
+		{"past the end", []string{"a", "b", "c"}, "a", 10, true, []string{"b", "c", "a"}},
+		{"before the start", []string{"a", "b", "c"}, "c", -4, true, []string{"c", "a", "b"}},
+		{"only the first copy", []string{"a", "b", "a"}, "a", 2, true, []string{"b", "a", "a"}},
+		{"no match", []string{"a", "b"}, "z", 0, false, []string{"a", "b"}},
+		{"empty", nil, "a", 0, false, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := testQueue(tt.queue...)
+			if ok := q.Move(func(entry QueueEntry) bool { return entry.Memo.name == tt.move }, tt.to); ok != tt.wantOK {
+				t.Errorf("Move returned %v, want %v", ok, tt.wantOK)
+			}
+			if got := queuedNames(q); !equalStrings(got, tt.want) {
+				t.Errorf("queue holds %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueueEntryFrames(t *testing.T) {
+	memo := func(frames int) *VoiceMemo { return &VoiceMemo{buffer: make([][]byte, frames)} }
+	tests := []struct {
+		name  string
+		entry QueueEntry
+		want  int
+	}{
+		{"whole memo", QueueEntry{Memo: memo(50)}, 50},
+		{"from a timestamp", QueueEntry{Memo: memo(50), Start: 20}, 30},
+		{"start past the end", QueueEntry{Memo: memo(50), Start: 50}, 50},
+		{"playlist", QueueEntry{Memo: memo(50), Rest: []*VoiceMemo{memo(10), memo(5)}}, 65},
+		{"rest of a playlist plays whole", QueueEntry{Memo: memo(50), Start: 40, Rest: []*VoiceMemo{memo(10)}}, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.entry.Frames(); got != tt.want {
+				t.Errorf("Frames() = %d, want %d", got, tt.want)
+			}
+			if got, want := tt.entry.Duration(), time.Duration(tt.want)*frameDuration; got != want {
+				t.Errorf("Duration() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+// Whatever way entries leave the queue, the frames counted for the ETA have to go with them.
+func TestQueuedFramesRelease(t *testing.T) {
+	gs := &GuildSession{PlayQueue: NewPlayQueue(5)}
+	for _, start := range []int{0, 20} {
+		if !gs.EnqueueEntry(QueueEntry{Memo: &VoiceMemo{name: "bruh", buffer: make([][]byte, 50)}, Start: start}) {
+			t.Fatal("couldn't queue bruh")
+		}
+	}
+	if got := gs.queuedFrames.Load(); got != 80 {
+		t.Errorf("queued %d frames, want 80", got)
+	}
+	gs.flush()
+	if got := gs.queuedFrames.Load(); got != 0 {
+		t.Errorf("%d frames still counted after flushing the queue", got)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Shuffles and random picks shouldn't come out the same every time the bot starts. The top-level math/rand
+// functions aren't seeded for this module's Go version, and rand.Seed is deprecated, so everything random
+// draws from rng.
+var rng = rand.New(&lockedSource{src: rand.NewSource(time.Now().UnixNano())})
+
+// Lets concurrent handlers share rng, as sources from rand.NewSource aren't safe for concurrent use.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
+
+const (
+	// How many of the latest plays !random stays away from unless !random avoid says otherwise, and the most
+	// it can be set to.
+	defaultRandomAvoid = 10
+	maxRandomAvoid     = 100
+)
+
+// Picks one of candidates at random, staying away from the memos among the guild's last avoid plays. If every
+// candidate was played that recently, picks the one that was played longest ago. Returns "" if there are no
+// candidates.
+func (m *VoiceMemoManager) PickRandom(guildID string, candidates []string, avoid int) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	// How many plays ago each of the recent memos was last played, 0 being the latest.
+	playedAgo := make(map[string]int)
+	for i, play := range m.Metadata.RecentPlays(guildID, avoid) {
+		if _, ok := playedAgo[play.Memo]; !ok {
+			playedAgo[play.Memo] = i
+		}
+	}
+
+	fresh := make([]string, 0, len(candidates))
+	for _, name := range candidates {
+		if _, ok := playedAgo[name]; !ok {
+			fresh = append(fresh, name)
+		}
+	}
+	if len(fresh) > 0 {
+		return fresh[rng.Intn(len(fresh))]
+	}
+
+	oldest := candidates[0]
+	for _, name := range candidates[1:] {
+		if playedAgo[name] > playedAgo[oldest] {
+			oldest = name
+		}
+	}
+	return oldest
+}
+
+// Plays a random memo from the guild's library, out of the ones with the tags asked for if there are any.
+// Long-form memos and ones the user isn't allowed to play are never picked, and recently played ones only
+// when there's nothing else.
+func (b *Bot) HandleRandom(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) > 0 && args[0] == "avoid" {
+		b.HandleRandomAvoid(s, g, c, m, args[1:])
+		return
+	}
+	if _, ok := b.Session(g.ID); !ok {
+		s.ChannelMessageSend(c.ID, "I need to !join a voice channel before I can play anything.")
+		return
+	}
+	tags, _ := ParseTagFilter(args)
+
+	candidates := make([]string, 0)
+	for _, vm := range b.VoiceMemoManager.FilterTagged(b.VoiceMemoManager.GuildLibrary(g.ID), tags) {
+		if b.VoiceMemoManager.Metadata.Memo(vm.name).AutoSelectable() && b.OutsideWindow(g.ID, vm.name) == "" && !b.WarnBlocked(g.ID, vm.name) &&
+			b.CanPlay(s, g, c.ID, m.Author.ID, vm.name) {
+			candidates = append(candidates, vm.name)
+		}
+	}
+	avoid := b.VoiceMemoManager.Metadata.Guild(g.ID).RandomAvoidCount()
+	name := b.VoiceMemoManager.PickRandom(g.ID, candidates, avoid)
+	if name == "" {
+		if len(tags) > 0 {
+			s.ChannelMessageSend(c.ID, "There are no voice memos tagged "+strings.Join(tags, ", ")+" I can pick for you.")
+			return
+		}
+		s.ChannelMessageSend(c.ID, "There are no voice memos I can pick for you.")
+		return
+	}
+	b.HandlePlay(s, g, c, m.Author.ID, name, 1, false, 0)
+}
+
+// Shows or changes how many of the latest plays !random stays away from.
+func (b *Bot) HandleRandomAvoid(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("!random stays away from the last %d plays in %s.", b.VoiceMemoManager.Metadata.Guild(g.ID).RandomAvoidCount(), g.Name))
+		return
+	}
+	if !b.IsDJ(s, g.ID, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins and DJs can change what !random stays away from.")
+		return
+	}
+
+	avoid, err := strconv.Atoi(args[0])
+	if err != nil || avoid < 0 || avoid > maxRandomAvoid {
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Usage: !random avoid [0-%d]", maxRandomAvoid))
+		return
+	}
+	err = b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		gs.RandomAvoid = &avoid
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+	if avoid == 0 {
+		s.ChannelMessageSend(c.ID, "!random can pick anything now, even what just played.")
+		return
+	}
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("!random will stay away from the last %d plays.", avoid))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Fans out the audio packets received on a guild session's voice connection to whoever is listening.
+// Packets are dropped when nobody is, so the connection's receive buffer never backs up.
+type VoiceReceiver struct {
+	mu        sync.Mutex
+	nextID    int
+	listeners map[int]chan *discordgo.Packet
+	ssrcUsers map[uint32]string
+	done      chan struct{}
+}
+
+func NewVoiceReceiver(vc *discordgo.VoiceConnection) *VoiceReceiver {
+	r := &VoiceReceiver{
+		listeners: make(map[int]chan *discordgo.Packet),
+		ssrcUsers: make(map[uint32]string),
+		done:      make(chan struct{}),
+	}
+
+	// Discord tells us which user is behind an SSRC when they start speaking.
+	vc.AddHandler(func(vc *discordgo.VoiceConnection, vs *discordgo.VoiceSpeakingUpdate) {
+		r.mu.Lock()
+		r.ssrcUsers[uint32(vs.SSRC)] = vs.UserID
+		r.mu.Unlock()
+	})
+
+	go r.run(vc)
+	return r
+}
+
+func (r *VoiceReceiver) run(vc *discordgo.VoiceConnection) {
+	// ChannelVoiceJoin only returns once the connection is ready, so the receive channel
+	// already exists unless we joined deafened.
+	vc.RLock()
+	recv := vc.OpusRecv
+	vc.RUnlock()
+	if recv == nil {
+		return
+	}
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case p, ok := <-recv:
+			if !ok {
+				return
+			}
+			r.dispatch(p)
+		}
+	}
+}
+
+func (r *VoiceReceiver) dispatch(p *discordgo.Packet) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, l := range r.listeners {
+		select {
+		case l <- p:
+		default:
+			// Listener is falling behind, drop the packet rather than stall everyone else.
+		}
+	}
+}
+
+// Subscribe returns a channel of received packets and a function to stop receiving them.
+func (r *VoiceReceiver) Subscribe() (<-chan *discordgo.Packet, func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.nextID
+	r.nextID++
+	l := make(chan *discordgo.Packet, 64)
+	r.listeners[id] = l
+
+	return l, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.listeners, id)
+	}
+}
+
+// Returns the ID of the user speaking on an SSRC, or "" if they haven't been seen yet.
+func (r *VoiceReceiver) UserID(ssrc uint32) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ssrcUsers[ssrc]
+}
+
+// Stops receiving. Must be called at most once.
+func (r *VoiceReceiver) Close() {
+	close(r.done)
+}
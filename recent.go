@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Uploads !recent shows when it isn't given a number, and the most it shows.
+	defaultRecentLength = 10
+	maxRecentLength     = 25
+)
+
+// Returns up to n of the memos in the guild's library, newest upload first. Memos without an upload
+// date, from before the bot kept track, are left out.
+func (m *VoiceMemoManager) RecentUploads(guildID string, n int) []MemoMetadata {
+	uploads := make([]MemoMetadata, 0)
+	for _, vm := range m.GuildLibrary(guildID) {
+		if md := m.Metadata.Memo(vm.name); !md.UploadedAt.IsZero() {
+			uploads = append(uploads, md)
+		}
+	}
+	sort.Slice(uploads, func(i, j int) bool {
+		return uploads[i].UploadedAt.After(uploads[j].UploadedAt)
+	})
+	if len(uploads) > n {
+		uploads = uploads[:n]
+	}
+	return uploads
+}
+
+func (b *Bot) HandleRecent(s *discordgo.Session, c *discordgo.Channel, args []string) {
+	n := defaultRecentLength
+	if len(args) > 0 {
+		count, err := strconv.Atoi(args[0])
+		if err != nil || count < 1 || count > maxRecentLength {
+			s.ChannelMessageSend(c.ID, fmt.Sprintf("Usage: !recent [1-%d]", maxRecentLength))
+			return
+		}
+		n = count
+	}
+
+	uploads := b.VoiceMemoManager.RecentUploads(c.GuildID, n)
+	if len(uploads) == 0 {
+		s.ChannelMessageSend(c.ID, "Nothing has been uploaded here yet.")
+		return
+	}
+	lines := make([]string, 0, len(uploads))
+	for _, md := range uploads {
+		line := md.Name
+		if md.UploaderID != "" {
+			line += " · added by <@" + md.UploaderID + ">"
+		}
+		line += fmt.Sprintf(" <t:%d:R>", md.UploadedAt.Unix())
+		if md.Type == MemoTypeLongForm {
+			line += " (long-form)"
+		}
+		lines = append(lines, line)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Recently added voice memos",
+		Description: strings.Join(lines, "\n"),
+		Color:       65535,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "!play <name> to play one"},
+	}
+	if len(embed.Description) > 4096 {
+		embed.Description = embed.Description[:4000] + "..."
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// How long !record listens when it isn't told, and the most it will ever listen.
+	defaultRecordLength = 10 * time.Second
+	maxRecordLength     = 60 * time.Second
+)
+
+// An opus frame that decodes to 20ms of silence.
+var silenceFrame = []byte{0xF8, 0xFF, 0xFE}
+
+// Returns the opus frames of the packets in order. Discord doesn't send anything while someone is quiet,
+// so gaps in the RTP timestamps are filled with silence to keep pauses where they were.
+func FillGaps(packets []*discordgo.Packet) [][]byte {
+	maxGap := uint32(maxRecordLength / frameDuration)
+	frames := make([][]byte, 0, len(packets))
+	for i, p := range packets {
+		if i > 0 {
+			// Timestamps count samples, and wrap around.
+			gap := (p.Timestamp-packets[i-1].Timestamp)/opusFrameSamples - 1
+			if gap > maxGap {
+				gap = 0
+			}
+			for j := uint32(0); j < gap; j++ {
+				frames = append(frames, silenceFrame)
+			}
+		}
+		frames = append(frames, p.Opus)
+	}
+	return frames
+}
+
+// Writes opus frames in the length-prefixed format ReadDCAFrame reads.
+func WriteDCA(w io.Writer, frames [][]byte) error {
+	for _, frame := range frames {
+		if err := binary.Write(w, binary.LittleEndian, int16(len(frame))); err != nil {
+			return err
+		}
+		if _, err := w.Write(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Bot) HandleRecord(ctx context.Context, s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if !b.RequireFeature(s, c, FeatureRecording) {
+		return
+	}
+	gs, ok := b.Session(g.ID)
+	if !ok {
+		fmt.Println("Error finding guild session.")
+		s.ChannelMessageSend(c.ID, "I need to !join a voice channel before I can record.")
+		return
+	}
+	if len(args) == 0 {
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Usage: !record <name> [seconds] (up to %d seconds)", int(maxRecordLength.Seconds())))
+		return
+	}
+
+	name := args[0]
+	length := defaultRecordLength
+	if len(args) > 1 {
+		seconds, err := strconv.Atoi(args[1])
+		if err != nil || seconds < 1 || time.Duration(seconds)*time.Second > maxRecordLength {
+			s.ChannelMessageSend(c.ID, fmt.Sprintf("I can record for 1 to %d seconds.", int(maxRecordLength.Seconds())))
+			return
+		}
+		length = time.Duration(seconds) * time.Second
+	}
+
+	err := b.CanAddMemo(g.ID, name)
+	var limitErr *MemoLimitError
+	if errors.As(err, &limitErr) {
+		b.SendMemoLimitReached(s, c.ID, g.ID, limitErr.Limit)
+		return
+	}
+	if err != nil {
+		s.ChannelMessageSend(c.ID, "Could not record: "+err.Error())
+		return
+	}
+	// Asking what to do about a taken name can wait until there's a recording to save.
+	policy := b.VoiceMemoManager.Metadata.Guild(g.ID).UploadNamePolicy()
+	var takenErr *NameTakenError
+	if _, _, err := b.ResolveMemoName(g.ID, name, policy); err != nil && !(errors.As(err, &takenErr) && takenErr.Prompt) {
+		s.ChannelMessageSend(c.ID, "Could not record: "+err.Error())
+		return
+	}
+
+	ctx, _, done := b.Jobs.Start(ctx, "recording", g.ID, m.Author.ID, "Recording "+name)
+	defer done()
+
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("Recording %s for %d seconds... go ahead.", name, int(length.Seconds())))
+	packets := gs.CapturePackets(ctx, m.Author.ID, length)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		s.ChannelMessageSend(c.ID, "Stopped recording, nothing was saved.")
+		return
+	}
+	if len(packets) == 0 {
+		s.ChannelMessageSend(c.ID, "I didn't hear anything.")
+		return
+	}
+
+	frames := FillGaps(packets)
+	vm, version, err := b.SaveRecording(g.ID, m.Author.ID, name, policy, frames)
+	if errors.As(err, &takenErr) && takenErr.Prompt {
+		b.SendNameClash(s, &NameClash{ID: m.ID, GuildID: g.ID, ChannelID: c.ID, UserID: m.Author.ID, Name: name, Frames: frames})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error saving recording ", name, ": ", err)
+		s.ChannelMessageSend(c.ID, "Could not save the recording: "+err.Error())
+		return
+	}
+	s.ChannelMessageSend(c.ID, RecordedMessage(vm, version))
+}
+
+// Reply announcing a saved recording. version is what the memo it replaced is called now, if any.
+func RecordedMessage(vm *VoiceMemo, version string) string {
+	msg := fmt.Sprintf("Recorded %s (%s). !play %s to hear it.", vm.name, FormatDuration(vm.Duration()), vm.name)
+	if version != "" {
+		msg += " The old " + vm.name + " is still around as " + version + "."
+	}
+	return msg
+}
+
+// Adds recorded opus frames to the library as a new memo, following policy if the name is taken. Returns
+// the memo and what the memo it replaced is called now, if any.
+func (b *Bot) SaveRecording(guildID, userID, name string, policy NamePolicy, frames [][]byte) (*VoiceMemo, string, error) {
+	name, version, err := b.ClaimMemoName(guildID, name, policy)
+	if err != nil {
+		return nil, "", err
+	}
+	vm, err := b.VoiceMemoManager.ImportFrames(name, frames, false, func(md *MemoMetadata) {
+		md.GuildID = guildID
+		md.UploaderID = userID
+		md.UploadedAt = time.Now()
+		md.PlayCount = 0
+		md.Type = MemoTypeSoundboard
+		md.Tags = nil
+		md.Fingerprint = nil
+		md.MessageLink = ""
+	})
+	if err == nil {
+		go b.TranscribeMemo(guildID, userID, vm)
+	}
+	return vm, version, err
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestFillGaps(t *testing.T) {
+	const frame = opusFrameSamples
+	tests := []struct {
+		name       string
+		timestamps []uint32
+		// The frames that come out: the packet's number, or "-" for silence.
+		want string
+	}{
+		{"nothing", nil, ""},
+		{"one", []uint32{0}, "0"},
+		{"back to back", []uint32{0, frame, 2 * frame}, "012"},
+		{"pause", []uint32{0, 3 * frame, 4 * frame}, "0--12"},
+		{"wraps around", []uint32{1<<32 - frame, 0, frame}, "012"},
+		{"pause across the wrap", []uint32{1<<32 - frame, frame}, "0-1"},
+		{"longer than a recording", []uint32{0, 5000 * frame}, "01"},
+		{"repeated", []uint32{frame, frame}, "01"},
+		{"out of order", []uint32{2 * frame, frame}, "01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packets := make([]*discordgo.Packet, 0, len(tt.timestamps))
+			for i, ts := range tt.timestamps {
+				packets = append(packets, &discordgo.Packet{Timestamp: ts, Opus: []byte{byte('0' + i)}})
+			}
+
+			var got bytes.Buffer
+			for _, f := range FillGaps(packets) {
+				if bytes.Equal(f, silenceFrame) {
+					got.WriteByte('-')
+					continue
+				}
+				got.Write(f)
+			}
+			if got.String() != tt.want {
+				t.Errorf("FillGaps(%v) = %q, want %q", tt.timestamps, got.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Reports whether a user may play a memo in a guild. Admins and DJs may play anything, everyone else needs
+// one of the roles the memo is restricted to, if it's restricted at all.
+func (b *Bot) CanPlay(s *discordgo.Session, g *discordgo.Guild, channelID, userID, name string) bool {
+	roles := b.VoiceMemoManager.Metadata.Guild(g.ID).Restrictions[name]
+	if len(roles) == 0 {
+		return true
+	}
+
+	member, err := s.State.Member(g.ID, userID)
+	if err != nil {
+		if member, err = s.GuildMember(g.ID, userID); err != nil {
+			fmt.Println("Error looking up member ", userID, ": ", err)
+			return false
+		}
+	}
+	for _, role := range member.Roles {
+		for _, allowed := range roles {
+			if role == allowed {
+				return true
+			}
+		}
+	}
+	return b.IsDJ(s, g.ID, userID, channelID)
+}
+
+// Returns the ID of the guild role a "@role" mention or bare role ID refers to.
+func ParseRole(g *discordgo.Guild, arg string) (string, bool) {
+	roleID := strings.TrimSuffix(strings.TrimPrefix(arg, "<@&"), ">")
+	for _, role := range g.Roles {
+		if role.ID == roleID {
+			return roleID, true
+		}
+	}
+	return "", false
+}
+
+func (b *Bot) HandleRestrict(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := "Usage: !restrict <name> @role... | !restrict <name> off | !restrict list"
+	if len(args) == 0 || args[0] == "list" {
+		b.SendRestrictions(s, g, c)
+		return
+	}
+
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can restrict voice memos.")
+		return
+	}
+	if len(args) < 2 {
+		s.ChannelMessageSend(c.ID, usage)
+		return
+	}
+
+	name := args[0]
+	if b.VoiceMemoManager.Get(name) == nil || !b.VoiceMemoManager.InLibrary(g.ID, name) {
+		s.ChannelMessageSend(c.ID, "Cannot find "+name)
+		return
+	}
+
+	roles := make([]string, 0, len(args)-1)
+	if args[1] != "off" {
+		for _, arg := range args[1:] {
+			role, ok := ParseRole(g, arg)
+			if !ok {
+				s.ChannelMessageSend(c.ID, "I can't find the role "+arg+" in "+g.Name+". "+usage)
+				return
+			}
+			roles = append(roles, role)
+		}
+	}
+
+	err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		if len(roles) == 0 {
+			delete(gs.Restrictions, name)
+			return
+		}
+		if gs.Restrictions == nil {
+			gs.Restrictions = make(map[string][]string)
+		}
+		gs.Restrictions[name] = roles
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+
+	if len(roles) == 0 {
+		s.ChannelMessageSend(c.ID, "Everyone can play "+name+" again.")
+		return
+	}
+	s.ChannelMessageSend(c.ID, "Only admins, DJs and "+RoleMentions(roles)+" can play "+name+" now.")
+}
+
+func RoleMentions(roles []string) string {
+	mentions := make([]string, 0, len(roles))
+	for _, role := range roles {
+		mentions = append(mentions, "<@&"+role+">")
+	}
+	return strings.Join(mentions, ", ")
+}
+
+func (b *Bot) SendRestrictions(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel) {
+	restrictions := b.VoiceMemoManager.Metadata.Guild(g.ID).Restrictions
+	if len(restrictions) == 0 {
+		s.ChannelMessageSend(c.ID, "Everyone can play every voice memo in "+g.Name)
+		return
+	}
+
+	names := make([]string, 0, len(restrictions))
+	for name := range restrictions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	embed := &discordgo.MessageEmbed{
+		Title:  "Restricted voice memos",
+		Color:  65535,
+		Fields: []*discordgo.MessageEmbedField{},
+	}
+	for _, name := range names {
+		// Embeds can hold at most 25 fields.
+		if len(embed.Fields) == 25 {
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("and %d more", len(names)-25)}
+			break
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   name,
+			Value:  RoleMentions(restrictions[name]),
+			Inline: true,
+		})
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Name of the message command that saves a message's audio, as it shows up when right-clicking a message.
+const saveMemoCommand = "Save as voice memo"
+
+// Runs the "Save as voice memo" message command, asking for the new memo's name in a modal.
+func (b *Bot) HandleSaveMemoCommand(s *discordgo.Session, i *discordgo.InteractionCreate, options map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	if !HasAdminPermissions(i.Member.Permissions) {
+		RespondEphemeral(s, i, "Only admins can save messages as voice memos.")
+		return
+	}
+	data := i.ApplicationCommandData()
+	var message *discordgo.Message
+	if data.Resolved != nil {
+		message = data.Resolved.Messages[data.TargetID]
+	}
+	if message == nil {
+		RespondEphemeral(s, i, "I can't see that message.")
+		return
+	}
+	attachment := AudioAttachment(message.Attachments)
+	if attachment == nil {
+		RespondEphemeral(s, i, "That message doesn't have an audio file.")
+		return
+	}
+
+	name := strings.TrimSuffix(attachment.Filename, filepath.Ext(attachment.Filename))
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: "savememo:" + message.ID,
+			Title:    saveMemoCommand,
+			Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.TextInput{CustomID: "name", Label: "Name", Style: discordgo.TextInputShort, Value: name, Required: true, MaxLength: 100},
+			}}},
+		},
+	})
+	if err != nil {
+		fmt.Println("Error responding to interaction: ", err)
+	}
+}
+
+// Handles the modal of "Save as voice memo", uploading the audio of the message it was opened on. arg is
+// the message's ID. The message is fetched again, as attachment links stop working after a while.
+func (b *Bot) HandleSaveMemoModal(s *discordgo.Session, i *discordgo.InteractionCreate, messageID string) {
+	if i.Member == nil || !HasAdminPermissions(i.Member.Permissions) {
+		RespondEphemeral(s, i, "Only admins can save messages as voice memos.")
+		return
+	}
+	name := ""
+	for _, row := range i.ModalSubmitData().Components {
+		if r, ok := row.(*discordgo.ActionsRow); ok {
+			for _, c := range r.Components {
+				if input, ok := c.(*discordgo.TextInput); ok && input.CustomID == "name" {
+					name = strings.TrimSpace(input.Value)
+				}
+			}
+		}
+	}
+
+	message, err := s.ChannelMessage(i.ChannelID, messageID)
+	if err != nil {
+		fmt.Println("Error fetching message to save: ", err)
+		RespondEphemeral(s, i, "I can't see that message anymore.")
+		return
+	}
+	attachment := AudioAttachment(message.Attachments)
+	if attachment == nil {
+		RespondEphemeral(s, i, "That message doesn't have an audio file anymore.")
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		fmt.Println("Error responding to interaction: ", err)
+		return
+	}
+	b.UploadDeferred(s, i, UploadRequest{
+		GuildID:     i.GuildID,
+		UploaderID:  i.Member.User.ID,
+		URL:         attachment.URL,
+		FileName:    attachment.Filename,
+		Name:        name,
+		Type:        MemoTypeSoundboard,
+		MessageLink: fmt.Sprintf("https://discord.com/channels/%s/%s/%s", i.GuildID, message.ChannelID, message.ID),
+	})
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func (b *Bot) HandleSay(ctx context.Context, s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if !b.RequireFeature(s, c, FeatureTTS) {
+		return
+	}
+	gs, ok := b.Session(g.ID)
+	if !ok {
+		fmt.Println("Error finding guild session.")
+		s.ChannelMessageSend(c.ID, "I need to !join a voice channel before I can talk.")
+		return
+	}
+
+	// Options come before the text, e.g. !say -voice=en-gb-female hello there
+	voice := b.VoiceMemoManager.Metadata.Guild(g.ID).Voice
+	for len(args) > 0 && strings.HasPrefix(args[0], "-voice=") {
+		voice = strings.TrimPrefix(args[0], "-voice=")
+		args = args[1:]
+	}
+
+	text := strings.Join(args, " ")
+	if text == "" {
+		s.ChannelMessageSend(c.ID, "Usage: !say [-voice=<voice>] <text>")
+		return
+	}
+
+	audio, err := b.TTS.Synthesize(ctx, text, voice)
+	if err != nil {
+		fmt.Println("Error synthesizing speech: ", err)
+		b.Alerts.Report(g.ID, AlertSpeech, err)
+		s.ChannelMessageSend(c.ID, "Sorry, I couldn't say that. Check !voices for the voices I know.")
+		return
+	}
+
+	// Synthesized speech is mono, so there's nothing to gain from a second channel.
+	frames, err := EncodeDCA(ctx, audio, 1)
+	if err != nil {
+		fmt.Println("Error encoding speech: ", err)
+		s.ChannelMessageSend(c.ID, "Sorry, I couldn't say that.")
+		return
+	}
+
+	// Speech isn't part of the library, so it only lives in the queue, or over the memo that's playing.
+	speech := &VoiceMemo{name: "say", buffer: frames}
+	if !gs.Announce(ctx, speech, b.VoiceMemoManager.Metadata.Guild(g.ID).DuckPercent(), m.Author.ID, m.ChannelID) {
+		b.Outbox.Error(s, c.ID, "The queue is full. Try again later.")
+		return
+	}
+}
+
+func (b *Bot) HandleVoices(ctx context.Context, s *discordgo.Session, c *discordgo.Channel) {
+	voices, err := b.TTS.Voices(ctx)
+	if err != nil {
+		fmt.Println("Error listing voices: ", err)
+		s.ChannelMessageSend(c.ID, "Sorry, I couldn't get the list of voices.")
+		return
+	}
+
+	// Embed descriptions are capped at 4096 characters.
+	description := strings.Join(voices, ", ")
+	if len(description) > 4000 {
+		description = description[:4000] + "..."
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Available voices",
+		Description: description,
+		Color:       65535,
+	}
+	if _, err := s.ChannelMessageSendEmbed(c.ID, embed); err != nil {
+		fmt.Println(err)
+		return
+	}
+}
+
+func (b *Bot) HandleVoice(ctx context.Context, s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		voice := b.VoiceMemoManager.Metadata.Guild(g.ID).Voice
+		if voice == "" {
+			s.ChannelMessageSend(c.ID, g.Name+" uses the default voice.")
+			return
+		}
+		s.ChannelMessageSend(c.ID, g.Name+" uses the "+voice+" voice.")
+		return
+	}
+
+	if !b.IsDJ(s, g.ID, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins and DJs can change the default voice.")
+		return
+	}
+
+	// "default" goes back to whatever the provider picks.
+	voice := args[0]
+	if voice == "default" {
+		voice = ""
+	} else if !b.IsVoiceAvailable(ctx, voice) {
+		s.ChannelMessageSend(c.ID, "I don't know the "+voice+" voice. Check !voices for the voices I know.")
+		return
+	}
+
+	err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		gs.Voice = voice
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+
+	if voice == "" {
+		s.ChannelMessageSend(c.ID, g.Name+" now uses the default voice.")
+		return
+	}
+	s.ChannelMessageSend(c.ID, g.Name+" now uses the "+voice+" voice.")
+}
+
+// Reports whether the TTS provider lists a voice, ignoring case. Assumes it does if the provider can't tell us.
+func (b *Bot) IsVoiceAvailable(ctx context.Context, voice string) bool {
+	voices, err := b.TTS.Voices(ctx)
+	if err != nil {
+		fmt.Println("Error listing voices: ", err)
+		return true
+	}
+
+	for _, v := range voices {
+		if strings.EqualFold(v, voice) {
+			return true
+		}
+	}
+	return false
+}
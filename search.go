@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Most results !search shows.
+const maxSearchResults = 20
+
+// How a memo name matched a search, from best to worst.
+type matchKind int
+
+const (
+	matchExact matchKind = iota
+	matchPrefix
+	matchSubstring
+	matchFuzzy
+)
+
+// A memo name that matched a search, with what's needed to rank it.
+type SearchResult struct {
+	Name string
+	kind matchKind
+
+	// Where the term starts in the name for substring matches, and how many edits away it is for fuzzy ones.
+	position int
+	distance int
+}
+
+// Typos allowed in a search term, roughly one every three characters. Terms can match anywhere in a name,
+// so short ones get none or they would match almost everything.
+func searchTolerance(term string) int {
+	return minInt(utf8.RuneCountInString(term)/3, 3)
+}
+
+// Matches names against a search term, ignoring case and punctuation. Returns the matches best first: the
+// exact name, names starting with the term, names containing it, then names containing it with a typo or two.
+func SearchNames(names []string, term string) []SearchResult {
+	term = NormalizeName(term)
+	if term == "" {
+		return nil
+	}
+	tolerance := searchTolerance(term)
+
+	results := make([]SearchResult, 0)
+	for _, name := range names {
+		normalized := NormalizeName(name)
+		result := SearchResult{Name: name}
+		switch i := strings.Index(normalized, term); {
+		case normalized == term:
+			result.kind = matchExact
+		case i == 0:
+			result.kind = matchPrefix
+		case i > 0:
+			result.kind, result.position = matchSubstring, i
+		default:
+			distance := SubstringDistance(term, normalized)
+			if distance > tolerance {
+				continue
+			}
+			result.kind, result.distance = matchFuzzy, distance
+		}
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		if a.distance != b.distance {
+			return a.distance < b.distance
+		}
+		if a.position != b.position {
+			return a.position < b.position
+		}
+		// The shorter of two names is the closer match.
+		if len(a.Name) != len(b.Name) {
+			return len(a.Name) < len(b.Name)
+		}
+		return a.Name < b.Name
+	})
+	return results
+}
+
+func (b *Bot) HandleSearch(s *discordgo.Session, c *discordgo.Channel, args []string) {
+	tags, args := ParseTagFilter(args)
+	term := strings.Join(args, " ")
+	if strings.TrimSpace(term) == "" {
+		s.ChannelMessageSend(c.ID, "Usage: !search [-tag=<tags>] <term>")
+		return
+	}
+
+	library := b.VoiceMemoManager.FilterTagged(b.VoiceMemoManager.GuildLibrary(c.GuildID), tags)
+	names := make([]string, 0, len(library))
+	streamed := make(map[string]bool)
+	for _, vm := range library {
+		names = append(names, vm.name)
+		streamed[vm.name] = vm.streamed
+	}
+
+	results := SearchNames(names, term)
+	if len(results) == 0 {
+		s.ChannelMessageSend(c.ID, "No voice memos match "+term)
+		return
+	}
+
+	footer := "!play <name> to play one"
+	if len(results) > maxSearchResults {
+		footer = fmt.Sprintf("Showing the best %d of %d · %s", maxSearchResults, len(results), footer)
+		results = results[:maxSearchResults]
+	}
+	lines := make([]string, 0, len(results))
+	for i, result := range results {
+		line := fmt.Sprintf("%d. %s", i+1, result.Name)
+		if streamed[result.Name] {
+			line += " (long-form)"
+		}
+		lines = append(lines, line)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Voice memos matching " + term,
+		Description: strings.Join(lines, "\n"),
+		Color:       65535,
+		Footer:      &discordgo.MessageEmbedFooter{Text: footer},
+	}
+	if len(embed.Title) > 256 {
+		embed.Title = embed.Title[:250] + "..."
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
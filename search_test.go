@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestSubstringDistance(t *testing.T) {
+	tests := []struct {
+		term, s string
+		want    int
+	}{
+		{"honk", "airhonk", 0},
+		{"hnok", "airhonk", 1},
+		{"honx", "honkers", 1},
+		{"hok", "airhonk", 1},
+		{"abcd", "xyz", 4},
+		{"honk", "", 4},
+		{"", "honk", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.term+" in "+tt.s, func(t *testing.T) {
+			if got := SubstringDistance(tt.term, tt.s); got != tt.want {
+				t.Errorf("SubstringDistance(%q, %q) = %d, want %d", tt.term, tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchNames(t *testing.T) {
+	names := []string{"airhorn", "Horn", "hornet", "bighorn", "horns", "thorn", "horrn", "magic"}
+	tests := []struct {
+		name string
+		term string
+		want []string
+	}{
+		{"exact, then prefix, substring and typos", "horn", []string{"Horn", "horns", "hornet", "thorn", "airhorn", "bighorn", "horrn"}},
+		{"punctuation", "Ho-rn!", []string{"Horn", "horns", "hornet", "thorn", "airhorn", "bighorn", "horrn"}},
+		{"short terms need to match", "ma", []string{"magic"}},
+		{"nothing close", "quack", []string{}},
+		{"only punctuation", "?!", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, result := range SearchNames(names, tt.term) {
+				got = append(got, result.Name)
+			}
+			if !equalStrings(got, tt.want) {
+				t.Errorf("SearchNames(%q) = %v, want %v", tt.term, got, tt.want)
+			}
+		})
+	}
+}
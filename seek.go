@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Reads a point in a memo like 0:15, 1:02:03 or 15s. Plain numbers aren't timestamps, so they can't be
+// mistaken for how many times !play should repeat.
+func ParseTimestamp(arg string) (time.Duration, bool) {
+	if !strings.Contains(arg, ":") {
+		d, err := time.ParseDuration(arg)
+		return d, err == nil && d >= 0
+	}
+	var d time.Duration
+	parts := strings.Split(arg, ":")
+	if len(parts) > 3 {
+		return 0, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || (i > 0 && (len(part) != 2 || n > 59)) {
+			return 0, false
+		}
+		d = d*60 + time.Duration(n)*time.Second
+	}
+	return d, true
+}
+
+// Asks the player to carry on from frame of the memo that's playing. Returns false if nothing is playing
+// or frame is past where it stops.
+func (gs *GuildSession) Seek(frame int) bool {
+	gs.duckMu.Lock()
+	defer gs.duckMu.Unlock()
+
+	if gs.playing == nil || frame < 0 || frame >= gs.playingEnd {
+		return false
+	}
+	gs.seekTo, gs.seeking = frame, true
+	return true
+}
+
+// Returns the frame !seek asked the player to go to, if it did since the last call. Speech waiting to be mixed
+// over the memo holds the seek back until it has played.
+func (gs *GuildSession) takeSeek() (int, bool) {
+	gs.duckMu.Lock()
+	defer gs.duckMu.Unlock()
+
+	if gs.duck != nil {
+		return 0, false
+	}
+	frame, ok := gs.seekTo, gs.seeking
+	gs.seeking = false
+	return frame, ok
+}
+
+func (b *Bot) HandleSeek(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	var to time.Duration
+	ok := len(args) == 1
+	if ok {
+		to, ok = ParseTimestamp(args[0])
+	}
+	if !ok {
+		s.ChannelMessageSend(c.ID, "Usage: !seek <m:ss>")
+		return
+	}
+
+	gs, ok := b.Session(g.ID)
+	if !ok {
+		s.ChannelMessageSend(c.ID, "Nothing is playing.")
+		return
+	}
+	current, requester := gs.Current()
+	if current == nil {
+		s.ChannelMessageSend(c.ID, "Nothing is playing.")
+		return
+	}
+	if requester != m.Author.ID && !b.IsDJ(s, g.ID, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins, DJs and whoever asked for "+current.name+" can seek in it.")
+		return
+	}
+
+	if !gs.Seek(int(to / frameDuration)) {
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("%s only plays for %s.", current.name, FormatDuration(current.Duration())))
+		return
+	}
+	s.ChannelMessageSend(c.ID, fmt.Sprintf("Playing %s from %s.", current.name, FormatDuration(to)))
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Returns the guild's session, if the bot is in one of its voice channels.
+func (b *Bot) Session(guildID string) (*GuildSession, bool) {
+	b.sessionsMu.RLock()
+	defer b.sessionsMu.RUnlock()
+
+	gs, ok := b.GuildSessions[guildID]
+	return gs, ok
+}
+
+// Returns the voice channel a user is in, or "" if they aren't in one.
+func UserVoiceChannel(g *discordgo.Guild, userID string) string {
+	for _, vs := range g.VoiceStates {
+		if vs.UserID == userID {
+			return vs.ChannelID
+		}
+	}
+	return ""
+}
+
+// Returns the guild's session, first joining the voice channel userID is in if the bot isn't in one yet, the
+// way !join would. Tells them in channelID when that isn't possible.
+func (b *Bot) SessionFor(s *discordgo.Session, g *discordgo.Guild, channelID, userID string) (*GuildSession, bool) {
+	if gs, ok := b.Session(g.ID); ok {
+		return gs, true
+	}
+	voiceChannelID := UserVoiceChannel(g, userID)
+	if voiceChannelID == "" {
+		s.ChannelMessageSend(channelID, "Join a voice channel first and I'll play it for you there.")
+		return nil, false
+	}
+
+	fmt.Println("Joining voice channel to play in ", g.Name)
+	gs, err := b.JoinChannel(s, g, voiceChannelID)
+	if err != nil {
+		// Another !play may have joined in the meantime.
+		if gs, ok := b.Session(g.ID); ok {
+			return gs, true
+		}
+		fmt.Println("Error joining voice channel:", err)
+		b.Alerts.Report(g.ID, AlertVoice, err)
+		b.Outbox.Error(s, channelID, "I couldn't join your voice channel. Try !join.")
+		return nil, false
+	}
+	gs.Stats.SetChannel(channelID)
+	b.Greet(s, g, gs, channelID)
+	return gs, true
+}
+
+// Joins a voice channel and starts a guild session for it. Fails if the guild already has a session.
+func (b *Bot) JoinChannel(s *discordgo.Session, g *discordgo.Guild, channelID string) (*GuildSession, error) {
+	b.joinMu.Lock()
+	defer b.joinMu.Unlock()
+
+	if _, ok := b.Session(g.ID); ok {
+		return nil, fmt.Errorf("already in a voice channel in %s", g.Name)
+	}
+
+	// Join undeafened so voice commands can be heard.
+	vc, err := s.ChannelVoiceJoin(g.ID, channelID, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create Guild Session.
+	fmt.Println("Creating new Guild session for ", g.Name)
+	gs := &GuildSession{
+		ID:              g.ID,
+		GuildName:       g.Name,
+		VoiceConnection: vc,
+		PlayQueue:       NewPlayQueue(10), // will set length of queue to 10 for now
+		IsVoicePlaying:  &atomic.Bool{},
+		Receiver:        NewVoiceReceiver(vc),
+	}
+
+	// Show what's playing on the voice channel itself.
+	status := NewVoiceStatus(s, channelID)
+	gs.OnPlay = func(entry QueueEntry) {
+		status.Set("🔊 " + entry.Memo.name)
+		gs.Stats.SetChannel(entry.ChannelID)
+		gs.Stats.CountPlay(VoiceChannelUsers(s, g, vc.ChannelID))
+		go b.SendNowPlaying(s, gs, entry)
+	}
+	gs.OnIdle = func() {
+		status.Set("")
+		gs.setNowPlaying("")
+	}
+	gs.OnMove = func(channelID string) {
+		status.Move(channelID)
+	}
+	gs.OnError = func(err error) {
+		b.Alerts.Report(g.ID, AlertPlayback, err)
+	}
+	gs.Prepare = func(ctx context.Context, vm *VoiceMemo) *VoiceMemo {
+		return b.Level(ctx, g.ID, vm)
+	}
+	gs.MaxPlayback = func() time.Duration {
+		return b.VoiceMemoManager.Metadata.Guild(g.ID).MaxPlayback()
+	}
+	gs.OnDeadLetter = func(entry QueueEntry, err error) {
+		b.Jobs.Park(g.ID, DeadLetter{
+			Memo:        entry.Memo.name,
+			RequesterID: entry.RequesterID,
+			Attempts:    entry.Attempts,
+			Err:         err.Error(),
+			At:          time.Now(),
+		})
+		b.Alerts.Report(g.ID, AlertPlayback, err)
+	}
+	gs.OnLeave = func() {
+		b.EndSession(s, g.ID, gs.Stats.Summary())
+	}
+	gs.Stats.Start()
+	gs.Touch()
+
+	b.sessionsMu.Lock()
+	b.GuildSessions[g.ID] = gs
+	b.sessionsMu.Unlock()
+	return gs, nil
+}
+
+// Disconnects from the guild's voice channel and ends its session. Returns false if there was none.
+func (b *Bot) LeaveGuild(guildID string) bool {
+	b.joinMu.Lock()
+	defer b.joinMu.Unlock()
+
+	b.sessionsMu.Lock()
+	gs, ok := b.GuildSessions[guildID]
+	delete(b.GuildSessions, guildID)
+	b.sessionsMu.Unlock()
+	if !ok {
+		return false
+	}
+
+	if gs.OnIdle != nil {
+		gs.OnIdle()
+	}
+	gs.Disconnect()
+	if gs.OnLeave != nil {
+		gs.OnLeave()
+	}
+	return true
+}
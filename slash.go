@@ -0,0 +1,1073 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A slash command and how its options map back onto the prefix command's arguments.
+type SlashCommand struct {
+	Definition *discordgo.ApplicationCommand
+
+	// Builds the prefix command arguments. Defaults to every option's value in definition order.
+	Args func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string
+
+	// Handles the interaction itself instead of going through the prefix command, for commands that
+	// need more than plain arguments.
+	Handler func(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, options map[string]*discordgo.ApplicationCommandInteractionDataOption)
+}
+
+var (
+	// Every command is about a guild's voice channels and library, so none of them work in DMs.
+	guildOnly = new(bool)
+
+	// Hidden from everyone but admins until they grant it to others in the server's integration settings.
+	manageGuild = int64(discordgo.PermissionManageServer)
+)
+
+var slashCommandList = []*SlashCommand{
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "join",
+		Description: "Join your voice channel",
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "leave",
+		Description: "Leave the voice channel",
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "handoff",
+		Description: "Move the bot and its queue to another voice channel",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionChannel, Name: "channel", Description: "Voice channel to move to", Required: true,
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice, discordgo.ChannelTypeGuildStageVoice}},
+		},
+	}},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "play",
+			Description: "Play a voice memo",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Voice memo to play", Required: true, Autocomplete: true},
+				{Type: discordgo.ApplicationCommandOptionInteger, Name: "times", Description: "How many times in a row to play it, up to 10"},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "full", Description: "Play it past the server's time limit, for admins and DJs"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "start", Description: "Where to start playing it, like 0:15"},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			args := make([]string, 0, 4)
+			if full, ok := options["full"]; ok && full.BoolValue() {
+				args = append(args, "-full")
+			}
+			for _, name := range []string{"name", "times", "start"} {
+				if opt, ok := options[name]; ok {
+					args = append(args, OptionString(opt))
+				}
+			}
+			return args
+		},
+	},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "playnext",
+			Description: "Play a voice memo straight after the one that's on",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Voice memo to play next", Required: true, Autocomplete: true},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "full", Description: "Play it past the server's time limit"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "start", Description: "Where to start playing it, like 0:15"},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			args := make([]string, 0, 3)
+			if full, ok := options["full"]; ok && full.BoolValue() {
+				args = append(args, "-full")
+			}
+			for _, name := range []string{"name", "start"} {
+				if opt, ok := options[name]; ok {
+					args = append(args, OptionString(opt))
+				}
+			}
+			return args
+		},
+	},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "cue",
+		Description: "Play a voice memo for someone, if they're in the voice channel",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "Who it's for", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Voice memo to play", Required: true, Autocomplete: true},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "skip",
+		Description: "Skip the voice memo that's playing",
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "stop",
+		Description: "Stop playing and clear the queue",
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "seek",
+		Description: "Jump to a point in the voice memo that's playing",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "to", Description: "Where to carry on playing, like 0:15", Required: true},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "pause",
+		Description: "Pause the voice memo that's playing",
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "resume",
+		Description: "Carry on playing where playback was paused",
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "queue",
+		Description: "Show what's queued up",
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "loop",
+		Description: "Repeat the voice memo that's playing",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "mode", Description: "\"on\" or \"off\", leave out to toggle"},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "loopqueue",
+		Description: "Keep replaying the whole queue",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "mode", Description: "\"on\" or \"off\", leave out to toggle"},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "shuffle",
+		Description: "Put the queued voice memos in a random order",
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "remove",
+		Description: "Take one voice memo off the queue",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "number", Description: "The number the memo has in the queue", Required: true},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "move",
+		Description: "Move a voice memo to another place in the queue",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "from", Description: "The number the memo has in the queue", Required: true},
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "to", Description: "The number it should have", Required: true},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "clearqueue",
+		Description: "Throw away everything waiting in the queue",
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "list",
+		Description: "List all voice memos",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "tag", Description: "Only list memos with these comma separated tags"},
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "page", Description: "Page to show"},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "sort",
+				Description: "What to order the voice memos by",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "name", Value: "name"},
+					{Name: "newest", Value: "date"},
+					{Name: "most played", Value: "plays"},
+					{Name: "largest", Value: "size"},
+				},
+			},
+		},
+	}, Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+		args := make([]string, 0)
+		if opt, ok := options["sort"]; ok {
+			args = append(args, "-sort="+opt.StringValue())
+		}
+		return append(args, tagFilterArgs(options)...)
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "search",
+		Description: "Find voice memos by name, even if it's misspelled",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "term", Description: "All or part of a name", Required: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "tag", Description: "Only search memos with these comma separated tags"},
+		},
+	}, Args: tagFilterArgs},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "recent",
+		Description: "Show the newest voice memos and who added them",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "count", Description: "How many to show"},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "tag",
+		Description: "Tag voice memos so they can be found and picked by tag",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "action",
+				Description: "What to do",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "add", Value: "add"},
+					{Name: "remove", Value: "remove"},
+					{Name: "list", Value: "list"},
+				},
+			},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Voice memo to tag", Autocomplete: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "tags", Description: "Comma separated tags"},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "history",
+		Description: "Show what was played lately, and who asked for it",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "Only show what they played"},
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "count", Description: "How many plays to show"},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "random",
+		Description: "Play a random voice memo",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "tag", Description: "Only pick memos with these comma separated tags"},
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "avoid", Description: "Change how many of the latest plays it stays away from, instead of playing"},
+		},
+	}, Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+		if opt, ok := options["avoid"]; ok {
+			return []string{"avoid", OptionString(opt)}
+		}
+		return tagFilterArgs(options)
+	}},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "upload",
+			Description: "Upload a voice memo",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionAttachment, Name: "file", Description: "Audio file to upload", Required: true},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Name of the voice memo, defaults to the file name"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "tags", Description: "Comma separated tags"},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "longform", Description: "Stream it from disk and leave it out of random picks"},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "mono", Description: "Encode it in mono, which halves its size"},
+			},
+		},
+		Handler: (*Bot).HandleUploadSlash,
+	},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "info",
+		Description: "Show everything known about a voice memo",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Voice memo to look up", Required: true, Autocomplete: true},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "stats",
+		Description: "Show how big a voice memo is and how often it's played here",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Voice memo to look up", Required: true, Autocomplete: true},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "describe",
+		Description: "Add a description or credit to a voice memo",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Voice memo to describe", Required: true, Autocomplete: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "description", Description: "Leave out to clear the description"},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "trim",
+		Description: "Cut the start or end off a voice memo",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Voice memo to trim", Required: true, Autocomplete: true},
+			{Type: discordgo.ApplicationCommandOptionNumber, Name: "start", Description: "Second to start at, leave both out to pick with buttons"},
+			{Type: discordgo.ApplicationCommandOptionNumber, Name: "end", Description: "Second to end at"},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "suggest",
+		Description: "Recommend voice memos you haven't played lately",
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "rename",
+		Description: "Give a voice memo a new name",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Voice memo to rename", Required: true, Autocomplete: true},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "new_name", Description: "Its new name", Required: true},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "transfer",
+		Description: "Give a voice memo you uploaded to someone else",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Voice memo to give away", Required: true, Autocomplete: true},
+			{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "Who gets it", Required: true},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:                     "delete",
+		Description:              "Delete a voice memo",
+		DefaultMemberPermissions: &manageGuild,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Voice memo to delete", Required: true, Autocomplete: true},
+			{Type: discordgo.ApplicationCommandOptionBoolean, Name: "dry_run", Description: "Only show what it would change"},
+		},
+	}, Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+		return dryRunArgs(options, "name")
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:                     "maxmemos",
+		Description:              "Show or change how many voice memos this server can have",
+		DefaultMemberPermissions: &manageGuild,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "limit", Description: "New limit, 0 for the default"},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:                     "prefix",
+		Description:              "Show or change what commands start with",
+		DefaultMemberPermissions: &manageGuild,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "prefix", Description: "New prefix, or \"default\" for !"},
+		},
+	}},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:                     "nudge",
+			Description:              "Point people typing prefix commands to the slash command",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "tips",
+					Description: "Turn tips on or off, or follow the bot's default",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "on", Value: "on"},
+						{Name: "off", Value: "off"},
+						{Name: "default", Value: "default"},
+					},
+				},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "message", Description: "New tip, {slash} stands for the slash command, or \"default\""},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			if opt, ok := options["tips"]; ok {
+				return []string{opt.StringValue()}
+			}
+			if opt, ok := options["message"]; ok {
+				return append([]string{"message"}, strings.Fields(opt.StringValue())...)
+			}
+			return nil
+		},
+	},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:                     "cooldown",
+		Description:              "Show or change how often each person can use a command",
+		DefaultMemberPermissions: &manageGuild,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "command", Description: "Command to change the cooldown of, e.g. play"},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "duration", Description: "Time between uses, like 10s or 2m, or \"off\""},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:                     "maxplay",
+		Description:              "Show or change how long voice memos play before they fade out",
+		DefaultMemberPermissions: &manageGuild,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "seconds", Description: "Seconds before memos fade out, or \"off\" to play them in full"},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:                     "namepolicy",
+		Description:              "Show or change what happens when a new memo's name is taken",
+		DefaultMemberPermissions: &manageGuild,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "policy",
+				Description: "What to do with the new memo",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "reject", Value: "reject"},
+					{Name: "suffix", Value: "suffix"},
+					{Name: "version", Value: "version"},
+					{Name: "prompt", Value: "prompt"},
+				},
+			},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:                     "preset",
+		Description:              "Show or change how new uploads are encoded",
+		DefaultMemberPermissions: &manageGuild,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "setting", Description: "show, a preset (default, meme, music), or bitrate, mono, normalize or trim"},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "value", Description: "New value for the setting"},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:                     "loudness",
+		Description:              "Show or change how loud memos play",
+		DefaultMemberPermissions: &manageGuild,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "target", Description: "Target in LUFS from -30 to -6, e.g. -14, or off"},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:                     "greeting",
+		Description:              "Show or change what the bot says and plays when it joins and leaves",
+		DefaultMemberPermissions: &manageGuild,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "setting", Description: "message, join or leave"},
+			{Type: discordgo.ApplicationCommandOptionString, Name: "value", Description: "Greeting text or a voice memo, default or off"},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "duck",
+		Description: "Show or change how far memos are turned down while the bot talks over them, in percent",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "percent", Description: "0 to 100, 0 waits for the memo to end instead"},
+		},
+	}},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "volume",
+			Description: "Show or change how loud memos play, in percent",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionInteger, Name: "percent", Description: "0 to 200, 100 plays memos as they are"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "reactions", Description: "Range the 🔉 and 🔊 reactions turn it within, like 50-150, or off"},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			if opt, ok := options["reactions"]; ok {
+				return []string{"reactions", opt.StringValue()}
+			}
+			if opt, ok := options["percent"]; ok {
+				return []string{OptionString(opt)}
+			}
+			return nil
+		},
+	},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "jobs",
+			Description: "List running uploads and transcriptions, and memos that failed to play",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionInteger, Name: "cancel", Description: "ID of a job to cancel"},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "clear", Description: "Forget the memos that failed to play"},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			if id, ok := options["cancel"]; ok {
+				return []string{"cancel", OptionString(id)}
+			}
+			if clear, ok := options["clear"]; ok && clear.BoolValue() {
+				return []string{"clear"}
+			}
+			return nil
+		},
+	},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "link",
+		Description: "Get a temporary link to listen to a voice memo outside Discord",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Voice memo to share", Required: true, Autocomplete: true},
+		},
+	}},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:                     "bind",
+			Description:              "Play a voice memo when an emoji is posted or reacted with in this channel",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "emoji", Description: "Emoji to bind, leave out to list the bindings"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Voice memo to play, leave out to remove the binding", Autocomplete: true},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "cooldown", Description: "How long before it can play again, e.g. 30s"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "pool", Description: "More memos to pick from at random, with optional weights, e.g. honk oof=2"},
+				{Type: discordgo.ApplicationCommandOptionInteger, Name: "chance", Description: "Percent chance of playing anything at all"},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			emoji, ok := options["emoji"]
+			if !ok {
+				return []string{"list"}
+			}
+			name, ok := options["name"]
+			if !ok {
+				return []string{"remove", emoji.StringValue()}
+			}
+			args := []string{"emoji", emoji.StringValue(), name.StringValue()}
+			if pool, ok := options["pool"]; ok {
+				args = append(args, strings.Fields(pool.StringValue())...)
+			}
+			if chance, ok := options["chance"]; ok {
+				args = append(args, "-chance="+OptionString(chance))
+			}
+			if cooldown, ok := options["cooldown"]; ok {
+				args = append(args, cooldown.StringValue())
+			}
+			return args
+		},
+	},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:                     "alias",
+			Description:              "Give a voice memo a short name to play it by",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "alias", Description: "Alias to add or remove, leave out to list the aliases"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Voice memo it plays, leave out to remove the alias", Autocomplete: true},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			alias, ok := options["alias"]
+			if !ok {
+				return []string{"list"}
+			}
+			name, ok := options["name"]
+			if !ok {
+				return []string{"remove", alias.StringValue()}
+			}
+			return []string{"add", alias.StringValue(), name.StringValue()}
+		},
+	},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "soundboard",
+			Description: "Post buttons that queue voice memos when pressed",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "What to do, leave out to post the soundboard",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "add", Value: "add"},
+						{Name: "remove", Value: "remove"},
+						{Name: "clear", Value: "clear"},
+					},
+				},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "memos", Description: "Voice memos to add or remove, separated by spaces"},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			args := make([]string, 0)
+			if opt, ok := options["action"]; ok {
+				args = append(args, opt.StringValue())
+			}
+			if opt, ok := options["memos"]; ok {
+				args = append(args, strings.Fields(opt.StringValue())...)
+			}
+			return args
+		},
+	},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:                     "autojoin",
+		Description:              "Show or change when the bot joins the busiest voice channel by itself",
+		DefaultMemberPermissions: &manageGuild,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "members", Description: "People needed in a channel before joining, or \"off\""},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:                     "idle",
+		Description:              "Show or change how long the bot stays when nothing is playing",
+		DefaultMemberPermissions: &manageGuild,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "after", Description: "How long to wait before leaving, like 30m, or \"off\""},
+		},
+	}},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:                     "pack",
+			Description:              "Share groups of voice memos between servers",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "What to do",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "list", Value: "list"},
+						{Name: "show", Value: "show"},
+						{Name: "create", Value: "create"},
+						{Name: "add", Value: "add"},
+						{Name: "remove", Value: "remove"},
+						{Name: "publish", Value: "publish"},
+						{Name: "unpublish", Value: "unpublish"},
+						{Name: "delete", Value: "delete"},
+						{Name: "subscribe", Value: "subscribe"},
+						{Name: "unsubscribe", Value: "unsubscribe"},
+					},
+				},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "pack", Description: "Name of the sound pack"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "memos", Description: "Voice memos to add or remove, separated by spaces"},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			args := make([]string, 0)
+			for _, name := range []string{"action", "pack"} {
+				if opt, ok := options[name]; ok {
+					args = append(args, opt.StringValue())
+				}
+			}
+			if opt, ok := options["memos"]; ok {
+				args = append(args, strings.Fields(opt.StringValue())...)
+			}
+			return args
+		},
+	},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "playlist",
+			Description: "Make playlists of voice memos and queue them in one go",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "What to do",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "list", Value: "list"},
+						{Name: "show", Value: "show"},
+						{Name: "play", Value: "play"},
+						{Name: "create", Value: "create"},
+						{Name: "add", Value: "add"},
+						{Name: "remove", Value: "remove"},
+						{Name: "delete", Value: "delete"},
+					},
+				},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "playlist", Description: "Name of the playlist"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "memos", Description: "Voice memos to add or remove, separated by spaces"},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			args := make([]string, 0)
+			for _, name := range []string{"action", "playlist"} {
+				if opt, ok := options[name]; ok {
+					args = append(args, opt.StringValue())
+				}
+			}
+			if opt, ok := options["memos"]; ok {
+				args = append(args, strings.Fields(opt.StringValue())...)
+			}
+			return args
+		},
+	},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:                     "grant",
+			Description:              "Make someone a DJ for a while",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "Who gets DJ access"},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "duration", Description: "How long it lasts, e.g. 2h, or \"off\" to take it back"},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			user, ok := options["user"]
+			if !ok {
+				return []string{"list"}
+			}
+			duration, ok := options["duration"]
+			if !ok {
+				return []string{OptionString(user)}
+			}
+			if duration.StringValue() == "off" {
+				return []string{OptionString(user), "off"}
+			}
+			return []string{OptionString(user), GrantDJ, duration.StringValue()}
+		},
+	},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:                     "intro",
+			Description:              "Let people come and go from voice channels without the bot joining or greeting them",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "What to do",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "list", Value: "list"},
+						{Name: "add", Value: "add"},
+						{Name: "remove", Value: "remove"},
+					},
+				},
+				{Type: discordgo.ApplicationCommandOptionMentionable, Name: "who", Description: "User or role to exempt or count again"},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			args := []string{"exempt"}
+			for _, name := range []string{"action", "who"} {
+				if opt, ok := options[name]; ok {
+					args = append(args, OptionString(opt))
+				}
+			}
+			return args
+		},
+	},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:                     "window",
+			Description:              "Only let a voice memo play at certain times",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Voice memo to change", Autocomplete: true},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "when", Description: "Months, weekdays, time of day and zone, like fri-sat 19:00-23:00, or \"off\""},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			name, ok := options["name"]
+			if !ok {
+				return []string{"list"}
+			}
+			args := []string{name.StringValue()}
+			if when, ok := options["when"]; ok {
+				args = append(args, strings.Fields(when.StringValue())...)
+			}
+			return args
+		},
+	},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:                     "warnwords",
+			Description:              "Give voice memos that say certain words a content warning",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "What to do, leave out to list the words",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "add", Value: "add"},
+						{Name: "remove", Value: "remove"},
+						{Name: "block", Value: "block on"},
+						{Name: "unblock", Value: "block off"},
+					},
+				},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "words", Description: "Words or phrases to add or remove, separated by commas"},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			action, ok := options["action"]
+			if !ok {
+				return []string{"list"}
+			}
+			args := strings.Fields(action.StringValue())
+			if words, ok := options["words"]; ok {
+				args = append(args, strings.Fields(words.StringValue())...)
+			}
+			return args
+		},
+	},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:                     "restrict",
+			Description:              "Reserve a voice memo for certain roles",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Voice memo to restrict", Autocomplete: true},
+				{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "Role allowed to play it"},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "off", Description: "Let everyone play it again"},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			name, ok := options["name"]
+			if !ok {
+				return []string{"list"}
+			}
+			if off, ok := options["off"]; ok && off.BoolValue() {
+				return []string{name.StringValue(), "off"}
+			}
+			if role, ok := options["role"]; ok {
+				return []string{name.StringValue(), OptionString(role)}
+			}
+			return []string{name.StringValue()}
+		},
+	},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:                     "cleanup",
+		Description:              "Pick voice memos to delete in one go",
+		DefaultMemberPermissions: &manageGuild,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "sort",
+				Description: "Which voice memos to offer first",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "never played", Value: "never"},
+					{Name: "oldest", Value: "oldest"},
+					{Name: "largest", Value: "largest"},
+				},
+			},
+			{Type: discordgo.ApplicationCommandOptionBoolean, Name: "dry_run", Description: "Only show what deleting the picks would change"},
+		},
+	}, Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+		return dryRunArgs(options, "sort")
+	}},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:                     "audit",
+			Description:              "Show or change the channel admin actions are logged to",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionChannel, Name: "channel", Description: "Channel to log admin actions to", ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText}},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "off", Description: "Stop logging admin actions"},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			if off, ok := options["off"]; ok && off.BoolValue() {
+				return []string{"off"}
+			}
+			if channel, ok := options["channel"]; ok {
+				return []string{OptionString(channel)}
+			}
+			return nil
+		},
+	},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:                     "alerts",
+			Description:              "Show or change the channel that repeated failures are posted to",
+			DefaultMemberPermissions: &manageGuild,
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionChannel, Name: "channel", Description: "Channel to post alerts to", ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText}},
+				{Type: discordgo.ApplicationCommandOptionBoolean, Name: "off", Description: "Stop posting alerts"},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			if off, ok := options["off"]; ok && off.BoolValue() {
+				return []string{"off"}
+			}
+			if channel, ok := options["channel"]; ok {
+				return []string{OptionString(channel)}
+			}
+			return nil
+		},
+	},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "record",
+		Description: "Record yourself in the voice channel as a new voice memo",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Name of the new voice memo", Required: true},
+			{Type: discordgo.ApplicationCommandOptionInteger, Name: "seconds", Description: "How long to record, up to 60 seconds"},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "listen",
+		Description: "Listen for a spoken \"play <name>\" command",
+	}},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Name:        "say",
+			Description: "Say something in the voice channel",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "text", Description: "What to say", Required: true},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "voice", Description: "Voice to say it in"},
+			},
+		},
+		Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+			args := []string{}
+			if voice, ok := options["voice"]; ok {
+				args = append(args, "-voice="+voice.StringValue())
+			}
+			return append(args, options["text"].StringValue())
+		},
+	},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "voices",
+		Description: "List the voices /say can use",
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "voice",
+		Description: "Show or change this server's default /say voice",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "New default voice, or \"default\""},
+		},
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:                     "purge-guild-data",
+		Description:              "Delete everything the bot stored for this server",
+		DefaultMemberPermissions: &manageGuild,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionBoolean, Name: "dry_run", Description: "Only count what it would delete"},
+		},
+	}, Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+		return dryRunArgs(options)
+	}},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "mydata",
+		Description: "Get or delete everything the bot stores about you",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "action",
+				Description: "What to do with your data",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "export", Value: "export"},
+					{Name: "delete", Value: "delete"},
+				},
+			},
+			{Type: discordgo.ApplicationCommandOptionBoolean, Name: "dry_run", Description: "Only show what deleting would remove"},
+		},
+	}, Args: func(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+		return dryRunArgs(options, "action")
+	}},
+	{
+		Definition: &discordgo.ApplicationCommand{
+			Type:                     discordgo.MessageApplicationCommand,
+			Name:                     saveMemoCommand,
+			DefaultMemberPermissions: &manageGuild,
+		},
+		Handler: (*Bot).HandleSaveMemoCommand,
+	},
+	{Definition: &discordgo.ApplicationCommand{
+		Name:        "help",
+		Description: "List the commands, or explain one of them",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "command", Description: "Command to explain"},
+		},
+	}},
+}
+
+// Slash commands by name.
+var slashCommands = func() map[string]*SlashCommand {
+	commands := make(map[string]*SlashCommand)
+	for _, sc := range slashCommandList {
+		sc.Definition.DMPermission = guildOnly
+		LocalizeCommand(sc.Definition)
+		commands[sc.Definition.Name] = sc
+	}
+	return commands
+}()
+
+// Registers every slash command globally once the session is ready, replacing whatever was registered before.
+func (b *Bot) RegisterSlashCommands(s *discordgo.Session, r *discordgo.Ready) {
+	definitions := make([]*discordgo.ApplicationCommand, 0, len(slashCommandList))
+	keys := make([]string, 0)
+	for _, sc := range slashCommandList {
+		definitions = append(definitions, sc.Definition)
+		// Message commands have a name but no description.
+		if sc.Definition.Type == discordgo.MessageApplicationCommand {
+			keys = append(keys, "command."+sc.Definition.Name+".name")
+			continue
+		}
+		keys = append(keys, "command."+sc.Definition.Name+".description")
+		for _, opt := range sc.Definition.Options {
+			keys = append(keys, "command."+sc.Definition.Name+".option."+opt.Name)
+		}
+	}
+	for locale, missing := range MissingTranslations(keys) {
+		fmt.Println("Missing ", locale, " translations: ", missing)
+	}
+
+	if _, err := s.ApplicationCommandBulkOverwrite(r.User.ID, "", definitions); err != nil {
+		fmt.Println("Error registering slash commands: ", err)
+		return
+	}
+	fmt.Println("Registered ", len(definitions), " slash commands.")
+}
+
+// Runs a slash command through the same handlers as its prefix command. Handlers reply in the channel,
+// so the interaction itself only gets a private acknowledgement.
+func (b *Bot) HandleSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	sc, ok := slashCommands[data.Name]
+	if !ok || i.Member == nil {
+		RespondEphemeral(s, i, T(i.Locale, "response.unknown_command", "I don't know that command anymore."))
+		return
+	}
+	fmt.Println("Slash command: ", data.Name)
+
+	c, err := s.State.Channel(i.ChannelID)
+	if err != nil {
+		RespondEphemeral(s, i, "I can't see this channel.")
+		return
+	}
+	g, err := s.State.Guild(i.GuildID)
+	if err != nil {
+		RespondEphemeral(s, i, "I can't see this server.")
+		return
+	}
+
+	if wait := b.CommandWait(s, g.ID, c.ID, i.Member.User.ID, data.Name); wait > 0 {
+		RespondEphemeral(s, i, CooldownNotice("/"+data.Name, wait))
+		return
+	}
+
+	options := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, opt := range data.Options {
+		options[opt.Name] = opt
+	}
+	if sc.Handler != nil {
+		sc.Handler(b, s, i, options)
+		return
+	}
+
+	var args []string
+	if sc.Args != nil {
+		args = sc.Args(options)
+	} else {
+		for _, def := range sc.Definition.Options {
+			if opt, ok := options[def.Name]; ok {
+				args = append(args, OptionString(opt))
+			}
+		}
+	}
+
+	// Handlers only look at who sent the command and where, so a stand-in message is all they need.
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        i.ID,
+		ChannelID: i.ChannelID,
+		GuildID:   i.GuildID,
+		Author:    i.Member.User,
+		Member:    i.Member,
+	}}
+
+	RespondEphemeral(s, i, fmt.Sprintf(T(i.Locale, "response.running", "Running /%s"), data.Name))
+	b.Dispatch(s, g, c, m, data.Name, args)
+}
+
+// Renders an option's value the way it would have been typed after a prefix command.
+func OptionString(opt *discordgo.ApplicationCommandInteractionDataOption) string {
+	switch opt.Type {
+	case discordgo.ApplicationCommandOptionInteger:
+		return strconv.FormatInt(opt.IntValue(), 10)
+	case discordgo.ApplicationCommandOptionBoolean:
+		return strconv.FormatBool(opt.BoolValue())
+	default:
+		return fmt.Sprint(opt.Value)
+	}
+}
+
+// Turns a "tag" option into the -tag= option prefix commands take, ahead of the other options.
+func tagFilterArgs(options map[string]*discordgo.ApplicationCommandInteractionDataOption) []string {
+	args := make([]string, 0)
+	if opt, ok := options["tag"]; ok {
+		args = append(args, "-tag="+strings.Join(ParseTags(opt.StringValue()), ","))
+	}
+	for _, name := range []string{"page", "term"} {
+		if opt, ok := options[name]; ok {
+			args = append(args, OptionString(opt))
+		}
+	}
+	return args
+}
+
+// Passes the named options on in order, followed by -dry-run if the "dry_run" option is set.
+func dryRunArgs(options map[string]*discordgo.ApplicationCommandInteractionDataOption, names ...string) []string {
+	args := make([]string, 0)
+	for _, name := range names {
+		if opt, ok := options[name]; ok {
+			args = append(args, OptionString(opt))
+		}
+	}
+	if opt, ok := options["dry_run"]; ok && opt.BoolValue() {
+		args = append(args, "-dry-run")
+	}
+	return args
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// How many names a library diff lists for each kind of change before the rest are only counted.
+const diffNames = 10
+
+// The memos a guild could see at some point, by name, with the hash of their audio.
+type LibrarySnapshot map[string]string
+
+func (m *VoiceMemoManager) SnapshotLibrary(guildID string) LibrarySnapshot {
+	snapshot := make(LibrarySnapshot)
+	for _, vm := range m.GuildLibrary(guildID) {
+		snapshot[vm.name] = vm.hash
+	}
+	return snapshot
+}
+
+// How a guild's library changed between two snapshots. Changed memos kept their name but got new audio.
+type LibraryDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+func DiffLibrary(before, after LibrarySnapshot) LibraryDiff {
+	diff := LibraryDiff{Added: make([]string, 0), Removed: make([]string, 0), Changed: make([]string, 0)}
+	for name, hash := range after {
+		previous, ok := before[name]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, name)
+		case previous != hash:
+			diff.Changed = append(diff.Changed, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+func (d LibraryDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Sums the diff up in a few lines, e.g. "Added 2: bruh, airhorn", listing up to diffNames names each.
+func (d LibraryDiff) Summary() string {
+	if d.Empty() {
+		return "The library didn't change."
+	}
+	lines := make([]string, 0, 3)
+	for _, change := range []struct {
+		verb  string
+		names []string
+	}{{"Added", d.Added}, {"Removed", d.Removed}, {"Changed", d.Changed}} {
+		if len(change.names) == 0 {
+			continue
+		}
+		names := strings.Join(change.names, ", ")
+		if len(change.names) > diffNames {
+			names = strings.Join(change.names[:diffNames], ", ") + fmt.Sprintf(" and %d more", len(change.names)-diffNames)
+		}
+		lines = append(lines, fmt.Sprintf("%s %d: %s", change.verb, len(change.names), names))
+	}
+	return strings.Join(lines, "\n")
+}
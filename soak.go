@@ -0,0 +1,247 @@
+//go:build soak
+
+// Soak test harness. Build with `go run -tags soak .` to hammer guild sessions and the voice memo
+// manager with simulated guilds instead of starting the bot. Discord is replaced by voice connections
+// that are never dialed, whose OpusSend is drained at a configurable frame rate.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	soakGuilds        = flag.Int("soak-guilds", 25, "Number of simulated guilds")
+	soakMemos         = flag.Int("soak-memos", 20, "Number of synthetic voice memos in the library")
+	soakBursts        = flag.Int("soak-bursts", 30, "Number of command bursts per guild")
+	soakBurstSize     = flag.Int("soak-burst-size", 15, "Number of concurrent !play commands per burst")
+	soakLongFrames    = flag.Int("soak-long-frames", 1500, "Frame count of the long synthetic memos (1500 is 30s of audio)")
+	soakFrameInterval = flag.Duration("soak-frame-interval", 500*time.Microsecond, "How long a simulated connection takes to send one frame (20ms is real time)")
+	soakDeletes       = flag.Int("soak-deletes", 3, "Number of memos deleted while playback is running")
+	soakMaxHeapGrowth = flag.Int("soak-max-heap-growth", 32, "Allowed heap growth in MB after teardown")
+)
+
+func init() {
+	harness = runSoak
+}
+
+// A simulated guild: a guild session whose voice connection only exists in memory.
+type soakGuild struct {
+	session *GuildSession
+	frames  atomic.Int64
+	stop    chan struct{}
+}
+
+func newSoakGuild(id int) *soakGuild {
+	vc := &discordgo.VoiceConnection{OpusSend: make(chan []byte, 2)}
+	sg := &soakGuild{
+		session: &GuildSession{
+			ID:              fmt.Sprintf("soak-guild-%d", id),
+			GuildName:       fmt.Sprintf("Soak Guild %d", id),
+			VoiceConnection: vc,
+			PlayQueue:       NewPlayQueue(10),
+			IsVoicePlaying:  &atomic.Bool{},
+			Receiver:        NewVoiceReceiver(vc),
+		},
+		stop: make(chan struct{}),
+	}
+
+	// Stand in for discordgo's opusSender.
+	go func() {
+		ticker := time.NewTicker(*soakFrameInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-sg.stop:
+				return
+			case <-ticker.C:
+			}
+
+			select {
+			case <-sg.stop:
+				return
+			case <-vc.OpusSend:
+				sg.frames.Add(1)
+			}
+		}
+	}()
+	return sg
+}
+
+func (sg *soakGuild) idle() bool {
+	return !sg.session.IsVoicePlaying.Load() && sg.session.PlayQueue.Len() == 0
+}
+
+func runSoak() int {
+	dir, err := os.MkdirTemp("", "voicememo-soak-")
+	if err != nil {
+		fmt.Println("Error creating soak workspace: ", err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	metadata, err := NewMetadataStore(dir + "/metadata.json")
+	if err != nil {
+		fmt.Println("Error creating metadata store: ", err)
+		return 1
+	}
+
+	baselineGoroutines, baselineHeap := settle()
+	fmt.Printf("Baseline: %d goroutines, %d KB heap\n", baselineGoroutines, baselineHeap/1024)
+
+	// Every other memo is a long one so bursts pile up behind real playback.
+	manager := &VoiceMemoManager{
+		store:      make(map[string]*VoiceMemo),
+		Metadata:   metadata,
+		tombstones: make(map[string]*VoiceMemo),
+	}
+	memos := make([]*VoiceMemo, 0, *soakMemos)
+	for i := 0; i < *soakMemos; i++ {
+		frames := 50
+		if i%2 == 1 {
+			frames = *soakLongFrames
+		}
+		vm := &VoiceMemo{name: fmt.Sprintf("soak-memo-%d", i), buffer: make([][]byte, frames)}
+		for f := range vm.buffer {
+			vm.buffer[f] = make([]byte, 40)
+		}
+		manager.Add(vm)
+		memos = append(memos, vm)
+	}
+
+	guilds := make([]*soakGuild, 0, *soakGuilds)
+	for i := 0; i < *soakGuilds; i++ {
+		guilds = append(guilds, newSoakGuild(i))
+	}
+
+	start := time.Now()
+	var plays, dropped atomic.Int64
+	var wg sync.WaitGroup
+
+	// Rapid command bursts, looking memos up by name like HandlePlay does.
+	for _, sg := range guilds {
+		wg.Add(1)
+		go func(sg *soakGuild) {
+			defer wg.Done()
+			for burst := 0; burst < *soakBursts; burst++ {
+				var bwg sync.WaitGroup
+				for i := 0; i < *soakBurstSize; i++ {
+					name := memos[rng.Intn(len(memos))].name
+					bwg.Add(1)
+					go func() {
+						defer bwg.Done()
+						vm := manager.Get(name)
+						if vm == nil {
+							// Deleted mid-soak.
+							dropped.Add(1)
+							return
+						}
+						if sg.session.Enqueue(vm, "soak-user", "") {
+							plays.Add(1)
+							manager.RecordPlay(sg.session.ID, "soak-user", vm.name)
+						} else {
+							dropped.Add(1)
+						}
+						sg.session.PlayFromQueue()
+					}()
+				}
+				bwg.Wait()
+				time.Sleep(time.Duration(rng.Intn(50)) * time.Millisecond)
+			}
+		}(sg)
+	}
+
+	// Delete memos while they're queued and playing to exercise deferred deletion.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < *soakDeletes && i < len(memos); i++ {
+			time.Sleep(200 * time.Millisecond)
+			if _, err := manager.Delete(memos[i].name); err != nil {
+				fmt.Println("Error deleting ", memos[i].name, ": ", err)
+			}
+		}
+	}()
+
+	wg.Wait()
+	fmt.Printf("Bursts finished after %s: %d plays queued, %d dropped\n", time.Since(start).Round(time.Millisecond), plays.Load(), dropped.Load())
+
+	// Let every guild drain its queue. A guild that never goes idle has stranded memos or a wedged sender.
+	failed := false
+	deadline := time.Now().Add(time.Duration(*soakBurstSize**soakLongFrames)**soakFrameInterval + 30*time.Second)
+	for _, sg := range guilds {
+		for !sg.idle() && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if !sg.idle() {
+			fmt.Printf("LEAK: %s never went idle (%d queued)\n", sg.session.ID, sg.session.PlayQueue.Len())
+			failed = true
+		}
+	}
+
+	var frames int64
+	for _, sg := range guilds {
+		sg.session.Close()
+		close(sg.stop)
+		frames += sg.frames.Load()
+	}
+	fmt.Printf("Playback finished after %s: %d frames sent\n", time.Since(start).Round(time.Millisecond), frames)
+
+	for _, vm := range memos {
+		if refs := vm.Refs(); refs != 0 {
+			fmt.Printf("LEAK: %s still has %d references\n", vm.name, refs)
+			failed = true
+		}
+		if vm.Tombstoned() && manager.IsPendingDeletion(vm.name) {
+			fmt.Printf("LEAK: %s was deleted but never purged\n", vm.name)
+			failed = true
+		}
+	}
+
+	guilds, memos, manager = nil, nil, nil
+	goroutines, heap := settle()
+	fmt.Printf("After teardown: %d goroutines, %d KB heap\n", goroutines, heap/1024)
+
+	if goroutines > baselineGoroutines {
+		fmt.Printf("LEAK: %d goroutines outlived the soak\n", goroutines-baselineGoroutines)
+		pprof.Lookup("goroutine").WriteTo(os.Stdout, 1)
+		failed = true
+	}
+	if growth := int64(heap) - int64(baselineHeap); growth > int64(*soakMaxHeapGrowth)*1024*1024 {
+		fmt.Printf("LEAK: heap grew by %d KB\n", growth/1024)
+		failed = true
+	}
+
+	if failed {
+		fmt.Println("Soak test FAILED")
+		return 1
+	}
+	fmt.Println("Soak test passed")
+	return 0
+}
+
+// Gives exiting goroutines a moment to finish, then returns the goroutine count and live heap size.
+func settle() (int, uint64) {
+	var goroutines int
+	for i := 0; i < 50; i++ {
+		runtime.GC()
+		n := runtime.NumGoroutine()
+		if n == goroutines {
+			break
+		}
+		goroutines = n
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return goroutines, ms.HeapAlloc
+}
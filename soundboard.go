@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Messages can have 5 rows of 5 buttons.
+	maxSoundboard     = 25
+	soundboardRowSize = 5
+)
+
+// Builds the rows of buttons for a guild's soundboard, one per memo in the order they were added.
+func SoundboardComponents(board []string) []discordgo.MessageComponent {
+	rows := make([]discordgo.MessageComponent, 0, (len(board)+soundboardRowSize-1)/soundboardRowSize)
+	for start := 0; start < len(board); start += soundboardRowSize {
+		end := minInt(start+soundboardRowSize, len(board))
+		buttons := make([]discordgo.MessageComponent, 0, end-start)
+		for _, name := range board[start:end] {
+			// Button labels are limited to 80 characters.
+			label := name
+			if len(label) > 80 {
+				label = label[:77] + "..."
+			}
+			buttons = append(buttons, discordgo.Button{Label: label, Style: discordgo.SecondaryButton, CustomID: "soundboard:" + nameToken(name)})
+		}
+		rows = append(rows, discordgo.ActionsRow{Components: buttons})
+	}
+	return rows
+}
+
+func (b *Bot) HandleSoundboard(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := "Usage: !soundboard | !soundboard add|remove <names...> | !soundboard clear"
+	if len(args) == 0 {
+		b.SendSoundboard(s, g, c)
+		return
+	}
+
+	if !b.IsDJ(s, g.ID, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins and DJs can change the soundboard.")
+		return
+	}
+
+	metadata := b.VoiceMemoManager.Metadata
+	switch {
+	case args[0] == "add" && len(args) >= 2:
+		board := metadata.Guild(g.ID).Soundboard
+		on := make(map[string]bool, len(board))
+		for _, name := range board {
+			on[name] = true
+		}
+		added, missing := make([]string, 0), make([]string, 0)
+		for _, arg := range args[1:] {
+			vm := b.VoiceMemoManager.GuildMemo(g.ID, arg)
+			switch {
+			case vm == nil:
+				missing = append(missing, arg)
+			case !on[vm.name]:
+				on[vm.name] = true
+				added = append(added, vm.name)
+			}
+		}
+		if len(board)+len(added) > maxSoundboard {
+			s.ChannelMessageSend(c.ID, fmt.Sprintf("The soundboard can have up to %d voice memos, and has %d already.", maxSoundboard, len(board)))
+			return
+		}
+
+		err := metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+			gs.Soundboard = append(gs.Soundboard, added...)
+		})
+		if err != nil {
+			fmt.Println("Error saving guild settings: ", err)
+			return
+		}
+		reply := fmt.Sprintf("Added %d voice memos to the soundboard. !soundboard to post it.", len(added))
+		if len(missing) > 0 {
+			reply += "\nCannot find: " + strings.Join(missing, ", ")
+		}
+		s.ChannelMessageSend(c.ID, reply)
+		if len(added) > 0 {
+			b.Audit(s, g.ID, fmt.Sprintf("<@%s> added %s to the soundboard.", m.Author.ID, strings.Join(added, ", ")))
+		}
+	case args[0] == "remove" && len(args) >= 2:
+		remove := make(map[string]bool, len(args)-1)
+		for _, arg := range args[1:] {
+			remove[b.VoiceMemoManager.ResolveAlias(g.ID, arg)] = true
+		}
+		removed := make([]string, 0)
+		err := metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+			kept := gs.Soundboard[:0]
+			for _, name := range gs.Soundboard {
+				if remove[name] {
+					removed = append(removed, name)
+					continue
+				}
+				kept = append(kept, name)
+			}
+			gs.Soundboard = kept
+		})
+		if err != nil {
+			fmt.Println("Error saving guild settings: ", err)
+			return
+		}
+		if len(removed) == 0 {
+			s.ChannelMessageSend(c.ID, "None of those are on the soundboard.")
+			return
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Removed %d voice memos from the soundboard.", len(removed)))
+		b.Audit(s, g.ID, fmt.Sprintf("<@%s> removed %s from the soundboard.", m.Author.ID, strings.Join(removed, ", ")))
+	case args[0] == "clear":
+		err := metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+			gs.Soundboard = nil
+		})
+		if err != nil {
+			fmt.Println("Error saving guild settings: ", err)
+			return
+		}
+		s.ChannelMessageSend(c.ID, "Cleared the soundboard.")
+		b.Audit(s, g.ID, fmt.Sprintf("<@%s> cleared the soundboard.", m.Author.ID))
+	default:
+		s.ChannelMessageSend(c.ID, usage)
+	}
+}
+
+// Posts the guild's soundboard, a button for each of its memos.
+func (b *Bot) SendSoundboard(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel) {
+	board := b.VoiceMemoManager.Metadata.Guild(g.ID).Soundboard
+	if len(board) == 0 {
+		s.ChannelMessageSend(c.ID, "There's no soundboard in "+g.Name+" yet. A DJ can add voice memos with !soundboard add <names...>")
+		return
+	}
+
+	msg := &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       "Soundboard",
+			Description: "Press a button to queue its voice memo.",
+			Color:       65535,
+		}},
+		Components: SoundboardComponents(board),
+	}
+	if _, err := s.ChannelMessageSendComplex(c.ID, msg); err != nil {
+		fmt.Println(err)
+		return
+	}
+}
+
+// Handles the buttons of a soundboard. token is the nameToken of the memo, which has to still be on the
+// guild's soundboard. Memos are queued for whoever pressed the button, as if they had played it by name.
+func (b *Bot) HandleSoundboardButton(s *discordgo.Session, i *discordgo.InteractionCreate, token string) {
+	name := ""
+	for _, memo := range b.VoiceMemoManager.Metadata.Guild(i.GuildID).Soundboard {
+		if nameToken(memo) == token {
+			name = memo
+			break
+		}
+	}
+	voiceMemo := b.VoiceMemoManager.Get(name)
+	if voiceMemo == nil || !b.VoiceMemoManager.InLibrary(i.GuildID, name) {
+		RespondEphemeral(s, i, "That voice memo isn't on the soundboard anymore. !soundboard posts the new one.")
+		return
+	}
+
+	gs, ok := b.Session(i.GuildID)
+	if !ok {
+		RespondEphemeral(s, i, "I'm not in a voice channel. !join first.")
+		return
+	}
+	g, err := s.State.Guild(i.GuildID)
+	if err != nil {
+		RespondEphemeral(s, i, "I can't see this server.")
+		return
+	}
+	userID := InteractionUserID(i)
+	if !b.CanPlay(s, g, i.ChannelID, userID, name) {
+		RespondEphemeral(s, i, "You don't have a role that can play "+name)
+		return
+	}
+	if reason := b.OutsideWindow(i.GuildID, name); reason != "" {
+		RespondEphemeral(s, i, reason)
+		return
+	}
+	// Buttons queue memos just like !play, so they share its cooldown.
+	if wait := b.CommandWait(s, i.GuildID, i.ChannelID, userID, "play"); wait > 0 {
+		RespondEphemeral(s, i, CooldownNotice("the soundboard", wait))
+		return
+	}
+
+	if !gs.EnqueueEntry(QueueEntry{Memo: voiceMemo, RequesterID: userID, ChannelID: i.ChannelID}) {
+		RespondEphemeral(s, i, "The queue is full. Try again later.")
+		return
+	}
+	b.VoiceMemoManager.RecordPlay(i.GuildID, userID, name)
+	RespondEphemeral(s, i, fmt.Sprintf("Queued %s (%s).", name, FormatDuration(voiceMemo.Duration())))
+
+	// Playback outlives the interaction.
+	go gs.PlayFromQueue()
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// STTProvider turns speech into text for voice commands and transcription.
+type STTProvider interface {
+	// Transcribe returns the text spoken in an audio file.
+	Transcribe(ctx context.Context, audioPath string) (string, error)
+}
+
+// TTSProvider turns text into speech for !say.
+type TTSProvider interface {
+	// Synthesize speaks text in the given voice ("" for the provider's default)
+	// and returns the audio in any format ffmpeg understands.
+	Synthesize(ctx context.Context, text, voice string) ([]byte, error)
+
+	// Voices lists the voice names Synthesize accepts.
+	Voices(ctx context.Context) ([]string, error)
+}
+
+// Talks to OpenAI compatible speech endpoints, which most hosted and self hosted speech servers speak.
+var speechHTTPClient = &http.Client{Timeout: 60 * time.Second}
+
+// Builds the speech-to-text provider selected by the -stt flag.
+func NewSTTProvider(kind string) (STTProvider, error) {
+	switch kind {
+	case "whisper":
+		return &WhisperCLI{Path: whisperPath, Model: whisperModel}, nil
+	case "http":
+		if sttURL == "" {
+			return nil, fmt.Errorf("-stt=http requires -stt-url")
+		}
+		return &HTTPTranscriber{URL: sttURL, Model: sttModel, APIKey: speechAPIKey}, nil
+	default:
+		return nil, fmt.Errorf("unknown speech-to-text provider %q", kind)
+	}
+}
+
+// Builds the text-to-speech provider selected by the -tts flag.
+func NewTTSProvider(kind string) (TTSProvider, error) {
+	switch kind {
+	case "espeak":
+		return &EspeakCLI{Path: espeakPath}, nil
+	case "http":
+		if ttsURL == "" {
+			return nil, fmt.Errorf("-tts=http requires -tts-url")
+		}
+		return &HTTPSynthesizer{URL: ttsURL, Model: ttsModel, APIKey: speechAPIKey, VoiceList: strings.Split(ttsVoices, ",")}, nil
+	default:
+		return nil, fmt.Errorf("unknown text-to-speech provider %q", kind)
+	}
+}
+
+// WhisperCLI transcribes with a locally installed openai-whisper command.
+type WhisperCLI struct {
+	Path  string
+	Model string
+}
+
+func (w *WhisperCLI) Transcribe(ctx context.Context, audioPath string) (string, error) {
+	dir, err := os.MkdirTemp("", "voicememo-whisper-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	whisper := exec.CommandContext(ctx, w.Path, audioPath, "--model", w.Model, "--language", "en", "--fp16", "False", "--output_format", "txt", "--output_dir", dir)
+	if out, err := whisper.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("%w: %s", err, out)
+	}
+
+	// Whisper names the transcript after the input file.
+	base := strings.TrimSuffix(filepath.Base(audioPath), filepath.Ext(audioPath))
+	text, err := os.ReadFile(filepath.Join(dir, base+".txt"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(text)), nil
+}
+
+// HTTPTranscriber posts audio to an OpenAI compatible /v1/audio/transcriptions endpoint.
+type HTTPTranscriber struct {
+	URL    string
+	Model  string
+	APIKey string
+}
+
+func (t *HTTPTranscriber) Transcribe(ctx context.Context, audioPath string) (string, error) {
+	audio, err := os.Open(audioPath)
+	if err != nil {
+		return "", err
+	}
+	defer audio.Close()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("model", t.Model)
+	part, err := form.CreateFormFile("file", filepath.Base(audioPath))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, audio); err != nil {
+		return "", err
+	}
+	form.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if t.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.APIKey)
+	}
+
+	res, err := speechHTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return "", fmt.Errorf("transcription failed with %s: %s", res.Status, msg)
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(result.Text), nil
+}
+
+// EspeakCLI synthesizes with a locally installed espeak-ng command.
+// Voices are espeak language codes, optionally suffixed with -male or -female (e.g. en-gb-female).
+type EspeakCLI struct {
+	Path string
+}
+
+var espeakVariants = map[string]string{
+	"-female": "+f3",
+	"-male":   "+m3",
+}
+
+func (e *EspeakCLI) Synthesize(ctx context.Context, text, voice string) ([]byte, error) {
+	args := []string{"--stdout"}
+	if voice != "" {
+		voice = strings.ToLower(voice)
+		for suffix, variant := range espeakVariants {
+			if strings.HasSuffix(voice, suffix) {
+				voice = strings.TrimSuffix(voice, suffix) + variant
+				break
+			}
+		}
+		args = append(args, "-v", voice)
+	}
+	// The text goes in on stdin, so text starting with - can't be taken for options.
+	args = append(args, "--stdin")
+
+	espeak := exec.CommandContext(ctx, e.Path, args...)
+	espeak.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	espeak.Stderr = &stderr
+	wav, err := espeak.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return wav, nil
+}
+
+// Lists the installed languages, each available as a male and female voice.
+func (e *EspeakCLI) Voices(ctx context.Context) ([]string, error) {
+	out, err := exec.CommandContext(ctx, e.Path, "--voices").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	voices := make([]string, 0)
+	lines := strings.Split(string(out), "\n")
+	for _, line := range lines[1:] {
+		// Pty Language Age/Gender VoiceName File Other Languages
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		voices = append(voices, fields[1]+"-female", fields[1]+"-male")
+	}
+	return voices, nil
+}
+
+// HTTPSynthesizer posts text to an OpenAI compatible /v1/audio/speech endpoint.
+// The API has no way to list voices, so they are configured with -tts-voices.
+type HTTPSynthesizer struct {
+	URL       string
+	Model     string
+	APIKey    string
+	VoiceList []string
+}
+
+func (t *HTTPSynthesizer) Voices(ctx context.Context) ([]string, error) {
+	return t.VoiceList, nil
+}
+
+// Voice used when neither the command nor the guild picked one. Every OpenAI compatible server has it.
+const httpDefaultVoice = "alloy"
+
+func (t *HTTPSynthesizer) Synthesize(ctx context.Context, text, voice string) ([]byte, error) {
+	if voice == "" {
+		voice = httpDefaultVoice
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"model": t.Model,
+		"input": text,
+		"voice": voice,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.APIKey)
+	}
+
+	res, err := speechHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return nil, fmt.Errorf("speech synthesis failed with %s: %s", res.Status, msg)
+	}
+	return io.ReadAll(res.Body)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// What happened in a guild session, from joining to leaving. The zero value is ready to use.
+type SessionStats struct {
+	frames atomic.Int64
+
+	mu        sync.Mutex
+	started   time.Time
+	memos     int
+	listeners map[string]bool
+
+	// Text channel the session was last used from, which is where its summary goes.
+	channelID string
+}
+
+// A finished session's numbers.
+type SessionSummary struct {
+	Connected time.Duration
+	Frames    int64
+	Memos     int
+	Listeners []string
+	ChannelID string
+}
+
+// Starts the clock on the session.
+func (st *SessionStats) Start() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.started = time.Now()
+}
+
+// Remembers the text channel the session was used from, unless channelID is empty.
+func (st *SessionStats) SetChannel(channelID string) {
+	if channelID == "" {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.channelID = channelID
+}
+
+// Counts an opus frame sent to the voice channel.
+func (st *SessionStats) CountFrame() {
+	st.frames.Add(1)
+}
+
+// Counts a memo starting to play, and who was in the voice channel to hear it.
+func (st *SessionStats) CountPlay(listenerIDs []string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.memos++
+	if st.listeners == nil {
+		st.listeners = make(map[string]bool)
+	}
+	for _, id := range listenerIDs {
+		st.listeners[id] = true
+	}
+}
+
+func (st *SessionStats) Summary() SessionSummary {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	summary := SessionSummary{
+		Frames:    st.frames.Load(),
+		Memos:     st.memos,
+		Listeners: make([]string, 0, len(st.listeners)),
+		ChannelID: st.channelID,
+	}
+	if !st.started.IsZero() {
+		summary.Connected = time.Since(st.started)
+	}
+	for id := range st.listeners {
+		summary.Listeners = append(summary.Listeners, id)
+	}
+	return summary
+}
+
+// Adds a finished session to the guild's stats and posts its summary where the session was last used.
+func (b *Bot) EndSession(s *discordgo.Session, guildID string, summary SessionSummary) {
+	if err := b.VoiceMemoManager.Metadata.AddSession(guildID, summary); err != nil {
+		fmt.Println("Error saving session stats: ", err)
+	}
+	if summary.ChannelID == "" {
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Session summary",
+		Color: 65535,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Connected", Value: FormatDuration(summary.Connected), Inline: true},
+			{Name: "Memos played", Value: fmt.Sprint(summary.Memos), Inline: true},
+			{Name: "Audio sent", Value: FormatDuration(time.Duration(summary.Frames) * frameDuration), Inline: true},
+			{Name: "Listeners", Value: fmt.Sprint(len(summary.Listeners)), Inline: true},
+		},
+	}
+
+	_, err := s.ChannelMessageSendEmbed(summary.ChannelID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
+
+// How often a memo was played in a guild, and when it last was if that's still in the history.
+func (ms *MetadataStore) GuildPlays(guildID, name string) (int, time.Time) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	plays, last := 0, time.Time{}
+	if stats, ok := ms.Stats[guildID]; ok {
+		plays = stats.Plays[name]
+	}
+	for _, play := range ms.History[guildID].Recent(maxHistory) {
+		if play.Memo == name {
+			last = play.At
+			break
+		}
+	}
+	return plays, last
+}
+
+func (b *Bot) HandleStats(s *discordgo.Session, c *discordgo.Channel, args []string) {
+	if len(args) == 0 {
+		s.ChannelMessageSend(c.ID, "Usage: !stats <name>")
+		return
+	}
+	vm := b.VoiceMemoManager.GuildMemo(c.GuildID, args[0])
+	if vm == nil {
+		s.ChannelMessageSend(c.ID, "Cannot find "+args[0])
+		return
+	}
+	md := b.VoiceMemoManager.Metadata.Memo(vm.name)
+	plays, last := b.VoiceMemoManager.Metadata.GuildPlays(c.GuildID, vm.name)
+
+	embed := &discordgo.MessageEmbed{
+		Title: "Stats for " + vm.name,
+		Color: 65535,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Length", Value: FormatDuration(vm.Duration()), Inline: true},
+			{Name: "Plays here", Value: fmt.Sprint(plays), Inline: true},
+			{Name: "Plays everywhere", Value: fmt.Sprint(md.PlayCount), Inline: true},
+		},
+	}
+	if info, err := os.Stat(ObjectPath(vm.hash)); err == nil {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Size", Value: FormatSize(info.Size()), Inline: true})
+	}
+	if md.UploaderID != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Uploaded by", Value: "<@" + md.UploaderID + ">", Inline: true})
+	}
+	if !md.UploadedAt.IsZero() {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Uploaded", Value: fmt.Sprintf("<t:%d:D>", md.UploadedAt.Unix()), Inline: true})
+	}
+	if !last.IsZero() {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Last played here", Value: fmt.Sprintf("<t:%d:R>", last.Unix()), Inline: true})
+	}
+	if len(embed.Title) > 256 {
+		embed.Title = embed.Title[:250] + "..."
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
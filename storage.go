@@ -0,0 +1,206 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Encoded memos live here named after the SHA-256 of their contents. Names only exist in the metadata,
+// so renaming is a metadata change and identical uploads share one file.
+const objectDir = "voicememo_files/objects"
+
+// Path of the object with the given hash.
+func ObjectPath(hash string) string {
+	return filepath.Join(objectDir, hash+".dca")
+}
+
+// Returns the hex SHA-256 of a file's contents.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Moves a file into the object store and returns its hash. If the store already has the same contents,
+// the file is dropped instead. Callers must hold m.objectsMu.
+func storeObject(path string) (string, error) {
+	hash, err := HashFile(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(ObjectPath(hash)); err == nil {
+		return hash, os.Remove(path)
+	}
+	return hash, os.Rename(path, ObjectPath(hash))
+}
+
+// Removes an object unless some memo still points at it. Callers must hold m.objectsMu.
+func (m *VoiceMemoManager) releaseObject(hash string) {
+	if hash == "" || m.Metadata.HashInUse(hash) {
+		return
+	}
+	if err := os.Remove(ObjectPath(hash)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Println("Error removing object ", hash, ": ", err)
+	}
+	m.Artifacts.Forget(hash)
+}
+
+// Adds the encoded file at path to the library as name. update fills in the rest of the memo's metadata.
+// Replaces any memo with the same name, dropping its file once nothing plays it and no other memo uses it.
+func (m *VoiceMemoManager) Import(name, path string, streamed bool, update func(md *MemoMetadata)) (*VoiceMemo, error) {
+	previous := m.Get(name)
+	vm, err := m.importObject(name, path, streamed, update)
+	if err != nil {
+		return nil, err
+	}
+
+	if previous != nil && previous.hash != vm.hash {
+		previous.Tombstone(func() {
+			m.objectsMu.Lock()
+			defer m.objectsMu.Unlock()
+			m.releaseObject(previous.hash)
+		})
+	}
+	return vm, nil
+}
+
+// Like Import, but for opus frames that aren't in a file yet.
+func (m *VoiceMemoManager) ImportFrames(name string, frames [][]byte, streamed bool, update func(md *MemoMetadata)) (*VoiceMemo, error) {
+	workspace, err := os.MkdirTemp("voicememo_files", uploadWorkspacePrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(workspace); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	path := filepath.Join(workspace, "frames.dca")
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	err = WriteDCA(f, frames)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Import(name, path, streamed, update)
+}
+
+func (m *VoiceMemoManager) importObject(name, path string, streamed bool, update func(md *MemoMetadata)) (*VoiceMemo, error) {
+	m.objectsMu.Lock()
+	defer m.objectsMu.Unlock()
+
+	hash, err := storeObject(path)
+	if err != nil {
+		return nil, err
+	}
+
+	vm := &VoiceMemo{name: name, hash: hash, buffer: make([][]byte, 0), streamed: streamed}
+	if streamed {
+		err = vm.CountFrames()
+	} else {
+		err = vm.Load()
+	}
+	if err != nil {
+		m.releaseObject(hash)
+		return nil, err
+	}
+
+	if err := m.Add(vm); err != nil {
+		m.releaseObject(hash)
+		return nil, err
+	}
+	err = m.Metadata.UpdateMemo(name, func(md *MemoMetadata) {
+		update(md)
+		// Measurements belong to the audio they were taken of.
+		if md.Hash != hash {
+			md.Loudness = 0
+		}
+		md.Hash = hash
+	})
+	if err != nil {
+		fmt.Println("Error saving metadata for ", name, ": ", err)
+	}
+	return vm, nil
+}
+
+// Moves memos saved before the object store existed (voicememo_files/<name>.dca) into it. With -dry-run it
+// only logs what it would move, and those memos stay out of the library until it runs for real.
+func (m *VoiceMemoManager) migrateLegacyFiles() error {
+	files, err := os.ReadDir("voicememo_files/")
+	if err != nil {
+		return err
+	}
+
+	m.objectsMu.Lock()
+	defer m.objectsMu.Unlock()
+
+	for _, f := range files {
+		// Upload workspaces left behind by a crash.
+		if f.IsDir() && strings.HasPrefix(f.Name(), uploadWorkspacePrefix) {
+			if dryRun {
+				fmt.Println("Dry run: would remove the abandoned upload workspace ", f.Name())
+				continue
+			}
+			os.RemoveAll("voicememo_files/" + f.Name())
+			continue
+		}
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".dca") {
+			continue
+		}
+
+		name := strings.Split(f.Name(), ".")[0]
+		if dryRun {
+			hash, err := HashFile("voicememo_files/" + f.Name())
+			if err != nil {
+				return err
+			}
+			fmt.Println("Dry run: would move ", name, " into the object store as ", hash)
+			continue
+		}
+		hash, err := storeObject("voicememo_files/" + f.Name())
+		if err != nil {
+			return fmt.Errorf("moving %s into the object store: %w", f.Name(), err)
+		}
+		err = m.Metadata.UpdateMemo(name, func(md *MemoMetadata) {
+			md.Hash = hash
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Println("Moved ", name, " into the object store as ", hash)
+	}
+	return nil
+}
+
+// Re-hashes every memo's file and returns the names of the memos whose file is missing or doesn't match.
+func (m *VoiceMemoManager) Verify() []string {
+	corrupt := make([]string, 0)
+	for _, vm := range m.List() {
+		hash, err := HashFile(ObjectPath(vm.hash))
+		if err != nil || hash != vm.hash {
+			corrupt = append(corrupt, vm.name)
+		}
+	}
+	return corrupt
+}
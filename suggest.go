@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Number of memos !suggest recommends.
+	suggestionCount = 5
+
+	// Memos the user asked for this recently aren't worth suggesting to them.
+	suggestRecentWindow = 7 * 24 * time.Hour
+
+	// How many of the user's most played memos their taste is judged by.
+	suggestFavorites = 10
+)
+
+// A memo worth recommending and why.
+type Suggestion struct {
+	Name   string
+	Score  float64
+	Reason string
+}
+
+// Picks memos for userID that they haven't asked for lately but that are popular in the guild or share
+// tags with their favorite picks.
+func (m *VoiceMemoManager) Suggest(guildID, userID string, n int) []Suggestion {
+	history := m.Metadata.GuildHistory(guildID)
+
+	guildPlays := make(map[string]int)
+	userPlays := make(map[string]int)
+	recent := make(map[string]bool)
+	for _, play := range history {
+		guildPlays[play.Memo]++
+		if play.UserID == userID {
+			userPlays[play.Memo]++
+			if time.Since(play.At) < suggestRecentWindow {
+				recent[play.Memo] = true
+			}
+		}
+	}
+
+	// The user's taste: the tags of their favorites, weighted by how often they played them.
+	favorites := make([]string, 0, len(userPlays))
+	for name := range userPlays {
+		favorites = append(favorites, name)
+	}
+	sort.Slice(favorites, func(i, j int) bool {
+		if userPlays[favorites[i]] != userPlays[favorites[j]] {
+			return userPlays[favorites[i]] > userPlays[favorites[j]]
+		}
+		return favorites[i] < favorites[j]
+	})
+	if len(favorites) > suggestFavorites {
+		favorites = favorites[:suggestFavorites]
+	}
+	tagWeights := make(map[string]int)
+	for _, name := range favorites {
+		for _, tag := range m.Metadata.Memo(name).Tags {
+			tagWeights[tag] += userPlays[name]
+		}
+	}
+
+	suggestions := make([]Suggestion, 0)
+	for _, vm := range m.List() {
+		md := m.Metadata.Memo(vm.name)
+		if recent[vm.name] || !md.AutoSelectable() {
+			continue
+		}
+
+		shared := make([]string, 0)
+		tagScore := 0
+		for _, tag := range md.Tags {
+			if w, ok := tagWeights[tag]; ok {
+				shared = append(shared, tag)
+				tagScore += w
+			}
+		}
+
+		plays := guildPlays[vm.name]
+		if plays == 0 && tagScore == 0 {
+			continue
+		}
+
+		// A shared tag counts for more than a play by someone else.
+		suggestion := Suggestion{Name: vm.name, Score: float64(plays) + 2*float64(tagScore)}
+		if len(shared) > 0 {
+			suggestion.Reason = "Shares " + strings.Join(shared, ", ") + " with your favorites"
+		} else {
+			suggestion.Reason = fmt.Sprintf("Played %d times here recently", plays)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Name < suggestions[j].Name
+	})
+	if len(suggestions) > n {
+		suggestions = suggestions[:n]
+	}
+	return suggestions
+}
+
+func (b *Bot) HandleSuggest(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate) {
+	suggestions := b.VoiceMemoManager.Suggest(g.ID, m.Author.ID, suggestionCount)
+	if len(suggestions) == 0 {
+		s.ChannelMessageSend(c.ID, "I don't have anything new to suggest yet. Play some memos first!")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:  "You might like",
+		Color:  65535,
+		Fields: []*discordgo.MessageEmbedField{},
+		Footer: &discordgo.MessageEmbedFooter{Text: "!play <name> to give one a try"},
+	}
+	for _, suggestion := range suggestions {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  suggestion.Name,
+			Value: suggestion.Reason,
+		})
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
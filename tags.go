@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Most tags a memo can have, and how long each can be.
+	maxTags      = 10
+	maxTagLength = 32
+)
+
+// Takes the leading -tag=<tags> options off a command's arguments, e.g. !list -tag=meme 2. Returns the tags
+// asked for, which memos must all have, and the arguments after the options.
+func ParseTagFilter(args []string) ([]string, []string) {
+	tags := make([]string, 0)
+	for len(args) > 0 {
+		value, ok := OptionValue(args[0], "tag")
+		if !ok {
+			break
+		}
+		tags = append(tags, ParseTags(value)...)
+		args = args[1:]
+	}
+	return tags, args
+}
+
+// Returns the value of an option like -name=value, or --name=value as people used to other tools type it.
+func OptionValue(arg, name string) (string, bool) {
+	if !strings.HasPrefix(arg, "-") {
+		return "", false
+	}
+	key, value, ok := strings.Cut(strings.TrimPrefix(arg[1:], "-"), "=")
+	if !ok || strings.ToLower(key) != name {
+		return "", false
+	}
+	return value, true
+}
+
+// Reports whether a memo has every one of tags.
+func (md MemoMetadata) HasTags(tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, t := range md.Tags {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Keeps the memos that have every one of tags.
+func (m *VoiceMemoManager) FilterTagged(memos []*VoiceMemo, tags []string) []*VoiceMemo {
+	if len(tags) == 0 {
+		return memos
+	}
+	tagged := make([]*VoiceMemo, 0)
+	for _, vm := range memos {
+		if m.Metadata.Memo(vm.name).HasTags(tags) {
+			tagged = append(tagged, vm)
+		}
+	}
+	return tagged
+}
+
+func (b *Bot) HandleTag(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := "Usage: !tag add|remove <name> <tags...> | !tag list"
+	if len(args) == 0 {
+		s.ChannelMessageSend(c.ID, usage)
+		return
+	}
+	if args[0] == "list" {
+		b.SendTags(s, c)
+		return
+	}
+	if (args[0] != "add" && args[0] != "remove") || len(args) < 3 {
+		s.ChannelMessageSend(c.ID, usage)
+		return
+	}
+
+	name := args[1]
+	if b.VoiceMemoManager.Get(name) == nil {
+		s.ChannelMessageSend(c.ID, "Cannot find "+name)
+		return
+	}
+	// Only admins and the original uploader may tag a memo.
+	md := b.VoiceMemoManager.Metadata.Memo(name)
+	if md.OtherGuild(g.ID) {
+		s.ChannelMessageSend(c.ID, name+" belongs to another server.")
+		return
+	}
+	if md.UploaderID != m.Author.ID && !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins or the uploader can change the tags of "+name)
+		return
+	}
+
+	changed := ParseTags(strings.Join(args[2:], " "))
+	tags := make([]string, 0)
+	if args[0] == "add" {
+		for _, tag := range changed {
+			if len(tag) > maxTagLength {
+				s.ChannelMessageSend(c.ID, fmt.Sprintf("Tags can be up to %d characters long.", maxTagLength))
+				return
+			}
+		}
+		tags = ParseTags(strings.Join(append(append([]string(nil), md.Tags...), changed...), ","))
+		if len(tags) > maxTags {
+			s.ChannelMessageSend(c.ID, fmt.Sprintf("A voice memo can have up to %d tags.", maxTags))
+			return
+		}
+	} else {
+		removed := make(map[string]bool)
+		for _, tag := range changed {
+			removed[tag] = true
+		}
+		for _, tag := range md.Tags {
+			if !removed[tag] {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	err := b.VoiceMemoManager.Metadata.UpdateMemo(name, func(md *MemoMetadata) {
+		md.Tags = tags
+	})
+	if err != nil {
+		fmt.Println("Error saving tags: ", err)
+		s.ChannelMessageSend(c.ID, "Error saving tags")
+		return
+	}
+	if len(tags) == 0 {
+		s.ChannelMessageSend(c.ID, name+" has no tags anymore.")
+		return
+	}
+	s.ChannelMessageSend(c.ID, name+" is tagged "+strings.Join(tags, ", "))
+}
+
+// Lists the tags used in the guild's library and how many memos have each.
+func (b *Bot) SendTags(s *discordgo.Session, c *discordgo.Channel) {
+	counts := make(map[string]int)
+	for _, vm := range b.VoiceMemoManager.GuildLibrary(c.GuildID) {
+		for _, tag := range b.VoiceMemoManager.Metadata.Memo(vm.name).Tags {
+			counts[tag]++
+		}
+	}
+	if len(counts) == 0 {
+		s.ChannelMessageSend(c.ID, "No voice memos have tags yet. !tag add <name> <tags...> to add some.")
+		return
+	}
+
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if counts[tags[i]] != counts[tags[j]] {
+			return counts[tags[i]] > counts[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	lines := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		lines = append(lines, fmt.Sprintf("%s (%d)", tag, counts[tag]))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Tags",
+		Description: strings.Join(lines, "\n"),
+		Color:       65535,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "!list -tag=<tag> to see the memos with one"},
+	}
+	if len(embed.Description) > 4096 {
+		embed.Description = embed.Description[:4000] + "..."
+	}
+
+	_, err := s.ChannelMessageSendEmbed(c.ID, embed)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How long whoever is offered a memo with !transfer has to accept it.
+const transferTTL = 24 * time.Hour
+
+// A memo offered to someone else with !transfer, waiting for them to accept. It's saved as a ComponentState
+// so the buttons keep working if the bot restarts. Hash is the audio that was offered, so a memo deleted and
+// uploaded again, or trimmed, isn't handed over instead.
+type Transfer struct {
+	ID      string    `json:"id"`
+	GuildID string    `json:"guild_id"`
+	Memo    string    `json:"memo"`
+	Hash    string    `json:"hash"`
+	FromID  string    `json:"from_id"`
+	ToID    string    `json:"to_id"`
+	Started time.Time `json:"started"`
+}
+
+func (t *Transfer) customID(choice string) string {
+	return "transfer:" + t.ID + ":" + choice
+}
+
+func (b *Bot) HandleTransfer(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) < 2 {
+		s.ChannelMessageSend(c.ID, "Usage: !transfer <name> @user")
+		return
+	}
+	name, toID := args[0], ParseUser(args[1])
+
+	if b.VoiceMemoManager.Get(name) == nil {
+		s.ChannelMessageSend(c.ID, "Cannot find "+name)
+		return
+	}
+	md := b.VoiceMemoManager.Metadata.Memo(name)
+	if md.OtherGuild(g.ID) {
+		s.ChannelMessageSend(c.ID, name+" belongs to another server.")
+		return
+	}
+	// Only admins and the uploader may give a memo away, just as only they may edit or delete it.
+	if md.UploaderID != m.Author.ID && !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins or the uploader can transfer "+name)
+		return
+	}
+	if toID == md.UploaderID {
+		s.ChannelMessageSend(c.ID, name+" already belongs to <@"+toID+">")
+		return
+	}
+	member, err := s.GuildMember(g.ID, toID)
+	if err != nil || member.User.Bot {
+		s.ChannelMessageSend(c.ID, "I can only transfer voice memos to people in "+g.Name)
+		return
+	}
+
+	t := &Transfer{ID: m.ID, GuildID: g.ID, Memo: name, Hash: md.Hash, FromID: m.Author.ID, ToID: toID, Started: time.Now()}
+	if err := b.VoiceMemoManager.Metadata.SaveComponent("transfer", t.ID, g.ID, t.Started.Add(transferTTL), t); err != nil {
+		fmt.Println("Error saving transfer: ", err)
+		return
+	}
+
+	msg := &discordgo.MessageSend{
+		Content: fmt.Sprintf("<@%s>, <@%s> wants to give you %s. You'll be able to edit and delete it. The offer stays open for a day.", toID, m.Author.ID, name),
+		Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Accept", Style: discordgo.SuccessButton, CustomID: t.customID("accept")},
+			discordgo.Button{Label: "Decline", Style: discordgo.SecondaryButton, CustomID: t.customID("decline")},
+		}}},
+		AllowedMentions: &discordgo.MessageAllowedMentions{Users: []string{toID}},
+	}
+	if _, err := s.ChannelMessageSendComplex(c.ID, msg); err != nil {
+		fmt.Println(err)
+		return
+	}
+}
+
+// Handles the buttons of a !transfer offer. arg is "<transfer id>:<accept|decline>". Only the recipient can
+// accept, and either side can call it off.
+func (b *Bot) HandleTransferButton(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	id, choice, _ := strings.Cut(arg, ":")
+	metadata := b.VoiceMemoManager.Metadata
+
+	t := &Transfer{}
+	if !metadata.LoadComponent("transfer", id, t) || t.GuildID != i.GuildID {
+		b.replaceComponentMessage(s, i, "This offer has expired. Run !transfer again.")
+		return
+	}
+	userID := InteractionUserID(i)
+	if userID != t.ToID && !(choice == "decline" && userID == t.FromID) {
+		RespondEphemeral(s, i, "Only <@"+t.ToID+"> can accept this.")
+		return
+	}
+	if err := metadata.DropComponent("transfer", id); err != nil {
+		fmt.Println("Error saving transfer: ", err)
+	}
+
+	if choice != "accept" {
+		b.replaceComponentMessage(s, i, fmt.Sprintf("<@%s> called off giving %s to <@%s>.", userID, t.Memo, t.ToID))
+		return
+	}
+	md := metadata.Memo(t.Memo)
+	if b.VoiceMemoManager.Get(t.Memo) == nil || md.Hash != t.Hash {
+		b.replaceComponentMessage(s, i, t.Memo+" was changed or deleted in the meantime. Run !transfer again.")
+		return
+	}
+	// Whoever offered it may have lost it, or their admin role, since.
+	if md.UploaderID != t.FromID && !IsAdmin(s, t.FromID, i.ChannelID) {
+		b.replaceComponentMessage(s, i, fmt.Sprintf("<@%s> can't give %s away anymore.", t.FromID, t.Memo))
+		return
+	}
+
+	from := md.UploaderID
+	err := metadata.UpdateMemo(t.Memo, func(md *MemoMetadata) {
+		md.UploaderID = t.ToID
+	})
+	if err != nil {
+		fmt.Println("Error saving memo metadata: ", err)
+		RespondEphemeral(s, i, "Could not transfer "+t.Memo)
+		return
+	}
+	b.replaceComponentMessage(s, i, fmt.Sprintf("%s now belongs to <@%s>.", t.Memo, t.ToID))
+
+	// An admin can give away someone else's memo, so say whose it was.
+	summary := fmt.Sprintf("<@%s> transferred %s to <@%s>", t.FromID, t.Memo, t.ToID)
+	if from != "" && from != t.FromID {
+		summary = fmt.Sprintf("<@%s> transferred %s from <@%s> to <@%s>", t.FromID, t.Memo, from, t.ToID)
+	}
+	b.Audit(s, i.GuildID, summary+".")
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Offers only work through their buttons, so they have to come back from the store exactly as they went in,
+// and stop coming back once they're answered or expire.
+func TestTransferOffer(t *testing.T) {
+	offer := &Transfer{ID: "123", GuildID: "1", Memo: "bruh", FromID: "u1", ToID: "u2", Started: time.Now()}
+	tests := []struct {
+		name    string
+		expires time.Time
+		drop    bool
+		kind    string
+		wantOK  bool
+	}{
+		{"open", offer.Started.Add(transferTTL), false, "transfer", true},
+		{"expired", offer.Started.Add(-time.Minute), false, "transfer", false},
+		{"answered", offer.Started.Add(transferTTL), true, "transfer", false},
+		{"another kind of component", offer.Started.Add(transferTTL), false, "queue", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := testMetadataStore(t)
+			if err := ms.SaveComponent("transfer", offer.ID, offer.GuildID, tt.expires, offer); err != nil {
+				t.Fatal(err)
+			}
+			if tt.drop {
+				if err := ms.DropComponent("transfer", offer.ID); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			loaded := &Transfer{}
+			ok := ms.LoadComponent(tt.kind, offer.ID, loaded)
+			if ok != tt.wantOK {
+				t.Fatalf("LoadComponent returned %v, want %v", ok, tt.wantOK)
+			}
+			if ok && (loaded.GuildID != offer.GuildID || loaded.Memo != offer.Memo || loaded.FromID != offer.FromID || loaded.ToID != offer.ToID) {
+				t.Errorf("loaded %+v, want %+v", loaded, offer)
+			}
+		})
+	}
+}
+
+func TestTransferCustomID(t *testing.T) {
+	offer := &Transfer{ID: "123"}
+	for _, choice := range []string{"accept", "decline"} {
+		// HandleTransferButton gets what comes after "transfer:".
+		kind, arg, _ := strings.Cut(offer.customID(choice), ":")
+		id, got, _ := strings.Cut(arg, ":")
+		if kind != "transfer" || id != offer.ID || got != choice {
+			t.Errorf("customID(%q) = %q", choice, offer.customID(choice))
+		}
+		if len(offer.customID(choice)) > 100 {
+			t.Errorf("customID(%q) is longer than Discord allows", choice)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// How far the trim buttons move an end.
+	trimNudge = 500 * time.Millisecond
+
+	// How long a !trim stays usable after it was started.
+	trimTTL = 15 * time.Minute
+)
+
+// A !trim in progress: the part of the memo that will be kept, in frames. It's saved as a ComponentState
+// so the buttons keep working if the bot restarts.
+type Trim struct {
+	ID      string    `json:"id"`
+	GuildID string    `json:"guild_id"`
+	UserID  string    `json:"user_id"`
+	Memo    string    `json:"memo"`
+	Start   int       `json:"start"`
+	End     int       `json:"end"`
+	Total   int       `json:"total"`
+	Started time.Time `json:"started"`
+}
+
+// Returns every opus frame of the memo, reading it from disk if it's streamed.
+func (vm *VoiceMemo) AllFrames() ([][]byte, error) {
+	frames := make([][]byte, 0, vm.Frames())
+	err := vm.EachFrame(func(frame []byte) bool {
+		frames = append(frames, frame)
+		return true
+	})
+	return frames, err
+}
+
+// Replaces a memo with frames [start, end) of itself. Everything else about the memo stays the same.
+func (m *VoiceMemoManager) TrimMemo(name string, start, end int) (*VoiceMemo, error) {
+	vm := m.Get(name)
+	if vm == nil {
+		return nil, fmt.Errorf("cannot find %s", name)
+	}
+	frames, err := vm.AllFrames()
+	if err != nil {
+		return nil, err
+	}
+	if start < 0 || end > len(frames) || start >= end {
+		return nil, fmt.Errorf("%s is only %s long", name, FormatSeconds(vm.Duration()))
+	}
+	return m.ImportFrames(name, frames[start:end], vm.streamed, func(md *MemoMetadata) {})
+}
+
+// Formats a duration in seconds to a tenth, which is finer than FormatDuration and what trimming needs.
+func FormatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+// Converts "1.5" seconds to a frame index.
+func parseTrimPoint(arg string) (int, bool) {
+	seconds, err := strconv.ParseFloat(strings.TrimSuffix(arg, "s"), 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return int(seconds * float64(time.Second/frameDuration)), true
+}
+
+func (b *Bot) HandleTrim(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := "Usage: !trim <name> [<start seconds> <end seconds>]"
+	if len(args) == 0 {
+		s.ChannelMessageSend(c.ID, usage)
+		return
+	}
+	name := args[0]
+	vm := b.VoiceMemoManager.Get(name)
+	if vm == nil {
+		s.ChannelMessageSend(c.ID, "Cannot find "+name)
+		return
+	}
+
+	// Only admins and the original uploader may trim a memo.
+	md := b.VoiceMemoManager.Metadata.Memo(name)
+	if md.OtherGuild(g.ID) {
+		s.ChannelMessageSend(c.ID, name+" belongs to another server.")
+		return
+	}
+	if md.UploaderID != m.Author.ID && !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins or the uploader can trim "+name)
+		return
+	}
+
+	if len(args) >= 3 {
+		start, ok := parseTrimPoint(args[1])
+		end, ok2 := parseTrimPoint(args[2])
+		if !ok || !ok2 {
+			s.ChannelMessageSend(c.ID, usage)
+			return
+		}
+		trimmed, err := b.VoiceMemoManager.TrimMemo(name, start, end)
+		if err != nil {
+			s.ChannelMessageSend(c.ID, "Could not trim "+name+": "+err.Error())
+			return
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("Trimmed %s, it's %s long now.", name, FormatSeconds(trimmed.Duration())))
+		return
+	}
+
+	// Without timestamps, let them find the right ones with buttons.
+	t := &Trim{
+		ID:      m.ID,
+		GuildID: g.ID,
+		UserID:  m.Author.ID,
+		Memo:    name,
+		End:     vm.Frames(),
+		Total:   vm.Frames(),
+		Started: time.Now(),
+	}
+
+	if err := b.VoiceMemoManager.Metadata.SaveComponent("trim", t.ID, t.GuildID, t.Started.Add(trimTTL), t); err != nil {
+		fmt.Println("Error saving trim: ", err)
+	}
+	embed, components := t.Message()
+
+	msg := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{embed}, Components: components}
+	if _, err := s.ChannelMessageSendComplex(c.ID, msg); err != nil {
+		fmt.Println(err)
+		return
+	}
+}
+
+// Renders the trim's current range and its buttons.
+func (t *Trim) Message() (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	start := time.Duration(t.Start) * frameDuration
+	end := time.Duration(t.End) * frameDuration
+	embed := &discordgo.MessageEmbed{
+		Title: "Trim " + t.Memo,
+		Description: fmt.Sprintf("Keeping %s to %s of %s (%s long).",
+			FormatSeconds(start), FormatSeconds(end), FormatSeconds(time.Duration(t.Total)*frameDuration), FormatSeconds(end-start)),
+		Color:  65535,
+		Footer: &discordgo.MessageEmbedFooter{Text: "Nudge the ends, preview it in voice, then save."},
+	}
+
+	nudge := int(trimNudge / frameDuration)
+	return embed, []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Start −0.5s", Style: discordgo.SecondaryButton, CustomID: t.customID("start-"), Disabled: t.Start == 0},
+			discordgo.Button{Label: "Start +0.5s", Style: discordgo.SecondaryButton, CustomID: t.customID("start+"), Disabled: t.Start+nudge >= t.End},
+			discordgo.Button{Label: "End −0.5s", Style: discordgo.SecondaryButton, CustomID: t.customID("end-"), Disabled: t.End-nudge <= t.Start},
+			discordgo.Button{Label: "End +0.5s", Style: discordgo.SecondaryButton, CustomID: t.customID("end+"), Disabled: t.End == t.Total},
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Preview", Style: discordgo.PrimaryButton, CustomID: t.customID("preview")},
+			discordgo.Button{Label: "Save", Style: discordgo.SuccessButton, CustomID: t.customID("save"), Disabled: t.Start == 0 && t.End == t.Total},
+			discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: t.customID("cancel")},
+		}},
+	}
+}
+
+func (t *Trim) customID(action string) string {
+	return "trim:" + t.ID + ":" + action
+}
+
+// Handles the buttons of a !trim message. arg is "<trim id>:<action>".
+func (b *Bot) HandleTrimInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	id, action, _ := strings.Cut(arg, ":")
+
+	// Clicks are handled one at a time so quick ones build on each other.
+	b.trimsMu.Lock()
+	t := &Trim{}
+	ok := b.VoiceMemoManager.Metadata.LoadComponent("trim", id, t)
+	if !ok || t.GuildID != i.GuildID {
+		b.trimsMu.Unlock()
+		RespondEphemeral(s, i, "This trim has expired. Run !trim again.")
+		return
+	}
+	if t.UserID != InteractionUserID(i) {
+		b.trimsMu.Unlock()
+		RespondEphemeral(s, i, "Only whoever started this trim can use these buttons.")
+		return
+	}
+
+	nudge := int(trimNudge / frameDuration)
+	switch action {
+	case "start-":
+		t.Start -= nudge
+		if t.Start < 0 {
+			t.Start = 0
+		}
+	case "start+":
+		if t.Start+nudge < t.End {
+			t.Start += nudge
+		}
+	case "end-":
+		if t.End-nudge > t.Start {
+			t.End -= nudge
+		}
+	case "end+":
+		t.End += nudge
+		if t.End > t.Total {
+			t.End = t.Total
+		}
+	case "preview":
+		b.trimsMu.Unlock()
+		b.previewTrim(s, i, *t)
+		return
+	case "save":
+		b.dropTrim(t.ID)
+		b.trimsMu.Unlock()
+		b.saveTrim(s, i, *t)
+		return
+	case "cancel":
+		b.dropTrim(t.ID)
+		b.trimsMu.Unlock()
+		b.updateTrimMessage(s, i, "Trim cancelled, "+t.Memo+" wasn't changed.")
+		return
+	}
+
+	if err := b.VoiceMemoManager.Metadata.SaveComponent("trim", t.ID, t.GuildID, t.Started.Add(trimTTL), t); err != nil {
+		fmt.Println("Error saving trim: ", err)
+	}
+	embed, components := t.Message()
+	b.trimsMu.Unlock()
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+	if err != nil {
+		fmt.Println("Error responding to interaction: ", err)
+	}
+}
+
+func (b *Bot) dropTrim(id string) {
+	if err := b.VoiceMemoManager.Metadata.DropComponent("trim", id); err != nil {
+		fmt.Println("Error saving trim: ", err)
+	}
+}
+
+// Plays just the part of the memo the trim would keep.
+func (b *Bot) previewTrim(s *discordgo.Session, i *discordgo.InteractionCreate, t Trim) {
+	gs, ok := b.Session(t.GuildID)
+	if !ok {
+		RespondEphemeral(s, i, "I need to !join a voice channel to play a preview.")
+		return
+	}
+	vm := b.VoiceMemoManager.Get(t.Memo)
+	if vm == nil {
+		RespondEphemeral(s, i, "Cannot find "+t.Memo)
+		return
+	}
+	frames, err := vm.AllFrames()
+	if err != nil || t.End > len(frames) {
+		fmt.Println("Error reading ", t.Memo, ": ", err)
+		RespondEphemeral(s, i, "Could not read "+t.Memo)
+		return
+	}
+
+	// Previews aren't part of the library, so they only live in the queue. The point is to hear where the
+	// memo ends, so they play past !maxplay.
+	preview := &VoiceMemo{name: t.Memo + " (preview)", buffer: frames[t.Start:t.End]}
+	if !gs.EnqueueEntry(QueueEntry{Memo: preview, RequesterID: t.UserID, ChannelID: i.ChannelID, Full: true}) {
+		RespondEphemeral(s, i, "The queue is full. Try again later.")
+		return
+	}
+	go gs.PlayFromQueue()
+	RespondEphemeral(s, i, "Playing the trimmed "+t.Memo+".")
+}
+
+func (b *Bot) saveTrim(s *discordgo.Session, i *discordgo.InteractionCreate, t Trim) {
+	// Long memos can take longer to rewrite than Discord waits for a response, so answer first.
+	b.updateTrimMessage(s, i, "Trimming "+t.Memo+"...")
+
+	result := ""
+	trimmed, err := b.VoiceMemoManager.TrimMemo(t.Memo, t.Start, t.End)
+	if err != nil {
+		fmt.Println("Error trimming ", t.Memo, ": ", err)
+		result = "Could not trim " + t.Memo + ": " + err.Error()
+	} else {
+		result = fmt.Sprintf("Trimmed %s, it's %s long now.", t.Memo, FormatSeconds(trimmed.Duration()))
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &result}); err != nil {
+		fmt.Println("Error editing interaction response: ", err)
+	}
+}
+
+// Replaces the trim message with a plain notice and takes its buttons away.
+func (b *Bot) updateTrimMessage(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Embeds:     []*discordgo.MessageEmbed{},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		fmt.Println("Error responding to interaction: ", err)
+	}
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Name prefix of the per-upload temp directories inside voicememo_files.
+const uploadWorkspacePrefix = ".upload-"
+
+// Everything the upload pipeline needs to turn an audio file into a voice memo.
+type UploadRequest struct {
+	GuildID    string
+	UploaderID string
+	URL        string
+	FileName   string
+
+	// Name of the new memo. Defaults to the file name without its extension.
+	Name string
+	Type MemoType
+	Tags []string
+
+	// Encode in mono even if the file is stereo. Files that are mono already always are.
+	Mono bool
+
+	// Jump link to the message or interaction the upload came from.
+	MessageLink string
+
+	// What to do if the name is taken. Empty follows the guild's !namepolicy.
+	NamePolicy NamePolicy
+}
+
+// What an upload produced.
+type UploadResult struct {
+	Name string
+
+	// Set when the name asked for was taken. Version is what the memo that had it is called now, if it
+	// was replaced, and Name is otherwise the free name the new memo got instead.
+	Requested string
+	Version   string
+
+	// Set when the new memo sounds almost identical to one that was already in the library.
+	Duplicate  *MemoMetadata
+	Similarity float64
+}
+
+var errUploadCancelled = errors.New("the upload was cancelled")
+
+// Returned when the guild has no room for another memo.
+type MemoLimitError struct {
+	Limit int
+}
+
+func (e *MemoLimitError) Error() string {
+	return fmt.Sprintf("this server already has %d voice memos", e.Limit)
+}
+
+// Reply announcing the upload, with a warning if the memo is a near-duplicate.
+func (r *UploadResult) Message() string {
+	msg := "Successfully uploaded " + r.Name
+	switch {
+	case r.Version != "":
+		msg += ". The old " + r.Name + " is still around as " + r.Version
+	case r.Requested != "" && r.Requested != r.Name:
+		msg += " (" + r.Requested + " was taken)"
+	}
+	if r.Duplicate != nil {
+		msg += fmt.Sprintf("\nHeads up: %s sounds almost identical to %s (%.0f%% similar).", r.Name, r.Duplicate.Name, r.Similarity*100)
+		if r.Duplicate.MessageLink != "" {
+			msg += " Original upload: " + r.Duplicate.MessageLink
+		}
+	}
+	return msg
+}
+
+// Reports why a memo can't be called name, or nil if it can. Names end up in file paths and prefix commands.
+func ValidateMemoName(name string) error {
+	if name == "" {
+		return errors.New("the name can't be empty")
+	}
+	if strings.ContainsAny(name, " \t\n/\\.") {
+		return errors.New("the name can't contain spaces, slashes or dots")
+	}
+	return nil
+}
+
+// Reports why the guild can't get a new memo called name, or nil if it can.
+func (b *Bot) CanAddMemo(guildID, name string) error {
+	if err := ValidateMemoName(name); err != nil {
+		return err
+	}
+
+	// Refuse the memo if the guild is already at its memo limit.
+	if limit := b.MemoLimit(guildID); limit > 0 && b.VoiceMemoManager.GuildMemoCount(guildID) >= limit {
+		return &MemoLimitError{Limit: limit}
+	}
+	if b.VoiceMemoManager.IsPendingDeletion(name) {
+		return errors.New("a voice memo with that name is still being deleted. Try again once it has finished playing")
+	}
+	return nil
+}
+
+// Splits a comma or space separated tag list into lowercase tags, dropping empty ones and repeats.
+func ParseTags(s string) []string {
+	tags := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, tag := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return r == ',' || r == ' ' }) {
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Downloads, converts and adds a voice memo. Returned errors are meant to be shown to the uploader.
+func (b *Bot) Upload(ctx context.Context, req UploadRequest) (*UploadResult, error) {
+	name := req.Name
+	if name == "" {
+		name = strings.Split(req.FileName, ".")[0]
+	}
+	if err := b.CanAddMemo(req.GuildID, name); err != nil {
+		return nil, err
+	}
+	policy := req.NamePolicy
+	if policy == "" {
+		policy = b.VoiceMemoManager.Metadata.Guild(req.GuildID).UploadNamePolicy()
+	}
+	// Find out about a taken name before spending time on the file. It's claimed once the file is ready,
+	// in case the name was taken or freed in the meantime.
+	if _, _, err := b.ResolveMemoName(req.GuildID, name, policy); err != nil {
+		return nil, err
+	}
+
+	// Show up in !jobs for as long as the upload runs, so it can be cancelled.
+	ctx, _, done := b.Jobs.Start(ctx, "upload", req.GuildID, req.UploaderID, "Uploading "+name)
+	defer done()
+
+	// Every upload gets its own workspace so concurrent uploads of files with the same name can't collide.
+	// It lives next to the object store so the finished file can be renamed into place atomically.
+	workspace, err := os.MkdirTemp("voicememo_files", uploadWorkspacePrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(workspace); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	original := filepath.Join(workspace, "original"+filepath.Ext(req.FileName))
+	err = Retry(ctx, "downloading "+req.FileName, func() error {
+		return Download(ctx, req.URL, original)
+	})
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return nil, errUploadCancelled
+	}
+	if err != nil {
+		fmt.Println("Error downloading ", req.FileName, ": ", err)
+		return nil, errors.New("the file could not be downloaded")
+	}
+
+	// A second channel would hold the same audio again, so mono files stay mono.
+	preset := b.VoiceMemoManager.Metadata.Guild(req.GuildID).Preset
+	if req.Mono {
+		preset.Mono = true
+	} else if !preset.Mono {
+		channels, err := ProbeChannels(ctx, original)
+		if err != nil {
+			fmt.Println("Error probing ", req.FileName, ": ", err)
+		}
+		preset.Mono = channels == 1
+	}
+
+	// Convert the original file to .dca, here or in an encoder.
+	converted := filepath.Join(workspace, "converted.dca")
+	if err := ConvertUpload(ctx, preset, original, converted); err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return nil, errUploadCancelled
+		}
+		fmt.Println("Error converting ", req.FileName, ": ", err)
+		b.Alerts.Report(req.GuildID, AlertConversion, fmt.Errorf("converting %s: %w", req.FileName, err))
+		return nil, errors.New("the file could not be converted. Is it an audio file?")
+	}
+
+	// Fingerprint the original, not the lossy opus version, to look for memos that sound the same.
+	fingerprint, err := Fingerprint(ctx, original)
+	if err != nil {
+		fmt.Println("Error fingerprinting ", req.FileName, ": ", err)
+	}
+
+	requested := name
+	name, version, err := b.ClaimMemoName(req.GuildID, name, policy)
+	if err != nil {
+		return nil, err
+	}
+	vm, err := b.VoiceMemoManager.Import(name, converted, req.Type == MemoTypeLongForm, func(md *MemoMetadata) {
+		md.GuildID = req.GuildID
+		md.UploaderID = req.UploaderID
+		md.UploadedAt = time.Now()
+		md.PlayCount = 0
+		md.Fingerprint = fingerprint
+		md.Type = req.Type
+		md.Tags = req.Tags
+		md.MessageLink = req.MessageLink
+	})
+	if err != nil {
+		fmt.Println("Error adding ", name, ": ", err)
+		return nil, err
+	}
+
+	// Measure now so playing it at the guild's target loudness doesn't have to wait for it.
+	if _, err := b.VoiceMemoManager.Loudness(ctx, vm); err != nil {
+		fmt.Println("Error measuring ", name, ": ", err)
+	}
+	// Transcripts can take a while and the upload doesn't need them, so they're made after it's done.
+	go b.TranscribeMemo(req.GuildID, req.UploaderID, vm)
+
+	result := &UploadResult{Name: name, Version: version}
+	if requested != name || version != "" {
+		result.Requested = requested
+	}
+	if duplicate, similarity, ok := b.VoiceMemoManager.FindNearDuplicate(fingerprint, name); ok {
+		result.Duplicate = &duplicate
+		result.Similarity = similarity
+	}
+	return result, nil
+}
+
+func (b *Bot) HandleUpload(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+	// Replying to an earlier message uploads its audio, so old clips don't have to be posted again.
+	source := m.Message
+	if len(m.Attachments) == 0 && m.MessageReference != nil {
+		source = m.ReferencedMessage
+		if source == nil {
+			var err error
+			source, err = s.ChannelMessage(m.MessageReference.ChannelID, m.MessageReference.MessageID)
+			if err != nil {
+				fmt.Println("Error fetching referenced message: ", err)
+				s.ChannelMessageSend(m.ChannelID, "I can't see the message you replied to.")
+				return
+			}
+		}
+	}
+
+	attachment := AudioAttachment(source.Attachments)
+	if attachment == nil {
+		s.ChannelMessageSend(m.ChannelID, "Please attach an audio file, or reply to a message that has one.")
+		return
+	}
+
+	req := UploadRequest{
+		GuildID:     m.GuildID,
+		UploaderID:  m.Author.ID,
+		URL:         attachment.URL,
+		FileName:    attachment.Filename,
+		Type:        MemoTypeSoundboard,
+		MessageLink: fmt.Sprintf("https://discord.com/channels/%s/%s/%s", m.GuildID, source.ChannelID, source.ID),
+	}
+	for _, arg := range args {
+		switch {
+		case arg == "-longform":
+			req.Type = MemoTypeLongForm
+		case arg == "-mono":
+			req.Mono = true
+		case strings.HasPrefix(arg, "-tags="):
+			req.Tags = ParseTags(strings.TrimPrefix(arg, "-tags="))
+		default:
+			req.Name = arg
+		}
+	}
+
+	result, err := b.Upload(ctx, req)
+	var limitErr *MemoLimitError
+	if errors.As(err, &limitErr) {
+		b.SendMemoLimitReached(s, m.ChannelID, m.GuildID, limitErr.Limit)
+		return
+	}
+	var takenErr *NameTakenError
+	if errors.As(err, &takenErr) && takenErr.Prompt {
+		b.SendNameClash(s, &NameClash{ID: m.ID, GuildID: m.GuildID, ChannelID: m.ChannelID, UserID: m.Author.ID, Name: takenErr.Name, Upload: &req})
+		return
+	}
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "Could not upload: "+err.Error())
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, result.Message())
+}
+
+// Downloads url to path. Failures that might go away on their own are TransientErrors.
+func Download(ctx context.Context, url, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if IsTransientHTTP(nil, err) {
+			return &TransientError{Err: err}
+		}
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		err := fmt.Errorf("unexpected status %s", res.Status)
+		if IsTransientHTTP(res, nil) {
+			return &TransientError{Err: err}
+		}
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// A connection that drops halfway through is worth another try.
+	if _, err := io.Copy(file, res.Body); err != nil {
+		if IsTransientHTTP(nil, err) {
+			return &TransientError{Err: err}
+		}
+		return err
+	}
+	return file.Close()
+}
+
+// Picks the attachment to upload: the first audio or video file (ffmpeg keeps just the audio), or the first
+// attachment Discord didn't label at all.
+func AudioAttachment(attachments []*discordgo.MessageAttachment) *discordgo.MessageAttachment {
+	for _, a := range attachments {
+		if strings.HasPrefix(a.ContentType, "audio/") || strings.HasPrefix(a.ContentType, "video/") {
+			return a
+		}
+	}
+	for _, a := range attachments {
+		if a.ContentType == "" {
+			return a
+		}
+	}
+	return nil
+}
+
+// Runs /upload. Converting can take a while, so the response is deferred and edited with the result
+// once the memo is in the library.
+func (b *Bot) HandleUploadSlash(s *discordgo.Session, i *discordgo.InteractionCreate, options map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	data := i.ApplicationCommandData()
+	var attachment *discordgo.MessageAttachment
+	if opt, ok := options["file"]; ok && data.Resolved != nil {
+		if id, ok := opt.Value.(string); ok {
+			attachment = data.Resolved.Attachments[id]
+		}
+	}
+	if attachment == nil {
+		RespondEphemeral(s, i, "Please attach an audio file.")
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		fmt.Println("Error responding to interaction: ", err)
+		return
+	}
+
+	req := UploadRequest{
+		GuildID:    i.GuildID,
+		UploaderID: i.Member.User.ID,
+		URL:        attachment.URL,
+		FileName:   attachment.Filename,
+		Type:       MemoTypeSoundboard,
+	}
+	if opt, ok := options["name"]; ok {
+		req.Name = opt.StringValue()
+	}
+	if opt, ok := options["tags"]; ok {
+		req.Tags = ParseTags(opt.StringValue())
+	}
+	if opt, ok := options["longform"]; ok && opt.BoolValue() {
+		req.Type = MemoTypeLongForm
+	}
+	if opt, ok := options["mono"]; ok && opt.BoolValue() {
+		req.Mono = true
+	}
+	b.UploadDeferred(s, i, req)
+}
+
+// Uploads req for an interaction whose response was deferred, editing the response with the result.
+func (b *Bot) UploadDeferred(s *discordgo.Session, i *discordgo.InteractionCreate, req UploadRequest) {
+	b.RunWithWatchdog(s, i.ChannelID, "upload", func(ctx context.Context) {
+		// Without a message of its own, the deferred response becomes the message the memo was uploaded from.
+		if req.MessageLink == "" {
+			if msg, err := s.InteractionResponse(i.Interaction); err == nil {
+				req.MessageLink = fmt.Sprintf("https://discord.com/channels/%s/%s/%s", i.GuildID, i.ChannelID, msg.ID)
+			}
+		}
+
+		edit := &discordgo.WebhookEdit{}
+		result, err := b.Upload(ctx, req)
+		var limitErr *MemoLimitError
+		var takenErr *NameTakenError
+		switch {
+		case errors.As(err, &limitErr):
+			embed, components := b.MemoLimitMessage(i.GuildID, limitErr.Limit)
+			edit.Embeds = &[]*discordgo.MessageEmbed{embed}
+			edit.Components = &components
+		case errors.As(err, &takenErr) && takenErr.Prompt:
+			embed, components := b.StartNameClash(s, &NameClash{ID: i.ID, GuildID: i.GuildID, ChannelID: i.ChannelID, UserID: req.UploaderID, Name: takenErr.Name, Upload: &req})
+			edit.Embeds = &[]*discordgo.MessageEmbed{embed}
+			edit.Components = &components
+		case err != nil:
+			content := "Could not upload: " + err.Error()
+			edit.Content = &content
+		default:
+			content := result.Message()
+			edit.Content = &content
+		}
+
+		if _, err := s.InteractionResponseEdit(i.Interaction, edit); err != nil {
+			fmt.Println("Error editing interaction response: ", err)
+		}
+	})
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How long the buttons !mydata delete asks for confirmation with keep working.
+const userDeleteTTL = 5 * time.Minute
+
+// Everything the bot stores about one user, across every guild. This is what !mydata export sends.
+type UserData struct {
+	UserID     string         `json:"user_id"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Memos      []MemoMetadata `json:"memos"`
+	Plays      []UserPlay     `json:"plays"`
+	Playlists  []Playlist     `json:"playlists"`
+	Grants     []UserGrant    `json:"grants"`
+	Sessions   map[string]int `json:"sessions_heard,omitempty"`
+
+	// Guilds whose !introexempt lists the user, and the cooldowns of theirs that are still running.
+	IntroExempt []string             `json:"intro_exempt,omitempty"`
+	Cooldowns   map[string]time.Time `json:"cooldowns,omitempty"`
+}
+
+// A play from a guild's history.
+type UserPlay struct {
+	GuildID string    `json:"guild_id"`
+	Memo    string    `json:"memo"`
+	At      time.Time `json:"at"`
+}
+
+type UserGrant struct {
+	GuildID string `json:"guild_id"`
+	Grant
+}
+
+// What !mydata delete removed.
+type UserDeletion struct {
+	Deleted   []string
+	Kept      []string
+	Failed    []string
+	Plays     int
+	Playlists int
+	Grants    int
+}
+
+// UserData collects everything stored about a user: the memos they uploaded, their plays, their playlists,
+// their temporary roles and how many voice sessions they were heard in, by guild.
+func (ms *MetadataStore) UserData(userID string) UserData {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	data := UserData{
+		UserID:     userID,
+		ExportedAt: time.Now(),
+		Memos:      make([]MemoMetadata, 0),
+		Plays:      make([]UserPlay, 0),
+		Playlists:  make([]Playlist, 0),
+		Grants:     make([]UserGrant, 0),
+		Sessions:   make(map[string]int),
+		Cooldowns:  make(map[string]time.Time),
+	}
+	for _, md := range ms.Memos {
+		if md.UploaderID == userID {
+			memo := *md
+			// Derived from the audio and meaningless to people.
+			memo.Fingerprint = nil
+			data.Memos = append(data.Memos, memo)
+		}
+	}
+	for guildID, history := range ms.History {
+		for _, play := range history.Plays() {
+			if play.UserID == userID {
+				data.Plays = append(data.Plays, UserPlay{GuildID: guildID, Memo: play.Memo, At: play.At})
+			}
+		}
+	}
+	for _, playlists := range ms.Playlists {
+		for _, pl := range playlists {
+			if pl.OwnerID == userID {
+				data.Playlists = append(data.Playlists, pl.clone())
+			}
+		}
+	}
+	for guildID, gs := range ms.Guilds {
+		if grant, ok := gs.Grants[userID]; ok {
+			data.Grants = append(data.Grants, UserGrant{GuildID: guildID, Grant: grant})
+		}
+		if containsString(gs.IntroExempt.Users, userID) {
+			data.IntroExempt = append(data.IntroExempt, guildID)
+		}
+	}
+	now := time.Now()
+	for key, until := range ms.Cooldowns {
+		if cooldownOwner(key) == userID && now.Before(until) {
+			data.Cooldowns[key] = until
+		}
+	}
+	for guildID, stats := range ms.Stats {
+		if n, ok := stats.Listeners[userID]; ok {
+			data.Sessions[guildID] = n
+		}
+	}
+	return data
+}
+
+// SharedMemos returns which of a user's memos someone else still uses: played by others, in someone
+// else's playlist, in a sound pack, bound to an emoji or set as a greeting.
+func (ms *MetadataStore) SharedMemos(userID string) map[string]bool {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	owned := make(map[string]bool)
+	for name, md := range ms.Memos {
+		if md.UploaderID == userID {
+			owned[name] = true
+		}
+	}
+	shared := make(map[string]bool)
+	use := func(name string) {
+		if owned[name] {
+			shared[name] = true
+		}
+	}
+
+	for _, history := range ms.History {
+		for _, play := range history.Plays() {
+			if play.UserID != userID {
+				use(play.Memo)
+			}
+		}
+	}
+	for _, playlists := range ms.Playlists {
+		for _, pl := range playlists {
+			if pl.OwnerID != userID {
+				for _, name := range pl.Memos {
+					use(name)
+				}
+			}
+		}
+	}
+	for _, pack := range ms.Packs {
+		for _, name := range pack.Memos {
+			use(name)
+		}
+	}
+	for _, gs := range ms.Guilds {
+		for _, binding := range gs.EmojiBindings {
+			for _, pm := range binding.Memos() {
+				use(pm.Memo)
+			}
+		}
+		use(gs.Greeting.JoinMemo)
+		use(gs.Greeting.LeaveMemo)
+	}
+	return shared
+}
+
+// ForgetUser removes a user's plays, playlists, temporary roles, intro exemptions, saved cooldowns and listener
+// counts, and drops their name from the memos in keep so those stay in the library without pointing back at
+// them. Cooldowns still running in memory are left to Cooldowns.Forget.
+func (ms *MetadataStore) ForgetUser(userID string, keep []string) (UserDeletion, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var deletion UserDeletion
+	for _, name := range keep {
+		if md, ok := ms.Memos[name]; ok && md.UploaderID == userID {
+			md.UploaderID = ""
+			md.MessageLink = ""
+		}
+	}
+	for _, history := range ms.History {
+		deletion.Plays += history.Remove(func(play PlayRecord) bool { return play.UserID == userID })
+	}
+	for _, playlists := range ms.Playlists {
+		for name, pl := range playlists {
+			if pl.OwnerID == userID {
+				delete(playlists, name)
+				deletion.Playlists++
+			}
+		}
+	}
+	for _, gs := range ms.Guilds {
+		if _, ok := gs.Grants[userID]; ok {
+			delete(gs.Grants, userID)
+			deletion.Grants++
+		}
+		kept := make([]string, 0, len(gs.IntroExempt.Users))
+		for _, id := range gs.IntroExempt.Users {
+			if id != userID {
+				kept = append(kept, id)
+			}
+		}
+		gs.IntroExempt.Users = kept
+	}
+	for key := range ms.Cooldowns {
+		if cooldownOwner(key) == userID {
+			delete(ms.Cooldowns, key)
+		}
+	}
+	for _, stats := range ms.Stats {
+		delete(stats.Listeners, userID)
+	}
+	return deletion, ms.save()
+}
+
+// Deletes everything stored about a user. Their memos are deleted too, except ones others still use when
+// keepShared is set; those stay in the library with nobody as their uploader.
+func (m *VoiceMemoManager) DeleteUserData(userID string, keepShared bool) (UserDeletion, error) {
+	shared := make(map[string]bool)
+	if keepShared {
+		shared = m.Metadata.SharedMemos(userID)
+	}
+
+	deleted, kept, failed := make([]string, 0), make([]string, 0), make([]string, 0)
+	for _, md := range m.Metadata.UserData(userID).Memos {
+		if shared[md.Name] {
+			kept = append(kept, md.Name)
+			continue
+		}
+		if _, err := m.Delete(md.Name); err != nil {
+			fmt.Println("Error deleting ", md.Name, ": ", err)
+			failed = append(failed, md.Name)
+			continue
+		}
+		deleted = append(deleted, md.Name)
+	}
+
+	// Memos that couldn't be deleted are anonymized like the ones that were kept.
+	deletion, err := m.Metadata.ForgetUser(userID, append(append([]string(nil), kept...), failed...))
+	deletion.Deleted, deletion.Kept, deletion.Failed = deleted, kept, failed
+	return deletion, err
+}
+
+func (b *Bot) HandleMyData(s *discordgo.Session, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := "Usage: !mydata export | !mydata delete [-dry-run]"
+	if len(args) == 0 {
+		data := b.VoiceMemoManager.Metadata.UserData(m.Author.ID)
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("I have %d voice memos you uploaded, %d of your plays and %d of your playlists. %s",
+			len(data.Memos), len(data.Plays), len(data.Playlists), usage))
+		return
+	}
+
+	switch args[0] {
+	case "export":
+		b.ExportMyData(s, c, m.Author.ID)
+	case "delete":
+		if dry, _ := ParseDryRun(args[1:]); dry {
+			b.SendDeleteMyDataDryRun(s, c, m.Author.ID)
+			return
+		}
+		b.ConfirmDeleteMyData(s, c, m.Author.ID)
+	default:
+		s.ChannelMessageSend(c.ID, usage)
+	}
+}
+
+// Sends a user everything stored about them as a JSON file, in a DM since it covers every server.
+func (b *Bot) ExportMyData(s *discordgo.Session, c *discordgo.Channel, userID string) {
+	// Include cooldowns that started since they were last saved.
+	b.Cooldowns.Flush()
+	data, err := json.MarshalIndent(b.VoiceMemoManager.Metadata.UserData(userID), "", "  ")
+	if err != nil {
+		fmt.Println("Error exporting user data: ", err)
+		return
+	}
+
+	dm, err := s.UserChannelCreate(userID)
+	if err == nil {
+		_, err = s.ChannelMessageSendComplex(dm.ID, &discordgo.MessageSend{
+			Content: "Here's everything I store about you. The audio of your memos isn't included, !link gets you that.",
+			Files:   []*discordgo.File{{Name: "mydata.json", ContentType: "application/json", Reader: bytes.NewReader(data)}},
+		})
+	}
+	if err != nil {
+		fmt.Println("Error sending user data: ", err)
+		s.ChannelMessageSend(c.ID, "I couldn't DM you. Allow direct messages from server members and try again.")
+		return
+	}
+	s.ChannelMessageSend(c.ID, "Sent you a DM with your data.")
+}
+
+// Asks a user how to deal with their memos before deleting their data.
+func (b *Bot) ConfirmDeleteMyData(s *discordgo.Session, c *discordgo.Channel, userID string) {
+	data := b.VoiceMemoManager.Metadata.UserData(userID)
+	shared := b.VoiceMemoManager.Metadata.SharedMemos(userID)
+
+	description := fmt.Sprintf("This deletes %d of your plays, %d playlists and any temporary roles, everywhere I am.", len(data.Plays), len(data.Playlists))
+	buttons := []discordgo.MessageComponent{
+		discordgo.Button{Label: fmt.Sprintf("Delete everything, %d memos too", len(data.Memos)), Style: discordgo.DangerButton, CustomID: "mydata:" + b.SignCustomID("all:"+userID, userDeleteTTL)},
+	}
+	if len(shared) > 0 {
+		names := make([]string, 0, len(shared))
+		for _, md := range data.Memos {
+			if shared[md.Name] {
+				names = append(names, md.Name)
+			}
+		}
+		description += fmt.Sprintf("\n\nOthers still use %d of your memos: %s. You can leave them to the server instead of deleting them, they just won't be linked to you anymore.",
+			len(names), strings.Join(names, ", "))
+		buttons = append(buttons, discordgo.Button{Label: "Delete, but leave memos others use", Style: discordgo.DangerButton, CustomID: "mydata:" + b.SignCustomID("keep:"+userID, userDeleteTTL)})
+	}
+	buttons = append(buttons, discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: "mydata:cancel:" + userID})
+	if len(description) > 4096 {
+		description = description[:4000] + "..."
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Delete your data?",
+		Description: description,
+		Color:       16711680,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "This can't be undone. !mydata export first to keep a copy."},
+	}
+	msg := &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}},
+	}
+	if _, err := s.ChannelMessageSendComplex(c.ID, msg); err != nil {
+		fmt.Println(err)
+		return
+	}
+}
+
+// Shows what !mydata delete would delete, counting the memos others use as deleted too.
+func (b *Bot) SendDeleteMyDataDryRun(s *discordgo.Session, c *discordgo.Channel, userID string) {
+	data := b.VoiceMemoManager.Metadata.UserData(userID)
+	names := make([]string, 0, len(data.Memos))
+	for _, md := range data.Memos {
+		names = append(names, md.Name)
+	}
+	fields := append(b.VoiceMemoManager.PlanDeletion(names).Fields(),
+		&discordgo.MessageEmbedField{Name: "Plays", Value: fmt.Sprint(len(data.Plays)), Inline: true},
+		&discordgo.MessageEmbedField{Name: "Your playlists", Value: fmt.Sprint(len(data.Playlists)), Inline: true},
+		&discordgo.MessageEmbedField{Name: "Temporary roles", Value: fmt.Sprint(len(data.Grants)), Inline: true},
+	)
+	if shared := b.VoiceMemoManager.Metadata.SharedMemos(userID); len(shared) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Used by others", Value: fmt.Sprintf("%d of the memos, which you could leave to the server instead", len(shared))})
+	}
+	SendDryRun(s, c.ID, "!mydata delete would delete your data", fields)
+}
+
+// Handles the buttons of a !mydata delete message. arg is "<all|keep|cancel>:<user id>", signed for all and
+// keep so they stop working after userDeleteTTL.
+func (b *Bot) HandleMyDataButton(s *discordgo.Session, i *discordgo.InteractionCreate, arg string) {
+	arg, ok := b.VerifyCustomID(arg)
+	choice, userID, _ := strings.Cut(arg, ":")
+	if InteractionUserID(i) != userID {
+		RespondEphemeral(s, i, "Only the person whose data this is can decide.")
+		return
+	}
+	if choice == "cancel" {
+		b.replaceComponentMessage(s, i, "Cancelled, nothing was deleted.")
+		return
+	}
+	if !ok || (choice != "all" && choice != "keep") {
+		b.replaceComponentMessage(s, i, "This has expired, nothing was deleted. Run !mydata delete again.")
+		return
+	}
+	// The button may be from before the bot was restarted with -dry-run.
+	if dryRun {
+		b.replaceComponentMessage(s, i, "The bot is running with -dry-run, so nothing was deleted. !mydata delete shows what would be.")
+		return
+	}
+
+	// Deleting can take longer than Discord waits for a response, so answer first.
+	b.replaceComponentMessage(s, i, "Deleting your data...")
+	b.Cooldowns.Forget(userID)
+	deletion, err := b.VoiceMemoManager.DeleteUserData(userID, choice == "keep")
+	if err != nil {
+		fmt.Println("Error deleting user data: ", err)
+	}
+
+	report := fmt.Sprintf("Deleted %d voice memos, %d plays, %d playlists and %d temporary roles.",
+		len(deletion.Deleted), deletion.Plays, deletion.Playlists, deletion.Grants)
+	if len(deletion.Kept) > 0 {
+		report += " Left to the server: " + strings.Join(deletion.Kept, ", ")
+	}
+	if len(deletion.Failed) > 0 {
+		report += " Could not delete, so they're no longer linked to you: " + strings.Join(deletion.Failed, ", ")
+	}
+	if err != nil {
+		report += " Saving that failed though, so some of it may come back when the bot restarts."
+	}
+	if len(report) > 2000 {
+		report = report[:1990] + "..."
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &report}); err != nil {
+		fmt.Println("Error editing interaction response: ", err)
+	}
+}
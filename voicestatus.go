@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// VoiceStatus shows what's playing on a voice channel. It uses the voice channel status API,
+// falling back to the channel topic if Discord won't let us set a status.
+type VoiceStatus struct {
+	session   *discordgo.Session
+	channelID string
+
+	// Updates run in the background so they never hold up audio. Only the latest one is applied.
+	mu       sync.Mutex
+	latest   atomic.Int64
+	useTopic bool
+}
+
+func NewVoiceStatus(s *discordgo.Session, channelID string) *VoiceStatus {
+	return &VoiceStatus{session: s, channelID: channelID}
+}
+
+// Sets the channel's status. An empty status clears it.
+func (vs *VoiceStatus) Set(status string) {
+	seq := vs.latest.Add(1)
+	go func() {
+		vs.mu.Lock()
+		defer vs.mu.Unlock()
+
+		// A newer status came in while we were waiting, don't bother with this one.
+		if vs.latest.Load() != seq {
+			return
+		}
+
+		if !vs.useTopic {
+			err := vs.setStatus(status)
+			if err == nil {
+				return
+			}
+			fmt.Println("Error setting voice channel status, falling back to the topic: ", err)
+			vs.useTopic = true
+		}
+
+		if err := vs.setTopic(status); err != nil {
+			fmt.Println("Error setting voice channel topic: ", err)
+		}
+	}()
+}
+
+func (vs *VoiceStatus) setStatus(status string) error {
+	endpoint := discordgo.EndpointChannel(vs.channelID) + "/voice-status"
+	_, err := vs.session.RequestWithBucketID("PUT", endpoint, map[string]string{"status": status}, endpoint)
+	return err
+}
+
+func (vs *VoiceStatus) setTopic(topic string) error {
+	// discordgo's ChannelEdit drops empty topics, so clear it with a raw request.
+	endpoint := discordgo.EndpointChannel(vs.channelID)
+	_, err := vs.session.RequestWithBucketID("PATCH", endpoint, map[string]string{"topic": topic}, endpoint)
+	return err
+}
+
+// Moves the status to another voice channel, clearing it on the one it was on.
+func (vs *VoiceStatus) Move(channelID string) {
+	// Updates still waiting were meant for the old channel.
+	vs.latest.Add(1)
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	var err error
+	if vs.useTopic {
+		err = vs.setTopic("")
+	} else {
+		err = vs.setStatus("")
+	}
+	if err != nil {
+		fmt.Println("Error clearing voice channel status: ", err)
+	}
+	vs.channelID = channelID
+}
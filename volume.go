@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// Reactions on the now playing message that turn the guild's !volume down and up while a memo plays.
+	volumeDownEmoji = "🔉"
+	volumeUpEmoji   = "🔊"
+
+	// How far one reaction turns the volume, in percent.
+	volumeStep = 10
+
+	// How often reactions may change the volume, so a row of them doesn't re-encode the memo for each one.
+	volumeReactionCooldown = 2 * time.Second
+)
+
+// Range the volume reactions may turn !volume within, set with !volume reactions.
+type VolumeBounds struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+var defaultVolumeBounds = VolumeBounds{Min: 50, Max: 150}
+
+// Reports whether !volume reactions turned the reactions off.
+func (vb VolumeBounds) Off() bool {
+	return vb.Max == 0
+}
+
+// Returns the range the volume reactions may turn !volume within.
+func (gs GuildSettings) ReactionVolume() VolumeBounds {
+	if gs.VolumeReactions == nil {
+		return defaultVolumeBounds
+	}
+	return *gs.VolumeReactions
+}
+
+// Has the player prepare the memo it's on again, e.g. because !volume changed, and carry on with the result
+// from the frame it's on. Memos queued after it are prepared when they come up anyway.
+func (gs *GuildSession) Relevel() {
+	gs.relevels.Add(1)
+}
+
+// Swaps what the player is sending for next, once the memo has been prepared again. Returns false while speech
+// is waiting to be mixed over what's playing now, which holds the swap back like it holds back !seek.
+func (gs *GuildSession) replacePlaying(next *VoiceMemo) bool {
+	gs.duckMu.Lock()
+	defer gs.duckMu.Unlock()
+
+	if gs.duck != nil || gs.playing == nil {
+		return false
+	}
+	gs.playing = next
+	return true
+}
+
+// Records the now playing message the volume reactions count on, or "" once it no longer shows what's playing.
+func (gs *GuildSession) setNowPlaying(messageID string) {
+	gs.nowPlayingMu.Lock()
+	defer gs.nowPlayingMu.Unlock()
+	gs.nowPlaying = messageID
+}
+
+func (gs *GuildSession) nowPlayingMessage() string {
+	gs.nowPlayingMu.Lock()
+	defer gs.nowPlayingMu.Unlock()
+	return gs.nowPlaying
+}
+
+// Adds the volume reactions to a now playing message, so people only have to click them.
+func (b *Bot) AddVolumeReactions(s *discordgo.Session, guildID string, msg *discordgo.Message) {
+	if b.VoiceMemoManager.Metadata.Guild(guildID).ReactionVolume().Off() {
+		return
+	}
+	for _, emoji := range []string{volumeDownEmoji, volumeUpEmoji} {
+		if err := s.MessageReactionAdd(msg.ChannelID, msg.ID, emoji); err != nil {
+			fmt.Println("Error adding volume reactions: ", err)
+			return
+		}
+	}
+}
+
+// Turns the guild's !volume down or up a step when someone reacts to the now playing message with 🔉 or 🔊,
+// and applies it to the memo that's playing. Returns false if the reaction isn't one of those.
+func (b *Bot) NudgeVolume(s *discordgo.Session, r *discordgo.MessageReactionAdd) bool {
+	step := 0
+	switch r.Emoji.Name {
+	case volumeDownEmoji:
+		step = -volumeStep
+	case volumeUpEmoji:
+		step = volumeStep
+	default:
+		return false
+	}
+	gs, ok := b.Session(r.GuildID)
+	if !ok || r.MessageID != gs.nowPlayingMessage() {
+		return false
+	}
+
+	// Take the reaction back off, so the same person can click it again. That needs Manage Messages.
+	s.MessageReactionRemove(r.ChannelID, r.MessageID, r.Emoji.APIName(), r.UserID)
+
+	settings := b.VoiceMemoManager.Metadata.Guild(r.GuildID)
+	bounds := settings.ReactionVolume()
+	if current, _ := gs.Current(); current == nil || bounds.Off() {
+		return true
+	}
+
+	// Volumes set outside the bounds with !volume can only be nudged back toward them.
+	percent := settings.VolumePercent()
+	next := percent + step
+	if step > 0 && next > bounds.Max {
+		next = bounds.Max
+	}
+	if step < 0 && next < bounds.Min {
+		next = bounds.Min
+	}
+	if (step > 0 && next <= percent) || (step < 0 && next >= percent) {
+		return true
+	}
+	if !b.Cooldowns.Try("volume:"+r.GuildID, volumeReactionCooldown) {
+		return true
+	}
+
+	err := b.VoiceMemoManager.Metadata.UpdateGuild(r.GuildID, func(gs *GuildSettings) {
+		gs.Volume = &next
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return true
+	}
+	gs.Relevel()
+	return true
+}
+
+// Reads bounds like 50-150 for !volume reactions.
+func ParseVolumeBounds(arg string) (VolumeBounds, bool) {
+	from, to, ok := strings.Cut(strings.ReplaceAll(arg, "%", ""), "-")
+	if !ok {
+		return VolumeBounds{}, false
+	}
+	min, err := strconv.Atoi(from)
+	if err != nil {
+		return VolumeBounds{}, false
+	}
+	max, err := strconv.Atoi(to)
+	if err != nil || min < 0 || max < min || max == 0 || max > maxVolume {
+		return VolumeBounds{}, false
+	}
+	return VolumeBounds{Min: min, Max: max}, true
+}
+
+func (b *Bot) HandleVolumeReactions(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	if len(args) == 0 {
+		s.ChannelMessageSend(c.ID, describeVolumeReactions(b.VoiceMemoManager.Metadata.Guild(g.ID).ReactionVolume()))
+		return
+	}
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can change how far the volume reactions go.")
+		return
+	}
+
+	var bounds VolumeBounds
+	if args[0] != "off" {
+		var ok bool
+		if bounds, ok = ParseVolumeBounds(args[0]); !ok {
+			s.ChannelMessageSend(c.ID, fmt.Sprintf("Usage: !volume reactions <min>-<max>|off, from 0 to %d, e.g. !volume reactions 50-150", maxVolume))
+			return
+		}
+	}
+
+	err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		gs.VolumeReactions = &bounds
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+	s.ChannelMessageSend(c.ID, describeVolumeReactions(bounds))
+	if bounds.Off() {
+		b.Audit(s, g.ID, fmt.Sprintf("<@%s> turned the volume reactions off.", m.Author.ID))
+		return
+	}
+	b.Audit(s, g.ID, fmt.Sprintf("<@%s> let the volume reactions go from %d%% to %d%%.", m.Author.ID, bounds.Min, bounds.Max))
+}
+
+func describeVolumeReactions(bounds VolumeBounds) string {
+	if bounds.Off() {
+		return "Reacting to the now playing message doesn't change the volume."
+	}
+	return fmt.Sprintf("Reacting to the now playing message with %s or %s turns the volume down or up by %d%%, between %d%% and %d%%.",
+		volumeDownEmoji, volumeUpEmoji, volumeStep, bounds.Min, bounds.Max)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Most words and phrases !warnwords keeps per guild.
+const maxWarnWords = 100
+
+// Transcribes a new memo with the speech-to-text provider when -transcribe-memos is set, so the guild's
+// !warnwords can flag it. Long-form memos are left out, they'd take ages and are never picked automatically.
+func (b *Bot) TranscribeMemo(guildID, userID string, vm *VoiceMemo) {
+	if !transcribeMemos || b.STT == nil || vm.streamed {
+		return
+	}
+
+	// Show up in !jobs, like the transcription of a voice command does.
+	ctx, _, done := b.Jobs.Start(context.Background(), "transcription", guildID, userID, "Transcribing "+vm.name)
+	defer done()
+
+	transcript, err := transcribeMemo(ctx, b.STT, vm)
+	if err != nil {
+		if ctx.Err() == nil {
+			fmt.Println("Error transcribing ", vm.name, ": ", err)
+		}
+		return
+	}
+	err = b.VoiceMemoManager.Metadata.UpdateMemo(vm.name, func(md *MemoMetadata) {
+		// The memo may have been replaced while it was being transcribed.
+		if md.Hash == vm.hash {
+			md.Transcript = transcript
+		}
+	})
+	if err != nil {
+		fmt.Println("Error saving metadata for ", vm.name, ": ", err)
+	}
+}
+
+func transcribeMemo(ctx context.Context, stt STTProvider, vm *VoiceMemo) (string, error) {
+	dir, err := os.MkdirTemp("", "voicememo-transcript-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	audioPath := filepath.Join(dir, "memo.ogg")
+	f, err := os.Create(audioPath)
+	if err != nil {
+		return "", err
+	}
+	err = vm.WriteOgg(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	return stt.Transcribe(ctx, audioPath)
+}
+
+// Splits text into lower case words, keeping apostrophes so "don't" and "don’t" are the same one word.
+func transcriptWords(text string) []string {
+	text = strings.ReplaceAll(strings.ToLower(text), "’", "'")
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// Returns the words and phrases of list that are said in transcript, in the order of list. They have to be
+// said whole, so "ass" doesn't flag "class".
+func MatchWarnWords(transcript string, list []string) []string {
+	spoken := " " + strings.Join(transcriptWords(transcript), " ") + " "
+	matched := make([]string, 0)
+	for _, entry := range list {
+		words := transcriptWords(entry)
+		if len(words) > 0 && strings.Contains(spoken, " "+strings.Join(words, " ")+" ") {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+// Returns the guild's !warnwords that are said in a memo, going by its transcript. Memos that haven't been
+// transcribed have none.
+func (b *Bot) ContentWarnings(guildID, name string) []string {
+	list := b.VoiceMemoManager.Metadata.Guild(guildID).WarnWords
+	if len(list) == 0 {
+		return nil
+	}
+	return MatchWarnWords(b.VoiceMemoManager.Metadata.Memo(name).Transcript, list)
+}
+
+// Reports whether !warnwords block keeps a memo out of !random and emoji bindings, because it has a content
+// warning. It can still be played by name.
+func (b *Bot) WarnBlocked(guildID, name string) bool {
+	return b.VoiceMemoManager.Metadata.Guild(guildID).BlockWarned && len(b.ContentWarnings(guildID, name)) > 0
+}
+
+// Formats words for a message, hidden behind spoilers so the message doesn't say them out loud itself.
+func spoilerList(words []string) string {
+	hidden := make([]string, 0, len(words))
+	for _, w := range words {
+		hidden = append(hidden, "||"+w+"||")
+	}
+	return strings.Join(hidden, ", ")
+}
+
+// Reads the comma separated words and phrases of !warnwords add and remove, e.g. "damn, shut up".
+func parseWarnWords(args []string) []string {
+	words := make([]string, 0)
+	for _, part := range strings.Split(strings.Join(args, " "), ",") {
+		if w := strings.Join(transcriptWords(part), " "); w != "" {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+func (b *Bot) HandleWarnWords(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := "Usage: !warnwords [list] | add|remove <word or phrase>, ... | block on|off"
+	settings := b.VoiceMemoManager.Metadata.Guild(g.ID)
+	if len(args) == 0 || args[0] == "list" {
+		if len(settings.WarnWords) == 0 {
+			s.ChannelMessageSend(c.ID, "No voice memos get content warnings in "+g.Name+". "+usage)
+			return
+		}
+		reply := fmt.Sprintf("Voice memos that say %s get a content warning", spoilerList(settings.WarnWords))
+		if settings.BlockWarned {
+			reply += ", and !random and emoji bindings pass them over"
+		}
+		if !transcribeMemos {
+			reply += ". New memos aren't being transcribed, so only ones transcribed before are checked"
+		}
+		s.ChannelMessageSend(c.ID, reply+".")
+		return
+	}
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can change which words get a content warning.")
+		return
+	}
+
+	switch {
+	case args[0] == "block" && len(args) == 2:
+		block, err := parseOnOff(args[1])
+		if err != nil {
+			s.ChannelMessageSend(c.ID, usage)
+			return
+		}
+		err = b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+			gs.BlockWarned = block
+		})
+		if err != nil {
+			fmt.Println("Error saving guild settings: ", err)
+			return
+		}
+		if block {
+			s.ChannelMessageSend(c.ID, "!random and emoji bindings won't pick voice memos with a content warning. They can still be played by name.")
+			b.Audit(s, g.ID, fmt.Sprintf("<@%s> kept voice memos with a content warning out of !random and emoji bindings.", m.Author.ID))
+			return
+		}
+		s.ChannelMessageSend(c.ID, "!random and emoji bindings can pick voice memos with a content warning again.")
+		b.Audit(s, g.ID, fmt.Sprintf("<@%s> let !random and emoji bindings pick voice memos with a content warning.", m.Author.ID))
+
+	case (args[0] == "add" || args[0] == "remove") && len(args) > 1:
+		words := parseWarnWords(args[1:])
+		if len(words) == 0 {
+			s.ChannelMessageSend(c.ID, usage)
+			return
+		}
+		add := args[0] == "add"
+		if add && len(settings.WarnWords)+len(words) > maxWarnWords {
+			s.ChannelMessageSend(c.ID, fmt.Sprintf("A server can have up to %d words and phrases.", maxWarnWords))
+			return
+		}
+		changed := 0
+		err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+			if add {
+				for _, w := range words {
+					if !containsString(gs.WarnWords, w) {
+						gs.WarnWords = append(gs.WarnWords, w)
+						changed++
+					}
+				}
+				return
+			}
+			kept := make([]string, 0, len(gs.WarnWords))
+			for _, w := range gs.WarnWords {
+				if !containsString(words, w) {
+					kept = append(kept, w)
+				}
+			}
+			changed = len(gs.WarnWords) - len(kept)
+			gs.WarnWords = kept
+		})
+		if err != nil {
+			fmt.Println("Error saving guild settings: ", err)
+			return
+		}
+
+		flagged := 0
+		for _, vm := range b.VoiceMemoManager.GuildLibrary(g.ID) {
+			if len(b.ContentWarnings(g.ID, vm.name)) > 0 {
+				flagged++
+			}
+		}
+		verb := "Added"
+		if !add {
+			verb = "Removed"
+		}
+		s.ChannelMessageSend(c.ID, fmt.Sprintf("%s %d words and phrases. %d voice memos in %s have a content warning now.", verb, changed, flagged, g.Name))
+		b.Audit(s, g.ID, fmt.Sprintf("<@%s> %s %d content warning words and phrases.", m.Author.ID, strings.ToLower(verb), changed))
+
+	default:
+		s.ChannelMessageSend(c.ID, usage)
+	}
+}
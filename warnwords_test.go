@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestMatchWarnWords(t *testing.T) {
+	tests := []struct {
+		name       string
+		transcript string
+		list       []string
+		want       []string
+	}{
+		{"word", "Well, damn.", []string{"damn"}, []string{"damn"}},
+		{"case", "DAMN it", []string{"Damn"}, []string{"Damn"}},
+		{"whole words only", "a class act", []string{"ass"}, []string{}},
+		{"phrase", "oh just shut up already", []string{"shut up"}, []string{"shut up"}},
+		{"phrase across punctuation", "shut... up!", []string{"shut up"}, []string{"shut up"}},
+		{"phrase out of order", "up, shut it", []string{"shut up"}, []string{}},
+		{"curly apostrophe", "don’t do that", []string{"don't"}, []string{"don't"}},
+		{"start and end", "heck this heck", []string{"heck"}, []string{"heck"}},
+		{"in list order", "crap and damn", []string{"damn", "heck", "crap"}, []string{"damn", "crap"}},
+		{"empty entry", "anything", []string{"", "!!"}, []string{}},
+		{"no transcript", "", []string{"damn"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchWarnWords(tt.transcript, tt.list); !equalStrings(got, tt.want) {
+				t.Errorf("MatchWarnWords(%q, %q) = %q, want %q", tt.transcript, tt.list, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Runs a command handler with a deadline. Handlers that respect ctx have their downloads and child
+// processes (ffmpeg, dca, whisper, ...) killed when it expires. If the handler still hasn't returned
+// by then, every goroutine's stack is dumped so the wedged operation can be found, and the channel
+// is told the command was abandoned.
+func (b *Bot) RunWithWatchdog(s *discordgo.Session, channelID, command string, handler func(ctx context.Context)) {
+	ctx, cancel := context.WithTimeout(context.Background(), cmdTimeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			select {
+			case <-done:
+				// Finished right as the deadline passed.
+				return
+			default:
+			}
+
+			fmt.Printf("WARNING: !%s has been running for over %s, cancelling it\n", command, cmdTimeout)
+			fmt.Println(string(StackDump()))
+			s.ChannelMessageSend(channelID, fmt.Sprintf("!%s took too long and was cancelled.", command))
+
+			// Cancelling ctx killed anything it controls. If the handler is stuck on something that
+			// doesn't listen to ctx, keep nagging so it shows up in the logs.
+			ticker := time.NewTicker(cmdTimeout)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					fmt.Printf("!%s finished after being cancelled\n", command)
+					return
+				case <-ticker.C:
+					fmt.Printf("WARNING: !%s is still stuck after being cancelled\n", command)
+				}
+			}
+		}
+	}()
+
+	handler(ctx)
+	close(done)
+}
+
+// Returns the stacks of every running goroutine.
+func StackDump() []byte {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
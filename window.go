@@ -0,0 +1,361 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// When a memo may be played, set with !window. Each part that's left out doesn't narrow it down, so a window
+// of only December is open all day every day that month.
+type Window struct {
+	Months   []time.Month   `json:"months,omitempty"`
+	Weekdays []time.Weekday `json:"weekdays,omitempty"`
+
+	// Minutes past midnight the window opens and closes at. Equal ones leave it open all day, and an evening
+	// closing after midnight, like 22:00-02:00, counts as part of the day it opened on.
+	From int `json:"from,omitempty"`
+	To   int `json:"to,omitempty"`
+
+	// Time zone the window is in, like Europe/Berlin. Empty is UTC.
+	Zone string `json:"zone,omitempty"`
+}
+
+func (w Window) location() *time.Location {
+	if loc, err := time.LoadLocation(w.Zone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// Reports whether the window is open at t.
+func (w Window) Open(t time.Time) bool {
+	t = t.In(w.location())
+	if w.From != w.To {
+		minute := t.Hour()*60 + t.Minute()
+		if w.From < w.To && (minute < w.From || minute >= w.To) {
+			return false
+		}
+		if w.From > w.To {
+			if minute >= w.To && minute < w.From {
+				return false
+			}
+			if minute < w.To {
+				t = t.AddDate(0, 0, -1)
+			}
+		}
+	}
+	if len(w.Months) > 0 && !containsMonth(w.Months, t.Month()) {
+		return false
+	}
+	if len(w.Weekdays) > 0 && !containsWeekday(w.Weekdays, t.Weekday()) {
+		return false
+	}
+	return true
+}
+
+func containsMonth(months []time.Month, month time.Month) bool {
+	for _, m := range months {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+func containsWeekday(weekdays []time.Weekday, weekday time.Weekday) bool {
+	for _, d := range weekdays {
+		if d == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// Describes the window for a sentence, e.g. "in December, on Friday and Saturday, from 19:00 to 23:00 (UTC)".
+func (w Window) String() string {
+	parts := make([]string, 0, 3)
+	if len(w.Months) > 0 {
+		names := make([]string, 0, len(w.Months))
+		for _, m := range w.Months {
+			names = append(names, m.String())
+		}
+		parts = append(parts, "in "+joinAnd(names))
+	}
+	if len(w.Weekdays) > 0 {
+		names := make([]string, 0, len(w.Weekdays))
+		for _, d := range w.Weekdays {
+			names = append(names, d.String())
+		}
+		parts = append(parts, "on "+joinAnd(names))
+	}
+	if w.From != w.To {
+		parts = append(parts, fmt.Sprintf("from %02d:%02d to %02d:%02d", w.From/60, w.From%60, w.To/60, w.To%60))
+	}
+	if len(parts) == 0 {
+		return "at any time"
+	}
+	zone := w.Zone
+	if zone == "" {
+		zone = "UTC"
+	}
+	return strings.Join(parts, ", ") + " (" + zone + ")"
+}
+
+func joinAnd(names []string) string {
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// Reads a window from the arguments of !window, in any order: months like dec or nov-jan, weekdays like
+// fri-sat or mon,wed, a time of day like 19:00-23:00 and a time zone like Europe/Berlin.
+func ParseWindow(args []string) (Window, error) {
+	var w Window
+	for _, arg := range args {
+		lower := strings.ToLower(arg)
+		if from, to, ok := parseTimeRange(lower); ok {
+			w.From, w.To = from, to
+			continue
+		}
+		if months, ok := parseCycle(lower, 12, monthIndex); ok {
+			for _, m := range months {
+				w.Months = append(w.Months, time.Month(m+1))
+			}
+			continue
+		}
+		if weekdays, ok := parseCycle(lower, 7, weekdayIndex); ok {
+			for _, d := range weekdays {
+				w.Weekdays = append(w.Weekdays, time.Weekday(d))
+			}
+			continue
+		}
+		if strings.Contains(arg, "/") || arg == "UTC" {
+			if _, err := time.LoadLocation(arg); err == nil {
+				w.Zone = arg
+				continue
+			}
+		}
+		return Window{}, fmt.Errorf("I don't understand %s", arg)
+	}
+	if len(w.Months) == 0 && len(w.Weekdays) == 0 && w.From == w.To {
+		return Window{}, errors.New("a window needs months, weekdays or a time of day")
+	}
+	w.Months = uniqueMonths(w.Months)
+	w.Weekdays = uniqueWeekdays(w.Weekdays)
+	return w, nil
+}
+
+// Reads a time of day like 19:00-23:00 into minutes past midnight.
+func parseTimeRange(arg string) (int, int, bool) {
+	from, to, ok := strings.Cut(arg, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	start, ok := parseClock(from)
+	if !ok {
+		return 0, 0, false
+	}
+	end, ok := parseClock(to)
+	return start, end, ok
+}
+
+func parseClock(s string) (int, bool) {
+	hours, minutes, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, false
+	}
+	h, err := strconv.Atoi(hours)
+	if err != nil || h < 0 || h > 24 {
+		return 0, false
+	}
+	m, err := strconv.Atoi(minutes)
+	if err != nil || m < 0 || m > 59 || len(minutes) != 2 || h*60+m > 24*60 {
+		return 0, false
+	}
+	return (h*60 + m) % (24 * 60), true
+}
+
+func monthIndex(name string) (int, bool) {
+	for m := time.January; m <= time.December; m++ {
+		full := strings.ToLower(m.String())
+		if name == full || (len(name) >= 3 && strings.HasPrefix(full, name)) {
+			return int(m) - 1, true
+		}
+	}
+	return 0, false
+}
+
+func weekdayIndex(name string) (int, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if name == full || (len(name) >= 3 && strings.HasPrefix(full, name)) {
+			return int(d), true
+		}
+	}
+	return 0, false
+}
+
+// Reads comma separated names and ranges of names from a cycle of n, like months or weekdays, into their
+// indexes. Ranges can wrap around, like nov-jan.
+func parseCycle(arg string, n int, index func(string) (int, bool)) ([]int, bool) {
+	indexes := make([]int, 0)
+	for _, item := range strings.Split(arg, ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		start, ok := index(from)
+		if !ok {
+			return nil, false
+		}
+		end := start
+		if isRange {
+			if end, ok = index(to); !ok {
+				return nil, false
+			}
+		}
+		for i := start; ; i = (i + 1) % n {
+			indexes = append(indexes, i)
+			if i == end {
+				break
+			}
+		}
+	}
+	return indexes, true
+}
+
+// Drops repeated months, keeping them in the order they were given so ranges like nov-jan read as written.
+func uniqueMonths(months []time.Month) []time.Month {
+	unique := make([]time.Month, 0, len(months))
+	for _, m := range months {
+		if !containsMonth(unique, m) {
+			unique = append(unique, m)
+		}
+	}
+	return unique
+}
+
+func uniqueWeekdays(weekdays []time.Weekday) []time.Weekday {
+	unique := make([]time.Weekday, 0, len(weekdays))
+	for _, d := range weekdays {
+		if !containsWeekday(unique, d) {
+			unique = append(unique, d)
+		}
+	}
+	return unique
+}
+
+// Returns why a memo can't be played in the guild right now because of its !window, or "" if it can.
+func (b *Bot) OutsideWindow(guildID, name string) string {
+	w, ok := b.VoiceMemoManager.Metadata.Guild(guildID).Windows[name]
+	if !ok || w.Open(time.Now()) {
+		return ""
+	}
+	return fmt.Sprintf("%s can only be played %s.", name, w)
+}
+
+func (b *Bot) HandleWindow(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel, m *discordgo.MessageCreate, args []string) {
+	usage := "Usage: !window <name> <months> <weekdays> <hh:mm-hh:mm> <time zone> | !window <name> off | !window list"
+	if len(args) == 0 || args[0] == "list" {
+		b.SendWindows(s, g, c)
+		return
+	}
+
+	if !IsAdmin(s, m.Author.ID, c.ID) {
+		s.ChannelMessageSend(c.ID, "Only admins can change when voice memos can be played.")
+		return
+	}
+	if len(args) < 2 {
+		s.ChannelMessageSend(c.ID, usage)
+		return
+	}
+
+	vm := b.VoiceMemoManager.GuildMemo(g.ID, args[0])
+	if vm == nil {
+		s.ChannelMessageSend(c.ID, "Cannot find "+args[0])
+		return
+	}
+	name := vm.name
+
+	off := args[1] == "off"
+	var w Window
+	if !off {
+		var err error
+		if w, err = ParseWindow(args[1:]); err != nil {
+			s.ChannelMessageSend(c.ID, err.Error()+". e.g. !window "+name+" dec, or !window "+name+" fri-sat 19:00-23:00 Europe/Berlin")
+			return
+		}
+	}
+
+	err := b.VoiceMemoManager.Metadata.UpdateGuild(g.ID, func(gs *GuildSettings) {
+		if off {
+			delete(gs.Windows, name)
+			return
+		}
+		if gs.Windows == nil {
+			gs.Windows = make(map[string]Window)
+		}
+		gs.Windows[name] = w
+	})
+	if err != nil {
+		fmt.Println("Error saving guild settings: ", err)
+		return
+	}
+
+	if off {
+		s.ChannelMessageSend(c.ID, name+" can be played at any time again.")
+		b.Audit(s, g.ID, fmt.Sprintf("<@%s> let %s be played at any time.", m.Author.ID, name))
+		return
+	}
+	reply := fmt.Sprintf("%s can only be played %s now.", name, w)
+	if !w.Open(time.Now()) {
+		reply += " That's not right now."
+	}
+	s.ChannelMessageSend(c.ID, reply)
+	b.Audit(s, g.ID, fmt.Sprintf("<@%s> made %s playable only %s.", m.Author.ID, name, w))
+}
+
+func (b *Bot) SendWindows(s *discordgo.Session, g *discordgo.Guild, c *discordgo.Channel) {
+	windows := b.VoiceMemoManager.Metadata.Guild(g.ID).Windows
+	if len(windows) == 0 {
+		s.ChannelMessageSend(c.ID, "Every voice memo in "+g.Name+" can be played at any time.")
+		return
+	}
+
+	names := make([]string, 0, len(windows))
+	for name := range windows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	embed := &discordgo.MessageEmbed{
+		Title:  "Voice memos with a window",
+		Color:  65535,
+		Fields: []*discordgo.MessageEmbedField{},
+	}
+	now := time.Now()
+	for _, name := range names {
+		// Embeds can hold at most 25 fields.
+		if len(embed.Fields) == 25 {
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("and %d more", len(names)-25)}
+			break
+		}
+		value := "Playable " + windows[name].String()
+		if windows[name].Open(now) {
+			value += " (open now)"
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   name,
+			Value:  value,
+			Inline: true,
+		})
+	}
+
+	if _, err := s.ChannelMessageSendEmbed(c.ID, embed); err != nil {
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    Window
+		wantErr bool
+	}{
+		{"month", []string{"dec"}, Window{Months: []time.Month{time.December}}, false},
+		{"month range wraps", []string{"nov-jan"}, Window{Months: []time.Month{time.November, time.December, time.January}}, false},
+		{"weekday range", []string{"fri-sat"}, Window{Weekdays: []time.Weekday{time.Friday, time.Saturday}}, false},
+		{"weekday list", []string{"mon,wed"}, Window{Weekdays: []time.Weekday{time.Monday, time.Wednesday}}, false},
+		{"weekday range wraps", []string{"sat-mon"}, Window{Weekdays: []time.Weekday{time.Saturday, time.Sunday, time.Monday}}, false},
+		{"time of day", []string{"19:00-23:00"}, Window{From: 19 * 60, To: 23 * 60}, false},
+		{"until midnight", []string{"22:00-24:00"}, Window{From: 22 * 60, To: 0}, false},
+		{
+			"everything in any order",
+			[]string{"Europe/Berlin", "22:00-02:00", "FRIDAY", "December"},
+			Window{Months: []time.Month{time.December}, Weekdays: []time.Weekday{time.Friday}, From: 22 * 60, To: 2 * 60, Zone: "Europe/Berlin"},
+			false,
+		},
+		{"repeats", []string{"dec", "nov-dec,december"}, Window{Months: []time.Month{time.December, time.November}}, false},
+		{"nothing", nil, Window{}, true},
+		{"only a zone", []string{"UTC"}, Window{}, true},
+		{"empty time of day", []string{"19:00-19:00"}, Window{}, true},
+		{"not a window", []string{"bruh"}, Window{}, true},
+		{"too short", []string{"de"}, Window{}, true},
+		{"past midnight", []string{"23:00-25:00"}, Window{}, true},
+		{"unknown zone", []string{"dec", "Mars/Olympus_Mons"}, Window{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWindow(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWindow(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(withoutEmpty(got), tt.want) {
+				t.Errorf("ParseWindow(%q) = %#v, want %#v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+// Returns the window with empty months and weekdays left nil, so it compares equal to one written out.
+func withoutEmpty(w Window) Window {
+	if len(w.Months) == 0 {
+		w.Months = nil
+	}
+	if len(w.Weekdays) == 0 {
+		w.Weekdays = nil
+	}
+	return w
+}
+
+func TestWindowOpen(t *testing.T) {
+	at := func(value string) time.Time {
+		t, err := time.Parse("2006-01-02 15:04", value)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+	december := []time.Month{time.December}
+	friday := []time.Weekday{time.Friday}
+
+	tests := []struct {
+		name   string
+		window Window
+		at     string
+		want   bool
+	}{
+		{"no limits", Window{}, "2026-06-01 12:00", true},
+		{"in the month", Window{Months: december}, "2026-12-18 12:00", true},
+		{"outside the month", Window{Months: december}, "2026-11-30 12:00", false},
+		{"on the weekday", Window{Weekdays: friday}, "2026-12-18 12:00", true},
+		{"on another weekday", Window{Weekdays: friday}, "2026-12-20 12:00", false},
+		{"opens", Window{From: 19 * 60, To: 23 * 60}, "2026-12-18 19:00", true},
+		{"about to close", Window{From: 19 * 60, To: 23 * 60}, "2026-12-18 22:59", true},
+		{"closes", Window{From: 19 * 60, To: 23 * 60}, "2026-12-18 23:00", false},
+		{"before it opens", Window{From: 19 * 60, To: 23 * 60}, "2026-12-18 18:59", false},
+		{"overnight evening", Window{Weekdays: friday, From: 22 * 60, To: 2 * 60}, "2026-12-18 23:00", true},
+		{"overnight counts as the day it opened", Window{Weekdays: friday, From: 22 * 60, To: 2 * 60}, "2026-12-19 01:30", true},
+		{"overnight closes", Window{Weekdays: friday, From: 22 * 60, To: 2 * 60}, "2026-12-19 02:00", false},
+		{"overnight from the day before", Window{Weekdays: friday, From: 22 * 60, To: 2 * 60}, "2026-12-18 01:00", false},
+		{"overnight into the next year", Window{Months: december, From: 22 * 60, To: 2 * 60}, "2027-01-01 01:00", true},
+		{"in the zone", Window{From: 19 * 60, To: 23 * 60, Zone: "Europe/Berlin"}, "2026-12-18 18:30", true},
+		{"outside the zone's hours", Window{From: 19 * 60, To: 23 * 60, Zone: "Europe/Berlin"}, "2026-12-18 22:30", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Open(at(tt.at)); got != tt.want {
+				t.Errorf("%s open at %s UTC = %v, want %v", tt.window, tt.at, got, tt.want)
+			}
+		})
+	}
+}